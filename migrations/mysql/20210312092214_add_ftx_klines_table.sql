@@ -0,0 +1,5 @@
+-- +up
+CREATE TABLE `ftx_klines` LIKE `klines`;
+
+-- +down
+DROP TABLE `ftx_klines`;
@@ -0,0 +1,92 @@
+-- +up
+-- +begin
+CREATE TABLE `backtest_runs`
+(
+    `id`             BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `session`        VARCHAR(32)     NOT NULL,
+    `exchange`       VARCHAR(24)     NOT NULL,
+    `quote_currency` VARCHAR(10)     NOT NULL,
+    `start_time`     DATETIME(3)     NOT NULL,
+    `end_time`       DATETIME(3)     NOT NULL,
+
+    -- config is the json snapshot of the bbgo config used by the run
+    `config`         MEDIUMTEXT      NOT NULL,
+
+    -- metrics is the json performance report without the equity curve
+    `metrics`        MEDIUMTEXT      NOT NULL,
+    `equity_curve`   LONGTEXT        NOT NULL,
+
+    `created_at`     DATETIME(3)     NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+
+    PRIMARY KEY (`id`)
+);
+-- +end
+
+-- +begin
+CREATE TABLE `backtest_trades`
+(
+    `gid`            BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `run_id`         BIGINT UNSIGNED NOT NULL,
+    `id`             BIGINT UNSIGNED NOT NULL,
+    `order_id`       BIGINT UNSIGNED NOT NULL,
+    `exchange`       VARCHAR(24)     NOT NULL DEFAULT '',
+    `symbol`         VARCHAR(20)     NOT NULL,
+    `price`          DECIMAL(16, 8)  NOT NULL,
+    `quantity`       DECIMAL(16, 8)  NOT NULL,
+    `quote_quantity` DECIMAL(16, 8)  NOT NULL,
+    `fee`            DECIMAL(16, 8)  NOT NULL,
+    `fee_currency`   VARCHAR(10)     NOT NULL,
+    `is_buyer`       BOOLEAN         NOT NULL DEFAULT FALSE,
+    `is_maker`       BOOLEAN         NOT NULL DEFAULT FALSE,
+    `side`           VARCHAR(4)      NOT NULL DEFAULT '',
+    `is_margin`      BOOLEAN         NOT NULL DEFAULT FALSE,
+    `is_isolated`    BOOLEAN         NOT NULL DEFAULT FALSE,
+    `traded_at`      DATETIME(3)     NOT NULL,
+
+    PRIMARY KEY (`gid`),
+    INDEX `backtest_trades_run_id` (`run_id`, `traded_at`)
+);
+-- +end
+
+-- +begin
+CREATE TABLE `backtest_orders`
+(
+    `gid`               BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `run_id`            BIGINT UNSIGNED NOT NULL,
+    `exchange`          VARCHAR(24)     NOT NULL DEFAULT '',
+    `order_id`          BIGINT UNSIGNED NOT NULL,
+    `client_order_id`   VARCHAR(42)     NOT NULL DEFAULT '',
+    `order_type`        VARCHAR(16)     NOT NULL,
+    `symbol`            VARCHAR(20)     NOT NULL,
+    `status`            VARCHAR(12)     NOT NULL,
+    `time_in_force`     VARCHAR(4)      NOT NULL,
+    `price`             DECIMAL(16, 8)  NOT NULL,
+    `stop_price`        DECIMAL(16, 8)  NOT NULL,
+    `quantity`          DECIMAL(16, 8)  NOT NULL,
+    `executed_quantity` DECIMAL(16, 8)  NOT NULL DEFAULT 0.0,
+    `side`              VARCHAR(4)      NOT NULL DEFAULT '',
+    `is_working`        BOOLEAN         NOT NULL DEFAULT FALSE,
+    `is_margin`         BOOLEAN         NOT NULL DEFAULT FALSE,
+    `is_isolated`       BOOLEAN         NOT NULL DEFAULT FALSE,
+    `created_at`        DATETIME(3)     NOT NULL,
+    `updated_at`        DATETIME(3)     NOT NULL,
+
+    PRIMARY KEY (`gid`),
+    INDEX `backtest_orders_run_id` (`run_id`, `created_at`)
+);
+-- +end
+
+
+-- +down
+
+-- +begin
+DROP TABLE IF EXISTS `backtest_orders`;
+-- +end
+
+-- +begin
+DROP TABLE IF EXISTS `backtest_trades`;
+-- +end
+
+-- +begin
+DROP TABLE IF EXISTS `backtest_runs`;
+-- +end
@@ -0,0 +1,29 @@
+-- +up
+-- +begin
+CREATE TABLE `internal_transfers`
+(
+    `gid`          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `exchange`     VARCHAR(24)     NOT NULL DEFAULT '',
+
+    -- transfer_id is the transfer id returned by the exchange
+    `transfer_id`  VARCHAR(64)     NOT NULL,
+
+    `asset`        VARCHAR(10)     NOT NULL,
+    `amount`       DECIMAL(16, 8)  NOT NULL,
+
+    -- from_account and to_account are the sub-accounts or the wallets, e.g., main, spot, margin or isolated:BTCUSDT
+    `from_account` VARCHAR(64)     NOT NULL,
+    `to_account`   VARCHAR(64)     NOT NULL,
+
+    `status`       VARCHAR(32)     NOT NULL DEFAULT '',
+    `time`         DATETIME(3)     NOT NULL,
+
+    PRIMARY KEY (`gid`),
+    UNIQUE KEY `transfer_id` (`exchange`, `transfer_id`)
+);
+-- +end
+
+-- +down
+-- +begin
+DROP TABLE IF EXISTS `internal_transfers`;
+-- +end
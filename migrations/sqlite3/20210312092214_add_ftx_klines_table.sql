@@ -0,0 +1,9 @@
+-- +up
+-- +begin
+CREATE TABLE `ftx_klines` AS SELECT * FROM `klines` WHERE 0
+-- +end
+
+-- +down
+-- +begin
+DROP TABLE IF EXISTS `ftx_klines`;
+-- +end
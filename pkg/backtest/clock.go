@@ -0,0 +1,149 @@
+package backtest
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+// Clock synchronizes the backtest exchanges of the simulated sessions.
+// It merges the kline data of the exchanges and dispatches the klines in the order of the kline end time,
+// so that the cross exchange strategies see the same market time on every session.
+type Clock struct {
+	mu        sync.Mutex
+	exchanges []*Exchange
+	streams   []*Stream
+	doneC     chan struct{}
+}
+
+func NewClock(exchanges ...*Exchange) *Clock {
+	c := &Clock{
+		exchanges: exchanges,
+		doneC:     make(chan struct{}),
+	}
+
+	for _, e := range exchanges {
+		e.clock = c
+	}
+
+	return c
+}
+
+func (c *Clock) addStream(s *Stream) {
+	c.mu.Lock()
+	c.streams = append(c.streams, s)
+	c.mu.Unlock()
+}
+
+// Done returns the channel that is closed when all the kline data are dispatched
+func (c *Clock) Done() chan struct{} {
+	return c.doneC
+}
+
+type klineCursor struct {
+	stream *Stream
+	klineC chan types.KLine
+	errC   chan error
+	head   types.KLine
+	ok     bool
+}
+
+func (cur *klineCursor) next() {
+	// the kline channel is nil when the query fails
+	if cur.klineC == nil {
+		cur.ok = false
+		return
+	}
+
+	cur.head, cur.ok = <-cur.klineC
+}
+
+// before returns true if the head kline of the cursor should be dispatched before the other one,
+// the shorter interval goes first when the end times are the same, so the matching engines are updated first.
+func (cur *klineCursor) before(other *klineCursor) bool {
+	if cur.head.EndTime.Equal(other.head.EndTime) {
+		return cur.head.Interval.Duration() < other.head.Interval.Duration()
+	}
+
+	return cur.head.EndTime.Before(other.head.EndTime)
+}
+
+// Run starts dispatching the klines of the connected streams, it must be called after the sessions are connected.
+func (c *Clock) Run(ctx context.Context) {
+	c.mu.Lock()
+	streams := c.streams
+	c.mu.Unlock()
+
+	go func() {
+		var cursors []*klineCursor
+		for _, s := range streams {
+			s.EmitConnect()
+
+			klineC, errC := s.queryKLines()
+			cursors = append(cursors, &klineCursor{stream: s, klineC: klineC, errC: errC})
+		}
+
+		c.dispatch(ctx, cursors)
+	}()
+}
+
+// dispatch merges the klines of the cursors by time and closes the streams when the klines are all consumed
+func (c *Clock) dispatch(ctx context.Context, cursors []*klineCursor) {
+	defer close(c.doneC)
+
+	for _, cur := range cursors {
+		cur.next()
+	}
+
+dispatch:
+	for {
+		var earliest *klineCursor
+		for _, cur := range cursors {
+			if cur.ok && (earliest == nil || cur.before(earliest)) {
+				earliest = cur
+			}
+		}
+
+		if earliest == nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			break dispatch
+		default:
+		}
+
+		earliest.stream.dispatchKLine(earliest.head)
+		earliest.next()
+	}
+
+	connected := map[*Exchange]struct{}{}
+	for _, cur := range cursors {
+		// drain the channel to release the query goroutine
+		if cur.klineC != nil {
+			for range cur.klineC {
+			}
+		}
+
+		if err := <-cur.errC; err != nil {
+			log.WithError(err).Error("backtest data feed error")
+		}
+
+		if err := cur.stream.Close(); err != nil {
+			log.WithError(err).Error("stream close error")
+		}
+
+		connected[cur.stream.exchange] = struct{}{}
+	}
+
+	// the exchanges without subscriptions are never connected
+	for _, e := range c.exchanges {
+		if _, ok := connected[e]; !ok {
+			close(e.doneC)
+		}
+	}
+}
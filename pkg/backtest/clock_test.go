@@ -0,0 +1,121 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/bbgo"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func newTestExchange(config *bbgo.Backtest) *Exchange {
+	account := &types.Account{}
+	account.UpdateBalances(config.Account.Balances.BalanceMap())
+
+	return &Exchange{
+		config:  config,
+		account: account,
+		markets: types.MarketMap{
+			"BTCUSDT": {Symbol: "BTCUSDT", BaseCurrency: "BTC", QuoteCurrency: "USDT", PricePrecision: 2, VolumePrecision: 6},
+		},
+		matchingBooks: make(map[string]MatchingEngine),
+		closedOrders:  make(map[string][]types.Order),
+		trades:        make(map[string][]types.Trade),
+		ids:           &idGenerator{},
+		doneC:         make(chan struct{}),
+	}
+}
+
+func newTestKLineCursor(stream *Stream, exchangeName string, basePrice float64, intervals ...types.Interval) *klineCursor {
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	klineC := make(chan types.KLine, 10)
+	errC := make(chan error, 1)
+	for i := 0; i < 5; i++ {
+		for _, interval := range intervals {
+			if (i+1)%int(interval.Minutes()) != 0 {
+				continue
+			}
+
+			price := basePrice + float64(i)
+			klineC <- types.KLine{
+				Exchange:  exchangeName,
+				Symbol:    "BTCUSDT",
+				Interval:  interval,
+				StartTime: startTime.Add(time.Duration(i+1)*time.Minute - interval.Duration()),
+				EndTime:   startTime.Add(time.Duration(i+1) * time.Minute),
+				Open:      price,
+				High:      price,
+				Low:       price,
+				Close:     price,
+				Closed:    true,
+			}
+		}
+	}
+	close(klineC)
+	close(errC)
+
+	return &klineCursor{stream: stream, klineC: klineC, errC: errC}
+}
+
+func TestClock_Dispatch(t *testing.T) {
+	config := &bbgo.Backtest{
+		StartTime: "2021-01-01",
+		EndTime:   "2021-01-02",
+	}
+
+	binanceExchange := newTestExchange(config)
+	maxExchange := newTestExchange(config)
+	idleExchange := newTestExchange(config)
+	clock := NewClock(binanceExchange, maxExchange, idleExchange)
+
+	var dispatched []string
+	var streams []*Stream
+	for _, ex := range []*Exchange{binanceExchange, maxExchange} {
+		stream := ex.NewStream().(*Stream)
+		stream.Subscribe(types.KLineChannel, "BTCUSDT", types.SubscribeOptions{Interval: string(types.Interval1m)})
+		stream.OnKLineClosed(func(kline types.KLine) {
+			// no session should run ahead of the current kline
+			for _, other := range []*Exchange{binanceExchange, maxExchange} {
+				ticker := other.matchingBooks["BTCUSDT"].Ticker()
+				assert.False(t, ticker.Time.After(kline.EndTime))
+			}
+			dispatched = append(dispatched, kline.Exchange+"-"+string(kline.Interval))
+		})
+		assert.NoError(t, stream.Connect(context.Background()))
+		streams = append(streams, stream)
+	}
+	assert.Len(t, clock.streams, 2)
+
+	clock.dispatch(context.Background(), []*klineCursor{
+		newTestKLineCursor(streams[0], "binance", 29000.0, types.Interval1m, types.Interval5m),
+		newTestKLineCursor(streams[1], "max", 29100.0, types.Interval1m),
+	})
+
+	assert.Equal(t, []string{
+		"binance-1m", "max-1m",
+		"binance-1m", "max-1m",
+		"binance-1m", "max-1m",
+		"binance-1m", "max-1m",
+		"binance-1m", "max-1m", "binance-5m",
+	}, dispatched)
+
+	ticker := maxExchange.matchingBooks["BTCUSDT"].Ticker()
+	assert.Equal(t, 29104.0, ticker.Last)
+
+	for _, ex := range []*Exchange{binanceExchange, maxExchange, idleExchange} {
+		select {
+		case <-ex.Done():
+		default:
+			t.Errorf("exchange is not done")
+		}
+	}
+
+	select {
+	case <-clock.Done():
+	default:
+		t.Errorf("clock is not done")
+	}
+}
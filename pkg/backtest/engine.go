@@ -0,0 +1,91 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/bbgo"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+// MatchingEngine is the order matching engine of a market used by the backtest exchange
+type MatchingEngine interface {
+	PlaceOrder(o types.SubmitOrder) (createdOrder *types.Order, trade *types.Trade, err error)
+	CancelOrder(o types.Order) (types.Order, error)
+
+	// OpenOrders returns the orders that are still working in the matching engine
+	OpenOrders() []types.Order
+
+	// Ticker returns the current ticker of the market
+	Ticker() types.Ticker
+
+	OnTradeUpdate(cb func(trade types.Trade))
+	OnOrderUpdate(cb func(order types.Order))
+	OnBalanceUpdate(cb func(balances types.BalanceMap))
+
+	processKLine(kline types.KLine)
+
+	// forcePlaceOrder places the order without the simulated latency, it's used by the forced liquidation
+	forcePlaceOrder(o types.SubmitOrder) (*types.Order, *types.Trade, error)
+
+	// cancelAll cancels all the open orders and the pending orders immediately
+	cancelAll() error
+}
+
+func (e *Exchange) newMatchingEngine(market types.Market) (MatchingEngine, error) {
+	switch engine := e.config.MatchingEngine(); engine {
+
+	case bbgo.BacktestMatchingKLine:
+		slippage, err := newSlippageModel(e.config.Slippage)
+		if err != nil {
+			return nil, err
+		}
+
+		var submitLatency, cancelLatency time.Duration
+		if e.config.Latency != nil {
+			submitLatency = e.config.Latency.Submit.Duration()
+			cancelLatency = e.config.Latency.Cancel.Duration()
+		}
+
+		return &SimplePriceMatching{
+			Symbol:          market.Symbol,
+			CurrentTime:     e.startTime,
+			Account:         e.account,
+			Market:          market,
+			ids:             e.ids,
+			fees:            e.fees,
+			margin:          e.margin,
+			slippage:        slippage,
+			submitLatency:   submitLatency,
+			cancelLatency:   cancelLatency,
+			MakerCommission: e.config.Account.MakerCommission,
+			TakerCommission: e.config.Account.TakerCommission,
+		}, nil
+
+	case bbgo.BacktestMatchingOrderBook:
+		return &OrderBookMatching{
+			Symbol:      market.Symbol,
+			CurrentTime: e.startTime,
+			Account:     e.account,
+			Market:      market,
+			ids:         e.ids,
+			fees:        e.fees,
+			margin:      e.margin,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported backtest matching engine: %s", engine)
+
+	}
+}
+
+// lastPrice returns the last price of the given currency pair from the matching engines
+func (e *Exchange) lastPrice(base, quote string) (float64, bool) {
+	matching, ok := e.matchingBooks[base+quote]
+	if !ok {
+		return 0, false
+	}
+
+	ticker := matching.Ticker()
+	return ticker.Last, ticker.Last > 0
+}
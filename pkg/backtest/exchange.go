@@ -3,6 +3,7 @@ package backtest
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
@@ -17,6 +18,8 @@ import (
 var ErrUnimplemented = errors.New("unimplemented method")
 
 type Exchange struct {
+	types.MarginSettings
+
 	sourceName         types.ExchangeName
 	publicExchange     types.Exchange
 	srv                *service.BacktestService
@@ -29,8 +32,12 @@ type Exchange struct {
 
 	trades        map[string][]types.Trade
 	closedOrders  map[string][]types.Order
-	matchingBooks map[string]*SimplePriceMatching
+	matchingBooks map[string]MatchingEngine
 	markets       types.MarketMap
+	ids           *idGenerator
+	fees          *FeeModel
+	margin        *MarginAccount
+	clock         *Clock
 	doneC         chan struct{}
 }
 
@@ -77,12 +84,28 @@ func NewExchange(sourceName types.ExchangeName, srv *service.BacktestService, co
 		account:        account,
 		startTime:      startTime,
 		endTime:        endTime,
-		matchingBooks:  make(map[string]*SimplePriceMatching),
+		matchingBooks:  make(map[string]MatchingEngine),
 		closedOrders:   make(map[string][]types.Order),
 		trades:         make(map[string][]types.Trade),
+		ids:            &idGenerator{},
 		doneC:          make(chan struct{}),
 	}
 
+	e.fees = newFeeModel(config.Fees, e.lastPrice)
+
+	e.margin, err = newMarginAccount(config.Margin, account, markets, config.Symbols, e.lastPrice)
+	if err != nil {
+		panic(err)
+	}
+
+	if config.Margin != nil {
+		if len(config.Margin.IsolatedSymbol) > 0 {
+			e.UseIsolatedMargin(config.Margin.IsolatedSymbol)
+		} else {
+			e.UseMargin()
+		}
+	}
+
 	return e
 }
 
@@ -101,14 +124,19 @@ func (e *Exchange) NewStream() types.Stream {
 		e.trades[trade.Symbol] = append(e.trades[trade.Symbol], trade)
 	})
 
+	e.stream.OnOrderUpdate(func(order types.Order) {
+		switch order.Status {
+		case types.OrderStatusFilled, types.OrderStatusCanceled, types.OrderStatusRejected:
+			e.closedOrders[order.Symbol] = append(e.closedOrders[order.Symbol], order)
+		}
+	})
+
 	for symbol, market := range e.markets {
-		matching := &SimplePriceMatching{
-			CurrentTime:     e.startTime,
-			Account:         e.account,
-			Market:          market,
-			MakerCommission: e.config.Account.MakerCommission,
-			TakerCommission: e.config.Account.TakerCommission,
+		matching, err := e.newMatchingEngine(market)
+		if err != nil {
+			panic(err)
 		}
+
 		matching.OnTradeUpdate(e.stream.EmitTradeUpdate)
 		matching.OnOrderUpdate(e.stream.EmitOrderUpdate)
 		matching.OnBalanceUpdate(e.stream.EmitBalanceUpdate)
@@ -126,25 +154,14 @@ func (e Exchange) SubmitOrders(ctx context.Context, orders ...types.SubmitOrder)
 			return nil, fmt.Errorf("matching engine is not initialized for symbol %s", symbol)
 		}
 
-		createdOrder, trade, err := matching.PlaceOrder(order)
+		// the order updates and the trade updates are emitted by the matching engine
+		createdOrder, _, err := matching.PlaceOrder(order)
 		if err != nil {
 			return nil, err
 		}
 
 		if createdOrder != nil {
 			createdOrders = append(createdOrders, *createdOrder)
-
-			// market order can be closed immediately.
-			switch createdOrder.Status {
-			case types.OrderStatusFilled, types.OrderStatusCanceled, types.OrderStatusRejected:
-				e.closedOrders[symbol] = append(e.closedOrders[symbol], *createdOrder)
-			}
-
-			e.stream.EmitOrderUpdate(*createdOrder)
-		}
-
-		if trade != nil {
-			e.stream.EmitTradeUpdate(*trade)
 		}
 	}
 
@@ -157,7 +174,7 @@ func (e Exchange) QueryOpenOrders(ctx context.Context, symbol string) (orders []
 		return nil, fmt.Errorf("matching engine is not initialized for symbol %s", symbol)
 	}
 
-	return append(matching.bidOrders, matching.askOrders...), nil
+	return matching.OpenOrders(), nil
 }
 
 func (e Exchange) QueryClosedOrders(ctx context.Context, symbol string, since, until time.Time, lastOrderID uint64) (orders []types.Order, err error) {
@@ -169,18 +186,45 @@ func (e Exchange) QueryClosedOrders(ctx context.Context, symbol string, since, u
 	return orders, nil
 }
 
+// Trades returns the executed trades of all the symbols in the time order
+func (e *Exchange) Trades() []types.Trade {
+	var trades []types.Trade
+	for _, symbolTrades := range e.trades {
+		trades = append(trades, symbolTrades...)
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Time.Time().Before(trades[j].Time.Time())
+	})
+	return trades
+}
+
+// Orders returns the closed orders and the orders that are still open in the matching engines, in the creation time order
+func (e *Exchange) Orders() []types.Order {
+	var orders []types.Order
+	for _, symbolOrders := range e.closedOrders {
+		orders = append(orders, symbolOrders...)
+	}
+
+	for _, matching := range e.matchingBooks {
+		orders = append(orders, matching.OpenOrders()...)
+	}
+
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].CreationTime.Time().Before(orders[j].CreationTime.Time())
+	})
+	return orders
+}
+
 func (e Exchange) CancelOrders(ctx context.Context, orders ...types.Order) error {
 	for _, order := range orders {
 		matching, ok := e.matchingBooks[order.Symbol]
 		if !ok {
 			return fmt.Errorf("matching engine is not initialized for symbol %s", order.Symbol)
 		}
-		canceledOrder, err := matching.CancelOrder(order)
-		if err != nil {
+		if _, err := matching.CancelOrder(order); err != nil {
 			return err
 		}
-
-		e.stream.EmitOrderUpdate(canceledOrder)
 	}
 
 	return nil
@@ -216,17 +260,8 @@ func (e Exchange) QueryTicker(ctx context.Context, symbol string) (*types.Ticker
 		return nil, fmt.Errorf("matching engine is not initialized for symbol %s", symbol)
 	}
 
-	kline := matching.LastKLine
-	return &types.Ticker{
-		Time:   kline.EndTime,
-		Volume: kline.Volume,
-		Last:   kline.Close,
-		Open:   kline.Open,
-		High:   kline.High,
-		Low:    kline.Low,
-		Buy:    kline.Close,
-		Sell:   kline.Close,
-	}, nil
+	ticker := matching.Ticker()
+	return &ticker, nil
 }
 
 func (e Exchange) QueryTickers(ctx context.Context, symbol ...string) (map[string]types.Ticker, error) {
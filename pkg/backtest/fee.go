@@ -0,0 +1,109 @@
+package backtest
+
+import (
+	"github.com/c9s/bbgo/pkg/bbgo"
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+type feeRate struct {
+	maker, taker float64
+}
+
+// FeeModel calculates the trade fees by the fee schedule of the symbols and deducts the fees from the account.
+// When the platform currency is defined, the fee is paid with the platform currency at the discounted rate
+// if the available balance of the platform currency is enough.
+// A nil FeeModel uses the account commissions and records the fees on the trades only.
+type FeeModel struct {
+	symbols map[string]feeRate
+
+	platformCurrency string
+	platformDiscount float64
+
+	// priceOf returns the last price of the given base currency in the quote currency
+	priceOf func(base, quote string) (float64, bool)
+}
+
+func newFeeModel(config *bbgo.BacktestFees, priceOf func(base, quote string) (float64, bool)) *FeeModel {
+	if config == nil {
+		return nil
+	}
+
+	model := &FeeModel{
+		symbols: make(map[string]feeRate),
+		priceOf: priceOf,
+	}
+
+	for symbol, rate := range config.Symbols {
+		model.symbols[symbol] = feeRate{
+			maker: rate.MakerFeeRate.Float64(),
+			taker: rate.TakerFeeRate.Float64(),
+		}
+	}
+
+	model.platformCurrency = config.PlatformCurrency
+	model.platformDiscount = config.PlatformDiscount.Float64()
+	return model
+}
+
+// rate returns the fee rate of the symbol, the account commissions are used if the symbol is not defined
+func (f *FeeModel) rate(account *types.Account, symbol string, isMaker bool) float64 {
+	if f != nil {
+		if rate, ok := f.symbols[symbol]; ok {
+			if isMaker {
+				return rate.maker
+			}
+			return rate.taker
+		}
+	}
+
+	return commissionRate(account, isMaker)
+}
+
+// platformPrice returns the price of the platform currency in the quote currency of the market
+func (f *FeeModel) platformPrice(market types.Market, price float64) (float64, bool) {
+	switch f.platformCurrency {
+	case market.BaseCurrency:
+		return price, true
+	case market.QuoteCurrency:
+		return 1.0, true
+	}
+
+	if f.priceOf == nil {
+		return 0, false
+	}
+
+	return f.priceOf(f.platformCurrency, market.QuoteCurrency)
+}
+
+// Fee returns the trade fee and the fee currency, the buyer pays the fee in the base currency
+// and the seller pays the fee in the quote currency unless the fee is paid with the platform currency.
+func (f *FeeModel) Fee(account *types.Account, market types.Market, side types.SideType, price, quantity float64, isMaker bool) (fee float64, feeCurrency string) {
+	var rate = f.rate(account, market.Symbol, isMaker)
+
+	if f != nil && len(f.platformCurrency) > 0 {
+		if platformPrice, ok := f.platformPrice(market, price); ok && platformPrice > 0 {
+			fee = price * quantity * rate * (1.0 - f.platformDiscount) / platformPrice
+			if balance, ok := account.Balance(f.platformCurrency); ok && balance.Available.Float64() >= fee {
+				return fee, f.platformCurrency
+			}
+		}
+	}
+
+	switch side {
+	case types.SideTypeBuy:
+		return quantity * rate, market.BaseCurrency
+
+	default:
+		return price * quantity * rate, market.QuoteCurrency
+	}
+}
+
+// Charge deducts the trade fee from the account
+func (f *FeeModel) Charge(account *types.Account, trade types.Trade) {
+	if f == nil || trade.Fee <= 0 {
+		return
+	}
+
+	_ = account.AddBalance(trade.FeeCurrency, fixedpoint.NewFromFloat(-trade.Fee))
+}
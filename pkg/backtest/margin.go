@@ -0,0 +1,544 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/c9s/bbgo/pkg/bbgo"
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+const (
+	defaultMaxLeverage            = 3.0
+	defaultLiquidationMarginLevel = 1.1
+
+	// liquidationClientOrderID is the client order id of the orders submitted by the forced liquidation
+	liquidationClientOrderID = "liquidation"
+)
+
+type MarginEventType string
+
+const (
+	MarginEventBorrow      = MarginEventType("borrow")
+	MarginEventRepay       = MarginEventType("repay")
+	MarginEventLiquidation = MarginEventType("liquidation")
+)
+
+type MarginEvent struct {
+	Time        time.Time       `json:"time"`
+	Type        MarginEventType `json:"type"`
+	Asset       string          `json:"asset,omitempty"`
+	Amount      float64         `json:"amount,omitempty"`
+	MarginLevel float64         `json:"marginLevel,omitempty"`
+}
+
+// MarginReport summarizes the margin activities of the backtest
+type MarginReport struct {
+	ValuationCurrency string `json:"valuationCurrency"`
+
+	// Borrowed, Repaid and Interest are the total amounts by asset
+	Borrowed map[string]float64 `json:"borrowed"`
+	Repaid   map[string]float64 `json:"repaid"`
+	Interest map[string]float64 `json:"interest"`
+
+	// MinMarginLevel is the lowest margin level observed while the account has debt
+	MinMarginLevel float64 `json:"minMarginLevel,omitempty"`
+	Liquidations   int     `json:"liquidations"`
+
+	Events []MarginEvent `json:"events,omitempty"`
+}
+
+// MarginAccount simulates the margin account of the backtest exchange.
+// The liabilities are kept in the Borrowed and Interest fields of the account balances,
+// so the strategies see the debt from the balance updates like the margin account of the real exchange.
+// The margin level is calculated as binance does: total asset value / total debt value.
+// A nil MarginAccount is a spot account, the margin side effects of the orders are ignored.
+type MarginAccount struct {
+	account *types.Account
+
+	isolatedSymbol    string
+	assets            map[string]struct{}
+	valuationCurrency string
+
+	maxLeverage         float64
+	interestRates       map[string]float64
+	defaultInterestRate float64
+	liquidationLevel    float64
+
+	// priceOf returns the last price of the given base currency in the quote currency
+	priceOf func(base, quote string) (float64, bool)
+
+	currentTime time.Time
+	lastAccrual time.Time
+
+	report MarginReport
+}
+
+func newMarginAccount(config *bbgo.BacktestMargin, account *types.Account, markets types.MarketMap, symbols []string, priceOf func(base, quote string) (float64, bool)) (*MarginAccount, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	m := &MarginAccount{
+		account:             account,
+		isolatedSymbol:      config.IsolatedSymbol,
+		valuationCurrency:   config.ValuationCurrency,
+		maxLeverage:         config.MaxLeverage.Float64(),
+		interestRates:       make(map[string]float64),
+		defaultInterestRate: config.DefaultInterestRate.Float64(),
+		liquidationLevel:    config.LiquidationMarginLevel.Float64(),
+		priceOf:             priceOf,
+		report: MarginReport{
+			Borrowed: make(map[string]float64),
+			Repaid:   make(map[string]float64),
+			Interest: make(map[string]float64),
+		},
+	}
+
+	for asset, rate := range config.InterestRates {
+		m.interestRates[asset] = rate.Float64()
+	}
+
+	if m.maxLeverage <= 0 {
+		m.maxLeverage = defaultMaxLeverage
+	}
+
+	if m.liquidationLevel <= 0 {
+		m.liquidationLevel = defaultLiquidationMarginLevel
+	}
+
+	var valuationSymbol = m.isolatedSymbol
+	if len(valuationSymbol) == 0 && len(symbols) > 0 {
+		valuationSymbol = symbols[0]
+	}
+
+	if len(m.isolatedSymbol) > 0 {
+		market, ok := markets[m.isolatedSymbol]
+		if !ok {
+			return nil, fmt.Errorf("isolated margin symbol %s not found", m.isolatedSymbol)
+		}
+
+		// the isolated margin account only holds the assets of the symbol
+		m.assets = map[string]struct{}{
+			market.BaseCurrency:  {},
+			market.QuoteCurrency: {},
+		}
+	}
+
+	if len(m.valuationCurrency) == 0 {
+		market, ok := markets[valuationSymbol]
+		if !ok {
+			return nil, fmt.Errorf("unable to decide the margin valuation currency, market %q not found", valuationSymbol)
+		}
+
+		m.valuationCurrency = market.QuoteCurrency
+	}
+
+	m.report.ValuationCurrency = m.valuationCurrency
+	return m, nil
+}
+
+// inScope returns true if the asset belongs to the (isolated) margin account
+func (m *MarginAccount) inScope(asset string) bool {
+	if m.assets == nil {
+		return true
+	}
+
+	_, ok := m.assets[asset]
+	return ok
+}
+
+// value converts the amount of the asset to the valuation currency
+func (m *MarginAccount) value(asset string, amount float64) (float64, bool) {
+	if asset == m.valuationCurrency {
+		return amount, true
+	}
+
+	if price, ok := m.priceOf(asset, m.valuationCurrency); ok && price > 0 {
+		return amount * price, true
+	}
+
+	if price, ok := m.priceOf(m.valuationCurrency, asset); ok && price > 0 {
+		return amount / price, true
+	}
+
+	return 0, false
+}
+
+// totals returns the total asset value and the total debt value in the valuation currency
+func (m *MarginAccount) totals() (assetValue, debtValue float64) {
+	for asset, balance := range m.account.Balances() {
+		if !m.inScope(asset) {
+			continue
+		}
+
+		if v, ok := m.value(asset, balance.Total().Float64()); ok {
+			assetValue += v
+		}
+
+		if v, ok := m.value(asset, balance.Debt().Float64()); ok {
+			debtValue += v
+		}
+	}
+
+	return assetValue, debtValue
+}
+
+// MarginLevel returns the total asset value over the total debt value,
+// ok is false when there is no debt.
+func (m *MarginAccount) MarginLevel() (level float64, ok bool) {
+	assetValue, debtValue := m.totals()
+	if debtValue <= 0 {
+		return 0, false
+	}
+
+	return assetValue / debtValue, true
+}
+
+// MarginAccount returns the snapshot of the account in the format of the exchange margin account
+func (m *MarginAccount) MarginAccount() types.MarginAccount {
+	account := types.MarginAccount{
+		BorrowEnabled:   true,
+		TradeEnabled:    true,
+		TransferEnabled: true,
+	}
+
+	if level, ok := m.MarginLevel(); ok {
+		account.MarginLevel = fixedpoint.NewFromFloat(level)
+	}
+
+	balances := m.account.Balances()
+	var assets []string
+	for asset := range balances {
+		if m.inScope(asset) {
+			assets = append(assets, asset)
+		}
+	}
+	sort.Strings(assets)
+
+	for _, asset := range assets {
+		balance := balances[asset]
+		account.UserAssets = append(account.UserAssets, types.MarginUserAsset{
+			Asset:    asset,
+			Borrowed: balance.Borrowed,
+			Free:     balance.Available,
+			Interest: balance.Interest,
+			Locked:   balance.Locked,
+			NetAsset: balance.Net(),
+		})
+	}
+
+	return account
+}
+
+func (m *MarginAccount) interestRate(asset string) float64 {
+	if rate, ok := m.interestRates[asset]; ok {
+		return rate
+	}
+
+	return m.defaultInterestRate
+}
+
+// Borrow borrows the asset if the total debt is still under the max leverage,
+// the interest of the first hour is charged immediately.
+func (m *MarginAccount) Borrow(asset string, amount fixedpoint.Value) error {
+	if !m.inScope(asset) {
+		return fmt.Errorf("asset %s can not be borrowed in the isolated margin account of %s", asset, m.isolatedSymbol)
+	}
+
+	amountValue, ok := m.value(asset, amount.Float64())
+	if !ok {
+		return fmt.Errorf("unable to borrow %s, the price in %s is unknown", asset, m.valuationCurrency)
+	}
+
+	assetValue, debtValue := m.totals()
+	maxDebt := (assetValue - debtValue) * (m.maxLeverage - 1.0)
+	if debtValue+amountValue > maxDebt {
+		return fmt.Errorf("insufficient margin to borrow %f %s: debt %f + %f exceeds the max debt %f %s",
+			amount.Float64(), asset, debtValue, amountValue, maxDebt, m.valuationCurrency)
+	}
+
+	if err := m.account.BorrowBalance(asset, amount); err != nil {
+		return err
+	}
+
+	if interest := amount.MulFloat64(m.interestRate(asset)); interest > 0 {
+		m.account.AddInterest(asset, interest)
+		m.report.Interest[asset] += interest.Float64()
+	}
+
+	m.report.Borrowed[asset] += amount.Float64()
+	m.report.Events = append(m.report.Events, MarginEvent{
+		Time:   m.currentTime,
+		Type:   MarginEventBorrow,
+		Asset:  asset,
+		Amount: amount.Float64(),
+	})
+
+	m.updateMinMarginLevel()
+	return nil
+}
+
+// Repay repays the debt of the asset with the available balance
+func (m *MarginAccount) Repay(asset string, amount fixedpoint.Value) (fixedpoint.Value, error) {
+	repaid, err := m.account.RepayBalance(asset, amount)
+	if err != nil || repaid <= 0 {
+		return repaid, err
+	}
+
+	m.report.Repaid[asset] += repaid.Float64()
+	m.report.Events = append(m.report.Events, MarginEvent{
+		Time:   m.currentTime,
+		Type:   MarginEventRepay,
+		Asset:  asset,
+		Amount: repaid.Float64(),
+	})
+
+	return repaid, nil
+}
+
+// LockBalance locks the order balance, the insufficient balance is borrowed for the MARGIN_BUY orders
+func (m *MarginAccount) LockBalance(account *types.Account, currency string, amount fixedpoint.Value, sideEffect types.MarginOrderSideEffectType) error {
+	if m != nil && sideEffect == types.SideEffectTypeMarginBuy {
+		balance, _ := account.Balance(currency)
+		if balance.Available < amount {
+			if err := m.Borrow(currency, amount-balance.Available); err != nil {
+				return err
+			}
+		}
+	}
+
+	return account.LockBalance(currency, amount)
+}
+
+// AutoRepay repays the debt of the received currency for the AUTO_REPAY orders
+func (m *MarginAccount) AutoRepay(account *types.Account, currency string, sideEffect types.MarginOrderSideEffectType) {
+	if m == nil || sideEffect != types.SideEffectTypeAutoRepay {
+		return
+	}
+
+	balance, ok := account.Balance(currency)
+	if !ok || balance.Debt() <= 0 {
+		return
+	}
+
+	if _, err := m.Repay(currency, balance.Debt()); err != nil {
+		log.WithError(err).Errorf("unable to repay %s", currency)
+	}
+}
+
+// accrueInterest charges the hourly interest on the borrowed amounts at every hour boundary passed since the last update,
+// it returns true if any interest is charged.
+func (m *MarginAccount) accrueInterest(t time.Time) (charged bool) {
+	m.currentTime = t
+
+	if m.lastAccrual.IsZero() {
+		m.lastAccrual = t
+		return false
+	}
+
+	hours := int64(t.Truncate(time.Hour).Sub(m.lastAccrual.Truncate(time.Hour)) / time.Hour)
+	if hours <= 0 {
+		return false
+	}
+
+	m.lastAccrual = t
+
+	for asset, balance := range m.account.Balances() {
+		if balance.Borrowed <= 0 {
+			continue
+		}
+
+		interest := balance.Borrowed.MulFloat64(m.interestRate(asset) * float64(hours))
+		if interest <= 0 {
+			continue
+		}
+
+		m.account.AddInterest(asset, interest)
+		m.report.Interest[asset] += interest.Float64()
+		charged = true
+	}
+
+	return charged
+}
+
+func (m *MarginAccount) updateMinMarginLevel() {
+	if level, ok := m.MarginLevel(); ok && (m.report.MinMarginLevel == 0 || level < m.report.MinMarginLevel) {
+		m.report.MinMarginLevel = level
+	}
+}
+
+// Report returns the margin report, the events are copied
+func (m *MarginAccount) Report() *MarginReport {
+	if m == nil {
+		return nil
+	}
+
+	report := m.report
+	report.Events = append([]MarginEvent{}, m.report.Events...)
+	return &report
+}
+
+// updateMargin accrues the interest and force-liquidates the margin account when the margin level drops below the threshold
+func (e *Exchange) updateMargin(t time.Time) {
+	if e.margin == nil {
+		return
+	}
+
+	if e.margin.accrueInterest(t) && e.stream != nil {
+		e.stream.EmitBalanceUpdate(e.account.Balances())
+	}
+
+	e.margin.updateMinMarginLevel()
+
+	level, ok := e.margin.MarginLevel()
+	if !ok || level >= e.margin.liquidationLevel {
+		return
+	}
+
+	log.Warnf("margin level %f is below the liquidation level %f at %s, liquidating the margin account", level, e.margin.liquidationLevel, t)
+	e.liquidate(level)
+}
+
+// liquidate cancels all the open orders, sells the assets into the valuation currency,
+// buys back the borrowed assets and repays all the debt that could be repaid.
+func (e *Exchange) liquidate(level float64) {
+	m := e.margin
+
+	var symbols []string
+	for symbol := range e.matchingBooks {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		if err := e.matchingBooks[symbol].cancelAll(); err != nil {
+			log.WithError(err).Errorf("liquidation: unable to cancel the %s orders", symbol)
+		}
+	}
+
+	// findMatching returns the matching engine of the asset traded against the valuation currency
+	findMatching := func(asset string) (MatchingEngine, types.Market, bool) {
+		for _, symbol := range symbols {
+			market := e.markets[symbol]
+			if market.BaseCurrency == asset && market.QuoteCurrency == m.valuationCurrency {
+				return e.matchingBooks[symbol], market, true
+			}
+		}
+		return nil, types.Market{}, false
+	}
+
+	var assets []string
+	for asset := range e.account.Balances() {
+		if asset != m.valuationCurrency && m.inScope(asset) {
+			assets = append(assets, asset)
+		}
+	}
+	sort.Strings(assets)
+
+	// sell the assets without debt for the valuation currency
+	for _, asset := range assets {
+		balance, _ := e.account.Balance(asset)
+		if balance.Debt() > 0 || balance.Available <= 0 {
+			continue
+		}
+
+		matching, market, ok := findMatching(asset)
+		if !ok {
+			continue
+		}
+
+		if _, _, err := matching.forcePlaceOrder(types.SubmitOrder{
+			ClientOrderID: liquidationClientOrderID,
+			Symbol:        market.Symbol,
+			Side:          types.SideTypeSell,
+			Type:          types.OrderTypeMarket,
+			Quantity:      balance.Available.Float64(),
+			Market:        market,
+		}); err != nil {
+			log.WithError(err).Errorf("liquidation: unable to sell %s", asset)
+		}
+	}
+
+	// buy back the borrowed assets
+	for _, asset := range assets {
+		balance, _ := e.account.Balance(asset)
+		shortfall := balance.Debt() - balance.Available
+		if shortfall <= 0 {
+			continue
+		}
+
+		matching, market, ok := findMatching(asset)
+		if !ok {
+			continue
+		}
+
+		quantity := shortfall.Float64()
+		if e.fees != nil {
+			// the buyer pays the fee in the base currency
+			quantity = quantity / (1.0 - e.fees.rate(e.account, market.Symbol, false))
+		}
+
+		price := matching.Ticker().Last
+		if quote, ok := e.account.Balance(m.valuationCurrency); ok && price > 0 && quantity*price > quote.Available.Float64() {
+			quantity = quote.Available.Float64() / price
+		}
+
+		if quantity <= 0 {
+			continue
+		}
+
+		if _, _, err := matching.forcePlaceOrder(types.SubmitOrder{
+			ClientOrderID: liquidationClientOrderID,
+			Symbol:        market.Symbol,
+			Side:          types.SideTypeBuy,
+			Type:          types.OrderTypeMarket,
+			Quantity:      quantity,
+			Market:        market,
+		}); err != nil {
+			log.WithError(err).Errorf("liquidation: unable to buy back %s", asset)
+		}
+	}
+
+	for _, asset := range append(assets, m.valuationCurrency) {
+		balance, _ := e.account.Balance(asset)
+		if balance.Debt() <= 0 {
+			continue
+		}
+
+		if _, err := m.Repay(asset, balance.Debt()); err != nil {
+			log.WithError(err).Errorf("liquidation: unable to repay %s", asset)
+		}
+	}
+
+	m.report.Liquidations++
+	m.report.Events = append(m.report.Events, MarginEvent{
+		Time:        m.currentTime,
+		Type:        MarginEventLiquidation,
+		MarginLevel: level,
+	})
+
+	if e.stream != nil {
+		e.stream.EmitBalanceUpdate(e.account.Balances())
+	}
+}
+
+// MarginReport returns the margin report of the simulated margin account, nil is returned for the spot account
+func (e *Exchange) MarginReport() *MarginReport {
+	return e.margin.Report()
+}
+
+// QueryMarginAccount returns the simulated margin account
+func (e *Exchange) QueryMarginAccount(ctx context.Context) (*types.MarginAccount, error) {
+	if e.margin == nil {
+		return nil, fmt.Errorf("margin is not enabled in the backtest config")
+	}
+
+	account := e.margin.MarginAccount()
+	return &account, nil
+}
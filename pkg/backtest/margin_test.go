@@ -0,0 +1,141 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/bbgo"
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func newTestMarginExchange(t *testing.T) (*Exchange, *SimplePriceMatching) {
+	config := &bbgo.Backtest{
+		StartTime: "2021-01-01",
+		EndTime:   "2021-01-02",
+		Symbols:   []string{"BTCUSDT"},
+		Account: bbgo.BacktestAccount{
+			Balances: bbgo.BacktestAccountBalanceMap{
+				"USDT": fixedpoint.NewFromFloat(10000.0),
+			},
+		},
+		Margin: &bbgo.BacktestMargin{
+			InterestRates: map[string]fixedpoint.Value{
+				"USDT": fixedpoint.NewFromFloat(0.0001),
+			},
+		},
+	}
+
+	e := newTestExchange(config)
+
+	var err error
+	e.margin, err = newMarginAccount(config.Margin, e.account, e.markets, config.Symbols, e.lastPrice)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	matching := &SimplePriceMatching{
+		Symbol:      "BTCUSDT",
+		Market:      e.markets["BTCUSDT"],
+		CurrentTime: startTime,
+		Account:     e.account,
+		ids:         e.ids,
+		margin:      e.margin,
+	}
+	matching.processKLine(newTestKLine(startTime, 9900.0, 10000.0, 9900.0, 10000.0))
+	e.matchingBooks["BTCUSDT"] = matching
+	return e, matching
+}
+
+func TestMarginAccount_BorrowAndInterest(t *testing.T) {
+	e, matching := newTestMarginExchange(t)
+	startTime := matching.CurrentTime
+
+	// the order without the side effect can not use the margin
+	_, _, err := matching.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeBuy, Type: types.OrderTypeMarket, Quantity: 2.0})
+	assert.Error(t, err)
+
+	// the debt can not exceed 2 times of the net asset value
+	_, _, err = matching.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeBuy, Type: types.OrderTypeMarket, Quantity: 4.0, MarginSideEffect: types.SideEffectTypeMarginBuy})
+	assert.Error(t, err)
+
+	_, trade, err := matching.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeBuy, Type: types.OrderTypeMarket, Quantity: 2.0, MarginSideEffect: types.SideEffectTypeMarginBuy})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2.0, trade.Quantity)
+	}
+
+	usdt, _ := e.account.Balance("USDT")
+	assert.Equal(t, fixedpoint.Value(0), usdt.Available)
+	assert.Equal(t, fixedpoint.NewFromFloat(10000.0), usdt.Borrowed)
+
+	// the interest of the first hour is charged when borrowing
+	assert.Equal(t, fixedpoint.NewFromFloat(1.0), usdt.Interest)
+
+	level, ok := e.margin.MarginLevel()
+	assert.True(t, ok)
+	assert.InDelta(t, 20000.0/10001.0, level, 1e-9)
+
+	// the interest is charged at every hour boundary
+	e.updateMargin(startTime.Add(10 * time.Minute))
+	e.updateMargin(startTime.Add(2*time.Hour + 5*time.Minute))
+	usdt, _ = e.account.Balance("USDT")
+	assert.Equal(t, fixedpoint.NewFromFloat(3.0), usdt.Interest)
+
+	// the proceeds of the AUTO_REPAY order repay the interest first
+	_, _, err = matching.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeSell, Type: types.OrderTypeMarket, Quantity: 1.0, MarginSideEffect: types.SideEffectTypeAutoRepay})
+	assert.NoError(t, err)
+
+	usdt, _ = e.account.Balance("USDT")
+	assert.Equal(t, fixedpoint.Value(0), usdt.Available)
+	assert.Equal(t, fixedpoint.Value(0), usdt.Interest)
+	assert.Equal(t, fixedpoint.NewFromFloat(3.0), usdt.Borrowed)
+
+	report := e.MarginReport()
+	assert.Equal(t, "USDT", report.ValuationCurrency)
+	assert.Equal(t, 10000.0, report.Borrowed["USDT"])
+	assert.Equal(t, 10000.0, report.Repaid["USDT"])
+	assert.InDelta(t, 3.0, report.Interest["USDT"], 1e-8)
+	assert.Equal(t, 0, report.Liquidations)
+	assert.Len(t, report.Events, 2)
+}
+
+func TestMarginAccount_Liquidation(t *testing.T) {
+	e, matching := newTestMarginExchange(t)
+	startTime := matching.CurrentTime
+
+	_, _, err := matching.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeBuy, Type: types.OrderTypeMarket, Quantity: 2.0, MarginSideEffect: types.SideEffectTypeMarginBuy})
+	assert.NoError(t, err)
+
+	_, _, err = matching.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeSell, Type: types.OrderTypeLimit, Price: 12000.0, Quantity: 1.0})
+	assert.NoError(t, err)
+
+	// the margin level is still above the liquidation level
+	kline := newTestKLine(startTime.Add(time.Minute), 10000.0, 10000.0, 6000.0, 6000.0)
+	matching.processKLine(kline)
+	e.updateMargin(kline.EndTime)
+	assert.Equal(t, 0, e.MarginReport().Liquidations)
+
+	// 2 * 5400 / 10001 < 1.1
+	kline = newTestKLine(startTime.Add(2*time.Minute), 6000.0, 6000.0, 5400.0, 5400.0)
+	matching.processKLine(kline)
+	e.updateMargin(kline.EndTime)
+
+	report := e.MarginReport()
+	assert.Equal(t, 1, report.Liquidations)
+	assert.InDelta(t, 10800.0/10001.0, report.MinMarginLevel, 1e-9)
+
+	assert.Empty(t, matching.OpenOrders())
+
+	btc, _ := e.account.Balance("BTC")
+	assert.Equal(t, fixedpoint.Value(0), btc.Total())
+
+	usdt, _ := e.account.Balance("USDT")
+	assert.Equal(t, fixedpoint.Value(0), usdt.Debt())
+	assert.Equal(t, fixedpoint.NewFromFloat(10800.0-10001.0), usdt.Available)
+
+	_, ok := e.margin.MarginLevel()
+	assert.False(t, ok)
+}
@@ -0,0 +1,151 @@
+package backtest
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+// MarketTradeRecord is a recorded public trade of the market,
+// Side is the side of the taker.
+type MarketTradeRecord struct {
+	Time     time.Time        `json:"time"`
+	Price    fixedpoint.Value `json:"price"`
+	Quantity fixedpoint.Value `json:"quantity"`
+	Side     types.SideType   `json:"side"`
+}
+
+// DepthSnapshotRecord is a recorded depth snapshot of the market,
+// each price level is encoded as a [price, volume] pair.
+type DepthSnapshotRecord struct {
+	Time time.Time             `json:"time"`
+	Bids [][2]fixedpoint.Value `json:"bids"`
+	Asks [][2]fixedpoint.Value `json:"asks"`
+}
+
+func (r DepthSnapshotRecord) OrderBook(symbol string) types.OrderBook {
+	book := types.OrderBook{Symbol: symbol}
+	for _, pv := range r.Bids {
+		book.Bids = append(book.Bids, types.PriceVolume{Price: pv[0], Volume: pv[1]})
+	}
+	for _, pv := range r.Asks {
+		book.Asks = append(book.Asks, types.PriceVolume{Price: pv[0], Volume: pv[1]})
+	}
+	return book
+}
+
+// MarketDataFeed replays the recorded market trades and depth snapshots of a symbol in time order.
+// The records are stored as json lines in {dataDir}/{symbol}-trades.jsonl and {dataDir}/{symbol}-depth.jsonl,
+// a missing file is treated as an empty record set.
+type MarketDataFeed struct {
+	Symbol string
+
+	files  []*os.File
+	trades *json.Decoder
+	depths *json.Decoder
+
+	nextTrade *MarketTradeRecord
+	nextDepth *DepthSnapshotRecord
+}
+
+func OpenMarketDataFeed(dataDir, symbol string) (*MarketDataFeed, error) {
+	feed := &MarketDataFeed{Symbol: symbol}
+
+	tradeFile, err := openRecordFile(filepath.Join(dataDir, symbol+"-trades.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	if tradeFile != nil {
+		feed.files = append(feed.files, tradeFile)
+		feed.trades = json.NewDecoder(tradeFile)
+	}
+
+	depthFile, err := openRecordFile(filepath.Join(dataDir, symbol+"-depth.jsonl"))
+	if err != nil {
+		_ = feed.Close()
+		return nil, err
+	}
+
+	if depthFile != nil {
+		feed.files = append(feed.files, depthFile)
+		feed.depths = json.NewDecoder(depthFile)
+	}
+
+	return feed, nil
+}
+
+func openRecordFile(path string) (*os.File, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return f, err
+}
+
+// ReplayUntil replays the records with time before or equal to the given time.
+// When a trade and a depth snapshot have the same time, the depth snapshot is replayed first.
+func (f *MarketDataFeed) ReplayUntil(until time.Time, onTrade func(trade MarketTradeRecord), onDepth func(depth DepthSnapshotRecord)) error {
+	for {
+		if err := f.peek(); err != nil {
+			return err
+		}
+
+		switch {
+		case f.nextDepth != nil && !f.nextDepth.Time.After(until) &&
+			(f.nextTrade == nil || !f.nextDepth.Time.After(f.nextTrade.Time)):
+			depth := *f.nextDepth
+			f.nextDepth = nil
+			onDepth(depth)
+
+		case f.nextTrade != nil && !f.nextTrade.Time.After(until):
+			trade := *f.nextTrade
+			f.nextTrade = nil
+			onTrade(trade)
+
+		default:
+			return nil
+		}
+	}
+}
+
+func (f *MarketDataFeed) peek() error {
+	if f.nextTrade == nil && f.trades != nil {
+		var trade MarketTradeRecord
+		if err := f.trades.Decode(&trade); err == io.EOF {
+			f.trades = nil
+		} else if err != nil {
+			return err
+		} else {
+			f.nextTrade = &trade
+		}
+	}
+
+	if f.nextDepth == nil && f.depths != nil {
+		var depth DepthSnapshotRecord
+		if err := f.depths.Decode(&depth); err == io.EOF {
+			f.depths = nil
+		} else if err != nil {
+			return err
+		} else {
+			f.nextDepth = &depth
+		}
+	}
+
+	return nil
+}
+
+func (f *MarketDataFeed) Close() (err error) {
+	for _, file := range f.files {
+		if err2 := file.Close(); err2 != nil {
+			err = err2
+		}
+	}
+	return err
+}
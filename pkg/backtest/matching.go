@@ -20,15 +20,19 @@ import (
 // MAX uses 0.050% for maker and 0.15% for taker
 const DefaultFeeRate = 0.15 * 0.001
 
-var orderID uint64 = 1
-var tradeID uint64 = 1
+// idGenerator generates the order ids and the trade ids of a backtest run.
+// Every backtest exchange owns its generator, so concurrent backtest runs do not interfere with each other.
+type idGenerator struct {
+	orderID uint64
+	tradeID uint64
+}
 
-func incOrderID() uint64 {
-	return atomic.AddUint64(&orderID, 1)
+func (g *idGenerator) incOrderID() uint64 {
+	return atomic.AddUint64(&g.orderID, 1)
 }
 
-func incTradeID() uint64 {
-	return atomic.AddUint64(&tradeID, 1)
+func (g *idGenerator) incTradeID() uint64 {
+	return atomic.AddUint64(&g.tradeID, 1)
 }
 
 // SimplePriceMatching implements a simple kline data driven matching engine for backtest
@@ -41,12 +45,27 @@ type SimplePriceMatching struct {
 	bidOrders []types.Order
 	askOrders []types.Order
 
+	// stopOrders are the stop orders waiting for the trigger price
+	stopOrders []types.Order
+
 	LastPrice   fixedpoint.Value
 	LastKLine   types.KLine
 	CurrentTime time.Time
 
 	Account *types.Account
 
+	ids *idGenerator
+
+	fees     *FeeModel
+	margin   *MarginAccount
+	slippage SlippageModel
+
+	// submitLatency and cancelLatency delay the order requests in the simulated time
+	submitLatency  time.Duration
+	cancelLatency  time.Duration
+	pendingOrders  []pendingOrder
+	pendingCancels []pendingCancel
+
 	MakerCommission fixedpoint.Value `json:"makerCommission"`
 	TakerCommission fixedpoint.Value `json:"takerCommission"`
 
@@ -55,37 +74,125 @@ type SimplePriceMatching struct {
 	balanceUpdateCallbacks []func(balances types.BalanceMap)
 }
 
-func (m *SimplePriceMatching) CancelOrder(o types.Order) (types.Order, error) {
-	found := false
+// pendingOrder is a submitted order that has not arrived at the matching engine yet
+type pendingOrder struct {
+	order types.Order
 
-	switch o.Side {
+	// lockedPrice is the price used for locking the quote balance of the buy order
+	lockedPrice float64
+}
 
-	case types.SideTypeBuy:
+// pendingCancel is a cancel request that does not take effect yet
+type pendingCancel struct {
+	order types.Order
+	time  time.Time
+}
+
+func (m *SimplePriceMatching) CancelOrder(o types.Order) (types.Order, error) {
+	if m.cancelLatency > 0 {
 		m.mu.Lock()
-		var orders []types.Order
-		for _, order := range m.bidOrders {
-			if o.OrderID == order.OrderID {
-				found = true
-				continue
+		m.pendingCancels = append(m.pendingCancels, pendingCancel{
+			order: o,
+			time:  m.CurrentTime.Add(m.cancelLatency),
+		})
+		m.mu.Unlock()
+		return o, nil
+	}
+
+	return m.cancelOrder(o)
+}
+
+func (m *SimplePriceMatching) hasOrder(orderID uint64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, orders := range [][]types.Order{m.bidOrders, m.askOrders, m.stopOrders} {
+		for _, o := range orders {
+			if o.OrderID == orderID {
+				return true
 			}
-			orders = append(orders, order)
 		}
-		m.bidOrders = orders
-		m.mu.Unlock()
+	}
 
-	case types.SideTypeSell:
+	for _, p := range m.pendingOrders {
+		if p.order.OrderID == orderID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *SimplePriceMatching) cancelOrder(o types.Order) (types.Order, error) {
+	found := false
+
+	// the pending orders are not in the book yet
+	var lockedPrice = lockPrice(o.SubmitOrder)
+	m.mu.Lock()
+	var pendingOrders []pendingOrder
+	for _, p := range m.pendingOrders {
+		if o.OrderID == p.order.OrderID {
+			found = true
+			lockedPrice = p.lockedPrice
+			continue
+		}
+		pendingOrders = append(pendingOrders, p)
+	}
+	m.pendingOrders = pendingOrders
+	m.mu.Unlock()
+
+	switch {
+	case found:
+
+	case o.Type == types.OrderTypeStopMarket || o.Type == types.OrderTypeStopLimit:
 		m.mu.Lock()
 		var orders []types.Order
-		for _, order := range m.askOrders {
+		for _, order := range m.stopOrders {
 			if o.OrderID == order.OrderID {
 				found = true
 				continue
 			}
 			orders = append(orders, order)
 		}
-		m.askOrders = orders
+		m.stopOrders = orders
 		m.mu.Unlock()
 
+		// the triggered stop limit order is moved to the bid/ask orders
+		if found {
+			break
+		}
+		fallthrough
+
+	default:
+		switch o.Side {
+
+		case types.SideTypeBuy:
+			m.mu.Lock()
+			var orders []types.Order
+			for _, order := range m.bidOrders {
+				if o.OrderID == order.OrderID {
+					found = true
+					continue
+				}
+				orders = append(orders, order)
+			}
+			m.bidOrders = orders
+			m.mu.Unlock()
+
+		case types.SideTypeSell:
+			m.mu.Lock()
+			var orders []types.Order
+			for _, order := range m.askOrders {
+				if o.OrderID == order.OrderID {
+					found = true
+					continue
+				}
+				orders = append(orders, order)
+			}
+			m.askOrders = orders
+			m.mu.Unlock()
+
+		}
 	}
 
 	if !found {
@@ -96,7 +203,7 @@ func (m *SimplePriceMatching) CancelOrder(o types.Order) (types.Order, error) {
 
 	switch o.Side {
 	case types.SideTypeBuy:
-		if err := m.Account.UnlockBalance(m.Market.QuoteCurrency, fixedpoint.NewFromFloat(o.Price*o.Quantity)); err != nil {
+		if err := m.Account.UnlockBalance(m.Market.QuoteCurrency, fixedpoint.NewFromFloat(lockedPrice*o.Quantity)); err != nil {
 			return o, err
 		}
 
@@ -112,27 +219,78 @@ func (m *SimplePriceMatching) CancelOrder(o types.Order) (types.Order, error) {
 	return o, nil
 }
 
+// cancelAll cancels the open orders and the pending orders immediately, the pending cancel requests are dropped
+func (m *SimplePriceMatching) cancelAll() error {
+	m.mu.Lock()
+	var orders = make([]types.Order, 0, len(m.pendingOrders))
+	for _, p := range m.pendingOrders {
+		orders = append(orders, p.order)
+	}
+	m.pendingCancels = nil
+	m.mu.Unlock()
+
+	for _, o := range append(orders, m.OpenOrders()...) {
+		if _, err := m.cancelOrder(o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lockPrice returns the price for locking the quote balance of the buy orders.
+// stop market orders are locked by the stop price since the order will be executed at the stop price.
+func lockPrice(o types.SubmitOrder) float64 {
+	if o.Type == types.OrderTypeStopMarket {
+		return o.StopPrice
+	}
+
+	return o.Price
+}
+
 func (m *SimplePriceMatching) PlaceOrder(o types.SubmitOrder) (closedOrders *types.Order, trades *types.Trade, err error) {
+	return m.placeOrder(o, m.submitLatency)
+}
+
+func (m *SimplePriceMatching) forcePlaceOrder(o types.SubmitOrder) (*types.Order, *types.Trade, error) {
+	return m.placeOrder(o, 0)
+}
+
+func (m *SimplePriceMatching) placeOrder(o types.SubmitOrder, submitLatency time.Duration) (*types.Order, *types.Trade, error) {
 
 	// price for checking account balance
 	price := o.Price
 	switch o.Type {
 	case types.OrderTypeMarket:
 		price = m.LastPrice.Float64()
-	case types.OrderTypeLimit:
+	case types.OrderTypeLimit, types.OrderTypeLimitMaker:
 		price = o.Price
+	case types.OrderTypeStopMarket, types.OrderTypeStopLimit:
+		if o.StopPrice <= 0 {
+			return nil, nil, fmt.Errorf("stop price of the %s order can not be zero", o.Type)
+		}
+
+		// the stop order can not be triggered immediately
+		if m.LastPrice > 0 {
+			lastPrice := m.LastPrice.Float64()
+			if (o.Side == types.SideTypeBuy && o.StopPrice <= lastPrice) || (o.Side == types.SideTypeSell && o.StopPrice >= lastPrice) {
+				return nil, nil, fmt.Errorf("%s %s order would trigger immediately, stop price %f, last price %f", o.Side, o.Type, o.StopPrice, lastPrice)
+			}
+		}
+
+		price = lockPrice(o)
 	}
 
 	switch o.Side {
 	case types.SideTypeBuy:
 		quote := price * o.Quantity
-		if err := m.Account.LockBalance(m.Market.QuoteCurrency, fixedpoint.NewFromFloat(quote)); err != nil {
+		if err := m.margin.LockBalance(m.Account, m.Market.QuoteCurrency, fixedpoint.NewFromFloat(quote), o.MarginSideEffect); err != nil {
 			return nil, nil, err
 		}
 
 	case types.SideTypeSell:
 		baseQuantity := o.Quantity
-		if err := m.Account.LockBalance(m.Market.BaseCurrency, fixedpoint.NewFromFloat(baseQuantity)); err != nil {
+		if err := m.margin.LockBalance(m.Account, m.Market.BaseCurrency, fixedpoint.NewFromFloat(baseQuantity), o.MarginSideEffect); err != nil {
 			return nil, nil, err
 		}
 	}
@@ -140,37 +298,64 @@ func (m *SimplePriceMatching) PlaceOrder(o types.SubmitOrder) (closedOrders *typ
 	m.EmitBalanceUpdate(m.Account.Balances())
 
 	// start from one
-	orderID := incOrderID()
+	orderID := m.ids.incOrderID()
 	order := m.newOrder(o, orderID)
 
-	if o.Type == types.OrderTypeMarket {
+	// the order arrives at the matching engine after the submit latency
+	if submitLatency > 0 {
+		m.mu.Lock()
+		m.pendingOrders = append(m.pendingOrders, pendingOrder{order: order, lockedPrice: price})
+		m.mu.Unlock()
+
+		m.EmitOrderUpdate(order)
+		return &order, nil, nil
+	}
+
+	return m.activateOrder(order, price)
+}
+
+// activateOrder executes the market order or puts the order into the book
+func (m *SimplePriceMatching) activateOrder(order types.Order, lockedPrice float64) (*types.Order, *types.Trade, error) {
+	if order.Type == types.OrderTypeMarket {
 		m.EmitOrderUpdate(order)
 
+		order.Price = m.executionPrice(order, m.LastPrice.Float64(), lockedPrice)
+
 		// emit trade before we publish order
-		trade := m.newTradeFromOrder(order, false)
-		m.executeTrade(trade)
+		trade := m.newTradeFromOrder(order, order.Price, false)
+		m.executeTrade(trade, order.MarginSideEffect)
 
 		// update the order status
 		order.Status = types.OrderStatusFilled
 		order.ExecutedQuantity = order.Quantity
-		order.Price = price
+		order.IsWorking = false
 		m.EmitOrderUpdate(order)
 		m.EmitBalanceUpdate(m.Account.Balances())
 		return &order, &trade, nil
 	}
 
-	// for limit maker orders
-	switch o.Side {
+	switch order.Type {
 
-	case types.SideTypeBuy:
+	// stop orders wait for the trigger price
+	case types.OrderTypeStopMarket, types.OrderTypeStopLimit:
 		m.mu.Lock()
-		m.bidOrders = append(m.bidOrders, order)
+		m.stopOrders = append(m.stopOrders, order)
 		m.mu.Unlock()
 
-	case types.SideTypeSell:
-		m.mu.Lock()
-		m.askOrders = append(m.askOrders, order)
-		m.mu.Unlock()
+	default:
+		// for limit maker orders
+		switch order.Side {
+
+		case types.SideTypeBuy:
+			m.mu.Lock()
+			m.bidOrders = append(m.bidOrders, order)
+			m.mu.Unlock()
+
+		case types.SideTypeSell:
+			m.mu.Lock()
+			m.askOrders = append(m.askOrders, order)
+			m.mu.Unlock()
+		}
 	}
 
 	m.EmitOrderUpdate(order)
@@ -178,7 +363,35 @@ func (m *SimplePriceMatching) PlaceOrder(o types.SubmitOrder) (closedOrders *typ
 	return &order, nil, nil
 }
 
-func (m *SimplePriceMatching) executeTrade(trade types.Trade) {
+// executionPrice applies the slippage model on the execution price of the marketable order.
+// The quote balance of the buy order is locked by the locked price, the difference is locked or unlocked here,
+// and the order is executed at the locked price if the available balance is not enough.
+func (m *SimplePriceMatching) executionPrice(order types.Order, price, lockedPrice float64) float64 {
+	if m.slippage != nil {
+		price = m.slippage.Slip(order.Side, price, order.Quantity, m.LastKLine)
+	}
+
+	if order.Side != types.SideTypeBuy || price == lockedPrice {
+		return price
+	}
+
+	locked := fixedpoint.NewFromFloat(lockedPrice * order.Quantity)
+	cost := fixedpoint.NewFromFloat(price * order.Quantity)
+	if cost > locked {
+		if err := m.Account.LockBalance(m.Market.QuoteCurrency, cost-locked); err != nil {
+			logrus.WithError(err).Warnf("insufficient balance for the slippage, executing the %s order at %f", order.Symbol, lockedPrice)
+			return lockedPrice
+		}
+	} else if err := m.Account.UnlockBalance(m.Market.QuoteCurrency, locked-cost); err != nil {
+		logrus.WithError(err).Errorf("unable to unlock the order balance: %+v", order)
+	}
+
+	return price
+}
+
+// executeTrade updates the account balances by the trade,
+// the debt of the received currency is repaid if the order has the AUTO_REPAY side effect.
+func (m *SimplePriceMatching) executeTrade(trade types.Trade, sideEffect types.MarginOrderSideEffectType) {
 	var err error
 	// execute trade, update account balances
 	if trade.IsBuyer {
@@ -195,44 +408,68 @@ func (m *SimplePriceMatching) executeTrade(trade types.Trade) {
 		panic(errors.Wrapf(err, "executeTrade exception, wanted to use more than the locked balance"))
 	}
 
+	m.fees.Charge(m.Account, trade)
+
+	if trade.IsBuyer {
+		m.margin.AutoRepay(m.Account, m.Market.BaseCurrency, sideEffect)
+	} else {
+		m.margin.AutoRepay(m.Account, m.Market.QuoteCurrency, sideEffect)
+	}
+
 	m.EmitTradeUpdate(trade)
 	m.EmitBalanceUpdate(m.Account.Balances())
 	return
 }
 
-func (m *SimplePriceMatching) newTradeFromOrder(order types.Order, isMaker bool) types.Trade {
+// commissionRate returns the fee rate of the account by the given liquidity role
+func commissionRate(account *types.Account, isMaker bool) float64 {
 	// BINANCE uses 0.1% for both maker and taker
 	// MAX uses 0.050% for maker and 0.15% for taker
 	var commission = DefaultFeeRate
-	if isMaker && m.Account.MakerCommission > 0 {
-		commission = fixedpoint.NewFromFloat(0.0001).Mul(m.Account.MakerCommission).Float64() // binance uses 10~15
-	} else if m.Account.TakerCommission > 0 {
-		commission = fixedpoint.NewFromFloat(0.0001).Mul(m.Account.TakerCommission).Float64() // binance uses 10~15
+	if isMaker && account.MakerCommission > 0 {
+		commission = fixedpoint.NewFromFloat(0.0001).Mul(account.MakerCommission).Float64() // binance uses 10~15
+	} else if account.TakerCommission > 0 {
+		commission = fixedpoint.NewFromFloat(0.0001).Mul(account.TakerCommission).Float64() // binance uses 10~15
 	}
 
-	var fee float64
-	var feeCurrency string
-
-	switch order.Side {
+	return commission
+}
 
-	case types.SideTypeBuy:
-		fee = order.Quantity * commission
-		feeCurrency = m.Market.BaseCurrency
+func (m *SimplePriceMatching) OpenOrders() []types.Order {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	case types.SideTypeSell:
-		fee = order.Quantity * order.Price * commission
-		feeCurrency = m.Market.QuoteCurrency
+	var orders = make([]types.Order, 0, len(m.bidOrders)+len(m.askOrders)+len(m.stopOrders))
+	orders = append(orders, m.bidOrders...)
+	orders = append(orders, m.askOrders...)
+	return append(orders, m.stopOrders...)
+}
 
+func (m *SimplePriceMatching) Ticker() types.Ticker {
+	kline := m.LastKLine
+	return types.Ticker{
+		Time:   kline.EndTime,
+		Volume: kline.Volume,
+		Last:   kline.Close,
+		Open:   kline.Open,
+		High:   kline.High,
+		Low:    kline.Low,
+		Buy:    kline.Close,
+		Sell:   kline.Close,
 	}
+}
+
+func (m *SimplePriceMatching) newTradeFromOrder(order types.Order, price float64, isMaker bool) types.Trade {
+	fee, feeCurrency := m.fees.Fee(m.Account, m.Market, order.Side, price, order.Quantity, isMaker)
 
-	var id = incTradeID()
+	var id = m.ids.incTradeID()
 	return types.Trade{
 		ID:            int64(id),
 		OrderID:       order.OrderID,
 		Exchange:      "backtest",
-		Price:         order.Price,
+		Price:         price,
 		Quantity:      order.Quantity,
-		QuoteQuantity: order.Quantity * order.Price,
+		QuoteQuantity: order.Quantity * price,
 		Symbol:        order.Symbol,
 		Side:          order.Side,
 		IsBuyer:       order.Side == types.SideTypeBuy,
@@ -243,68 +480,96 @@ func (m *SimplePriceMatching) newTradeFromOrder(order types.Order, isMaker bool)
 	}
 }
 
-func (m *SimplePriceMatching) BuyToPrice(price fixedpoint.Value) (closedOrders []types.Order, trades []types.Trade) {
+// triggerStopOrders activates the stop orders of the given side when the price crosses the stop price.
+// The triggered stop market orders and the marketable stop limit orders are executed at the stop price,
+// the other stop limit orders are moved to the order book as limit orders.
+func (m *SimplePriceMatching) triggerStopOrders(side types.SideType, price fixedpoint.Value) (closedOrders []types.Order, trades []types.Trade) {
 	var priceF = price.Float64()
-	var askOrders []types.Order
-
-	for _, o := range m.askOrders {
-		switch o.Type {
+	var triggeredOrders []types.Order
 
-		case types.OrderTypeStopMarket:
-			// should we trigger the order
-			if priceF <= o.StopPrice {
-				// not triggering it, put it back
-				askOrders = append(askOrders, o)
-				break
-			}
+	m.mu.Lock()
+	var stopOrders []types.Order
+	for _, o := range m.stopOrders {
+		if o.Side == side && ((side == types.SideTypeBuy && priceF >= o.StopPrice) || (side == types.SideTypeSell && priceF <= o.StopPrice)) {
+			triggeredOrders = append(triggeredOrders, o)
+		} else {
+			stopOrders = append(stopOrders, o)
+		}
+	}
+	m.stopOrders = stopOrders
+	m.mu.Unlock()
 
-			o.Type = types.OrderTypeMarket
-			o.ExecutedQuantity = o.Quantity
-			o.Price = priceF
-			o.Status = types.OrderStatusFilled
-			closedOrders = append(closedOrders, o)
+	for _, o := range triggeredOrders {
+		var marketable = o.Type == types.OrderTypeStopMarket ||
+			(side == types.SideTypeBuy && o.Price >= o.StopPrice) ||
+			(side == types.SideTypeSell && o.Price <= o.StopPrice)
 
-			trade := m.newTradeFromOrder(o, false)
-			m.executeTrade(trade)
+		if !marketable {
+			o.UpdateTime = datatype.Time(m.CurrentTime)
 
-			trades = append(trades, trade)
+			m.mu.Lock()
+			if side == types.SideTypeBuy {
+				m.bidOrders = append(m.bidOrders, o)
+			} else {
+				m.askOrders = append(m.askOrders, o)
+			}
+			m.mu.Unlock()
 
 			m.EmitOrderUpdate(o)
+			continue
+		}
 
-		case types.OrderTypeStopLimit:
-			// should we trigger the order?
-			if priceF <= o.StopPrice {
-				askOrders = append(askOrders, o)
-				break
+		// the triggered stop market order is a taker order, so the slippage applies
+		var executionPrice = o.StopPrice
+		if o.Type == types.OrderTypeStopMarket {
+			executionPrice = m.executionPrice(o, o.StopPrice, o.StopPrice)
+		}
+
+		trade := m.newTradeFromOrder(o, executionPrice, false)
+		m.executeTrade(trade, o.MarginSideEffect)
+
+		// release the quote balance locked by the limit price
+		if side == types.SideTypeBuy && o.Type == types.OrderTypeStopLimit && o.Price > o.StopPrice {
+			if err := m.Account.UnlockBalance(m.Market.QuoteCurrency, fixedpoint.NewFromFloat((o.Price-o.StopPrice)*o.Quantity)); err != nil {
+				logrus.WithError(err).Errorf("unable to unlock the stop order balance: %+v", o)
 			}
+			m.EmitBalanceUpdate(m.Account.Balances())
+		}
 
-			o.Type = types.OrderTypeLimit
+		if o.Type == types.OrderTypeStopMarket {
+			o.Price = executionPrice
+		}
 
-			// is it a taker order?
-			if priceF >= o.Price {
-				o.ExecutedQuantity = o.Quantity
-				o.Status = types.OrderStatusFilled
-				closedOrders = append(closedOrders, o)
+		o.ExecutedQuantity = o.Quantity
+		o.Status = types.OrderStatusFilled
+		o.IsWorking = false
+		o.UpdateTime = datatype.Time(m.CurrentTime)
+		closedOrders = append(closedOrders, o)
+		trades = append(trades, trade)
 
-				trade := m.newTradeFromOrder(o, false)
-				m.executeTrade(trade)
+		m.EmitOrderUpdate(o)
+	}
 
-				trades = append(trades, trade)
+	return closedOrders, trades
+}
 
-				m.EmitOrderUpdate(o)
-			} else {
-				// maker order
-				askOrders = append(askOrders, o)
-			}
+func (m *SimplePriceMatching) BuyToPrice(price fixedpoint.Value) (closedOrders []types.Order, trades []types.Trade) {
+	closedOrders, trades = m.triggerStopOrders(types.SideTypeBuy, price)
+
+	var priceF = price.Float64()
+	var askOrders []types.Order
 
-		case types.OrderTypeLimit:
+	for _, o := range m.askOrders {
+		switch o.Type {
+
+		case types.OrderTypeLimit, types.OrderTypeLimitMaker, types.OrderTypeStopLimit:
 			if priceF >= o.Price {
 				o.ExecutedQuantity = o.Quantity
 				o.Status = types.OrderStatusFilled
 				closedOrders = append(closedOrders, o)
 
-				trade := m.newTradeFromOrder(o, true)
-				m.executeTrade(trade)
+				trade := m.newTradeFromOrder(o, o.Price, true)
+				m.executeTrade(trade, o.MarginSideEffect)
 
 				trades = append(trades, trade)
 
@@ -326,21 +591,21 @@ func (m *SimplePriceMatching) BuyToPrice(price fixedpoint.Value) (closedOrders [
 }
 
 func (m *SimplePriceMatching) SellToPrice(price fixedpoint.Value) (closedOrders []types.Order, trades []types.Trade) {
+	closedOrders, trades = m.triggerStopOrders(types.SideTypeSell, price)
+
 	var sellPrice = price.Float64()
 	var bidOrders []types.Order
 	for _, o := range m.bidOrders {
 		switch o.Type {
 
-		case types.OrderTypeStopMarket:
-			// should we trigger the order
-			if sellPrice <= o.StopPrice {
+		case types.OrderTypeLimit, types.OrderTypeLimitMaker, types.OrderTypeStopLimit:
+			if sellPrice <= o.Price {
 				o.ExecutedQuantity = o.Quantity
-				o.Price = sellPrice
 				o.Status = types.OrderStatusFilled
 				closedOrders = append(closedOrders, o)
 
-				trade := m.newTradeFromOrder(o, false)
-				m.executeTrade(trade)
+				trade := m.newTradeFromOrder(o, o.Price, true)
+				m.executeTrade(trade, o.MarginSideEffect)
 
 				trades = append(trades, trade)
 
@@ -349,60 +614,83 @@ func (m *SimplePriceMatching) SellToPrice(price fixedpoint.Value) (closedOrders
 				bidOrders = append(bidOrders, o)
 			}
 
-		case types.OrderTypeStopLimit:
-			// should we trigger the order
-			if sellPrice <= o.StopPrice {
-				o.Type = types.OrderTypeLimit
-
-				if sellPrice <= o.Price {
-					o.ExecutedQuantity = o.Quantity
-					o.Status = types.OrderStatusFilled
-					closedOrders = append(closedOrders, o)
+		default:
+			bidOrders = append(bidOrders, o)
+		}
+	}
 
-					trade := m.newTradeFromOrder(o, false)
-					m.executeTrade(trade)
+	m.bidOrders = bidOrders
+	m.LastPrice = price
 
-					trades = append(trades, trade)
-					m.EmitOrderUpdate(o)
+	return closedOrders, trades
+}
 
-				} else {
-					bidOrders = append(bidOrders, o)
-				}
-			} else {
-				bidOrders = append(bidOrders, o)
-			}
+// activatePendingOrders activates the pending orders arrived before the given time,
+// the market orders are executed at the given price.
+func (m *SimplePriceMatching) activatePendingOrders(t time.Time, price fixedpoint.Value) {
+	var arrived []pendingOrder
 
-		case types.OrderTypeLimit:
-			if sellPrice <= o.Price {
-				o.ExecutedQuantity = o.Quantity
-				o.Status = types.OrderStatusFilled
-				closedOrders = append(closedOrders, o)
+	m.mu.Lock()
+	var pendingOrders []pendingOrder
+	for _, p := range m.pendingOrders {
+		if !p.order.CreationTime.Time().Add(m.submitLatency).After(t) {
+			arrived = append(arrived, p)
+		} else {
+			pendingOrders = append(pendingOrders, p)
+		}
+	}
+	m.pendingOrders = pendingOrders
+	m.mu.Unlock()
 
-				trade := m.newTradeFromOrder(o, true)
-				m.executeTrade(trade)
+	if len(arrived) == 0 {
+		return
+	}
 
-				trades = append(trades, trade)
+	m.LastPrice = price
+	for _, p := range arrived {
+		if _, _, err := m.activateOrder(p.order, p.lockedPrice); err != nil {
+			logrus.WithError(err).Errorf("unable to activate the pending order: %+v", p.order)
+		}
+	}
+}
 
-				m.EmitOrderUpdate(o)
-			} else {
-				bidOrders = append(bidOrders, o)
-			}
+// applyPendingCancels cancels the orders whose cancel requests take effect before the given time,
+// the orders filled in the meantime are skipped.
+func (m *SimplePriceMatching) applyPendingCancels(t time.Time) {
+	var effective []pendingCancel
 
-		default:
-			bidOrders = append(bidOrders, o)
+	m.mu.Lock()
+	var pendingCancels []pendingCancel
+	for _, c := range m.pendingCancels {
+		if !c.time.After(t) {
+			effective = append(effective, c)
+		} else {
+			pendingCancels = append(pendingCancels, c)
 		}
 	}
+	m.pendingCancels = pendingCancels
+	m.mu.Unlock()
 
-	m.bidOrders = bidOrders
-	m.LastPrice = price
+	for _, c := range effective {
+		if !m.hasOrder(c.order.OrderID) {
+			continue
+		}
 
-	return closedOrders, trades
+		if _, err := m.cancelOrder(c.order); err != nil {
+			logrus.WithError(err).Errorf("unable to cancel the order: %+v", c.order)
+		}
+	}
 }
 
 func (m *SimplePriceMatching) processKLine(kline types.KLine) {
 	m.CurrentTime = kline.EndTime
 	m.LastKLine = kline
 
+	// the orders arrived within this kline are matched from the open price,
+	// and the orders stay in the book until the cancel requests take effect.
+	m.activatePendingOrders(kline.EndTime, fixedpoint.NewFromFloat(kline.Open))
+	defer m.applyPendingCancels(kline.EndTime)
+
 	switch kline.Direction() {
 	case types.DirectionDown:
 		if kline.High > kline.Open {
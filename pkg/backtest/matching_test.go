@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/c9s/bbgo/pkg/bbgo"
 	"github.com/c9s/bbgo/pkg/fixedpoint"
 	"github.com/c9s/bbgo/pkg/types"
 )
@@ -47,6 +48,7 @@ func TestSimplePriceMatching_LimitOrder(t *testing.T) {
 		CurrentTime: time.Now(),
 		Account:     account,
 		Market:      market,
+		ids:         &idGenerator{},
 	}
 
 	for i := 0; i < 5; i++ {
@@ -100,3 +102,288 @@ func TestSimplePriceMatching_LimitOrder(t *testing.T) {
 	assert.Len(t, closedOrders, 4)
 	assert.Len(t, trades, 4)
 }
+
+func newStopOrder(symbol string, side types.SideType, orderType types.OrderType, stopPrice, price, quantity float64) types.SubmitOrder {
+	return types.SubmitOrder{
+		Symbol:      symbol,
+		Side:        side,
+		Type:        orderType,
+		Quantity:    quantity,
+		Price:       price,
+		StopPrice:   stopPrice,
+		TimeInForce: "GTC",
+	}
+}
+
+func TestSimplePriceMatching_StopOrder(t *testing.T) {
+	account := &types.Account{
+		MakerCommission: 15,
+		TakerCommission: 15,
+	}
+
+	account.UpdateBalances(types.BalanceMap{
+		"USDT": {Currency: "USDT", Available: fixedpoint.NewFromFloat(100000.0)},
+		"BTC":  {Currency: "BTC", Available: fixedpoint.NewFromFloat(10.0)},
+	})
+
+	market := types.Market{
+		Symbol:          "BTCUSDT",
+		PricePrecision:  8,
+		VolumePrecision: 8,
+		QuoteCurrency:   "USDT",
+		BaseCurrency:    "BTC",
+		MinNotional:     0.001,
+		MinAmount:       10.0,
+		MinQuantity:     0.001,
+	}
+
+	engine := &SimplePriceMatching{
+		CurrentTime: time.Now(),
+		Account:     account,
+		Market:      market,
+		ids:         &idGenerator{},
+		LastPrice:   fixedpoint.NewFromFloat(9000.0),
+	}
+
+	// the sell stop order can not be triggered immediately
+	_, _, err := engine.PlaceOrder(newStopOrder("BTCUSDT", types.SideTypeSell, types.OrderTypeStopMarket, 9100.0, 0, 1.0))
+	assert.Error(t, err)
+
+	_, _, err = engine.PlaceOrder(newStopOrder("BTCUSDT", types.SideTypeSell, types.OrderTypeStopMarket, 8500.0, 0, 1.0))
+	assert.NoError(t, err)
+
+	_, _, err = engine.PlaceOrder(newStopOrder("BTCUSDT", types.SideTypeBuy, types.OrderTypeStopLimit, 9500.0, 9600.0, 1.0))
+	assert.NoError(t, err)
+
+	_, _, err = engine.PlaceOrder(newStopOrder("BTCUSDT", types.SideTypeBuy, types.OrderTypeStopLimit, 9800.0, 9700.0, 1.0))
+	assert.NoError(t, err)
+
+	assert.Len(t, engine.stopOrders, 3)
+	assert.Len(t, engine.OpenOrders(), 3)
+
+	usdt, _ := account.Balance("USDT")
+	assert.Equal(t, fixedpoint.NewFromFloat(9600.0+9700.0), usdt.Locked)
+
+	btc, _ := account.Balance("BTC")
+	assert.Equal(t, fixedpoint.NewFromFloat(1.0), btc.Locked)
+
+	closedOrders, trades := engine.SellToPrice(fixedpoint.NewFromFloat(8600.0))
+	assert.Len(t, closedOrders, 0)
+	assert.Len(t, trades, 0)
+
+	// the sell stop market order is executed at the stop price
+	closedOrders, trades = engine.SellToPrice(fixedpoint.NewFromFloat(8400.0))
+	if assert.Len(t, closedOrders, 1) && assert.Len(t, trades, 1) {
+		assert.Equal(t, types.OrderStatusFilled, closedOrders[0].Status)
+		assert.Equal(t, 8500.0, trades[0].Price)
+		assert.False(t, trades[0].IsMaker)
+	}
+
+	btc, _ = account.Balance("BTC")
+	assert.Equal(t, fixedpoint.Value(0), btc.Locked)
+
+	// the buy stop limit order is executed at the stop price, and the buy stop limit order with the limit price lower
+	// than the stop price becomes a limit order
+	closedOrders, trades = engine.BuyToPrice(fixedpoint.NewFromFloat(9900.0))
+	if assert.Len(t, closedOrders, 1) && assert.Len(t, trades, 1) {
+		assert.Equal(t, 9500.0, trades[0].Price)
+	}
+	assert.Len(t, engine.stopOrders, 0)
+	assert.Len(t, engine.bidOrders, 1)
+
+	usdt, _ = account.Balance("USDT")
+	assert.Equal(t, fixedpoint.NewFromFloat(9700.0), usdt.Locked)
+
+	closedOrders, trades = engine.SellToPrice(fixedpoint.NewFromFloat(9650.0))
+	assert.Len(t, closedOrders, 1)
+	assert.Len(t, trades, 1)
+	assert.Len(t, engine.bidOrders, 0)
+
+	usdt, _ = account.Balance("USDT")
+	assert.Equal(t, fixedpoint.Value(0), usdt.Locked)
+}
+
+func TestSimplePriceMatching_CancelStopOrder(t *testing.T) {
+	account := &types.Account{}
+	account.UpdateBalances(types.BalanceMap{
+		"USDT": {Currency: "USDT", Available: fixedpoint.NewFromFloat(100000.0)},
+	})
+
+	engine := &SimplePriceMatching{
+		CurrentTime: time.Now(),
+		Account:     account,
+		Market:      types.Market{Symbol: "BTCUSDT", QuoteCurrency: "USDT", BaseCurrency: "BTC"},
+		ids:         &idGenerator{},
+	}
+
+	order, _, err := engine.PlaceOrder(newStopOrder("BTCUSDT", types.SideTypeBuy, types.OrderTypeStopMarket, 9500.0, 0, 1.0))
+	assert.NoError(t, err)
+
+	usdt, _ := account.Balance("USDT")
+	assert.Equal(t, fixedpoint.NewFromFloat(9500.0), usdt.Locked)
+
+	canceled, err := engine.CancelOrder(*order)
+	assert.NoError(t, err)
+	assert.Equal(t, types.OrderStatusCanceled, canceled.Status)
+	assert.Len(t, engine.stopOrders, 0)
+
+	usdt, _ = account.Balance("USDT")
+	assert.Equal(t, fixedpoint.Value(0), usdt.Locked)
+}
+
+func newTestSimplePriceMatching() *SimplePriceMatching {
+	account := &types.Account{}
+	account.UpdateBalances(types.BalanceMap{
+		"USDT": {Currency: "USDT", Available: fixedpoint.NewFromFloat(100000.0)},
+		"BTC":  {Currency: "BTC", Available: fixedpoint.NewFromFloat(10.0)},
+		"BNB":  {Currency: "BNB", Available: fixedpoint.NewFromFloat(1.0)},
+	})
+
+	return &SimplePriceMatching{
+		CurrentTime: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		Account:     account,
+		Market:      types.Market{Symbol: "BTCUSDT", QuoteCurrency: "USDT", BaseCurrency: "BTC"},
+		ids:         &idGenerator{},
+		LastPrice:   fixedpoint.NewFromFloat(10000.0),
+	}
+}
+
+func newTestKLine(startTime time.Time, open, high, low, close float64) types.KLine {
+	return types.KLine{
+		Symbol:    "BTCUSDT",
+		Interval:  types.Interval1m,
+		StartTime: startTime,
+		EndTime:   startTime.Add(time.Minute),
+		Open:      open,
+		High:      high,
+		Low:       low,
+		Close:     close,
+		Volume:    10.0,
+	}
+}
+
+func TestSimplePriceMatching_MarketOrderSlippage(t *testing.T) {
+	engine := newTestSimplePriceMatching()
+	engine.slippage = PercentageSlippage{Ratio: 0.001}
+
+	order, trade, err := engine.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeBuy, Type: types.OrderTypeMarket, Quantity: 1.0})
+	assert.NoError(t, err)
+	assert.Equal(t, types.OrderStatusFilled, order.Status)
+	assert.Equal(t, 10010.0, trade.Price)
+
+	usdt, _ := engine.Account.Balance("USDT")
+	assert.Equal(t, fixedpoint.NewFromFloat(100000.0-10010.0), usdt.Available)
+	assert.Equal(t, fixedpoint.Value(0), usdt.Locked)
+
+	engine.LastKLine = types.KLine{Volume: 4.0}
+	engine.slippage = VolumeSlippage{Ratio: 0.01}
+	_, trade, err = engine.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeSell, Type: types.OrderTypeMarket, Quantity: 2.0})
+	assert.NoError(t, err)
+	assert.Equal(t, 9950.0, trade.Price)
+
+	engine.slippage = FixedSlippage{Offset: 5.0}
+	_, trade, err = engine.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeSell, Type: types.OrderTypeMarket, Quantity: 1.0})
+	assert.NoError(t, err)
+	assert.Equal(t, 9995.0, trade.Price)
+}
+
+func TestSimplePriceMatching_Latency(t *testing.T) {
+	engine := newTestSimplePriceMatching()
+	engine.submitLatency = 90 * time.Second
+	engine.cancelLatency = 90 * time.Second
+
+	var trades []types.Trade
+	engine.OnTradeUpdate(func(trade types.Trade) {
+		trades = append(trades, trade)
+	})
+
+	startTime := engine.CurrentTime
+	order, trade, err := engine.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeBuy, Type: types.OrderTypeMarket, Quantity: 1.0})
+	assert.NoError(t, err)
+	assert.Nil(t, trade)
+	assert.Equal(t, types.OrderStatusNew, order.Status)
+
+	// the order does not arrive within the first minute
+	engine.processKLine(newTestKLine(startTime, 10000.0, 10100.0, 9900.0, 10050.0))
+	assert.Len(t, trades, 0)
+
+	engine.processKLine(newTestKLine(startTime.Add(time.Minute), 10050.0, 10200.0, 10000.0, 10100.0))
+	if assert.Len(t, trades, 1) {
+		assert.Equal(t, 10050.0, trades[0].Price)
+	}
+
+	usdt, _ := engine.Account.Balance("USDT")
+	assert.Equal(t, fixedpoint.NewFromFloat(100000.0-10050.0), usdt.Available)
+	assert.Equal(t, fixedpoint.Value(0), usdt.Locked)
+
+	// the sell order could be filled before the cancel request takes effect
+	engine.submitLatency = 0
+	order, _, err = engine.PlaceOrder(newLimitOrder("BTCUSDT", types.SideTypeSell, 10300.0, 1.0))
+	assert.NoError(t, err)
+
+	_, err = engine.CancelOrder(*order)
+	assert.NoError(t, err)
+	assert.Len(t, engine.OpenOrders(), 1)
+
+	engine.processKLine(newTestKLine(startTime.Add(2*time.Minute), 10100.0, 10400.0, 10100.0, 10200.0))
+	assert.Len(t, trades, 2)
+	assert.Len(t, engine.OpenOrders(), 0)
+
+	// the cancel request takes effect after the latency
+	order, _, err = engine.PlaceOrder(newLimitOrder("BTCUSDT", types.SideTypeSell, 10500.0, 1.0))
+	assert.NoError(t, err)
+
+	_, err = engine.CancelOrder(*order)
+	assert.NoError(t, err)
+
+	engine.processKLine(newTestKLine(startTime.Add(3*time.Minute), 10200.0, 10300.0, 10100.0, 10200.0))
+	assert.Len(t, engine.OpenOrders(), 1)
+
+	engine.processKLine(newTestKLine(startTime.Add(4*time.Minute), 10200.0, 10300.0, 10100.0, 10200.0))
+	assert.Len(t, engine.OpenOrders(), 0)
+
+	btc, _ := engine.Account.Balance("BTC")
+	assert.Equal(t, fixedpoint.Value(0), btc.Locked)
+}
+
+func TestSimplePriceMatching_FeeModel(t *testing.T) {
+	engine := newTestSimplePriceMatching()
+	engine.fees = newFeeModel(&bbgo.BacktestFees{
+		Symbols: map[string]bbgo.BacktestFeeRate{
+			"BTCUSDT": {MakerFeeRate: fixedpoint.NewFromFloat(0.0002), TakerFeeRate: fixedpoint.NewFromFloat(0.001)},
+		},
+	}, nil)
+
+	_, trade, err := engine.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeSell, Type: types.OrderTypeMarket, Quantity: 1.0})
+	assert.NoError(t, err)
+	assert.Equal(t, "USDT", trade.FeeCurrency)
+	assert.InDelta(t, 10.0, trade.Fee, 1e-9)
+
+	usdt, _ := engine.Account.Balance("USDT")
+	assert.Equal(t, fixedpoint.NewFromFloat(100000.0+10000.0-10.0), usdt.Available)
+
+	// pay the fee with BNB at 25% off, the price of BNB is 50 USDT
+	engine.fees = newFeeModel(&bbgo.BacktestFees{
+		PlatformCurrency: "BNB",
+		PlatformDiscount: fixedpoint.NewFromFloat(0.25),
+		Symbols: map[string]bbgo.BacktestFeeRate{
+			"BTCUSDT": {MakerFeeRate: fixedpoint.NewFromFloat(0.0002), TakerFeeRate: fixedpoint.NewFromFloat(0.001)},
+		},
+	}, func(base, quote string) (float64, bool) {
+		return 50.0, base == "BNB" && quote == "USDT"
+	})
+
+	_, trade, err = engine.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeBuy, Type: types.OrderTypeMarket, Quantity: 1.0})
+	assert.NoError(t, err)
+	assert.Equal(t, "BNB", trade.FeeCurrency)
+	assert.InDelta(t, 0.15, trade.Fee, 1e-9)
+
+	bnb, _ := engine.Account.Balance("BNB")
+	assert.Equal(t, fixedpoint.NewFromFloat(0.85), bnb.Available)
+
+	// not enough BNB, the fee is paid in the base currency
+	_, trade, err = engine.PlaceOrder(types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeBuy, Type: types.OrderTypeMarket, Quantity: 6.0})
+	assert.NoError(t, err)
+	assert.Equal(t, "BTC", trade.FeeCurrency)
+	assert.InDelta(t, 0.006, trade.Fee, 1e-9)
+}
@@ -0,0 +1,633 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/c9s/bbgo/pkg/datatype"
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+// bookOrder is an order resting in the order book matching engine
+type bookOrder struct {
+	types.Order
+
+	// queueAhead is the market volume queued before this order at the same price level
+	queueAhead fixedpoint.Value
+
+	// locked is the remaining balance locked by this order
+	locked fixedpoint.Value
+}
+
+func (o *bookOrder) remaining() fixedpoint.Value {
+	return fixedpoint.NewFromFloat(o.Quantity - o.ExecutedQuantity)
+}
+
+// OrderBookMatching implements a matching engine driven by the recorded market trades and depth snapshots.
+// It simulates the price-time priority and the queue position of our orders in the market book,
+// so that orders could be partially filled and marketable orders pay the spread.
+//
+// All the entry points hold the lock during the whole operation, the updates are queued and emitted after the lock
+// is released, so that the callbacks can place or cancel orders.
+//
+//go:generate callbackgen -type OrderBookMatching
+type OrderBookMatching struct {
+	Symbol string
+	Market types.Market
+
+	mu        sync.Mutex
+	book      types.OrderBook
+	bidOrders []*bookOrder
+	askOrders []*bookOrder
+
+	LastPrice     fixedpoint.Value
+	LastTradeTime time.Time
+	LastKLine     types.KLine
+	CurrentTime   time.Time
+
+	Account *types.Account
+
+	ids    *idGenerator
+	fees   *FeeModel
+	margin *MarginAccount
+
+	// events are the updates queued while the lock is held
+	events []func()
+
+	tradeUpdateCallbacks   []func(trade types.Trade)
+	orderUpdateCallbacks   []func(order types.Order)
+	balanceUpdateCallbacks []func(balances types.BalanceMap)
+}
+
+func (m *OrderBookMatching) OpenOrders() (orders []types.Order) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, o := range m.bidOrders {
+		orders = append(orders, o.Order)
+	}
+
+	for _, o := range m.askOrders {
+		orders = append(orders, o.Order)
+	}
+
+	return orders
+}
+
+func (m *OrderBookMatching) Ticker() types.Ticker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kline := m.LastKLine
+	ticker := types.Ticker{
+		Time:   m.CurrentTime,
+		Volume: kline.Volume,
+		Last:   m.LastPrice.Float64(),
+		Open:   kline.Open,
+		High:   kline.High,
+		Low:    kline.Low,
+		Buy:    m.LastPrice.Float64(),
+		Sell:   m.LastPrice.Float64(),
+	}
+
+	if bid, ok := m.book.BestBid(); ok {
+		ticker.Buy = bid.Price.Float64()
+	}
+
+	if ask, ok := m.book.BestAsk(); ok {
+		ticker.Sell = ask.Price.Float64()
+	}
+
+	return ticker
+}
+
+// Book returns a copy of the current market book
+func (m *OrderBookMatching) Book() types.OrderBook {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.book.Copy()
+}
+
+// unlock releases the lock and emits the queued updates
+func (m *OrderBookMatching) unlock() {
+	events := m.events
+	m.events = nil
+	m.mu.Unlock()
+
+	for _, emit := range events {
+		emit()
+	}
+}
+
+func (m *OrderBookMatching) emitTradeUpdate(trade types.Trade) {
+	m.events = append(m.events, func() { m.EmitTradeUpdate(trade) })
+}
+
+func (m *OrderBookMatching) emitOrderUpdate(order types.Order) {
+	m.events = append(m.events, func() { m.EmitOrderUpdate(order) })
+}
+
+func (m *OrderBookMatching) emitBalanceUpdate() {
+	balances := m.Account.Balances()
+	m.events = append(m.events, func() { m.EmitBalanceUpdate(balances) })
+}
+
+func (m *OrderBookMatching) PlaceOrder(o types.SubmitOrder) (*types.Order, *types.Trade, error) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	switch o.Type {
+	case types.OrderTypeMarket, types.OrderTypeLimit, types.OrderTypeLimitMaker:
+	default:
+		return nil, nil, fmt.Errorf("order type %s is not supported by the orderbook matching engine", o.Type)
+	}
+
+	quantity := fixedpoint.NewFromFloat(o.Quantity)
+	price := fixedpoint.NewFromFloat(o.Price)
+
+	// find the matchable liquidity from the opposite side of the book
+	var fills, rest types.PriceVolumeSlice
+	switch o.Type {
+	case types.OrderTypeMarket:
+		fills, rest = m.sweep(o.Side, quantity, nil)
+		if len(fills) == 0 {
+			return nil, nil, fmt.Errorf("no liquidity for the %s market order of %s", o.Side, o.Symbol)
+		}
+
+	default:
+		fills, rest = m.sweep(o.Side, quantity, &price)
+		if len(fills) > 0 && o.Type == types.OrderTypeLimitMaker {
+			return nil, nil, fmt.Errorf("limit maker order %s %f @ %f would immediately match", o.Side, o.Quantity, o.Price)
+		}
+	}
+
+	order := &bookOrder{
+		Order: types.Order{
+			OrderID:      m.ids.incOrderID(),
+			SubmitOrder:  o,
+			Exchange:     "backtest",
+			Status:       types.OrderStatusNew,
+			IsWorking:    true,
+			CreationTime: datatype.Time(m.CurrentTime),
+			UpdateTime:   datatype.Time(m.CurrentTime),
+		},
+	}
+
+	var currency string
+	switch o.Side {
+	case types.SideTypeBuy:
+		currency = m.Market.QuoteCurrency
+		if o.Type == types.OrderTypeMarket {
+			for _, fill := range fills {
+				order.locked += fill.Price.Mul(fill.Volume)
+			}
+		} else {
+			order.locked = price.Mul(quantity)
+		}
+
+	case types.SideTypeSell:
+		currency = m.Market.BaseCurrency
+		if o.Type == types.OrderTypeMarket {
+			for _, fill := range fills {
+				order.locked += fill.Volume
+			}
+		} else {
+			order.locked = quantity
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("invalid order side: %s", o.Side)
+	}
+
+	if err := m.margin.LockBalance(m.Account, currency, order.locked, o.MarginSideEffect); err != nil {
+		return nil, nil, err
+	}
+
+	m.emitBalanceUpdate()
+	m.emitOrderUpdate(order.Order)
+
+	if o.Type == types.OrderTypeMarket {
+		order.Price = averagePrice(fills)
+	}
+
+	// take the liquidity from the book
+	m.setLevels(o.Side.Reverse(), rest)
+	for _, fill := range fills {
+		m.fill(order, fill.Price, fill.Volume, false)
+		m.LastPrice = fill.Price
+	}
+
+	if o.Type == types.OrderTypeMarket || o.TimeInForce == types.TimeInForceIOC {
+		if order.Status != types.OrderStatusFilled {
+			if err := m.cancel(order); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		createdOrder := order.Order
+		return &createdOrder, nil, nil
+	}
+
+	if order.Status != types.OrderStatusFilled {
+		// our order joins the end of the queue of the price level
+		order.queueAhead = volumeAt(m.levels(o.Side), price, o.Side == types.SideTypeBuy)
+		m.enqueue(order)
+	}
+
+	createdOrder := order.Order
+	return &createdOrder, nil, nil
+}
+
+func averagePrice(fills types.PriceVolumeSlice) float64 {
+	var quote, base fixedpoint.Value
+	for _, fill := range fills {
+		quote += fill.Price.Mul(fill.Volume)
+		base += fill.Volume
+	}
+
+	if base == 0 {
+		return 0
+	}
+
+	return quote.Div(base).Float64()
+}
+
+// forcePlaceOrder places the order immediately, the order book matching engine has no simulated latency
+func (m *OrderBookMatching) forcePlaceOrder(o types.SubmitOrder) (*types.Order, *types.Trade, error) {
+	return m.PlaceOrder(o)
+}
+
+func (m *OrderBookMatching) cancelAll() error {
+	m.mu.Lock()
+	defer m.unlock()
+
+	for _, side := range []types.SideType{types.SideTypeBuy, types.SideTypeSell} {
+		for _, order := range m.ordersBySide(side) {
+			m.dequeue(order)
+			if err := m.cancel(order); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *OrderBookMatching) CancelOrder(o types.Order) (types.Order, error) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	for _, order := range m.ordersBySide(o.Side) {
+		if order.OrderID == o.OrderID {
+			m.dequeue(order)
+			if err := m.cancel(order); err != nil {
+				return o, err
+			}
+			return order.Order, nil
+		}
+	}
+
+	return o, fmt.Errorf("cancel order failed, order %d not found: %+v", o.OrderID, o)
+}
+
+func (m *OrderBookMatching) processKLine(kline types.KLine) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	m.LastKLine = kline
+	if m.CurrentTime.Before(kline.EndTime) {
+		m.CurrentTime = kline.EndTime
+	}
+
+	// use the kline close price when there is no recorded trade in the kline
+	if m.LastTradeTime.Before(kline.StartTime) {
+		m.LastPrice = fixedpoint.NewFromFloat(kline.Close)
+	}
+}
+
+// processDepthSnapshot replaces the market book with the recorded snapshot
+func (m *OrderBookMatching) processDepthSnapshot(depth DepthSnapshotRecord) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	m.CurrentTime = depth.Time
+
+	book := depth.OrderBook(m.Symbol)
+	sort.Sort(sort.Reverse(book.Bids))
+	sort.Sort(book.Asks)
+	m.setLevels(types.SideTypeBuy, book.Bids.Trim())
+	m.setLevels(types.SideTypeSell, book.Asks.Trim())
+
+	// the volume ahead of our orders can only be reduced by cancellations
+	for _, side := range []types.SideType{types.SideTypeBuy, types.SideTypeSell} {
+		levels := m.levels(side)
+		for _, order := range m.ordersBySide(side) {
+			volume := volumeAt(levels, fixedpoint.NewFromFloat(order.Price), side == types.SideTypeBuy)
+			if volume < order.queueAhead {
+				order.queueAhead = volume
+			}
+		}
+	}
+
+	// the market moved through our price without trades recorded, fill our orders with the crossed volume
+	m.matchCrossedOrders(types.SideTypeBuy)
+	m.matchCrossedOrders(types.SideTypeSell)
+}
+
+func (m *OrderBookMatching) matchCrossedOrders(side types.SideType) {
+	for _, order := range m.ordersBySide(side) {
+		if !order.IsWorking {
+			continue
+		}
+
+		price := fixedpoint.NewFromFloat(order.Price)
+		fills, rest := m.sweep(side, order.remaining(), &price)
+		if len(fills) == 0 {
+			continue
+		}
+
+		m.setLevels(side.Reverse(), rest)
+		order.queueAhead = 0
+		for _, fill := range fills {
+			// our order was resting in the book, so it's filled at our price as a maker
+			m.fill(order, price, fill.Volume, true)
+		}
+
+		if order.Status == types.OrderStatusFilled {
+			m.dequeue(order)
+		}
+	}
+}
+
+// processMarketTrade matches our resting orders with the recorded market trade
+func (m *OrderBookMatching) processMarketTrade(trade MarketTradeRecord) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	m.CurrentTime = trade.Time
+	m.LastPrice = trade.Price
+	m.LastTradeTime = trade.Time
+
+	// a taker buy trade consumes the asks, and a taker sell trade consumes the bids
+	makerSide := trade.Side.Reverse()
+	isBid := makerSide == types.SideTypeBuy
+
+	remaining := trade.Quantity
+	var consumedAhead fixedpoint.Value
+	for _, order := range m.ordersBySide(makerSide) {
+		if !order.IsWorking {
+			continue
+		}
+
+		price := fixedpoint.NewFromFloat(order.Price)
+
+		switch {
+		case (isBid && price > trade.Price) || (!isBid && price < trade.Price):
+			// the market traded through our price, our order has a better price than the trade
+			m.fill(order, price, order.remaining(), true)
+
+		case price == trade.Price:
+			if remaining <= 0 {
+				continue
+			}
+
+			// consume the market volume queued before our order first
+			if ahead := order.queueAhead - consumedAhead; ahead > 0 {
+				consumed := fixedpoint.Min(ahead, remaining)
+				consumedAhead += consumed
+				remaining -= consumed
+			}
+
+			if remaining <= 0 {
+				continue
+			}
+
+			quantity := fixedpoint.Min(order.remaining(), remaining)
+			remaining -= quantity
+			m.fill(order, price, quantity, true)
+		}
+	}
+
+	for _, order := range m.ordersBySide(makerSide) {
+		if order.Status == types.OrderStatusFilled {
+			m.dequeue(order)
+			continue
+		}
+
+		if fixedpoint.NewFromFloat(order.Price) == trade.Price {
+			order.queueAhead = fixedpoint.Max(0, order.queueAhead-consumedAhead)
+		}
+	}
+
+	// remove the traded volume from the market book
+	levels := m.levels(makerSide)
+	if pv, idx := levels.Find(trade.Price, isBid); pv.Price == trade.Price {
+		levels = levels.Copy()
+		if pv.Volume > trade.Quantity {
+			levels[idx].Volume = pv.Volume - trade.Quantity
+		} else {
+			levels = levels.Remove(trade.Price, isBid)
+		}
+		m.setLevels(makerSide, levels)
+	}
+}
+
+// volumeAt returns the volume of the given price level
+func volumeAt(levels types.PriceVolumeSlice, price fixedpoint.Value, descending bool) fixedpoint.Value {
+	pv, _ := levels.Find(price, descending)
+	if pv.Price != price {
+		return 0
+	}
+	return pv.Volume
+}
+
+// sweep finds the liquidity on the opposite side of the book for the given taker side,
+// if the limit price is given, only the price levels better than or equal to the limit price are matched.
+// It returns the matched price volumes and the remaining levels of the opposite side.
+func (m *OrderBookMatching) sweep(side types.SideType, quantity fixedpoint.Value, limitPrice *fixedpoint.Value) (fills, rest types.PriceVolumeSlice) {
+	levels := m.levels(side.Reverse()).Copy()
+
+	for len(levels) > 0 && quantity > 0 {
+		level := levels[0]
+		if limitPrice != nil {
+			if side == types.SideTypeBuy && level.Price > *limitPrice {
+				break
+			}
+
+			if side == types.SideTypeSell && level.Price < *limitPrice {
+				break
+			}
+		}
+
+		volume := fixedpoint.Min(level.Volume, quantity)
+		fills = append(fills, types.PriceVolume{Price: level.Price, Volume: volume})
+		quantity -= volume
+
+		if volume < level.Volume {
+			levels[0].Volume -= volume
+		} else {
+			levels = levels[1:]
+		}
+	}
+
+	return fills, levels
+}
+
+// levels returns the price levels of the market book, it's called with the lock held
+func (m *OrderBookMatching) levels(side types.SideType) types.PriceVolumeSlice {
+	return m.book.PriceVolumesBySide(side)
+}
+
+func (m *OrderBookMatching) setLevels(side types.SideType, levels types.PriceVolumeSlice) {
+	switch side {
+	case types.SideTypeBuy:
+		m.book.Bids = levels
+	case types.SideTypeSell:
+		m.book.Asks = levels
+	}
+}
+
+// fill executes the given quantity of the order, updates the account balances and emits the updates
+func (m *OrderBookMatching) fill(order *bookOrder, price, quantity fixedpoint.Value, isMaker bool) {
+	order.ExecutedQuantity = fixedpoint.NewFromFloat(order.ExecutedQuantity + quantity.Float64()).Float64()
+	order.UpdateTime = datatype.Time(m.CurrentTime)
+	if order.remaining() <= 0 {
+		order.Status = types.OrderStatusFilled
+		order.IsWorking = false
+	} else {
+		order.Status = types.OrderStatusPartiallyFilled
+	}
+
+	var err error
+	switch order.Side {
+	case types.SideTypeBuy:
+		// the locked quote is released by the order price, the price difference is returned
+		release := quantity.Mul(price)
+		if order.Type != types.OrderTypeMarket {
+			release = quantity.MulFloat64(order.Price)
+		}
+		if release > order.locked || order.Status == types.OrderStatusFilled {
+			release = order.locked
+		}
+
+		cost := fixedpoint.Min(release, quantity.Mul(price))
+		order.locked -= release
+		err = m.Account.UseLockedBalance(m.Market.QuoteCurrency, cost)
+		if err == nil && release > cost {
+			err = m.Account.UnlockBalance(m.Market.QuoteCurrency, release-cost)
+		}
+		_ = m.Account.AddBalance(m.Market.BaseCurrency, quantity)
+
+	case types.SideTypeSell:
+		release := quantity
+		if release > order.locked || order.Status == types.OrderStatusFilled {
+			release = order.locked
+		}
+
+		order.locked -= release
+		err = m.Account.UseLockedBalance(m.Market.BaseCurrency, release)
+		_ = m.Account.AddBalance(m.Market.QuoteCurrency, quantity.Mul(price))
+	}
+
+	if err != nil {
+		panic(errors.Wrapf(err, "order book matching exception, wanted to use more than the locked balance"))
+	}
+
+	trade := m.newTrade(order, price, quantity, isMaker)
+	m.fees.Charge(m.Account, trade)
+
+	if order.Side == types.SideTypeBuy {
+		m.margin.AutoRepay(m.Account, m.Market.BaseCurrency, order.MarginSideEffect)
+	} else {
+		m.margin.AutoRepay(m.Account, m.Market.QuoteCurrency, order.MarginSideEffect)
+	}
+
+	m.emitTradeUpdate(trade)
+	m.emitOrderUpdate(order.Order)
+	m.emitBalanceUpdate()
+}
+
+func (m *OrderBookMatching) newTrade(order *bookOrder, price, quantity fixedpoint.Value, isMaker bool) types.Trade {
+	fee, feeCurrency := m.fees.Fee(m.Account, m.Market, order.Side, price.Float64(), quantity.Float64(), isMaker)
+
+	return types.Trade{
+		ID:            int64(m.ids.incTradeID()),
+		OrderID:       order.OrderID,
+		Exchange:      "backtest",
+		Price:         price.Float64(),
+		Quantity:      quantity.Float64(),
+		QuoteQuantity: quantity.Mul(price).Float64(),
+		Symbol:        order.Symbol,
+		Side:          order.Side,
+		IsBuyer:       order.Side == types.SideTypeBuy,
+		IsMaker:       isMaker,
+		Time:          datatype.Time(m.CurrentTime),
+		Fee:           fee,
+		FeeCurrency:   feeCurrency,
+	}
+}
+
+// cancel releases the locked balance of the order and emits the canceled order
+func (m *OrderBookMatching) cancel(order *bookOrder) error {
+	var currency = m.Market.QuoteCurrency
+	if order.Side == types.SideTypeSell {
+		currency = m.Market.BaseCurrency
+	}
+
+	if order.locked > 0 {
+		if err := m.Account.UnlockBalance(currency, order.locked); err != nil {
+			return err
+		}
+		order.locked = 0
+	}
+
+	order.Status = types.OrderStatusCanceled
+	order.IsWorking = false
+	order.UpdateTime = datatype.Time(m.CurrentTime)
+	m.emitOrderUpdate(order.Order)
+	m.emitBalanceUpdate()
+	return nil
+}
+
+// ordersBySide returns a copy of the resting orders sorted by the price-time priority
+func (m *OrderBookMatching) ordersBySide(side types.SideType) []*bookOrder {
+	if side == types.SideTypeBuy {
+		return append([]*bookOrder{}, m.bidOrders...)
+	}
+	return append([]*bookOrder{}, m.askOrders...)
+}
+
+func (m *OrderBookMatching) enqueue(order *bookOrder) {
+	switch order.Side {
+	case types.SideTypeBuy:
+		idx := sort.Search(len(m.bidOrders), func(i int) bool {
+			return m.bidOrders[i].Price < order.Price
+		})
+		m.bidOrders = append(m.bidOrders[:idx], append([]*bookOrder{order}, m.bidOrders[idx:]...)...)
+
+	case types.SideTypeSell:
+		idx := sort.Search(len(m.askOrders), func(i int) bool {
+			return m.askOrders[i].Price > order.Price
+		})
+		m.askOrders = append(m.askOrders[:idx], append([]*bookOrder{order}, m.askOrders[idx:]...)...)
+	}
+}
+
+func (m *OrderBookMatching) dequeue(order *bookOrder) {
+	var orders = &m.bidOrders
+	if order.Side == types.SideTypeSell {
+		orders = &m.askOrders
+	}
+
+	for i, o := range *orders {
+		if o == order {
+			*orders = append((*orders)[:i], (*orders)[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func newTestOrderBookMatching() *OrderBookMatching {
+	account := &types.Account{
+		MakerCommission: 15,
+		TakerCommission: 15,
+	}
+
+	account.UpdateBalances(types.BalanceMap{
+		"USDT": {Currency: "USDT", Available: fixedpoint.NewFromFloat(1000000.0)},
+		"BTC":  {Currency: "BTC", Available: fixedpoint.NewFromFloat(100.0)},
+	})
+
+	market := types.Market{
+		Symbol:          "BTCUSDT",
+		PricePrecision:  8,
+		VolumePrecision: 8,
+		QuoteCurrency:   "USDT",
+		BaseCurrency:    "BTC",
+		MinNotional:     0.001,
+		MinAmount:       10.0,
+		MinQuantity:     0.001,
+	}
+
+	engine := &OrderBookMatching{
+		Symbol:      "BTCUSDT",
+		CurrentTime: time.Now(),
+		Account:     account,
+		Market:      market,
+		ids:         &idGenerator{},
+	}
+
+	engine.processDepthSnapshot(DepthSnapshotRecord{
+		Time: time.Now(),
+		Bids: [][2]fixedpoint.Value{
+			{fixedpoint.NewFromFloat(8999.0), fixedpoint.NewFromFloat(1.0)},
+			{fixedpoint.NewFromFloat(8998.0), fixedpoint.NewFromFloat(2.0)},
+		},
+		Asks: [][2]fixedpoint.Value{
+			{fixedpoint.NewFromFloat(9001.0), fixedpoint.NewFromFloat(1.0)},
+			{fixedpoint.NewFromFloat(9002.0), fixedpoint.NewFromFloat(2.0)},
+		},
+	})
+	return engine
+}
+
+func TestOrderBookMatching_MarketOrderSweepsTheBook(t *testing.T) {
+	engine := newTestOrderBookMatching()
+
+	var trades []types.Trade
+	engine.OnTradeUpdate(func(trade types.Trade) {
+		trades = append(trades, trade)
+	})
+
+	order, _, err := engine.PlaceOrder(types.SubmitOrder{
+		Symbol:   "BTCUSDT",
+		Side:     types.SideTypeBuy,
+		Type:     types.OrderTypeMarket,
+		Quantity: 2.0,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, types.OrderStatusFilled, order.Status)
+	assert.Equal(t, 9001.5, order.Price)
+
+	if assert.Len(t, trades, 2) {
+		assert.Equal(t, 9001.0, trades[0].Price)
+		assert.Equal(t, 9002.0, trades[1].Price)
+		assert.False(t, trades[0].IsMaker)
+	}
+
+	book := engine.Book()
+	ask, ok := book.BestAsk()
+	assert.True(t, ok)
+	assert.Equal(t, fixedpoint.NewFromFloat(9002.0), ask.Price)
+	assert.Equal(t, fixedpoint.NewFromFloat(1.0), ask.Volume)
+
+	balance, _ := engine.Account.Balance("USDT")
+	assert.Equal(t, fixedpoint.NewFromFloat(1000000.0-9001.0-9002.0), balance.Available)
+	assert.Equal(t, fixedpoint.Value(0), balance.Locked)
+}
+
+func TestOrderBookMatching_LimitOrderCrossingTheSpread(t *testing.T) {
+	engine := newTestOrderBookMatching()
+
+	order, _, err := engine.PlaceOrder(newLimitOrder("BTCUSDT", types.SideTypeBuy, 9001.0, 3.0))
+	assert.NoError(t, err)
+	assert.Equal(t, types.OrderStatusPartiallyFilled, order.Status)
+	assert.Equal(t, 1.0, order.ExecutedQuantity)
+
+	openOrders := engine.OpenOrders()
+	if assert.Len(t, openOrders, 1) {
+		assert.Equal(t, 2.0, openOrders[0].Quantity-openOrders[0].ExecutedQuantity)
+	}
+
+	balance, _ := engine.Account.Balance("USDT")
+	assert.Equal(t, fixedpoint.NewFromFloat(9001.0*2.0), balance.Locked)
+
+	_, _, err = engine.PlaceOrder(types.SubmitOrder{
+		Symbol:   "BTCUSDT",
+		Side:     types.SideTypeSell,
+		Type:     types.OrderTypeLimitMaker,
+		Price:    8999.0,
+		Quantity: 1.0,
+	})
+	assert.Error(t, err)
+}
+
+func TestOrderBookMatching_QueuePosition(t *testing.T) {
+	engine := newTestOrderBookMatching()
+
+	var trades []types.Trade
+	engine.OnTradeUpdate(func(trade types.Trade) {
+		trades = append(trades, trade)
+	})
+
+	// 1.0 BTC is queued before our order at 8999
+	_, _, err := engine.PlaceOrder(newLimitOrder("BTCUSDT", types.SideTypeBuy, 8999.0, 1.0))
+	assert.NoError(t, err)
+
+	engine.processMarketTrade(MarketTradeRecord{
+		Time:     time.Now(),
+		Price:    fixedpoint.NewFromFloat(8999.0),
+		Quantity: fixedpoint.NewFromFloat(0.6),
+		Side:     types.SideTypeSell,
+	})
+	assert.Len(t, trades, 0)
+
+	engine.processMarketTrade(MarketTradeRecord{
+		Time:     time.Now(),
+		Price:    fixedpoint.NewFromFloat(8999.0),
+		Quantity: fixedpoint.NewFromFloat(0.6),
+		Side:     types.SideTypeSell,
+	})
+	if assert.Len(t, trades, 1) {
+		assert.True(t, trades[0].IsMaker)
+		assert.Equal(t, 0.2, trades[0].Quantity)
+	}
+
+	// the market trades through our price
+	engine.processMarketTrade(MarketTradeRecord{
+		Time:     time.Now(),
+		Price:    fixedpoint.NewFromFloat(8998.0),
+		Quantity: fixedpoint.NewFromFloat(0.1),
+		Side:     types.SideTypeSell,
+	})
+	if assert.Len(t, trades, 2) {
+		assert.Equal(t, 8999.0, trades[1].Price)
+		assert.Equal(t, 0.8, trades[1].Quantity)
+	}
+	assert.Len(t, engine.OpenOrders(), 0)
+}
+
+func TestOrderBookMatching_CancelOrder(t *testing.T) {
+	engine := newTestOrderBookMatching()
+
+	order, _, err := engine.PlaceOrder(newLimitOrder("BTCUSDT", types.SideTypeSell, 9005.0, 1.0))
+	assert.NoError(t, err)
+
+	balance, _ := engine.Account.Balance("BTC")
+	assert.Equal(t, fixedpoint.NewFromFloat(1.0), balance.Locked)
+
+	canceled, err := engine.CancelOrder(*order)
+	assert.NoError(t, err)
+	assert.Equal(t, types.OrderStatusCanceled, canceled.Status)
+
+	balance, _ = engine.Account.Balance("BTC")
+	assert.Equal(t, fixedpoint.Value(0), balance.Locked)
+	assert.Len(t, engine.OpenOrders(), 0)
+}
+
+func TestOrderBookMatching_PlaceOrderInCallback(t *testing.T) {
+	engine := newTestOrderBookMatching()
+
+	// the callbacks are emitted after the lock is released, so the strategy can place orders in the callbacks
+	var orders []*types.Order
+	engine.OnTradeUpdate(func(trade types.Trade) {
+		if trade.Side != types.SideTypeBuy {
+			return
+		}
+
+		order, _, err := engine.PlaceOrder(newLimitOrder("BTCUSDT", types.SideTypeSell, 9010.0, trade.Quantity))
+		assert.NoError(t, err)
+		orders = append(orders, order)
+	})
+
+	_, _, err := engine.PlaceOrder(newLimitOrder("BTCUSDT", types.SideTypeBuy, 9001.0, 0.5))
+	assert.NoError(t, err)
+
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, 0.5, orders[0].Quantity)
+	}
+	assert.Len(t, engine.OpenOrders(), 1)
+}
+
+func TestOrderBookMatching_LastPrice(t *testing.T) {
+	engine := newTestOrderBookMatching()
+	startTime := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// without the recorded trades, the last price follows the kline close price
+	for i, closePrice := range []float64{9000.0, 9010.0} {
+		engine.processKLine(types.KLine{
+			StartTime: startTime.Add(time.Duration(i) * time.Minute),
+			EndTime:   startTime.Add(time.Duration(i+1)*time.Minute - time.Millisecond),
+			Close:     closePrice,
+		})
+		assert.Equal(t, fixedpoint.NewFromFloat(closePrice), engine.LastPrice)
+	}
+
+	// the recorded trade price is kept if the trade is in the kline
+	engine.processMarketTrade(MarketTradeRecord{
+		Time:     startTime.Add(2*time.Minute + 30*time.Second),
+		Price:    fixedpoint.NewFromFloat(9005.0),
+		Quantity: fixedpoint.NewFromFloat(0.1),
+		Side:     types.SideTypeBuy,
+	})
+	engine.processKLine(types.KLine{
+		StartTime: startTime.Add(2 * time.Minute),
+		EndTime:   startTime.Add(3*time.Minute - time.Millisecond),
+		Close:     9020.0,
+	})
+	assert.Equal(t, fixedpoint.NewFromFloat(9005.0), engine.LastPrice)
+	assert.Equal(t, 9005.0, engine.Ticker().Last)
+}
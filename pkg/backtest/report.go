@@ -0,0 +1,634 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/c9s/bbgo/pkg/bbgo"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+// EquityPoint is a sample of the account equity valued in the quote currency
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+
+	// Exposure is the ratio of the equity held in the assets other than the quote currency
+	Exposure float64 `json:"exposure"`
+}
+
+// PerformanceRecorder samples the account equity of the session on every 1m kline and collects the trades.
+type PerformanceRecorder struct {
+	QuoteCurrency string
+
+	session *bbgo.ExchangeSession
+
+	lastPrices  map[string]float64
+	pendingTime time.Time
+	equityCurve []EquityPoint
+	trades      []types.Trade
+}
+
+func NewPerformanceRecorder(session *bbgo.ExchangeSession, quoteCurrency string) *PerformanceRecorder {
+	return &PerformanceRecorder{
+		QuoteCurrency: quoteCurrency,
+		session:       session,
+		lastPrices:    make(map[string]float64),
+	}
+}
+
+func (r *PerformanceRecorder) BindStream(stream types.Stream) {
+	stream.OnKLineClosed(func(kline types.KLine) {
+		if kline.Interval != types.Interval1m {
+			return
+		}
+
+		// all the klines of the pending time are processed, sample the equity
+		if !r.pendingTime.IsZero() && kline.EndTime.After(r.pendingTime) {
+			r.sample(r.pendingTime)
+		}
+
+		r.lastPrices[kline.Symbol] = kline.Close
+		r.pendingTime = kline.EndTime
+	})
+
+	stream.OnTradeUpdate(func(trade types.Trade) {
+		r.trades = append(r.trades, trade)
+	})
+}
+
+func (r *PerformanceRecorder) sample(t time.Time) {
+	var equity, assetValue float64
+	for currency, balance := range r.session.Account.Balances() {
+		// the debt of the margin account is deducted from the equity
+		total := balance.Net().Float64()
+		if currency == r.QuoteCurrency {
+			equity += total
+			continue
+		}
+
+		price, ok := r.price(currency)
+		if !ok {
+			continue
+		}
+
+		equity += total * price
+		assetValue += total * price
+	}
+
+	point := EquityPoint{Time: t, Equity: equity}
+	if equity > 0 {
+		point.Exposure = assetValue / equity
+	}
+
+	r.equityCurve = append(r.equityCurve, point)
+}
+
+// price returns the last price of the currency in the quote currency
+func (r *PerformanceRecorder) price(currency string) (float64, bool) {
+	for symbol, market := range r.session.Markets() {
+		price, ok := r.lastPrices[symbol]
+		if !ok || price == 0 {
+			continue
+		}
+
+		if market.BaseCurrency == currency && market.QuoteCurrency == r.QuoteCurrency {
+			return price, true
+		}
+
+		if market.BaseCurrency == r.QuoteCurrency && market.QuoteCurrency == currency {
+			return 1.0 / price, true
+		}
+	}
+
+	return 0, false
+}
+
+// Report samples the last equity and calculates the performance report
+func (r *PerformanceRecorder) Report() *PerformanceReport {
+	if !r.pendingTime.IsZero() {
+		if n := len(r.equityCurve); n == 0 || r.equityCurve[n-1].Time.Before(r.pendingTime) {
+			r.sample(r.pendingTime)
+		}
+	}
+
+	return NewPerformanceReport(r.QuoteCurrency, r.session.Markets(), r.equityCurve, r.trades)
+}
+
+// PerformanceReport is the performance summary of a backtest run
+type PerformanceReport struct {
+	QuoteCurrency string    `json:"quoteCurrency"`
+	StartTime     time.Time `json:"startTime"`
+	EndTime       time.Time `json:"endTime"`
+
+	InitialEquity float64 `json:"initialEquity"`
+	FinalEquity   float64 `json:"finalEquity"`
+	TotalReturn   float64 `json:"totalReturn"`
+
+	MaxDrawdown         float64       `json:"maxDrawdown"`
+	MaxDrawdownDuration time.Duration `json:"maxDrawdownDuration"`
+	SharpeRatio         float64       `json:"sharpeRatio"`
+	SortinoRatio        float64       `json:"sortinoRatio"`
+
+	NumTrades      int           `json:"numTrades"`
+	NumRoundTrips  int           `json:"numRoundTrips"`
+	WinningTrips   int           `json:"winningTrips"`
+	LosingTrips    int           `json:"losingTrips"`
+	WinRate        float64       `json:"winRate"`
+	GrossProfit    float64       `json:"grossProfit"`
+	GrossLoss      float64       `json:"grossLoss"`
+	ProfitFactor   float64       `json:"profitFactor"`
+	AverageHolding time.Duration `json:"averageHoldingTime"`
+
+	// Exposure is the average ratio of the equity held in the assets other than the quote currency
+	Exposure float64 `json:"exposure"`
+
+	// Fees is the total trading fee of each fee currency
+	Fees map[string]float64 `json:"fees"`
+
+	// FeeInQuote is the total trading fee converted to the quote currency by the trade price
+	FeeInQuote float64 `json:"feeInQuote"`
+
+	// Margin is the summary of the simulated margin account, it's nil for the spot account
+	Margin *MarginReport `json:"margin,omitempty"`
+
+	EquityCurve []EquityPoint `json:"equityCurve,omitempty"`
+}
+
+// NewPerformanceReport calculates the performance report from the equity curve and the trades,
+// the markets are used for converting the trading fees to the quote currency.
+func NewPerformanceReport(quoteCurrency string, markets types.MarketMap, equityCurve []EquityPoint, trades []types.Trade) *PerformanceReport {
+	report := &PerformanceReport{
+		QuoteCurrency: quoteCurrency,
+		NumTrades:     len(trades),
+		Fees:          make(map[string]float64),
+		EquityCurve:   equityCurve,
+	}
+
+	if len(equityCurve) > 0 {
+		first, last := equityCurve[0], equityCurve[len(equityCurve)-1]
+		report.StartTime = first.Time
+		report.EndTime = last.Time
+		report.InitialEquity = first.Equity
+		report.FinalEquity = last.Equity
+		if first.Equity != 0 {
+			report.TotalReturn = (last.Equity - first.Equity) / first.Equity
+		}
+	}
+
+	report.MaxDrawdown, report.MaxDrawdownDuration = maxDrawdown(equityCurve)
+	report.SharpeRatio, report.SortinoRatio = riskAdjustedReturns(equityCurve)
+
+	var exposure float64
+	for _, p := range equityCurve {
+		exposure += p.Exposure
+	}
+	if len(equityCurve) > 0 {
+		report.Exposure = exposure / float64(len(equityCurve))
+	}
+
+	for _, trade := range trades {
+		report.Fees[trade.FeeCurrency] += trade.Fee
+		if trade.FeeCurrency == quoteCurrency {
+			report.FeeInQuote += trade.Fee
+		} else if market, ok := markets[trade.Symbol]; ok && trade.FeeCurrency == market.BaseCurrency {
+			report.FeeInQuote += trade.Fee * trade.Price
+		}
+	}
+
+	var holding time.Duration
+	var holdingQuantity float64
+	for _, trip := range roundTrips(markets, trades) {
+		report.NumRoundTrips++
+		if trip.Profit > 0 {
+			report.WinningTrips++
+			report.GrossProfit += trip.Profit
+		} else {
+			report.LosingTrips++
+			report.GrossLoss -= trip.Profit
+		}
+
+		holding += time.Duration(float64(trip.HoldingTime) * trip.Quantity)
+		holdingQuantity += trip.Quantity
+	}
+
+	if report.NumRoundTrips > 0 {
+		report.WinRate = float64(report.WinningTrips) / float64(report.NumRoundTrips)
+	}
+
+	if report.GrossLoss > 0 {
+		report.ProfitFactor = report.GrossProfit / report.GrossLoss
+	}
+
+	if holdingQuantity > 0 {
+		report.AverageHolding = time.Duration(float64(holding) / holdingQuantity)
+	}
+
+	return report
+}
+
+// StitchReports chains the equity curves of the consecutive backtest runs into one report,
+// each curve is scaled to start from the final equity of the previous curve so that the returns are compounded.
+// The trade statistics are summed up since the trades are not kept in the reports.
+func StitchReports(reports []*PerformanceReport) *PerformanceReport {
+	var quoteCurrency string
+	var equityCurve []EquityPoint
+	var scale = 1.0
+	for _, r := range reports {
+		if len(r.EquityCurve) == 0 {
+			continue
+		}
+
+		quoteCurrency = r.QuoteCurrency
+		if len(equityCurve) > 0 && r.EquityCurve[0].Equity != 0 {
+			scale = equityCurve[len(equityCurve)-1].Equity / r.EquityCurve[0].Equity
+		}
+
+		for _, p := range r.EquityCurve {
+			// skip the duplicated point at the window boundary
+			if len(equityCurve) > 0 && !p.Time.After(equityCurve[len(equityCurve)-1].Time) {
+				continue
+			}
+
+			equityCurve = append(equityCurve, EquityPoint{
+				Time:     p.Time,
+				Equity:   p.Equity * scale,
+				Exposure: p.Exposure,
+			})
+		}
+	}
+
+	report := NewPerformanceReport(quoteCurrency, nil, equityCurve, nil)
+	sumTradeStats(report, reports)
+	return report
+}
+
+// MergeReports combines the reports of the sessions running at the same time into one report,
+// the equity of each session is carried forward to the sample times of the other sessions and then summed up.
+// The sessions must be valued in the same quote currency.
+func MergeReports(reports []*PerformanceReport) (*PerformanceReport, error) {
+	var quoteCurrency string
+	var times []time.Time
+	var seen = map[time.Time]struct{}{}
+	for _, r := range reports {
+		if len(quoteCurrency) > 0 && r.QuoteCurrency != quoteCurrency {
+			return nil, fmt.Errorf("can not merge the reports of the different quote currencies %s and %s", quoteCurrency, r.QuoteCurrency)
+		}
+		quoteCurrency = r.QuoteCurrency
+
+		for _, p := range r.EquityCurve {
+			if _, ok := seen[p.Time]; !ok {
+				seen[p.Time] = struct{}{}
+				times = append(times, p.Time)
+			}
+		}
+	}
+
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	var equityCurve []EquityPoint
+	var cursors = make([]int, len(reports))
+	for _, t := range times {
+		var equity, exposed float64
+		for i, r := range reports {
+			for cursors[i]+1 < len(r.EquityCurve) && !r.EquityCurve[cursors[i]+1].Time.After(t) {
+				cursors[i]++
+			}
+
+			if len(r.EquityCurve) == 0 {
+				continue
+			}
+
+			// the curve starts at its first point, even if the other sessions are sampled earlier
+			p := r.EquityCurve[cursors[i]]
+			equity += p.Equity
+			exposed += p.Equity * p.Exposure
+		}
+
+		point := EquityPoint{Time: t, Equity: equity}
+		if equity != 0 {
+			point.Exposure = exposed / equity
+		}
+		equityCurve = append(equityCurve, point)
+	}
+
+	report := NewPerformanceReport(quoteCurrency, nil, equityCurve, nil)
+	sumTradeStats(report, reports)
+	return report, nil
+}
+
+// sumTradeStats sums up the trade statistics of the reports into the report
+func sumTradeStats(report *PerformanceReport, reports []*PerformanceReport) {
+	var holding float64
+	for _, r := range reports {
+		report.NumTrades += r.NumTrades
+		report.NumRoundTrips += r.NumRoundTrips
+		report.WinningTrips += r.WinningTrips
+		report.LosingTrips += r.LosingTrips
+		report.GrossProfit += r.GrossProfit
+		report.GrossLoss += r.GrossLoss
+		report.FeeInQuote += r.FeeInQuote
+		for currency, fee := range r.Fees {
+			report.Fees[currency] += fee
+		}
+
+		holding += float64(r.AverageHolding) * float64(r.NumRoundTrips)
+	}
+
+	if report.NumRoundTrips > 0 {
+		report.WinRate = float64(report.WinningTrips) / float64(report.NumRoundTrips)
+		report.AverageHolding = time.Duration(holding / float64(report.NumRoundTrips))
+	}
+
+	if report.GrossLoss > 0 {
+		report.ProfitFactor = report.GrossProfit / report.GrossLoss
+	}
+}
+
+// maxDrawdown returns the max peak-to-trough decline ratio and the longest duration under the previous peak
+func maxDrawdown(equityCurve []EquityPoint) (drawdown float64, duration time.Duration) {
+	var peak EquityPoint
+	for i, p := range equityCurve {
+		if i == 0 || p.Equity >= peak.Equity {
+			peak = p
+			continue
+		}
+
+		if peak.Equity > 0 {
+			if dd := (peak.Equity - p.Equity) / peak.Equity; dd > drawdown {
+				drawdown = dd
+			}
+		}
+
+		if d := p.Time.Sub(peak.Time); d > duration {
+			duration = d
+		}
+	}
+
+	return drawdown, duration
+}
+
+// riskAdjustedReturns returns the annualized sharpe ratio and sortino ratio of the equity returns,
+// the risk free rate is treated as zero.
+func riskAdjustedReturns(equityCurve []EquityPoint) (sharpe, sortino float64) {
+	if len(equityCurve) < 3 {
+		return 0, 0
+	}
+
+	var returns []float64
+	for i := 1; i < len(equityCurve); i++ {
+		if prev := equityCurve[i-1].Equity; prev > 0 {
+			returns = append(returns, equityCurve[i].Equity/prev-1.0)
+		}
+	}
+
+	if len(returns) < 2 {
+		return 0, 0
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance, downsideVariance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downsideVariance += r * r
+		}
+	}
+	stddev := math.Sqrt(variance / float64(len(returns)-1))
+	downsideDeviation := math.Sqrt(downsideVariance / float64(len(returns)))
+
+	period := equityCurve[len(equityCurve)-1].Time.Sub(equityCurve[0].Time) / time.Duration(len(equityCurve)-1)
+	if period <= 0 {
+		return 0, 0
+	}
+	annualization := math.Sqrt(float64(365*24*time.Hour) / float64(period))
+
+	if stddev > 0 {
+		sharpe = mean / stddev * annualization
+	}
+
+	if downsideDeviation > 0 {
+		sortino = mean / downsideDeviation * annualization
+	}
+
+	return sharpe, sortino
+}
+
+// RoundTrip is a closed position matched from a buy trade and a sell trade
+type RoundTrip struct {
+	Symbol      string
+	Quantity    float64
+	Profit      float64
+	HoldingTime time.Duration
+}
+
+type openLot struct {
+	price, quantity, fee float64
+	time                 time.Time
+}
+
+// roundTrips matches the sell trades with the previous buy trades in the FIFO order,
+// the sell trades without the previous buy trades are not counted.
+func roundTrips(markets types.MarketMap, trades []types.Trade) (trips []RoundTrip) {
+	var sorted = make([]types.Trade, len(trades))
+	copy(sorted, trades)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Time().Before(sorted[j].Time.Time())
+	})
+
+	var lots = map[string][]openLot{}
+	for _, trade := range sorted {
+		fee := tradeFeeInQuote(markets, trade)
+
+		if trade.IsBuyer {
+			lots[trade.Symbol] = append(lots[trade.Symbol], openLot{
+				price:    trade.Price,
+				quantity: trade.Quantity,
+				fee:      fee,
+				time:     trade.Time.Time(),
+			})
+			continue
+		}
+
+		remaining := trade.Quantity
+		symbolLots := lots[trade.Symbol]
+		for len(symbolLots) > 0 && remaining > 0 {
+			lot := &symbolLots[0]
+			quantity := math.Min(lot.quantity, remaining)
+
+			// allocate the fees by the matched quantity
+			buyFee := lot.fee * quantity / lot.quantity
+			sellFee := fee * quantity / trade.Quantity
+
+			trips = append(trips, RoundTrip{
+				Symbol:      trade.Symbol,
+				Quantity:    quantity,
+				Profit:      (trade.Price-lot.price)*quantity - buyFee - sellFee,
+				HoldingTime: trade.Time.Time().Sub(lot.time),
+			})
+
+			lot.fee -= buyFee
+			lot.quantity -= quantity
+			remaining -= quantity
+			if lot.quantity <= 0 {
+				symbolLots = symbolLots[1:]
+			}
+		}
+		lots[trade.Symbol] = symbolLots
+	}
+
+	return trips
+}
+
+// tradeFeeInQuote converts the trading fee to the quote currency of the trade symbol,
+// the fees paid in the other currencies (e.g., BNB) are not counted.
+func tradeFeeInQuote(markets types.MarketMap, trade types.Trade) float64 {
+	market, ok := markets[trade.Symbol]
+	if !ok {
+		return 0
+	}
+
+	switch trade.FeeCurrency {
+	case market.BaseCurrency:
+		return trade.Fee * trade.Price
+	case market.QuoteCurrency:
+		return trade.Fee
+	}
+
+	return 0
+}
+
+func (report *PerformanceReport) metrics() [][2]string {
+	var rows = [][2]string{
+		{"Start Time", report.StartTime.Format(time.RFC3339)},
+		{"End Time", report.EndTime.Format(time.RFC3339)},
+		{"Initial Equity", formatFloat(report.InitialEquity) + " " + report.QuoteCurrency},
+		{"Final Equity", formatFloat(report.FinalEquity) + " " + report.QuoteCurrency},
+		{"Total Return", formatPercentage(report.TotalReturn)},
+		{"Max Drawdown", formatPercentage(report.MaxDrawdown)},
+		{"Max Drawdown Duration", report.MaxDrawdownDuration.String()},
+		{"Sharpe Ratio", formatFloat(report.SharpeRatio)},
+		{"Sortino Ratio", formatFloat(report.SortinoRatio)},
+		{"Number of Trades", strconv.Itoa(report.NumTrades)},
+		{"Number of Round Trips", strconv.Itoa(report.NumRoundTrips)},
+		{"Win Rate", formatPercentage(report.WinRate)},
+		{"Profit Factor", formatFloat(report.ProfitFactor)},
+		{"Average Holding Time", report.AverageHolding.String()},
+		{"Exposure", formatPercentage(report.Exposure)},
+		{"Fee In Quote", formatFloat(report.FeeInQuote) + " " + report.QuoteCurrency},
+	}
+
+	var currencies []string
+	for currency := range report.Fees {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	for _, currency := range currencies {
+		rows = append(rows, [2]string{"Fee " + currency, formatFloat(report.Fees[currency])})
+	}
+
+	if margin := report.Margin; margin != nil {
+		rows = append(rows,
+			[2]string{"Min Margin Level", formatFloat(margin.MinMarginLevel)},
+			[2]string{"Liquidations", strconv.Itoa(margin.Liquidations)},
+		)
+
+		var assets []string
+		for asset := range margin.Borrowed {
+			assets = append(assets, asset)
+		}
+		sort.Strings(assets)
+
+		for _, asset := range assets {
+			rows = append(rows,
+				[2]string{"Borrowed " + asset, formatFloat(margin.Borrowed[asset])},
+				[2]string{"Interest " + asset, formatFloat(margin.Interest[asset])},
+			)
+		}
+	}
+
+	return rows
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 4, 64)
+}
+
+func formatPercentage(f float64) string {
+	return strconv.FormatFloat(f*100.0, 'f', 2, 64) + "%"
+}
+
+// Render renders the performance metrics as a table
+func (report *PerformanceReport) Render(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METRIC\tVALUE")
+	for _, row := range report.metrics() {
+		fmt.Fprintf(tw, "%s\t%s\n", row[0], row[1])
+	}
+	return tw.Flush()
+}
+
+func (report *PerformanceReport) Print() {
+	if err := report.Render(os.Stdout); err != nil {
+		log.WithError(err).Error("performance report render error")
+	}
+}
+
+// WriteFile writes the report to the given file, the format is decided by the file extension.
+// The json format contains the equity curve, and the csv format contains the metrics only.
+func (report *PerformanceReport) WriteFile(filename string) error {
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+
+	case ".json":
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filename, out, 0644)
+
+	case ".csv":
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w := csv.NewWriter(f)
+		if err := w.Write([]string{"metric", "value"}); err != nil {
+			return err
+		}
+
+		for _, row := range report.metrics() {
+			if err := w.Write(row[:]); err != nil {
+				return err
+			}
+		}
+
+		w.Flush()
+		return w.Error()
+
+	default:
+		return fmt.Errorf("unsupported report format: %s, please use .json or .csv", ext)
+	}
+}
@@ -0,0 +1,206 @@
+package backtest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/datatype"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func TestNewPerformanceReport(t *testing.T) {
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var equityCurve []EquityPoint
+	for i, equity := range []float64{1000, 1100, 990, 1050, 1210, 1150} {
+		equityCurve = append(equityCurve, EquityPoint{
+			Time:     startTime.Add(time.Duration(i) * time.Hour),
+			Equity:   equity,
+			Exposure: 0.5,
+		})
+	}
+
+	trades := []types.Trade{
+		{Symbol: "BTCUSDT", Price: 100, Quantity: 2, IsBuyer: true, Side: types.SideTypeBuy, Fee: 0.002, FeeCurrency: "BTC", Time: datatype.Time(startTime)},
+		{Symbol: "BTCUSDT", Price: 110, Quantity: 1, Side: types.SideTypeSell, Fee: 0.11, FeeCurrency: "USDT", Time: datatype.Time(startTime.Add(time.Hour))},
+		{Symbol: "BTCUSDT", Price: 90, Quantity: 1, Side: types.SideTypeSell, Fee: 0.09, FeeCurrency: "USDT", Time: datatype.Time(startTime.Add(3 * time.Hour))},
+	}
+
+	markets := types.MarketMap{
+		"BTCUSDT": {Symbol: "BTCUSDT", BaseCurrency: "BTC", QuoteCurrency: "USDT"},
+	}
+
+	report := NewPerformanceReport("USDT", markets, equityCurve, trades)
+	assert.Equal(t, 1000.0, report.InitialEquity)
+	assert.Equal(t, 1150.0, report.FinalEquity)
+	assert.InDelta(t, 0.15, report.TotalReturn, 1e-9)
+	assert.InDelta(t, 0.1, report.MaxDrawdown, 1e-9)
+	assert.Equal(t, 2*time.Hour, report.MaxDrawdownDuration)
+	assert.True(t, report.SharpeRatio > 0)
+	assert.True(t, report.SortinoRatio > 0)
+	assert.Equal(t, 0.5, report.Exposure)
+
+	assert.Equal(t, 3, report.NumTrades)
+	assert.Equal(t, 2, report.NumRoundTrips)
+	assert.Equal(t, 1, report.WinningTrips)
+	assert.Equal(t, 1, report.LosingTrips)
+	assert.Equal(t, 0.5, report.WinRate)
+	assert.InDelta(t, 9.79, report.GrossProfit, 1e-9)
+	assert.InDelta(t, 10.19, report.GrossLoss, 1e-9)
+	assert.Equal(t, 2*time.Hour, report.AverageHolding)
+
+	assert.InDelta(t, 0.002, report.Fees["BTC"], 1e-9)
+	assert.InDelta(t, 0.2, report.Fees["USDT"], 1e-9)
+	assert.InDelta(t, 0.4, report.FeeInQuote, 1e-9)
+}
+
+func TestTradeFeeInQuote(t *testing.T) {
+	markets := types.MarketMap{
+		"BTCUSDT": {Symbol: "BTCUSDT", BaseCurrency: "BTC", QuoteCurrency: "USDT"},
+	}
+
+	assert.Equal(t, 2.0, tradeFeeInQuote(markets, types.Trade{Symbol: "BTCUSDT", Price: 100, Fee: 0.02, FeeCurrency: "BTC"}))
+	assert.Equal(t, 0.5, tradeFeeInQuote(markets, types.Trade{Symbol: "BTCUSDT", Price: 100, Fee: 0.5, FeeCurrency: "USDT"}))
+
+	// the symbol starts with "BT" but BT is not the base currency of BTCUSDT
+	assert.Equal(t, 0.0, tradeFeeInQuote(markets, types.Trade{Symbol: "BTCUSDT", Price: 100, Fee: 1, FeeCurrency: "BT"}))
+	assert.Equal(t, 0.0, tradeFeeInQuote(markets, types.Trade{Symbol: "BTCUSDT", Price: 100, Fee: 1, FeeCurrency: ""}))
+	assert.Equal(t, 0.0, tradeFeeInQuote(markets, types.Trade{Symbol: "BTCUSDT", Price: 100, Fee: 1, FeeCurrency: "BNB"}))
+}
+
+func TestStitchReports(t *testing.T) {
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	first := &PerformanceReport{
+		QuoteCurrency: "USDT",
+		EquityCurve: []EquityPoint{
+			{Time: startTime, Equity: 1000},
+			{Time: startTime.Add(time.Hour), Equity: 1100},
+		},
+		NumTrades:      2,
+		NumRoundTrips:  1,
+		WinningTrips:   1,
+		GrossProfit:    100,
+		AverageHolding: time.Hour,
+		Fees:           map[string]float64{"USDT": 1},
+		FeeInQuote:     1,
+	}
+
+	// every window starts from the initial balance, so the curve is scaled by the previous final equity
+	second := &PerformanceReport{
+		QuoteCurrency: "USDT",
+		EquityCurve: []EquityPoint{
+			{Time: startTime.Add(time.Hour), Equity: 1000},
+			{Time: startTime.Add(2 * time.Hour), Equity: 900},
+		},
+		NumTrades:      2,
+		NumRoundTrips:  1,
+		LosingTrips:    1,
+		GrossLoss:      50,
+		AverageHolding: 3 * time.Hour,
+		Fees:           map[string]float64{"USDT": 2},
+		FeeInQuote:     2,
+	}
+
+	report := StitchReports([]*PerformanceReport{first, second})
+	assert.Equal(t, "USDT", report.QuoteCurrency)
+	if assert.Len(t, report.EquityCurve, 3) {
+		assert.InDelta(t, 990.0, report.EquityCurve[2].Equity, 1e-9)
+	}
+	assert.InDelta(t, -0.01, report.TotalReturn, 1e-9)
+	assert.InDelta(t, 0.1, report.MaxDrawdown, 1e-9)
+
+	assert.Equal(t, 4, report.NumTrades)
+	assert.Equal(t, 2, report.NumRoundTrips)
+	assert.Equal(t, 0.5, report.WinRate)
+	assert.Equal(t, 2.0, report.ProfitFactor)
+	assert.Equal(t, 2*time.Hour, report.AverageHolding)
+	assert.Equal(t, 3.0, report.Fees["USDT"])
+	assert.Equal(t, 3.0, report.FeeInQuote)
+}
+
+func TestMergeReports(t *testing.T) {
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	binanceReport := &PerformanceReport{
+		QuoteCurrency: "USDT",
+		EquityCurve: []EquityPoint{
+			{Time: startTime, Equity: 1000, Exposure: 0.5},
+			{Time: startTime.Add(2 * time.Minute), Equity: 1100, Exposure: 0.5},
+		},
+		NumTrades:     2,
+		NumRoundTrips: 1,
+		WinningTrips:  1,
+		GrossProfit:   100,
+		Fees:          map[string]float64{"USDT": 1},
+		FeeInQuote:    1,
+	}
+
+	maxReport := &PerformanceReport{
+		QuoteCurrency: "USDT",
+		EquityCurve: []EquityPoint{
+			{Time: startTime, Equity: 1000},
+			{Time: startTime.Add(time.Minute), Equity: 900},
+		},
+		NumTrades:     2,
+		NumRoundTrips: 1,
+		LosingTrips:   1,
+		GrossLoss:     100,
+		Fees:          map[string]float64{"MAX": 2},
+	}
+
+	report, err := MergeReports([]*PerformanceReport{binanceReport, maxReport})
+	assert.NoError(t, err)
+	assert.Equal(t, "USDT", report.QuoteCurrency)
+	if assert.Len(t, report.EquityCurve, 3) {
+		assert.Equal(t, 2000.0, report.EquityCurve[0].Equity)
+		assert.Equal(t, 0.25, report.EquityCurve[0].Exposure)
+
+		// the binance equity is carried forward to the sample time of max
+		assert.Equal(t, 1900.0, report.EquityCurve[1].Equity)
+		assert.Equal(t, 2000.0, report.EquityCurve[2].Equity)
+	}
+	assert.Equal(t, 0.0, report.TotalReturn)
+	assert.Equal(t, 4, report.NumTrades)
+	assert.Equal(t, 0.5, report.WinRate)
+	assert.Equal(t, 1.0, report.ProfitFactor)
+	assert.Equal(t, map[string]float64{"USDT": 1, "MAX": 2}, report.Fees)
+
+	_, err = MergeReports([]*PerformanceReport{binanceReport, {QuoteCurrency: "TWD"}})
+	assert.Error(t, err)
+}
+
+func TestPerformanceReport_WriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bbgo-backtest-report")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	report := NewPerformanceReport("USDT", nil, []EquityPoint{
+		{Time: time.Now(), Equity: 1000},
+		{Time: time.Now().Add(time.Minute), Equity: 1010},
+	}, nil)
+
+	jsonFile := filepath.Join(dir, "report.json")
+	assert.NoError(t, report.WriteFile(jsonFile))
+
+	content, err := ioutil.ReadFile(jsonFile)
+	assert.NoError(t, err)
+
+	var loaded PerformanceReport
+	assert.NoError(t, json.Unmarshal(content, &loaded))
+	assert.Equal(t, 1010.0, loaded.FinalEquity)
+	assert.Len(t, loaded.EquityCurve, 2)
+
+	csvFile := filepath.Join(dir, "report.csv")
+	assert.NoError(t, report.WriteFile(csvFile))
+
+	assert.Error(t, report.WriteFile(filepath.Join(dir, "report.txt")))
+}
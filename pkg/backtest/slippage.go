@@ -0,0 +1,71 @@
+package backtest
+
+import (
+	"fmt"
+
+	"github.com/c9s/bbgo/pkg/bbgo"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+// SlippageModel moves the execution price of the marketable orders against the taker
+type SlippageModel interface {
+	// Slip returns the execution price of the given side and quantity
+	Slip(side types.SideType, price, quantity float64, kline types.KLine) float64
+}
+
+// FixedSlippage moves the price by a fixed price offset
+type FixedSlippage struct {
+	Offset float64
+}
+
+func (s FixedSlippage) Slip(side types.SideType, price, quantity float64, kline types.KLine) float64 {
+	return slip(side, price, s.Offset)
+}
+
+// PercentageSlippage moves the price by a ratio of the price
+type PercentageSlippage struct {
+	Ratio float64
+}
+
+func (s PercentageSlippage) Slip(side types.SideType, price, quantity float64, kline types.KLine) float64 {
+	return slip(side, price, price*s.Ratio)
+}
+
+// VolumeSlippage moves the price proportionally to the order quantity over the kline volume,
+// the price moves by the given ratio when the order quantity equals to the kline volume.
+type VolumeSlippage struct {
+	Ratio float64
+}
+
+func (s VolumeSlippage) Slip(side types.SideType, price, quantity float64, kline types.KLine) float64 {
+	if kline.Volume <= 0 {
+		return price
+	}
+
+	return slip(side, price, price*s.Ratio*quantity/kline.Volume)
+}
+
+func slip(side types.SideType, price, offset float64) float64 {
+	if side == types.SideTypeBuy {
+		return price + offset
+	}
+
+	return price - offset
+}
+
+func newSlippageModel(config *bbgo.BacktestSlippage) (SlippageModel, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	switch config.Type {
+	case bbgo.BacktestSlippageFixed:
+		return FixedSlippage{Offset: config.Value.Float64()}, nil
+	case bbgo.BacktestSlippagePercentage:
+		return PercentageSlippage{Ratio: config.Value.Float64()}, nil
+	case bbgo.BacktestSlippageVolume:
+		return VolumeSlippage{Ratio: config.Value.Float64()}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported backtest slippage model: %s", config.Type)
+}
@@ -3,9 +3,11 @@ package backtest
 import (
 	"context"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/c9s/bbgo/pkg/bbgo"
 	"github.com/c9s/bbgo/pkg/types"
 )
 
@@ -13,12 +15,52 @@ type Stream struct {
 	types.StandardStream
 
 	exchange *Exchange
+
+	symbols     []string
+	intervals   []types.Interval
+	feeds       map[string]*MarketDataFeed
+	books       map[string]*OrderBookMatching
+	bookSymbols map[string]struct{}
 }
 
 func (s *Stream) Connect(ctx context.Context) error {
+	if err := s.prepare(); err != nil {
+		return err
+	}
+
+	// the klines of the synchronized sessions are dispatched by the clock
+	if s.exchange.clock != nil {
+		s.exchange.clock.addStream(s)
+		return nil
+	}
+
+	go func() {
+		s.EmitConnect()
+
+		klineC, errC := s.queryKLines()
+		for k := range klineC {
+			s.dispatchKLine(k)
+		}
+
+		if err := <-errC; err != nil {
+			log.WithError(err).Error("backtest data feed error")
+		}
+
+		if err := s.Close(); err != nil {
+			log.WithError(err).Error("stream close error")
+		}
+	}()
+
+	return nil
+}
+
+// prepare collects the symbols and the intervals from the subscriptions and opens the market data feeds
+func (s *Stream) prepare() error {
 	log.Infof("collecting backtest configurations...")
 
 	loadedSymbols := map[string]struct{}{}
+	bookSymbols := map[string]struct{}{}
+	useOrderBook := s.exchange.config.MatchingEngine() == bbgo.BacktestMatchingOrderBook
 	loadedIntervals := map[types.Interval]struct{}{
 		// 1m interval is required for the backtest matching engine
 		types.Interval1m: {},
@@ -32,6 +74,13 @@ func (s *Stream) Connect(ctx context.Context) error {
 		case types.KLineChannel:
 			loadedIntervals[types.Interval(sub.Options.Interval)] = struct{}{}
 
+		case types.BookChannel:
+			// the book stream is replayed from the recorded depth snapshots
+			if !useOrderBook {
+				return fmt.Errorf("stream channel %s is only supported by the orderbook matching engine", sub.Channel)
+			}
+			bookSymbols[sub.Symbol] = struct{}{}
+
 		default:
 			return fmt.Errorf("stream channel %s is not supported in backtest", sub.Channel)
 		}
@@ -49,39 +98,88 @@ func (s *Stream) Connect(ctx context.Context) error {
 
 	log.Infof("used symbols: %v and intervals: %v", symbols, intervals)
 
-	go func() {
-		s.EmitConnect()
+	feeds := map[string]*MarketDataFeed{}
+	books := map[string]*OrderBookMatching{}
+	if useOrderBook {
+		for _, symbol := range symbols {
+			book, ok := s.exchange.matchingBooks[symbol].(*OrderBookMatching)
+			if !ok {
+				return fmt.Errorf("orderbook matching engine of %s is not initialized", symbol)
+			}
 
-		klineC, errC := s.exchange.srv.QueryKLinesCh(s.exchange.startTime, s.exchange.endTime, s.exchange, symbols, intervals)
-		for k := range klineC {
-			if k.Interval == types.Interval1m {
-				matching, ok := s.exchange.matchingBooks[k.Symbol]
-				if !ok {
-					log.Errorf("matching book of %s is not initialized", k.Symbol)
-				}
-				matching.processKLine(k)
+			feed, err := OpenMarketDataFeed(s.exchange.config.Matching.DataDir, symbol)
+			if err != nil {
+				return err
 			}
 
-			s.EmitKLineClosed(k)
+			feeds[symbol] = feed
+			books[symbol] = book
 		}
+	}
 
-		if err := <-errC; err != nil {
-			log.WithError(err).Error("backtest data feed error")
+	s.symbols = symbols
+	s.intervals = intervals
+	s.feeds = feeds
+	s.books = books
+	s.bookSymbols = bookSymbols
+	return nil
+}
+
+func (s *Stream) queryKLines() (chan types.KLine, chan error) {
+	return s.exchange.srv.QueryKLinesCh(s.exchange.startTime, s.exchange.endTime, s.exchange, s.symbols, s.intervals)
+}
+
+// dispatchKLine feeds the kline to the matching engine and emits the kline closed event
+func (s *Stream) dispatchKLine(k types.KLine) {
+	if k.Interval == types.Interval1m {
+		if err := s.matchKLine(k); err != nil {
+			log.WithError(err).Errorf("%s kline matching error", k.Symbol)
 		}
+	}
 
-		if err := s.Close(); err != nil {
-			log.WithError(err).Error("stream close error")
+	s.EmitKLineClosed(k)
+}
+
+// matchKLine replays the recorded market data until the end of the kline and then feeds the kline to the matching engine
+func (s *Stream) matchKLine(k types.KLine) error {
+	matching, ok := s.exchange.matchingBooks[k.Symbol]
+	if !ok {
+		return fmt.Errorf("matching book of %s is not initialized", k.Symbol)
+	}
+
+	if feed, ok := s.feeds[k.Symbol]; ok {
+		if err := s.replayMarketData(feed, s.books[k.Symbol], k.EndTime, s.bookSymbols); err != nil {
+			log.WithError(err).Errorf("%s market data replay error", k.Symbol)
 		}
-	}()
+	}
 
+	matching.processKLine(k)
+	s.exchange.updateMargin(k.EndTime)
 	return nil
 }
 
+// replayMarketData replays the recorded market data to the order book matching engine until the given time
+func (s *Stream) replayMarketData(feed *MarketDataFeed, matching *OrderBookMatching, until time.Time, bookSymbols map[string]struct{}) error {
+	_, emitBook := bookSymbols[feed.Symbol]
+	return feed.ReplayUntil(until, matching.processMarketTrade, func(depth DepthSnapshotRecord) {
+		matching.processDepthSnapshot(depth)
+		if emitBook {
+			s.EmitBookSnapshot(depth.OrderBook(feed.Symbol))
+		}
+	})
+}
+
 func (s *Stream) SetPublicOnly() {
 	return
 }
 
 func (s *Stream) Close() error {
+	for _, feed := range s.feeds {
+		if err := feed.Close(); err != nil {
+			log.WithError(err).Error("market data feed close error")
+		}
+	}
+
 	close(s.exchange.doneC)
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"sync"
 
 	"github.com/pkg/errors"
 
@@ -16,11 +17,17 @@ import (
 
 type DataFetcher func() (interface{}, error)
 
+// cacheMutex serializes the cache file access, the backtest runs of the optimizer load the markets concurrently
+var cacheMutex sync.Mutex
+
 // WithCache let you use the cache with the given cache key, variable reference and your data fetcher,
 // The key must be an unique ID.
 // obj is the pointer of your local variable
 // fetcher is the closure that will fetch your remote data or some slow operation.
 func WithCache(key string, obj interface{}, fetcher DataFetcher) error {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
 	cacheDir := CacheDir()
 	cacheFile := path.Join(cacheDir, key+".json")
 
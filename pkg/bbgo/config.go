@@ -94,6 +94,163 @@ type Backtest struct {
 
 	Account BacktestAccount `json:"account" yaml:"account"`
 	Symbols []string        `json:"symbols" yaml:"symbols"`
+
+	Matching *BacktestMatching `json:"matching,omitempty" yaml:"matching,omitempty"`
+
+	// Slippage simulates the price impact of the marketable orders
+	Slippage *BacktestSlippage `json:"slippage,omitempty" yaml:"slippage,omitempty"`
+
+	// Latency simulates the order submission and cancellation latency in the simulated time
+	Latency *BacktestLatency `json:"latency,omitempty" yaml:"latency,omitempty"`
+
+	// Fees defines the fee schedule, the account commissions are used when it's not defined
+	Fees *BacktestFees `json:"fees,omitempty" yaml:"fees,omitempty"`
+
+	// Margin simulates the margin account, the orders with the MARGIN_BUY side effect borrow the insufficient balance
+	Margin *BacktestMargin `json:"margin,omitempty" yaml:"margin,omitempty"`
+
+	// Sessions defines the simulated exchange sessions by the session name,
+	// it's required for backtesting the cross exchange strategies.
+	Sessions map[string]BacktestSession `json:"sessions,omitempty" yaml:"sessions,omitempty"`
+}
+
+// BacktestSession is a simulated exchange session, the orders are matched with the kline data of the source exchange
+type BacktestSession struct {
+	Exchange types.ExchangeName `json:"exchange" yaml:"exchange"`
+	Account  BacktestAccount    `json:"account" yaml:"account"`
+
+	// Symbols overrides the symbols of the backtest config for syncing the kline data of this session
+	Symbols []string `json:"symbols,omitempty" yaml:"symbols,omitempty"`
+
+	// Margin overrides the margin simulation of the backtest config for this session
+	Margin *BacktestMargin `json:"margin,omitempty" yaml:"margin,omitempty"`
+}
+
+// SessionConfig returns the backtest config of the given simulated session
+func (t Backtest) SessionConfig(session BacktestSession) *Backtest {
+	config := t
+	config.Account = session.Account
+	if len(session.Symbols) > 0 {
+		config.Symbols = session.Symbols
+	}
+
+	if session.Margin != nil {
+		config.Margin = session.Margin
+	}
+
+	config.Sessions = nil
+	return &config
+}
+
+const (
+	// BacktestMatchingKLine matches the orders with the kline high/low prices
+	BacktestMatchingKLine = "kline"
+
+	// BacktestMatchingOrderBook matches the orders with the recorded market trades and depth snapshots
+	BacktestMatchingOrderBook = "orderbook"
+)
+
+// BacktestMatching defines the matching engine used by the backtest exchange
+type BacktestMatching struct {
+	// Engine is the matching engine name, "kline" or "orderbook", defaults to "kline"
+	Engine string `json:"engine,omitempty" yaml:"engine,omitempty"`
+
+	// DataDir is the directory of the recorded market data for the orderbook engine,
+	// the files are named as {symbol}-trades.jsonl and {symbol}-depth.jsonl
+	DataDir string `json:"dataDir,omitempty" yaml:"dataDir,omitempty"`
+}
+
+const (
+	// BacktestSlippageFixed moves the execution price by a fixed price offset
+	BacktestSlippageFixed = "fixed"
+
+	// BacktestSlippagePercentage moves the execution price by a ratio of the price
+	BacktestSlippagePercentage = "percentage"
+
+	// BacktestSlippageVolume moves the execution price proportionally to the order quantity over the kline volume
+	BacktestSlippageVolume = "volume"
+)
+
+type BacktestSlippage struct {
+	// Type is the slippage model, "fixed", "percentage" or "volume"
+	Type string `json:"type" yaml:"type"`
+
+	// Value is the price offset of the fixed model, the price ratio of the percentage model (0.001 = 0.1%),
+	// or the price ratio of the volume model when the order quantity equals to the kline volume
+	Value fixedpoint.Value `json:"value" yaml:"value"`
+}
+
+type BacktestLatency struct {
+	// Submit is the delay before the submitted orders arrive at the matching engine
+	Submit types.Duration `json:"submit,omitempty" yaml:"submit,omitempty"`
+
+	// Cancel is the delay before the cancel requests take effect, the orders could still be filled in the meantime
+	Cancel types.Duration `json:"cancel,omitempty" yaml:"cancel,omitempty"`
+}
+
+type BacktestFeeRate struct {
+	MakerFeeRate fixedpoint.Value `json:"makerFeeRate" yaml:"makerFeeRate"`
+	TakerFeeRate fixedpoint.Value `json:"takerFeeRate" yaml:"takerFeeRate"`
+}
+
+type BacktestFees struct {
+	// Symbols defines the fee rates by symbol, e.g., 0.001 = 0.1%
+	Symbols map[string]BacktestFeeRate `json:"symbols,omitempty" yaml:"symbols,omitempty"`
+
+	// PlatformCurrency pays the fee with the platform currency (e.g., BNB or MAX) when the balance is enough
+	PlatformCurrency string `json:"platformCurrency,omitempty" yaml:"platformCurrency,omitempty"`
+
+	// PlatformDiscount is the fee discount ratio of paying with the platform currency, e.g., 0.25 = 25% off
+	PlatformDiscount fixedpoint.Value `json:"platformDiscount,omitempty" yaml:"platformDiscount,omitempty"`
+}
+
+type BacktestMargin struct {
+	// IsolatedSymbol simulates the isolated margin account of the symbol, the cross margin account is simulated if it's empty
+	IsolatedSymbol string `json:"isolatedSymbol,omitempty" yaml:"isolatedSymbol,omitempty"`
+
+	// MaxLeverage limits the total debt to (MaxLeverage - 1) times the net asset value, defaults to 3
+	MaxLeverage fixedpoint.Value `json:"maxLeverage,omitempty" yaml:"maxLeverage,omitempty"`
+
+	// InterestRates are the hourly interest rates by asset, e.g., 0.00000417 = 0.01% daily
+	InterestRates map[string]fixedpoint.Value `json:"interestRates,omitempty" yaml:"interestRates,omitempty"`
+
+	// DefaultInterestRate is the hourly interest rate of the assets not defined in InterestRates
+	DefaultInterestRate fixedpoint.Value `json:"defaultInterestRate,omitempty" yaml:"defaultInterestRate,omitempty"`
+
+	// LiquidationMarginLevel is the margin level that triggers the forced liquidation, defaults to 1.1
+	LiquidationMarginLevel fixedpoint.Value `json:"liquidationMarginLevel,omitempty" yaml:"liquidationMarginLevel,omitempty"`
+
+	// ValuationCurrency is the currency for calculating the margin level,
+	// defaults to the quote currency of the isolated symbol or the first backtest symbol
+	ValuationCurrency string `json:"valuationCurrency,omitempty" yaml:"valuationCurrency,omitempty"`
+}
+
+// Validate checks the engine names of the backtest config, so that the invalid config fails on loading
+// instead of failing when the backtest exchange is set up.
+func (t Backtest) Validate() error {
+	switch engine := t.MatchingEngine(); engine {
+	case BacktestMatchingKLine, BacktestMatchingOrderBook:
+	default:
+		return fmt.Errorf("unsupported backtest matching engine: %s", engine)
+	}
+
+	if t.Slippage != nil {
+		switch t.Slippage.Type {
+		case BacktestSlippageFixed, BacktestSlippagePercentage, BacktestSlippageVolume:
+		default:
+			return fmt.Errorf("unsupported backtest slippage model: %s", t.Slippage.Type)
+		}
+	}
+
+	return nil
+}
+
+func (t Backtest) MatchingEngine() string {
+	if t.Matching == nil || len(t.Matching.Engine) == 0 {
+		return BacktestMatchingKLine
+	}
+
+	return t.Matching.Engine
 }
 
 func (t Backtest) ParseEndTime() (time.Time, error) {
@@ -113,10 +270,10 @@ func (t Backtest) ParseStartTime() (time.Time, error) {
 }
 
 type BacktestAccount struct {
-	MakerCommission  fixedpoint.Value          `json:"makerCommission"`
-	TakerCommission  fixedpoint.Value          `json:"takerCommission"`
-	BuyerCommission  int                       `json:"buyerCommission"`
-	SellerCommission int                       `json:"sellerCommission"`
+	MakerCommission  fixedpoint.Value          `json:"makerCommission" yaml:"makerCommission"`
+	TakerCommission  fixedpoint.Value          `json:"takerCommission" yaml:"takerCommission"`
+	BuyerCommission  int                       `json:"buyerCommission" yaml:"buyerCommission"`
+	SellerCommission int                       `json:"sellerCommission" yaml:"sellerCommission"`
 	Balances         BacktestAccountBalanceMap `json:"balances" yaml:"balances"`
 }
 
@@ -179,6 +336,8 @@ type Config struct {
 
 	RiskControls *RiskControls `json:"riskControls,omitempty" yaml:"riskControls,omitempty"`
 
+	Withdrawal *WithdrawalConfig `json:"withdrawal,omitempty" yaml:"withdrawal,omitempty"`
+
 	ExchangeStrategies      []ExchangeStrategyMount `json:"-" yaml:"-"`
 	CrossExchangeStrategies []CrossExchangeStrategy `json:"-" yaml:"-"`
 
@@ -294,17 +453,28 @@ func LoadBuildConfig(configFile string) (*Config, error) {
 
 // Load parses the config
 func Load(configFile string, loadStrategies bool) (*Config, error) {
-	var config Config
-
 	content, err := ioutil.ReadFile(configFile)
 	if err != nil {
 		return nil, err
 	}
 
+	return LoadContent(content, loadStrategies)
+}
+
+// LoadContent loads the config from the given yaml (or json) content
+func LoadContent(content []byte, loadStrategies bool) (*Config, error) {
+	var config Config
+
 	if err := yaml.Unmarshal(content, &config); err != nil {
 		return nil, err
 	}
 
+	if config.Backtest != nil {
+		if err := config.Backtest.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	// for backward compatible
 	if config.Build == nil {
 		config.Build = &BuildConfig{
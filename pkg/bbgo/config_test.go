@@ -4,9 +4,12 @@ import (
 	"context"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
 )
 
 func init() {
@@ -185,6 +188,27 @@ func TestLoadConfig(t *testing.T) {
 				assert.NotNil(t, config.Backtest.Account.Balances)
 				assert.Len(t, config.Backtest.Account.Balances, 2)
 				assert.NotEmpty(t, config.Backtest.StartTime)
+				assert.Equal(t, fixedpoint.NewFromFloat(15.0), config.Backtest.Account.MakerCommission)
+
+				if assert.NotNil(t, config.Backtest.Slippage) {
+					assert.Equal(t, BacktestSlippagePercentage, config.Backtest.Slippage.Type)
+					assert.Equal(t, fixedpoint.NewFromFloat(0.0005), config.Backtest.Slippage.Value)
+				}
+
+				if assert.NotNil(t, config.Backtest.Latency) {
+					assert.Equal(t, 200*time.Millisecond, config.Backtest.Latency.Submit.Duration())
+					assert.Equal(t, time.Second, config.Backtest.Latency.Cancel.Duration())
+				}
+
+				if assert.NotNil(t, config.Backtest.Fees) {
+					assert.Equal(t, "BNB", config.Backtest.Fees.PlatformCurrency)
+					assert.Equal(t, fixedpoint.NewFromFloat(0.0004), config.Backtest.Fees.Symbols["BTCUSDT"].TakerFeeRate)
+				}
+
+				if assert.NotNil(t, config.Backtest.Margin) {
+					assert.Equal(t, fixedpoint.NewFromFloat(3.0), config.Backtest.Margin.MaxLeverage)
+					assert.Equal(t, fixedpoint.NewFromFloat(0.00000833), config.Backtest.Margin.InterestRates["USDT"])
+				}
 			},
 		},
 	}
@@ -211,3 +235,21 @@ func TestLoadConfig(t *testing.T) {
 	}
 
 }
+
+func TestLoadContent_InvalidBacktestMatching(t *testing.T) {
+	_, err := LoadContent([]byte(`
+backtest:
+  startTime: "2021-01-01"
+  matching:
+    engine: "tick"
+`), false)
+	assert.EqualError(t, err, "unsupported backtest matching engine: tick")
+
+	_, err = LoadContent([]byte(`
+backtest:
+  startTime: "2021-01-01"
+  slippage:
+    type: "random"
+`), false)
+	assert.EqualError(t, err, "unsupported backtest slippage model: random")
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/c9s/bbgo/pkg/accounting/pnl"
 	"github.com/c9s/bbgo/pkg/cmd/cmdutil"
+	"github.com/c9s/bbgo/pkg/exchange/paper"
 	"github.com/c9s/bbgo/pkg/notifier/slacknotifier"
 	"github.com/c9s/bbgo/pkg/notifier/telegramnotifier"
 	"github.com/c9s/bbgo/pkg/service"
@@ -70,6 +71,13 @@ type Environment struct {
 	TradeService             *service.TradeService
 	RewardService            *service.RewardService
 	SyncService              *service.SyncService
+	BacktestRunService       *service.BacktestRunService
+	InternalTransferService  *service.InternalTransferService
+
+	// Withdrawer is only set if the withdrawal is configured
+	Withdrawer *Withdrawer
+
+	telegramInteraction *telegramnotifier.Interaction
 
 	// startTime is the time of start point (which is used in the backtest)
 	startTime time.Time
@@ -159,6 +167,8 @@ func (environ *Environment) ConfigureDatabaseDriver(ctx context.Context, driver
 	environ.OrderService = &service.OrderService{DB: db}
 	environ.TradeService = &service.TradeService{DB: db}
 	environ.RewardService = &service.RewardService{DB: db}
+	environ.BacktestRunService = &service.BacktestRunService{DB: db}
+	environ.InternalTransferService = &service.InternalTransferService{DB: db}
 
 	environ.SyncService = &service.SyncService{
 		TradeService:    environ.TradeService,
@@ -217,7 +227,22 @@ func NewExchangeSessionFromConfig(name string, sessionConfig *ExchangeSession) (
 
 	var exchange types.Exchange
 
-	if sessionConfig.Key != "" && sessionConfig.Secret != "" {
+	if sessionConfig.Futures && sessionConfig.Margin {
+		return nil, fmt.Errorf("futures session %s can not be a margin session", name)
+	}
+
+	if sessionConfig.PaperTrading {
+		if sessionConfig.Futures {
+			return nil, fmt.Errorf("paper trading session %s does not support futures", name)
+		}
+
+		if sessionConfig.Margin {
+			return nil, fmt.Errorf("paper trading session %s does not support margin", name)
+		}
+
+		// only the public api of the exchange is used by the paper trading session
+		exchange, err = cmdutil.NewExchangeStandard(exchangeName, "", "", "")
+	} else if sessionConfig.Key != "" && sessionConfig.Secret != "" {
 		if !sessionConfig.PublicOnly {
 			if len(sessionConfig.Key) == 0 || len(sessionConfig.Secret) == 0 {
 				return nil, fmt.Errorf("can not create exchange %s: empty key or secret", exchangeName)
@@ -233,6 +258,15 @@ func NewExchangeSessionFromConfig(name string, sessionConfig *ExchangeSession) (
 		return nil, err
 	}
 
+	if sessionConfig.PaperTrading {
+		var account paper.AccountConfig
+		if sessionConfig.PaperTradingAccount != nil {
+			account = *sessionConfig.PaperTradingAccount
+		}
+
+		exchange = paper.New(exchange, account)
+	}
+
 	// configure exchange
 	if sessionConfig.Margin {
 		marginExchange, ok := exchange.(types.MarginExchange)
@@ -247,6 +281,15 @@ func NewExchangeSessionFromConfig(name string, sessionConfig *ExchangeSession) (
 		}
 	}
 
+	if sessionConfig.Futures {
+		futuresExchange, ok := exchange.(types.FuturesExchange)
+		if !ok {
+			return nil, fmt.Errorf("exchange %s does not support futures", exchangeName)
+		}
+
+		futuresExchange.UseFutures()
+	}
+
 	session := NewExchangeSession(name, exchange)
 	session.ExchangeName = sessionConfig.ExchangeName
 	session.EnvVarPrefix = sessionConfig.EnvVarPrefix
@@ -257,6 +300,9 @@ func NewExchangeSessionFromConfig(name string, sessionConfig *ExchangeSession) (
 	session.Margin = sessionConfig.Margin
 	session.IsolatedMargin = sessionConfig.IsolatedMargin
 	session.IsolatedMarginSymbol = sessionConfig.IsolatedMarginSymbol
+	session.Futures = sessionConfig.Futures
+	session.PaperTrading = sessionConfig.PaperTrading
+	session.PaperTradingAccount = sessionConfig.PaperTradingAccount
 	return session, nil
 }
 
@@ -550,6 +596,11 @@ func (environ *Environment) syncSession(ctx context.Context, session *ExchangeSe
 		return err
 	}
 
+	// there is nothing to sync from the paper trading session, and the futures trades are not stored
+	if session.PaperTrading || session.Futures {
+		return nil
+	}
+
 	symbols, err := getSessionSymbols(session, defaultSymbols...)
 	if err != nil {
 		return err
@@ -641,6 +692,7 @@ func (environ *Environment) ConfigureNotificationSystem(userConfig *Config) erro
 		}
 
 		go interaction.Start(session)
+		environ.telegramInteraction = interaction
 
 		var notifier = telegramnotifier.New(interaction)
 		environ.Notifiability.AddNotifier(notifier)
@@ -655,6 +707,34 @@ func (environ *Environment) ConfigureNotificationSystem(userConfig *Config) erro
 	return nil
 }
 
+// ConfigureWithdrawal sets up the withdrawer, it should be called after the notification system is configured
+// since the telegram interaction is used to confirm the withdrawals.
+func (environ *Environment) ConfigureWithdrawal(userConfig *Config) error {
+	if userConfig.Withdrawal == nil {
+		return nil
+	}
+
+	// the daily limits count the withdrawals in the store, they would be reset by restarting with the memory store
+	if len(userConfig.Withdrawal.DailyLimits) > 0 &&
+		environ.PersistenceServiceFacade.Redis == nil && environ.PersistenceServiceFacade.Json == nil {
+		return errors.New("withdrawal daily limits require the redis or the json persistence, please configure the persistence")
+	}
+
+	persistence := environ.PersistenceServiceFacade.Get()
+	withdrawer := NewWithdrawer(userConfig.Withdrawal, persistence.NewStore("bbgo", "withdrawals"))
+
+	if userConfig.Withdrawal.TOTPConfirmation {
+		if environ.telegramInteraction == nil {
+			return errors.New("withdrawal totp confirmation requires the telegram bot, please set the telegram bot token")
+		}
+
+		withdrawer.Confirmer = environ.telegramInteraction
+	}
+
+	environ.Withdrawer = withdrawer
+	return nil
+}
+
 func writeOTPKeyAsQRCodePNG(key *otp.Key, imagePath string) error {
 	// Convert TOTP key into a PNG
 	var buf bytes.Buffer
@@ -4,3 +4,8 @@ import "errors"
 
 var ErrSessionAlreadyInitialized = errors.New("session is already initialized")
 
+var ErrWithdrawAddressNotWhitelisted = errors.New("withdraw address is not whitelisted")
+
+var ErrWithdrawDailyLimitExceeded = errors.New("withdraw daily limit exceeded")
+
+var ErrWithdrawNotConfirmed = errors.New("withdrawal is not confirmed")
@@ -0,0 +1,191 @@
+package bbgo
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+type orderAmendment struct {
+	Order   types.Order
+	Desired types.SubmitOrder
+}
+
+// orderDiff is the result of diffing the active orders and the desired orders
+type orderDiff struct {
+	// Keep is the active orders that are the same as the desired orders
+	Keep []types.Order
+
+	// Amend is the active orders that should be amended to the desired orders
+	Amend []orderAmendment
+
+	// Cancel is the active orders that are not desired anymore
+	Cancel []types.Order
+
+	// Submit is the desired orders that are not matched by any active order
+	Submit []types.SubmitOrder
+}
+
+type orderGroupKey struct {
+	Symbol string
+	Side   types.SideType
+	Type   types.OrderType
+}
+
+// diffOrders pairs the active orders and the desired orders of the same symbol, side and type by their price levels,
+// the bids are paired from the highest price and the asks are paired from the lowest price.
+// The paired orders are kept if the price and the remaining quantity are not changed after formatting.
+func diffOrders(activeOrders []types.Order, desiredOrders []types.SubmitOrder) (diff orderDiff) {
+	var keys []orderGroupKey
+	var activeGroups = make(map[orderGroupKey][]types.Order)
+	var desiredGroups = make(map[orderGroupKey][]types.SubmitOrder)
+
+	for _, order := range activeOrders {
+		key := orderGroupKey{Symbol: order.Symbol, Side: order.Side, Type: order.Type}
+		if _, ok := activeGroups[key]; !ok {
+			keys = append(keys, key)
+		}
+		activeGroups[key] = append(activeGroups[key], order)
+	}
+
+	for _, order := range desiredOrders {
+		key := orderGroupKey{Symbol: order.Symbol, Side: order.Side, Type: order.Type}
+		if _, ok := activeGroups[key]; !ok {
+			if _, ok := desiredGroups[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		desiredGroups[key] = append(desiredGroups[key], order)
+	}
+
+	for _, key := range keys {
+		active := activeGroups[key]
+		desired := desiredGroups[key]
+
+		sort.SliceStable(active, func(i, j int) bool {
+			return isBetterPrice(key.Side, active[i].Price, active[j].Price)
+		})
+		sort.SliceStable(desired, func(i, j int) bool {
+			return isBetterPrice(key.Side, desired[i].Price, desired[j].Price)
+		})
+
+		for i := 0; i < len(active) || i < len(desired); i++ {
+			switch {
+			case i >= len(desired):
+				diff.Cancel = append(diff.Cancel, active[i])
+
+			case i >= len(active):
+				diff.Submit = append(diff.Submit, desired[i])
+
+			case isSameOrder(active[i], desired[i]):
+				diff.Keep = append(diff.Keep, active[i])
+
+			default:
+				diff.Amend = append(diff.Amend, orderAmendment{Order: active[i], Desired: desired[i]})
+			}
+		}
+	}
+
+	return diff
+}
+
+func isBetterPrice(side types.SideType, a, b float64) bool {
+	if side == types.SideTypeBuy {
+		return a > b
+	}
+	return a < b
+}
+
+// isSameOrder compares the active order and the desired order with the precision of the market,
+// the remaining quantity of the active order is compared since the executed quantity can not be amended.
+func isSameOrder(order types.Order, desired types.SubmitOrder) bool {
+	remaining := order.Quantity - order.ExecutedQuantity
+	market := desired.Market
+	if market.Symbol == "" {
+		return order.Price == desired.Price && remaining == desired.Quantity
+	}
+
+	return market.FormatPrice(order.Price) == market.FormatPrice(desired.Price) &&
+		market.FormatQuantity(remaining) == market.FormatQuantity(desired.Quantity)
+}
+
+// AmendOrders updates the active orders to the desired orders and only amends the orders that are changed.
+// The active orders that are not desired anymore are canceled and the desired orders that are not matched are submitted.
+// The orders are amended by the ExchangeAmendService if the exchange supports it, otherwise they are canceled and
+// submitted again. The active order book is updated with the amended and the submitted orders, which are returned.
+func (e *ExchangeOrderExecutor) AmendOrders(ctx context.Context, activeOrders *LocalActiveOrderBook, desiredOrders ...types.SubmitOrder) (types.OrderSlice, error) {
+	formattedOrders, err := formatOrders(e.Session, desiredOrders)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffOrders(activeOrders.Orders(), formattedOrders)
+
+	log.Infof("amending orders: %d kept, %d amended, %d canceled, %d submitted",
+		len(diff.Keep), len(diff.Amend), len(diff.Cancel), len(diff.Submit))
+
+	if len(diff.Cancel) > 0 {
+		if err := e.Session.Exchange.CancelOrders(ctx, diff.Cancel...); err != nil {
+			return nil, err
+		}
+
+		for _, order := range diff.Cancel {
+			activeOrders.Remove(order)
+		}
+	}
+
+	var changedOrders types.OrderSlice
+	for _, amendment := range diff.Amend {
+		amendedOrder, err := e.amendOrder(ctx, amendment.Order, amendment.Desired)
+		if err != nil {
+			return changedOrders, err
+		}
+
+		activeOrders.Remove(amendment.Order)
+		activeOrders.Add(*amendedOrder)
+		changedOrders = append(changedOrders, *amendedOrder)
+	}
+
+	if len(diff.Submit) > 0 {
+		createdOrders, err := e.SubmitOrders(ctx, diff.Submit...)
+		activeOrders.Add(createdOrders...)
+		changedOrders = append(changedOrders, createdOrders...)
+		if err != nil {
+			return changedOrders, err
+		}
+	}
+
+	return changedOrders, nil
+}
+
+func (e *ExchangeOrderExecutor) amendOrder(ctx context.Context, order types.Order, desired types.SubmitOrder) (*types.Order, error) {
+	order.Market = desired.Market
+
+	log.Infof("amending order #%d %s %s price %s -> %s, quantity %f -> %s",
+		order.OrderID, order.Symbol, order.Side,
+		desired.Market.FormatPrice(order.Price), desired.PriceString,
+		order.Quantity-order.ExecutedQuantity, desired.QuantityString)
+
+	if service, ok := e.Session.Exchange.(types.ExchangeAmendService); ok {
+		return service.AmendOrder(ctx, order, desired.Price, desired.Quantity)
+	}
+
+	if err := e.Session.Exchange.CancelOrders(ctx, order); err != nil {
+		return nil, err
+	}
+
+	createdOrders, err := e.SubmitOrders(ctx, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(createdOrders) == 0 {
+		return nil, errors.New("no order is created")
+	}
+
+	return &createdOrders[0], nil
+}
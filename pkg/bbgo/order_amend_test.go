@@ -0,0 +1,80 @@
+package bbgo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func newTestActiveOrder(orderID uint64, side types.SideType, price, quantity, executedQuantity float64) types.Order {
+	return types.Order{
+		SubmitOrder: types.SubmitOrder{
+			Symbol:   "BTCUSDT",
+			Side:     side,
+			Type:     types.OrderTypeLimit,
+			Price:    price,
+			Quantity: quantity,
+		},
+		OrderID:          orderID,
+		Status:           types.OrderStatusNew,
+		ExecutedQuantity: executedQuantity,
+	}
+}
+
+func newTestDesiredOrder(side types.SideType, price, quantity float64) types.SubmitOrder {
+	return types.SubmitOrder{
+		Symbol:   "BTCUSDT",
+		Side:     side,
+		Type:     types.OrderTypeLimit,
+		Price:    price,
+		Quantity: quantity,
+		Market: types.Market{
+			Symbol:          "BTCUSDT",
+			PricePrecision:  2,
+			VolumePrecision: 6,
+			TickSize:        0.01,
+			StepSize:        0.000001,
+		},
+	}
+}
+
+func Test_diffOrders(t *testing.T) {
+	activeOrders := []types.Order{
+		newTestActiveOrder(1, types.SideTypeBuy, 9990.0, 0.1, 0),
+		newTestActiveOrder(2, types.SideTypeBuy, 9980.0, 0.1, 0.05),
+		newTestActiveOrder(3, types.SideTypeBuy, 9970.0, 0.1, 0),
+		newTestActiveOrder(4, types.SideTypeSell, 10010.0, 0.1, 0),
+	}
+
+	desiredOrders := []types.SubmitOrder{
+		// the desired orders are not sorted
+		newTestDesiredOrder(types.SideTypeBuy, 9980.0, 0.05),
+		newTestDesiredOrder(types.SideTypeBuy, 9990.001, 0.1),
+		newTestDesiredOrder(types.SideTypeSell, 10020.0, 0.1),
+		newTestDesiredOrder(types.SideTypeSell, 10030.0, 0.1),
+	}
+
+	diff := diffOrders(activeOrders, desiredOrders)
+
+	// the price 9990.001 is the same as 9990 after formatting,
+	// and the remaining quantity of the order 2 is the same as the desired quantity
+	if assert.Len(t, diff.Keep, 2) {
+		assert.Equal(t, uint64(1), diff.Keep[0].OrderID)
+		assert.Equal(t, uint64(2), diff.Keep[1].OrderID)
+	}
+
+	if assert.Len(t, diff.Cancel, 1) {
+		assert.Equal(t, uint64(3), diff.Cancel[0].OrderID)
+	}
+
+	if assert.Len(t, diff.Amend, 1) {
+		assert.Equal(t, uint64(4), diff.Amend[0].Order.OrderID)
+		assert.Equal(t, 10020.0, diff.Amend[0].Desired.Price)
+	}
+
+	if assert.Len(t, diff.Submit, 1) {
+		assert.Equal(t, 10030.0, diff.Submit[0].Price)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 
+	"github.com/c9s/bbgo/pkg/exchange/paper"
 	"github.com/c9s/bbgo/pkg/indicator"
 	"github.com/c9s/bbgo/pkg/service"
 	"github.com/c9s/bbgo/pkg/types"
@@ -118,6 +119,15 @@ type ExchangeSession struct {
 	IsolatedMargin       bool   `json:"isolatedMargin,omitempty" yaml:"isolatedMargin,omitempty"`
 	IsolatedMarginSymbol string `json:"isolatedMarginSymbol,omitempty" yaml:"isolatedMarginSymbol,omitempty"`
 
+	// Futures switches the session to the USDT-M futures account of the exchange,
+	// the futures trades are not stored in the database.
+	Futures bool `json:"futures,omitempty" yaml:"futures,omitempty"`
+
+	// PaperTrading runs the session with the live market data of the exchange,
+	// but the orders are matched locally against the virtual balances of PaperTradingAccount.
+	PaperTrading        bool                 `json:"paperTrading,omitempty" yaml:"paperTrading,omitempty"`
+	PaperTradingAccount *paper.AccountConfig `json:"paperTradingAccount,omitempty" yaml:"paperTradingAccount,omitempty"`
+
 	// ---------------------------
 	// Runtime fields
 	// ---------------------------
@@ -245,8 +255,8 @@ func (session *ExchangeSession) Init(ctx context.Context, environ *Environment)
 
 	session.Account.BindStream(session.Stream)
 
-	// insert trade into db right before everything
-	if environ.TradeService != nil {
+	// insert trade into db right before everything, the paper trades and the futures trades are not stored
+	if environ.TradeService != nil && !session.PaperTrading && !session.Futures {
 		session.Stream.OnTradeUpdate(func(trade types.Trade) {
 			if err := environ.TradeService.Insert(trade); err != nil {
 				log.WithError(err).Errorf("trade insert error: %+v", trade)
@@ -267,6 +277,15 @@ func (session *ExchangeSession) Init(ctx context.Context, environ *Environment)
 		session.lastPrices[kline.Symbol] = kline.Close
 	})
 
+	// the book ticker updates the last prices more frequently than the closed klines when it's subscribed
+	session.Stream.OnBookTickerUpdate(func(bookTicker types.BookTicker) {
+		if bookTicker.Buy <= 0 || bookTicker.Sell <= 0 {
+			return
+		}
+
+		session.lastPrices[bookTicker.Symbol] = bookTicker.MidPrice()
+	})
+
 	session.IsInitialized = true
 	return nil
 }
@@ -301,7 +320,8 @@ func (session *ExchangeSession) InitSymbol(ctx context.Context, environ *Environ
 
 	var err error
 	var trades []types.Trade
-	if environ.SyncService != nil {
+	// the position of the paper trading session starts from the virtual balances
+	if environ.SyncService != nil && !session.PaperTrading && !session.Futures {
 		tradingFeeCurrency := session.Exchange.PlatformFeeCurrency()
 		if strings.HasPrefix(symbol, tradingFeeCurrency) {
 			trades, err = environ.TradeService.QueryForTradingFeeCurrency(session.Exchange.Name(), symbol, tradingFeeCurrency)
@@ -503,6 +523,59 @@ func (session *ExchangeSession) FormatOrder(order types.SubmitOrder) (types.Subm
 	return order, nil
 }
 
+// CancelOpenOrders cancels the open orders of the given symbols, or all the open orders if no symbol is given.
+// The batch cancel api is used if the exchange implements types.ExchangeCancelService, otherwise the open orders
+// of the symbols (the used symbols of the session if no symbol is given) are queried and canceled.
+func (session *ExchangeSession) CancelOpenOrders(ctx context.Context, symbols ...string) (canceledOrders []types.Order, err error) {
+	if service, ok := session.Exchange.(types.ExchangeCancelService); ok {
+		if len(symbols) == 0 {
+			return service.CancelAllOrders(ctx)
+		}
+
+		for _, symbol := range symbols {
+			orders, err := service.CancelOrdersBySymbol(ctx, symbol)
+			if err != nil {
+				return canceledOrders, err
+			}
+			canceledOrders = append(canceledOrders, orders...)
+		}
+
+		return canceledOrders, nil
+	}
+
+	if len(symbols) == 0 {
+		for symbol := range session.usedSymbols {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("exchange %s does not support canceling all orders, symbols are required", session.ExchangeName)
+	}
+
+	for _, symbol := range symbols {
+		openOrders, err := session.Exchange.QueryOpenOrders(ctx, symbol)
+		if err != nil {
+			return canceledOrders, err
+		}
+
+		if len(openOrders) == 0 {
+			continue
+		}
+
+		if err := session.Exchange.CancelOrders(ctx, openOrders...); err != nil {
+			return canceledOrders, err
+		}
+
+		for _, order := range openOrders {
+			order.Status = types.OrderStatusCanceled
+			canceledOrders = append(canceledOrders, order)
+		}
+	}
+
+	return canceledOrders, nil
+}
+
 func (session *ExchangeSession) UpdatePrices(ctx context.Context) (err error) {
 	if session.lastPriceUpdatedAt.After(time.Now().Add(- time.Hour)) {
 		return nil
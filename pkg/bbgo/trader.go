@@ -331,6 +331,12 @@ func (trader *Trader) injectCommonServices(rs reflect.Value) error {
 		}
 	}
 
+	if trader.environment.Withdrawer != nil {
+		if err := injectField(rs, "Withdrawer", trader.environment.Withdrawer, true); err != nil {
+			return errors.Wrap(err, "failed to inject Withdrawer")
+		}
+	}
+
 	if field, ok := hasField(rs, "Persistence"); ok {
 		if trader.environment.PersistenceServiceFacade == nil {
 			log.Warnf("strategy has Persistence field but persistence service is not defined")
@@ -0,0 +1,217 @@
+package bbgo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/service"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+// withdrawConfirmTimeout is how long the withdrawal waits for the confirmation
+const withdrawConfirmTimeout = 5 * time.Minute
+
+type WithdrawalAddress struct {
+	Asset   string `json:"asset" yaml:"asset"`
+	Address string `json:"address" yaml:"address"`
+
+	// Network is optional, the address can be used with any network if it's empty
+	Network string `json:"network,omitempty" yaml:"network,omitempty"`
+}
+
+type WithdrawalConfig struct {
+	// Whitelist is the addresses that are allowed to withdraw to
+	Whitelist []WithdrawalAddress `json:"whitelist,omitempty" yaml:"whitelist,omitempty"`
+
+	// DailyLimits is the max withdrawal amount of the asset in 24 hours,
+	// the assets without the limit are not limited.
+	DailyLimits map[string]fixedpoint.Value `json:"dailyLimits,omitempty" yaml:"dailyLimits,omitempty"`
+
+	// TOTPConfirmation requires the withdrawals to be confirmed by the one-time password via the telegram bot
+	TOTPConfirmation bool `json:"totpConfirmation,omitempty" yaml:"totpConfirmation,omitempty"`
+}
+
+// WithdrawalConfirmer confirms the withdrawal, an error is returned if the withdrawal is not confirmed
+type WithdrawalConfirmer interface {
+	Confirm(ctx context.Context, message string) error
+}
+
+type withdrawalRecord struct {
+	Asset  string    `json:"asset"`
+	Amount float64   `json:"amount"`
+	Time   time.Time `json:"time"`
+}
+
+// Withdrawer submits the withdrawals through the ExchangeWithdrawService of the session,
+// the withdrawals are only allowed to the whitelisted addresses and within the daily limits.
+// The submitted withdrawals are saved in the store, so that the daily limits still apply after restarting.
+type Withdrawer struct {
+	config *WithdrawalConfig
+
+	// Confirmer is required if the TOTP confirmation is enabled
+	Confirmer WithdrawalConfirmer
+
+	store service.Store
+
+	mu      sync.Mutex
+	records []withdrawalRecord
+}
+
+func NewWithdrawer(config *WithdrawalConfig, store service.Store) *Withdrawer {
+	w := &Withdrawer{
+		config: config,
+		store:  store,
+	}
+
+	if err := store.Load(&w.records); err != nil && err != service.ErrPersistenceNotExists {
+		log.WithError(err).Error("can not load the withdrawal records")
+	}
+
+	return w
+}
+
+func (w *Withdrawer) Withdraw(ctx context.Context, session *ExchangeSession, asset string, amount float64, address, network string) (*types.Withdraw, error) {
+	withdrawService, ok := session.Exchange.(types.ExchangeWithdrawService)
+	if !ok {
+		return nil, fmt.Errorf("exchange %s does not support withdrawal", session.ExchangeName)
+	}
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("invalid withdraw amount %f", amount)
+	}
+
+	asset = strings.ToUpper(asset)
+
+	if !w.isWhitelisted(asset, address, network) {
+		return nil, fmt.Errorf("%s address %s (network %q): %w", asset, address, network, ErrWithdrawAddressNotWhitelisted)
+	}
+
+	// reserve the amount before the confirmation, so that the concurrent withdrawals can't exceed the limit
+	// while the lock is released
+	record, err := w.reserve(asset, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.config.TOTPConfirmation {
+		if w.Confirmer == nil {
+			w.release(record)
+			return nil, fmt.Errorf("withdrawal confirmer is not configured: %w", ErrWithdrawNotConfirmed)
+		}
+
+		message := fmt.Sprintf("Withdraw %f %s from %s to %s (network %q)?", amount, asset, session.Name, address, network)
+
+		confirmCtx, cancel := context.WithTimeout(ctx, withdrawConfirmTimeout)
+		err := w.Confirmer.Confirm(confirmCtx, message)
+		cancel()
+		if err != nil {
+			w.release(record)
+			return nil, fmt.Errorf("%v: %w", err, ErrWithdrawNotConfirmed)
+		}
+	}
+
+	log.Infof("withdrawing %f %s from session %s to %s (network %q)", amount, asset, session.Name, address, network)
+
+	withdraw, err := withdrawService.Withdraw(ctx, asset, amount, address, network)
+
+	// the reserved amount is kept even if the withdrawal fails, since the withdrawal could be submitted
+	// before the error is returned, e.g., the response can't be read or parsed
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.store.Save(&w.records); err != nil {
+		log.WithError(err).Error("can not save the withdrawal records")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return withdraw, nil
+}
+
+// reserve checks the daily limit of the asset and adds the withdrawal record,
+// the record should be released if the withdrawal is not confirmed
+func (w *Withdrawer) reserve(asset string, amount float64) (withdrawalRecord, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if limit, ok := w.dailyLimit(asset); ok {
+		withdrawn := w.withdrawnAmount(asset, now)
+		if withdrawn+amount > limit.Float64() {
+			return withdrawalRecord{}, fmt.Errorf("%s withdrawn %f + %f > limit %f: %w", asset, withdrawn, amount, limit.Float64(), ErrWithdrawDailyLimitExceeded)
+		}
+	}
+
+	record := withdrawalRecord{Asset: asset, Amount: amount, Time: now}
+	w.records = append(w.records, record)
+	return record, nil
+}
+
+// release removes the reserved record of the withdrawal that is not confirmed
+func (w *Withdrawer) release(record withdrawalRecord) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i, r := range w.records {
+		if r == record {
+			w.records = append(w.records[:i], w.records[i+1:]...)
+			break
+		}
+	}
+
+	// the reserved record could be saved with the records of the other withdrawals
+	if err := w.store.Save(&w.records); err != nil {
+		log.WithError(err).Error("can not save the withdrawal records")
+	}
+}
+
+func (w *Withdrawer) dailyLimit(asset string) (fixedpoint.Value, bool) {
+	for limitAsset, limit := range w.config.DailyLimits {
+		if strings.EqualFold(limitAsset, asset) {
+			return limit, true
+		}
+	}
+
+	return 0, false
+}
+
+func (w *Withdrawer) isWhitelisted(asset, address, network string) bool {
+	for _, a := range w.config.Whitelist {
+		if !strings.EqualFold(a.Asset, asset) || a.Address != address {
+			continue
+		}
+
+		if a.Network == "" || strings.EqualFold(a.Network, network) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withdrawnAmount returns the withdrawn amount of the asset in the last 24 hours and prunes the expired records,
+// it's called with the lock held
+func (w *Withdrawer) withdrawnAmount(asset string, now time.Time) (amount float64) {
+	var records []withdrawalRecord
+	for _, record := range w.records {
+		if now.Sub(record.Time) >= 24*time.Hour {
+			continue
+		}
+
+		records = append(records, record)
+		if strings.EqualFold(record.Asset, asset) {
+			amount += record.Amount
+		}
+	}
+
+	w.records = records
+	return amount
+}
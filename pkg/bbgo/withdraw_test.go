@@ -0,0 +1,251 @@
+package bbgo
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/service"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+type testWithdrawExchange struct {
+	types.Exchange
+
+	withdraws []types.Withdraw
+	err       error
+}
+
+func (e *testWithdrawExchange) Withdraw(ctx context.Context, asset string, amount float64, address string, network string) (*types.Withdraw, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	withdraw := types.Withdraw{Asset: asset, Amount: amount, Address: address, Network: network}
+	e.withdraws = append(e.withdraws, withdraw)
+	return &withdraw, nil
+}
+
+type testConfirmer struct {
+	messages []string
+	err      error
+}
+
+func (c *testConfirmer) Confirm(ctx context.Context, message string) error {
+	c.messages = append(c.messages, message)
+	return c.err
+}
+
+func newTestWithdrawer(config *WithdrawalConfig) (*Withdrawer, *ExchangeSession, *testWithdrawExchange) {
+	exchange := &testWithdrawExchange{}
+	session := &ExchangeSession{Name: "max", Exchange: exchange}
+	store := service.NewMemoryService().NewStore("bbgo", "withdrawals")
+	return NewWithdrawer(config, store), session, exchange
+}
+
+func TestWithdrawer_Whitelist(t *testing.T) {
+	withdrawer, session, exchange := newTestWithdrawer(&WithdrawalConfig{
+		Whitelist: []WithdrawalAddress{
+			{Asset: "USDT", Address: "0xabc", Network: "TRC20"},
+			{Asset: "BTC", Address: "bc1q"},
+		},
+	})
+
+	ctx := context.Background()
+
+	_, err := withdrawer.Withdraw(ctx, session, "USDT", 100.0, "0xabc", "trc20")
+	assert.NoError(t, err)
+
+	_, err = withdrawer.Withdraw(ctx, session, "BTC", 1.0, "bc1q", "BTC")
+	assert.NoError(t, err)
+
+	_, err = withdrawer.Withdraw(ctx, session, "USDT", 100.0, "0xabc", "ERC20")
+	assert.True(t, errors.Is(err, ErrWithdrawAddressNotWhitelisted))
+
+	_, err = withdrawer.Withdraw(ctx, session, "USDT", 100.0, "0xdef", "TRC20")
+	assert.True(t, errors.Is(err, ErrWithdrawAddressNotWhitelisted))
+
+	// the asset is case-insensitive
+	_, err = withdrawer.Withdraw(ctx, session, "btc", 1.0, "bc1q", "")
+	assert.NoError(t, err)
+
+	if assert.Len(t, exchange.withdraws, 3) {
+		assert.Equal(t, "BTC", exchange.withdraws[2].Asset)
+	}
+}
+
+func TestWithdrawer_DailyLimits(t *testing.T) {
+	config := &WithdrawalConfig{
+		Whitelist:   []WithdrawalAddress{{Asset: "USDT", Address: "0xabc"}},
+		DailyLimits: map[string]fixedpoint.Value{"USDT": fixedpoint.NewFromFloat(1000.0)},
+	}
+	withdrawer, session, exchange := newTestWithdrawer(config)
+
+	ctx := context.Background()
+
+	_, err := withdrawer.Withdraw(ctx, session, "USDT", 600.0, "0xabc", "")
+	assert.NoError(t, err)
+
+	_, err = withdrawer.Withdraw(ctx, session, "USDT", 500.0, "0xabc", "")
+	assert.True(t, errors.Is(err, ErrWithdrawDailyLimitExceeded))
+
+	_, err = withdrawer.Withdraw(ctx, session, "USDT", 400.0, "0xabc", "")
+	assert.NoError(t, err)
+
+	assert.Len(t, exchange.withdraws, 2)
+
+	// the records older than 24 hours are not counted
+	withdrawer.records[0].Time = withdrawer.records[0].Time.AddDate(0, 0, -1)
+	_, err = withdrawer.Withdraw(ctx, session, "USDT", 500.0, "0xabc", "")
+	assert.NoError(t, err)
+}
+
+func TestWithdrawer_DailyLimitsWithdrawFailed(t *testing.T) {
+	withdrawer, session, exchange := newTestWithdrawer(&WithdrawalConfig{
+		Whitelist:   []WithdrawalAddress{{Asset: "USDT", Address: "0xabc"}},
+		DailyLimits: map[string]fixedpoint.Value{"USDT": fixedpoint.NewFromFloat(1000.0)},
+	})
+
+	ctx := context.Background()
+
+	// the failed withdrawal could be submitted, the amount is still counted
+	exchange.err = errors.New("response timeout")
+	_, err := withdrawer.Withdraw(ctx, session, "USDT", 600.0, "0xabc", "")
+	assert.Error(t, err)
+
+	exchange.err = nil
+	_, err = withdrawer.Withdraw(ctx, session, "USDT", 500.0, "0xabc", "")
+	assert.True(t, errors.Is(err, ErrWithdrawDailyLimitExceeded))
+}
+
+func TestWithdrawer_DailyLimitsCaseInsensitive(t *testing.T) {
+	withdrawer, session, _ := newTestWithdrawer(&WithdrawalConfig{
+		Whitelist:   []WithdrawalAddress{{Asset: "usdt", Address: "0xabc"}},
+		DailyLimits: map[string]fixedpoint.Value{"usdt": fixedpoint.NewFromFloat(1000.0)},
+	})
+
+	ctx := context.Background()
+
+	_, err := withdrawer.Withdraw(ctx, session, "USDT", 600.0, "0xabc", "")
+	assert.NoError(t, err)
+
+	_, err = withdrawer.Withdraw(ctx, session, "Usdt", 500.0, "0xabc", "")
+	assert.True(t, errors.Is(err, ErrWithdrawDailyLimitExceeded))
+}
+
+func TestWithdrawer_DailyLimitsPersisted(t *testing.T) {
+	config := &WithdrawalConfig{
+		Whitelist:   []WithdrawalAddress{{Asset: "USDT", Address: "0xabc"}},
+		DailyLimits: map[string]fixedpoint.Value{"USDT": fixedpoint.NewFromFloat(1000.0)},
+	}
+
+	store := service.NewMemoryService().NewStore("bbgo", "withdrawals")
+	session := &ExchangeSession{Name: "max", Exchange: &testWithdrawExchange{}}
+
+	_, err := NewWithdrawer(config, store).Withdraw(context.Background(), session, "USDT", 600.0, "0xabc", "")
+	assert.NoError(t, err)
+
+	// the withdrawals before restarting are still counted
+	_, err = NewWithdrawer(config, store).Withdraw(context.Background(), session, "USDT", 600.0, "0xabc", "")
+	assert.True(t, errors.Is(err, ErrWithdrawDailyLimitExceeded))
+}
+
+func TestWithdrawer_TOTPConfirmation(t *testing.T) {
+	withdrawer, session, exchange := newTestWithdrawer(&WithdrawalConfig{
+		Whitelist:        []WithdrawalAddress{{Asset: "USDT", Address: "0xabc"}},
+		TOTPConfirmation: true,
+	})
+
+	ctx := context.Background()
+
+	_, err := withdrawer.Withdraw(ctx, session, "USDT", 100.0, "0xabc", "")
+	assert.True(t, errors.Is(err, ErrWithdrawNotConfirmed))
+
+	confirmer := &testConfirmer{err: context.DeadlineExceeded}
+	withdrawer.Confirmer = confirmer
+
+	_, err = withdrawer.Withdraw(ctx, session, "USDT", 100.0, "0xabc", "")
+	assert.True(t, errors.Is(err, ErrWithdrawNotConfirmed))
+	assert.Len(t, exchange.withdraws, 0)
+
+	confirmer.err = nil
+	_, err = withdrawer.Withdraw(ctx, session, "USDT", 100.0, "0xabc", "")
+	assert.NoError(t, err)
+	assert.Len(t, exchange.withdraws, 1)
+	assert.Len(t, confirmer.messages, 2)
+}
+
+type blockingConfirmer struct {
+	confirming chan struct{}
+	result     chan error
+}
+
+func (c *blockingConfirmer) Confirm(ctx context.Context, message string) error {
+	c.confirming <- struct{}{}
+	return <-c.result
+}
+
+func TestWithdrawer_ReserveDuringConfirmation(t *testing.T) {
+	withdrawer, session, exchange := newTestWithdrawer(&WithdrawalConfig{
+		Whitelist:        []WithdrawalAddress{{Asset: "USDT", Address: "0xabc"}},
+		DailyLimits:      map[string]fixedpoint.Value{"USDT": fixedpoint.NewFromFloat(1000.0)},
+		TOTPConfirmation: true,
+	})
+
+	confirmer := &blockingConfirmer{confirming: make(chan struct{}), result: make(chan error)}
+	withdrawer.Confirmer = confirmer
+
+	ctx := context.Background()
+
+	errC := make(chan error)
+	go func() {
+		_, err := withdrawer.Withdraw(ctx, session, "USDT", 600.0, "0xabc", "")
+		errC <- err
+	}()
+
+	<-confirmer.confirming
+
+	// the lock is not held while waiting for the confirmation, and the amount is reserved
+	_, err := withdrawer.Withdraw(ctx, session, "USDT", 500.0, "0xabc", "")
+	assert.True(t, errors.Is(err, ErrWithdrawDailyLimitExceeded))
+
+	// the reserved amount is released after the confirmation is rejected
+	confirmer.result <- errors.New("rejected")
+	assert.True(t, errors.Is(<-errC, ErrWithdrawNotConfirmed))
+	assert.Len(t, withdrawer.records, 0)
+
+	go func() {
+		<-confirmer.confirming
+		confirmer.result <- nil
+	}()
+
+	_, err = withdrawer.Withdraw(ctx, session, "USDT", 500.0, "0xabc", "")
+	assert.NoError(t, err)
+	assert.Len(t, exchange.withdraws, 1)
+}
+
+func TestEnvironment_ConfigureWithdrawal(t *testing.T) {
+	config := &Config{
+		Withdrawal: &WithdrawalConfig{
+			DailyLimits: map[string]fixedpoint.Value{"USDT": fixedpoint.NewFromFloat(1000.0)},
+		},
+	}
+
+	environ := &Environment{PersistenceServiceFacade: &service.PersistenceServiceFacade{Memory: service.NewMemoryService()}}
+	assert.Error(t, environ.ConfigureWithdrawal(config))
+
+	dir, err := ioutil.TempDir("", "withdrawals")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	environ.PersistenceServiceFacade.Json = &service.JsonPersistenceService{Directory: dir}
+	assert.NoError(t, environ.ConfigureWithdrawal(config))
+	assert.NotNil(t, environ.Withdrawer)
+}
@@ -2,7 +2,11 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -13,6 +17,7 @@ import (
 	"github.com/c9s/bbgo/pkg/backtest"
 	"github.com/c9s/bbgo/pkg/bbgo"
 	"github.com/c9s/bbgo/pkg/cmd/cmdutil"
+	"github.com/c9s/bbgo/pkg/datatype"
 	"github.com/c9s/bbgo/pkg/service"
 	"github.com/c9s/bbgo/pkg/types"
 )
@@ -25,6 +30,11 @@ func init() {
 	BacktestCmd.Flags().Bool("base-asset-baseline", false, "use base asset performance as the competitive baseline performance")
 	BacktestCmd.Flags().CountP("verbose", "v", "verbose level")
 	BacktestCmd.Flags().String("config", "config/bbgo.yaml", "strategy config file")
+	BacktestCmd.Flags().String("output", "", "write the performance report to the given file, supports .json and .csv")
+	BacktestCmd.Flags().Bool("record", false, "store the backtest run with its trades, orders and metrics in the database")
+	BacktestCmd.Flags().Bool("walk-forward", false, "run the walk-forward optimization defined in the optimizer config")
+	BacktestCmd.Flags().String("optimizer-config", "optimizer.yaml", "optimizer config file, defines the parameter matrix and the walk-forward windows")
+	BacktestCmd.Flags().Int("workers", 4, "the number of backtests running concurrently in the walk-forward mode")
 	RootCmd.AddCommand(BacktestCmd)
 }
 
@@ -52,6 +62,11 @@ var BacktestCmd = &cobra.Command{
 			return err
 		}
 
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
 		wantSync, err := cmd.Flags().GetBool("sync")
 		if err != nil {
 			return err
@@ -72,17 +87,27 @@ var BacktestCmd = &cobra.Command{
 			return err
 		}
 
-		exchangeNameStr, err := cmd.Flags().GetString("exchange")
+		wantRecord, err := cmd.Flags().GetBool("record")
 		if err != nil {
 			return err
 		}
 
-		exchangeName, err := types.ValidExchangeName(exchangeNameStr)
+		walkForward, err := cmd.Flags().GetBool("walk-forward")
 		if err != nil {
 			return err
 		}
 
-		sourceExchange, err := cmdutil.NewExchange(exchangeName)
+		optimizerConfigFile, err := cmd.Flags().GetString("optimizer-config")
+		if err != nil {
+			return err
+		}
+
+		workers, err := cmd.Flags().GetInt("workers")
+		if err != nil {
+			return err
+		}
+
+		exchangeNameStr, err := cmd.Flags().GetString("exchange")
 		if err != nil {
 			return err
 		}
@@ -109,6 +134,28 @@ var BacktestCmd = &cobra.Command{
 			return err
 		}
 
+		// without the simulated sessions, the backtest runs on the single session of the given exchange
+		sessionConfigs := userConfig.Backtest.Sessions
+		if len(sessionConfigs) == 0 {
+			exchangeName, err := types.ValidExchangeName(exchangeNameStr)
+			if err != nil {
+				return err
+			}
+
+			sessionConfigs = map[string]bbgo.BacktestSession{
+				exchangeName.String(): {
+					Exchange: exchangeName,
+					Account:  userConfig.Backtest.Account,
+				},
+			}
+		}
+
+		var sessionNames []string
+		for sessionName := range sessionConfigs {
+			sessionNames = append(sessionNames, sessionName)
+		}
+		sort.Strings(sessionNames)
+
 		environ := bbgo.NewEnvironment()
 		if err := environ.ConfigureDatabase(ctx); err != nil {
 			return err
@@ -121,68 +168,80 @@ var BacktestCmd = &cobra.Command{
 		backtestService := &service.BacktestService{DB: environ.DatabaseService.DB}
 
 		if wantSync {
-			log.Info("starting synchronization...")
-			for _, symbol := range userConfig.Backtest.Symbols {
-				if err := backtestService.Sync(ctx, sourceExchange, symbol, syncFromTime); err != nil {
+			for _, sessionName := range sessionNames {
+				sessionConfig := sessionConfigs[sessionName]
+				if err := syncBacktestData(ctx, backtestService, sessionConfig.Exchange, userConfig.Backtest.SessionConfig(sessionConfig).Symbols, syncFromTime, startTime, verboseCnt); err != nil {
 					return err
 				}
 			}
-			log.Info("synchronization done")
-
-			var corruptCnt = 0
-			for _, symbol := range userConfig.Backtest.Symbols {
-				log.Infof("verifying backtesting data...")
-
-				for interval := range types.SupportedIntervals {
-					log.Infof("verifying %s %s kline data...", symbol, interval)
-
-					klineC, errC := backtestService.QueryKLinesCh(startTime, time.Now(), sourceExchange, []string{symbol}, []types.Interval{interval})
-					var emptyKLine types.KLine
-					var prevKLine types.KLine
-					for k := range klineC {
-						if verboseCnt > 1 {
-							fmt.Print(".")
-						}
-
-						if prevKLine != emptyKLine {
-							if prevKLine.StartTime.Add(interval.Duration()) != k.StartTime {
-								corruptCnt++
-								log.Errorf("found kline data corrupted at time: %s kline: %+v", k.StartTime, k)
-								log.Errorf("between %d and %d",
-									prevKLine.StartTime.Unix(),
-									k.StartTime.Unix())
-							}
-						}
-
-						prevKLine = k
-					}
 
-					if verboseCnt > 1 {
-						fmt.Println()
-					}
+			if syncOnly {
+				return nil
+			}
+		}
 
-					if err := <-errC; err != nil {
-						return err
-					}
-				}
+		if walkForward {
+			if len(userConfig.Backtest.Sessions) > 0 {
+				return errors.New("walk-forward mode does not support the simulated sessions")
 			}
 
-			log.Infof("backtest verification completed")
-			if corruptCnt > 0 {
-				log.Errorf("found %d corruptions", corruptCnt)
-			} else {
-				log.Infof("found %d corruptions", corruptCnt)
+			endTime, err := userConfig.Backtest.ParseEndTime()
+			if err != nil {
+				return err
 			}
 
-			if syncOnly {
-				return nil
+			if verboseCnt == 2 {
+				log.SetLevel(log.DebugLevel)
+			} else if verboseCnt > 0 {
+				log.SetLevel(log.InfoLevel)
+			} else {
+				log.SetLevel(log.ErrorLevel)
 			}
-		}
 
-		backtestExchange := backtest.NewExchange(exchangeName, backtestService, userConfig.Backtest)
+			return runWalkForward(ctx, configFile, optimizerConfigFile, sessionConfigs[sessionNames[0]].Exchange, backtestService, startTime, endTime, workers, outputFile)
+		}
 
 		environ.SetStartTime(startTime)
-		environ.AddExchange(exchangeName.String(), backtestExchange)
+
+		var backtestExchanges = map[string]*backtest.Exchange{}
+		var performanceRecorders = map[string]*backtest.PerformanceRecorder{}
+		for _, sessionName := range sessionNames {
+			sessionConfig := sessionConfigs[sessionName]
+			backtestConfig := userConfig.Backtest.SessionConfig(sessionConfig)
+			backtestExchange := backtest.NewExchange(sessionConfig.Exchange, backtestService, backtestConfig)
+			backtestSession := environ.AddExchange(sessionName, backtestExchange)
+			if backtestConfig.Margin != nil {
+				backtestSession.Margin = true
+				backtestSession.IsolatedMargin = len(backtestConfig.Margin.IsolatedSymbol) > 0
+				backtestSession.IsolatedMarginSymbol = backtestConfig.Margin.IsolatedSymbol
+			}
+
+			markets, err := backtestExchange.QueryMarkets(ctx)
+			if err != nil {
+				return err
+			}
+
+			quoteCurrency, err := backtestQuoteCurrency(markets, backtestConfig.Symbols)
+			if err != nil {
+				return err
+			}
+
+			performanceRecorder := backtest.NewPerformanceRecorder(backtestSession, quoteCurrency)
+			performanceRecorder.BindStream(backtestSession.Stream)
+
+			backtestExchanges[sessionName] = backtestExchange
+			performanceRecorders[sessionName] = performanceRecorder
+		}
+
+		// the simulated sessions share the clock, so the kline data of the sessions are dispatched in time order
+		var clock *backtest.Clock
+		if len(userConfig.Backtest.Sessions) > 0 {
+			var exchanges []*backtest.Exchange
+			for _, sessionName := range sessionNames {
+				exchanges = append(exchanges, backtestExchanges[sessionName])
+			}
+			clock = backtest.NewClock(exchanges...)
+		}
 
 		environ.Notifiability = bbgo.Notifiability{
 			SymbolChannelRouter:  bbgo.NewPatternChannelRouter(nil),
@@ -207,20 +266,42 @@ var BacktestCmd = &cobra.Command{
 			trader.SetRiskControls(userConfig.RiskControls)
 		}
 
-		for _, entry := range userConfig.ExchangeStrategies {
-			log.Infof("attaching strategy %T on %s instead of %v", entry.Strategy, exchangeName.String(), entry.Mounts)
-			trader.AttachStrategyOn(exchangeName.String(), entry.Strategy)
-		}
+		if clock != nil {
+			for _, entry := range userConfig.ExchangeStrategies {
+				for _, mount := range entry.Mounts {
+					log.Infof("attaching strategy %T on %s", entry.Strategy, mount)
+					if err := trader.AttachStrategyOn(mount, entry.Strategy); err != nil {
+						return err
+					}
+				}
+			}
+
+			for _, strategy := range userConfig.CrossExchangeStrategies {
+				log.Infof("attaching cross exchange strategy %T", strategy)
+				trader.AttachCrossExchangeStrategy(strategy)
+			}
+		} else {
+			sessionName := sessionNames[0]
+			for _, entry := range userConfig.ExchangeStrategies {
+				log.Infof("attaching strategy %T on %s instead of %v", entry.Strategy, sessionName, entry.Mounts)
+				trader.AttachStrategyOn(sessionName, entry.Strategy)
+			}
 
-		if len(userConfig.CrossExchangeStrategies) > 0 {
-			log.Warnf("backtest does not support CrossExchangeStrategy, strategies won't be added.")
+			if len(userConfig.CrossExchangeStrategies) > 0 {
+				log.Warnf("CrossExchangeStrategy requires the simulated sessions defined in backtest.sessions, strategies won't be added.")
+			}
 		}
 
 		if err := trader.Run(ctx); err != nil {
 			return err
 		}
 
-		<-backtestExchange.Done()
+		if clock != nil {
+			clock.Run(ctx)
+			<-clock.Done()
+		} else {
+			<-backtestExchanges[sessionNames[0]].Done()
+		}
 
 		log.Infof("shutting down trader...")
 		shutdownCtx, cancel := context.WithDeadline(ctx, time.Now().Add(10*time.Second))
@@ -229,7 +310,19 @@ var BacktestCmd = &cobra.Command{
 
 		// put the logger back to print the pnl
 		log.SetLevel(log.InfoLevel)
-		for _, session := range environ.Sessions() {
+
+		var configJSON []byte
+		if wantRecord {
+			configJSON, err = loadConfigJSON(configFile)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, sessionName := range sessionNames {
+			session, _ := environ.Session(sessionName)
+			backtestExchange := backtestExchanges[sessionName]
+			sessionConfig := sessionConfigs[sessionName]
 
 			calculator := &pnl.AverageCostCalculator{
 				TradingFeeCurrency: backtestExchange.PlatformFeeCurrency(),
@@ -245,7 +338,7 @@ var BacktestCmd = &cobra.Command{
 					return fmt.Errorf("start price not found: %s", symbol)
 				}
 
-				log.Infof("%s PROFIT AND LOSS REPORT", symbol)
+				log.Infof("%s %s PROFIT AND LOSS REPORT", sessionName, symbol)
 				log.Infof("===============================================")
 
 				lastPrice, ok := session.LastPrice(symbol)
@@ -256,7 +349,7 @@ var BacktestCmd = &cobra.Command{
 				report := calculator.Calculate(symbol, trades.Trades, lastPrice)
 				report.Print()
 
-				initBalances := userConfig.Backtest.Account.Balances.BalanceMap()
+				initBalances := sessionConfig.Account.Balances.BalanceMap()
 				finalBalances := session.Account.Balances()
 
 				log.Infof("INITIAL BALANCES:")
@@ -275,8 +368,154 @@ var BacktestCmd = &cobra.Command{
 					log.Infof("%s PERFORMANCE: %.2f%% (= (%.2f - %.2f) / %.2f)", market.BaseCurrency, (lastPrice-startPrice)/startPrice*100.0, lastPrice, startPrice, startPrice)
 				}
 			}
+
+			log.Infof("%s PERFORMANCE REPORT", sessionName)
+			log.Infof("===============================================")
+			performanceReport := performanceRecorders[sessionName].Report()
+			performanceReport.Margin = backtestExchange.MarginReport()
+			performanceReport.Print()
+
+			if wantRecord {
+				run, err := recordBacktestRun(ctx, environ.BacktestRunService, sessionName, sessionConfig.Exchange, userConfig.Backtest.SessionConfig(sessionConfig), configJSON, backtestExchange, performanceReport)
+				if err != nil {
+					return err
+				}
+				log.Infof("backtest run is stored with id %d", run.ID)
+			}
+
+			if len(outputFile) > 0 {
+				// write the report of each simulated session to its own file
+				reportFile := outputFile
+				if len(sessionNames) > 1 {
+					ext := filepath.Ext(outputFile)
+					reportFile = strings.TrimSuffix(outputFile, ext) + "-" + sessionName + ext
+				}
+
+				if err := performanceReport.WriteFile(reportFile); err != nil {
+					return err
+				}
+				log.Infof("performance report is written to %s", reportFile)
+			}
 		}
 
 		return nil
 	},
 }
+
+// recordBacktestRun stores the run of the backtest session with its trades, orders and performance metrics
+func recordBacktestRun(ctx context.Context, runService *service.BacktestRunService, sessionName string, exchangeName types.ExchangeName, backtestConfig *bbgo.Backtest, configJSON []byte, backtestExchange *backtest.Exchange, report *backtest.PerformanceReport) (*service.BacktestRun, error) {
+	startTime, err := backtestConfig.ParseStartTime()
+	if err != nil {
+		return nil, err
+	}
+
+	endTime, err := backtestConfig.ParseEndTime()
+	if err != nil {
+		return nil, err
+	}
+
+	equityCurve, err := json.Marshal(report.EquityCurve)
+	if err != nil {
+		return nil, err
+	}
+
+	// the equity curve is stored in its own column
+	metricsReport := *report
+	metricsReport.EquityCurve = nil
+	metrics, err := json.Marshal(metricsReport)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &service.BacktestRun{
+		Session:       sessionName,
+		Exchange:      exchangeName,
+		QuoteCurrency: report.QuoteCurrency,
+		StartTime:     datatype.Time(startTime),
+		EndTime:       datatype.Time(endTime),
+		Config:        configJSON,
+		Metrics:       metrics,
+		EquityCurve:   equityCurve,
+	}
+
+	if err := runService.Insert(ctx, run, backtestExchange.Trades(), backtestExchange.Orders()); err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// backtestQuoteCurrency returns the quote currency of the first backtest symbol,
+// the performance report is valued in this currency.
+func backtestQuoteCurrency(markets types.MarketMap, symbols []string) (string, error) {
+	if len(symbols) > 0 {
+		if market, ok := markets[symbols[0]]; ok {
+			return market.QuoteCurrency, nil
+		}
+	}
+
+	return "", errors.New("can not find the quote currency of the backtest symbols, please check backtest.symbols")
+}
+
+// syncBacktestData syncs the kline data of the source exchange and verifies the synchronized data
+func syncBacktestData(ctx context.Context, backtestService *service.BacktestService, exchangeName types.ExchangeName, symbols []string, syncFromTime, startTime time.Time, verboseCnt int) error {
+	sourceExchange, err := cmdutil.NewExchange(exchangeName)
+	if err != nil {
+		return err
+	}
+
+	log.Info("starting synchronization...")
+	for _, symbol := range symbols {
+		if err := backtestService.Sync(ctx, sourceExchange, symbol, syncFromTime); err != nil {
+			return err
+		}
+	}
+	log.Info("synchronization done")
+
+	var corruptCnt = 0
+	for _, symbol := range symbols {
+		log.Infof("verifying backtesting data...")
+
+		for interval := range types.SupportedIntervals {
+			log.Infof("verifying %s %s kline data...", symbol, interval)
+
+			klineC, errC := backtestService.QueryKLinesCh(startTime, time.Now(), sourceExchange, []string{symbol}, []types.Interval{interval})
+			var emptyKLine types.KLine
+			var prevKLine types.KLine
+			for k := range klineC {
+				if verboseCnt > 1 {
+					fmt.Print(".")
+				}
+
+				if prevKLine != emptyKLine {
+					if prevKLine.StartTime.Add(interval.Duration()) != k.StartTime {
+						corruptCnt++
+						log.Errorf("found kline data corrupted at time: %s kline: %+v", k.StartTime, k)
+						log.Errorf("between %d and %d",
+							prevKLine.StartTime.Unix(),
+							k.StartTime.Unix())
+					}
+				}
+
+				prevKLine = k
+			}
+
+			if verboseCnt > 1 {
+				fmt.Println()
+			}
+
+			if err := <-errC; err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Infof("backtest verification completed")
+	if corruptCnt > 0 {
+		log.Errorf("found %d corruptions", corruptCnt)
+	} else {
+		log.Infof("found %d corruptions", corruptCnt)
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/c9s/bbgo/pkg/bbgo"
+	"github.com/c9s/bbgo/pkg/service"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func init() {
+	BacktestImportCmd.Flags().String("exchange", "", "the exchange of the klines, the klines are imported into the <exchange>_klines table")
+	BacktestImportCmd.Flags().String("symbol", "", "the symbol of the klines, e.g., BTCUSDT")
+	BacktestImportCmd.Flags().String("interval", "1m", "the interval of the klines")
+	BacktestImportCmd.Flags().String("format", "", "the file format: csv or jsonl, detected by the file extension if it's not given")
+	BacktestImportCmd.Flags().String("columns", "", "the column mapping, e.g., startTime=0,open=1,high=2,low=3,close=4,volume=5. CSV columns are indexes or header names, JSON columns are keys")
+	BacktestImportCmd.Flags().String("time-format", "", "the time format: unix, unixms or a go time layout. numeric timestamps and RFC3339 are detected if it's not given")
+	BacktestImportCmd.Flags().Bool("header", false, "the first row of the CSV file is the header row")
+	BacktestImportCmd.Flags().Bool("allow-gaps", false, "import the klines even if there are missing klines")
+	BacktestCmd.AddCommand(BacktestImportCmd)
+}
+
+var BacktestImportCmd = &cobra.Command{
+	Use:          "import [files...]",
+	Short:        "import klines from CSV or JSON lines files into the backtest database",
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		exchangeNameStr, err := cmd.Flags().GetString("exchange")
+		if err != nil {
+			return err
+		}
+
+		exchangeName, err := service.ValidKLineExchangeName(exchangeNameStr)
+		if err != nil {
+			return err
+		}
+
+		symbol, err := cmd.Flags().GetString("symbol")
+		if err != nil {
+			return err
+		}
+
+		if len(symbol) == 0 {
+			return errors.New("--symbol option is required")
+		}
+
+		interval, err := cmd.Flags().GetString("interval")
+		if err != nil {
+			return err
+		}
+
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+
+		columns, err := cmd.Flags().GetString("columns")
+		if err != nil {
+			return err
+		}
+
+		timeFormat, err := cmd.Flags().GetString("time-format")
+		if err != nil {
+			return err
+		}
+
+		header, err := cmd.Flags().GetBool("header")
+		if err != nil {
+			return err
+		}
+
+		allowGaps, err := cmd.Flags().GetBool("allow-gaps")
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+
+		environ := bbgo.NewEnvironment()
+		if err := environ.ConfigureDatabase(ctx); err != nil {
+			return err
+		}
+
+		if environ.DatabaseService == nil {
+			return errors.New("database service is not enabled, please check your environment variables DB_DRIVER and DB_DSN")
+		}
+
+		backtestService := &service.BacktestService{DB: environ.DatabaseService.DB}
+
+		for _, file := range args {
+			options := service.KLineImportOptions{
+				Exchange:   exchangeName,
+				Symbol:     strings.ToUpper(symbol),
+				Interval:   types.Interval(interval),
+				Format:     service.KLineFileFormat(format),
+				TimeFormat: timeFormat,
+				Header:     header,
+				AllowGaps:  allowGaps,
+			}
+
+			if len(options.Format) == 0 {
+				options.Format = detectKLineFileFormat(file)
+			}
+
+			if len(columns) > 0 {
+				mapping, err := parseKLineColumnMapping(options.Format, columns)
+				if err != nil {
+					return err
+				}
+
+				options.Columns = mapping
+			}
+
+			if err := importKLineFile(ctx, backtestService, file, options); err != nil {
+				return errors.Wrapf(err, "%s import error", file)
+			}
+		}
+
+		return nil
+	},
+}
+
+func importKLineFile(ctx context.Context, backtestService *service.BacktestService, file string, options service.KLineImportOptions) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	log.Infof("importing %s %s klines from %s", options.Symbol, options.Interval, file)
+
+	report, err := backtestService.Import(ctx, f, options)
+	if report != nil {
+		for _, gap := range report.Gaps {
+			log.Warnf("missing %s %s klines from %s to %s", options.Symbol, options.Interval, gap.From, gap.To)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	log.Infof("imported %d %s %s klines from %s to %s", report.Imported, options.Symbol, options.Interval, report.StartTime, report.EndTime)
+	return nil
+}
+
+func detectKLineFileFormat(file string) service.KLineFileFormat {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".jsonl", ".json", ".ndjson":
+		return service.KLineFileFormatJSONLines
+	}
+
+	return service.KLineFileFormatCSV
+}
+
+// parseKLineColumnMapping parses the column mapping spec like "startTime=0,open=1",
+// the columns not given in the spec use the default mapping of the format.
+func parseKLineColumnMapping(format service.KLineFileFormat, spec string) (*service.KLineColumnMapping, error) {
+	mapping := service.DefaultCSVColumnMapping
+	if format == service.KLineFileFormatJSONLines {
+		mapping = service.DefaultJSONColumnMapping
+	}
+
+	for _, pair := range strings.Split(spec, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid column mapping %q, expecting field=column", pair)
+		}
+
+		field, column := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		switch field {
+		case "startTime":
+			mapping.StartTime = column
+		case "endTime":
+			mapping.EndTime = column
+		case "open":
+			mapping.Open = column
+		case "high":
+			mapping.High = column
+		case "low":
+			mapping.Low = column
+		case "close":
+			mapping.Close = column
+		case "volume":
+			mapping.Volume = column
+		default:
+			return nil, fmt.Errorf("unknown kline field %q in the column mapping", field)
+		}
+	}
+
+	return &mapping, nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/c9s/bbgo/pkg/backtest"
+	"github.com/c9s/bbgo/pkg/optimizer"
+	"github.com/c9s/bbgo/pkg/service"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+// runWalkForward optimizes the parameters on the rolling in-sample windows and
+// prints the report of the stitched out-of-sample equity curve.
+func runWalkForward(ctx context.Context, configFile, optimizerConfigFile string, exchangeName types.ExchangeName, backtestService *service.BacktestService, startTime, endTime time.Time, workers int, outputFile string) error {
+	optimizerConfig, err := optimizer.LoadConfig(optimizerConfigFile)
+	if err != nil {
+		return err
+	}
+
+	if optimizerConfig.WalkForward == nil {
+		return errors.Errorf("optimizer config %s: walkForward is not defined", optimizerConfigFile)
+	}
+
+	configJSON, err := loadConfigJSON(configFile)
+	if err != nil {
+		return err
+	}
+
+	walkForward := &optimizer.WalkForward{
+		Config: optimizerConfig,
+		Optimizer: &optimizer.Optimizer{
+			Config:  optimizerConfig,
+			Workers: workers,
+		},
+	}
+
+	report, err := walkForward.Run(ctx, configJSON, startTime, endTime, func(ctx context.Context, configJSON []byte) (*backtest.PerformanceReport, error) {
+		return runOptimizerBacktest(ctx, configJSON, exchangeName, backtestService)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := optimizer.RenderWindows(os.Stdout, report.Windows, optimizerConfig.WalkForward.MetricName()); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("OUT-OF-SAMPLE PERFORMANCE")
+	report.Report.Print()
+
+	if len(outputFile) > 0 {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(outputFile, out, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
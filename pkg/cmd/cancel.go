@@ -12,10 +12,9 @@ import (
 	"github.com/c9s/bbgo/pkg/types"
 )
 
-type advancedOrderCancelApi interface {
-	CancelAllOrders(ctx context.Context) ([]types.Order, error)
-	CancelOrdersBySymbol(ctx context.Context, symbol string) ([]types.Order, error)
-	CancelOrdersByGroupID(ctx context.Context, groupID int64) ([]types.Order, error)
+// groupOrderCancelApi is implemented by the exchanges that support the order group, like MAX
+type groupOrderCancelApi interface {
+	CancelOrdersByGroupID(ctx context.Context, groupID uint32) ([]types.Order, error)
 }
 
 func init() {
@@ -53,6 +52,10 @@ var CancelCmd = &cobra.Command{
 			return err
 		}
 
+		if !all && groupID == 0 && len(symbol) == 0 {
+			return errors.New("one of --all, --symbol or --group-id is required")
+		}
+
 		configFile, err := cmd.Flags().GetString("config")
 		if err != nil {
 			return err
@@ -96,53 +99,38 @@ var CancelCmd = &cobra.Command{
 		for sessionID, session := range sessions {
 			var log = logrus.WithField("session", sessionID)
 
-			e, ok := session.Exchange.(advancedOrderCancelApi)
-			if ok {
-				if all {
-					log.Infof("canceling all orders")
-
-					orders, err := e.CancelAllOrders(ctx)
-					if err != nil {
-						return err
-					}
-
-					for _, o := range orders {
-						log.Info("CANCELED ", o.String())
-					}
-				} else if groupID > 0 {
-					log.Infof("canceling orders by group id: %d", groupID)
-
-					orders, err := e.CancelOrdersByGroupID(ctx, groupID)
-					if err != nil {
-						return err
-					}
-
-					for _, o := range orders {
-						log.Info("CANCELED ", o.String())
-					}
-				} else if len(symbol) > 0 {
-					log.Infof("canceling orders by symbol: %s", symbol)
-
-					orders, err := e.CancelOrdersBySymbol(ctx, symbol)
-					if err != nil {
-						return err
-					}
-
-					for _, o := range orders {
-						log.Info("CANCELED ", o.String())
-					}
+			var orders []types.Order
+			if groupID > 0 {
+				e, ok := session.Exchange.(groupOrderCancelApi)
+				if !ok {
+					log.Errorf("exchange %s does not support canceling orders by group id", session.ExchangeName)
+					continue
 				}
+
+				log.Infof("canceling orders by group id: %d", groupID)
+
+				orders, err = e.CancelOrdersByGroupID(ctx, uint32(groupID))
+			} else if all {
+				log.Infof("canceling all orders")
+
+				orders, err = session.CancelOpenOrders(ctx)
 			} else if len(symbol) > 0 {
-				openOrders, err := session.Exchange.QueryOpenOrders(ctx, symbol)
-				if err != nil {
-					return err
-				}
+				log.Infof("canceling orders by symbol: %s", symbol)
+
+				orders, err = session.CancelOpenOrders(ctx, symbol)
+			}
+
+			if err != nil {
+				return err
+			}
 
-				if err := session.Exchange.CancelOrders(ctx, openOrders...); err != nil {
-					return err
+			for _, o := range orders {
+				if o.Status == types.OrderStatusCanceled {
+					log.Info("CANCELED ", o.String())
+				} else {
+					// the exchange queues the cancellation, the order could still be filled before it's canceled
+					log.Info("CANCEL REQUESTED ", o.String())
 				}
-			} else {
-				log.Error("unsupported operation")
 			}
 		}
 
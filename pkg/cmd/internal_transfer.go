@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/c9s/bbgo/pkg/bbgo"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func init() {
+	TransferCmd.Flags().String("session", "", "the exchange session to transfer")
+	TransferCmd.Flags().String("asset", "", "the asset to transfer")
+	TransferCmd.Flags().Float64("amount", 0, "the amount to transfer")
+	TransferCmd.Flags().String("from", "", "the source account, e.g., main or the sub-account name for ftx, spot, margin, futures or isolated:BTCUSDT for binance")
+	TransferCmd.Flags().String("to", "", "the destination account")
+	RootCmd.AddCommand(TransferCmd)
+}
+
+// TransferCmd transfers the asset between the sub-accounts or the wallets of the exchange session
+var TransferCmd = &cobra.Command{
+	Use:   "transfer",
+	Short: "transfer the asset between the sub-accounts or the wallets",
+
+	// SilenceUsage is an option to silence usage when an error occurs.
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		sessionName, err := cmd.Flags().GetString("session")
+		if err != nil {
+			return err
+		}
+
+		asset, err := cmd.Flags().GetString("asset")
+		if err != nil {
+			return err
+		}
+
+		amount, err := cmd.Flags().GetFloat64("amount")
+		if err != nil {
+			return err
+		}
+
+		from, err := cmd.Flags().GetString("from")
+		if err != nil {
+			return err
+		}
+
+		to, err := cmd.Flags().GetString("to")
+		if err != nil {
+			return err
+		}
+
+		if len(sessionName) == 0 || len(asset) == 0 || len(from) == 0 || len(to) == 0 {
+			return errors.New("--session, --asset, --from and --to are required")
+		}
+
+		if amount <= 0 {
+			return errors.New("--amount should be greater than 0")
+		}
+
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+
+		if len(configFile) == 0 {
+			return errors.New("--config option is required")
+		}
+
+		userConfig, err := bbgo.Load(configFile, false)
+		if err != nil {
+			return err
+		}
+
+		environ := bbgo.NewEnvironment()
+		if err := environ.ConfigureDatabase(ctx); err != nil {
+			return err
+		}
+
+		if err := environ.ConfigureExchangeSessions(userConfig); err != nil {
+			return err
+		}
+
+		session, ok := environ.Session(sessionName)
+		if !ok {
+			return fmt.Errorf("session %s not found", sessionName)
+		}
+
+		transferService, ok := session.Exchange.(types.ExchangeInternalTransferService)
+		if !ok {
+			return fmt.Errorf("exchange session %s does not support internal transfer", sessionName)
+		}
+
+		transfer, err := transferService.InternalTransfer(ctx, asset, amount, types.TransferAccount(from), types.TransferAccount(to))
+		if err != nil {
+			return err
+		}
+
+		logrus.Infof("TRANSFERRED %s [%s] #%s", transfer.String(), transfer.Status, transfer.TransferID)
+
+		if environ.InternalTransferService != nil {
+			if err := environ.InternalTransferService.Insert(*transfer); err != nil {
+				// the transfer has been executed, the command should not fail because of the record
+				logrus.WithError(err).Warnf("can not save the record of the internal transfer #%s", transfer.TransferID)
+			}
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/c9s/bbgo/pkg/backtest"
+	"github.com/c9s/bbgo/pkg/bbgo"
+	"github.com/c9s/bbgo/pkg/optimizer"
+	"github.com/c9s/bbgo/pkg/service"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func init() {
+	OptimizeCmd.Flags().String("config", "config/bbgo.yaml", "strategy config file")
+	OptimizeCmd.Flags().String("optimizer-config", "optimizer.yaml", "optimizer config file, defines the parameter matrix")
+	OptimizeCmd.Flags().String("exchange", "", "target exchange")
+	OptimizeCmd.Flags().Int("workers", 4, "the number of backtests running concurrently")
+	OptimizeCmd.Flags().String("metric", "totalReturn", "the metric used for ranking the results: totalReturn, finalEquity, sharpeRatio, sortinoRatio, profitFactor, winRate or maxDrawdown")
+	OptimizeCmd.Flags().String("output", "", "write the ranked results to the given json file")
+	OptimizeCmd.Flags().CountP("verbose", "v", "verbose level")
+	RootCmd.AddCommand(OptimizeCmd)
+}
+
+var OptimizeCmd = &cobra.Command{
+	Use:          "optimize",
+	Short:        "run the backtests of the strategy parameter matrix and rank the results",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		verboseCnt, err := cmd.Flags().GetCount("verbose")
+		if err != nil {
+			return err
+		}
+
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+
+		optimizerConfigFile, err := cmd.Flags().GetString("optimizer-config")
+		if err != nil {
+			return err
+		}
+
+		workers, err := cmd.Flags().GetInt("workers")
+		if err != nil {
+			return err
+		}
+
+		metric, err := cmd.Flags().GetString("metric")
+		if err != nil {
+			return err
+		}
+
+		if _, ok := optimizer.Metrics[metric]; !ok {
+			return errors.Errorf("unsupported metric: %s", metric)
+		}
+
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		exchangeNameStr, err := cmd.Flags().GetString("exchange")
+		if err != nil {
+			return err
+		}
+
+		// the exchange is optional if the simulated sessions are defined in the backtest config
+		var exchangeName types.ExchangeName
+		if len(exchangeNameStr) > 0 {
+			exchangeName, err = types.ValidExchangeName(exchangeNameStr)
+			if err != nil {
+				return err
+			}
+		}
+
+		optimizerConfig, err := optimizer.LoadConfig(optimizerConfigFile)
+		if err != nil {
+			return err
+		}
+
+		configJSON, err := loadConfigJSON(configFile)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		environ := bbgo.NewEnvironment()
+		if err := environ.ConfigureDatabase(ctx); err != nil {
+			return err
+		}
+
+		if environ.DatabaseService == nil {
+			return errors.New("database service is not enabled, please check your environment variables DB_DRIVER and DB_DSN")
+		}
+
+		backtestService := &service.BacktestService{DB: environ.DatabaseService.DB}
+
+		// the log level is set once before the workers start since the workers share the standard logger,
+		// the strategy logging of the workers is disabled by the trader
+		if verboseCnt == 2 {
+			log.SetLevel(log.DebugLevel)
+		} else if verboseCnt > 0 {
+			log.SetLevel(log.InfoLevel)
+		} else {
+			log.SetLevel(log.ErrorLevel)
+		}
+
+		o := &optimizer.Optimizer{
+			Config:  optimizerConfig,
+			Workers: workers,
+		}
+
+		results, err := o.Run(ctx, configJSON, func(ctx context.Context, configJSON []byte) (*backtest.PerformanceReport, error) {
+			return runOptimizerBacktest(ctx, configJSON, exchangeName, backtestService)
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := optimizer.SortResults(results, metric); err != nil {
+			return err
+		}
+
+		if err := optimizer.RenderResults(os.Stdout, results, metric); err != nil {
+			return err
+		}
+
+		if len(outputFile) > 0 {
+			out, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			if err := ioutil.WriteFile(outputFile, out, 0644); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+// loadConfigJSON loads the yaml config file as a json document,
+// the optimizer parameters are patched on the json document by the json pointers.
+func loadConfigJSON(configFile string) ([]byte, error) {
+	configYAML, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var configDoc interface{}
+	if err := yaml.Unmarshal(configYAML, &configDoc); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(configDoc)
+}
+
+// runOptimizerBacktest runs one backtest of the optimizer.
+// Every run allocates its own environment, backtest exchanges and strategy instances,
+// only the backtest service (the kline data) is shared between the runs.
+// With the simulated sessions defined in the backtest config, the reports of the sessions are merged into one report.
+func runOptimizerBacktest(ctx context.Context, configJSON []byte, exchangeName types.ExchangeName, backtestService *service.BacktestService) (*backtest.PerformanceReport, error) {
+	userConfig, err := bbgo.LoadContent(configJSON, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if userConfig.Backtest == nil {
+		return nil, errors.New("backtest config is not defined")
+	}
+
+	if len(userConfig.Backtest.StartTime) == 0 {
+		userConfig.Backtest.StartTime = time.Now().AddDate(0, -6, 0).Format("2006-01-02")
+	}
+
+	startTime, err := userConfig.Backtest.ParseStartTime()
+	if err != nil {
+		return nil, err
+	}
+
+	// without the simulated sessions, the backtest runs on the single session of the given exchange
+	sessionConfigs := userConfig.Backtest.Sessions
+	if len(sessionConfigs) == 0 {
+		if len(exchangeName) == 0 {
+			return nil, errors.New("exchange is not defined, please use --exchange or define the sessions in the backtest config")
+		}
+
+		sessionConfigs = map[string]bbgo.BacktestSession{
+			exchangeName.String(): {
+				Exchange: exchangeName,
+				Account:  userConfig.Backtest.Account,
+			},
+		}
+	}
+
+	var sessionNames []string
+	for sessionName := range sessionConfigs {
+		sessionNames = append(sessionNames, sessionName)
+	}
+	sort.Strings(sessionNames)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	environ := bbgo.NewEnvironment()
+	environ.SetStartTime(startTime)
+	environ.Notifiability = bbgo.Notifiability{
+		SymbolChannelRouter:  bbgo.NewPatternChannelRouter(nil),
+		SessionChannelRouter: bbgo.NewPatternChannelRouter(nil),
+		ObjectChannelRouter:  bbgo.NewObjectChannelRouter(),
+	}
+
+	var backtestExchanges []*backtest.Exchange
+	var performanceRecorders []*backtest.PerformanceRecorder
+	for _, sessionName := range sessionNames {
+		sessionConfig := sessionConfigs[sessionName]
+		backtestConfig := userConfig.Backtest.SessionConfig(sessionConfig)
+		backtestExchange := backtest.NewExchange(sessionConfig.Exchange, backtestService, backtestConfig)
+		backtestSession := environ.AddExchange(sessionName, backtestExchange)
+		if backtestConfig.Margin != nil {
+			backtestSession.Margin = true
+			backtestSession.IsolatedMargin = len(backtestConfig.Margin.IsolatedSymbol) > 0
+			backtestSession.IsolatedMarginSymbol = backtestConfig.Margin.IsolatedSymbol
+		}
+
+		markets, err := backtestExchange.QueryMarkets(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		quoteCurrency, err := backtestQuoteCurrency(markets, backtestConfig.Symbols)
+		if err != nil {
+			return nil, err
+		}
+
+		performanceRecorder := backtest.NewPerformanceRecorder(backtestSession, quoteCurrency)
+		performanceRecorder.BindStream(backtestSession.Stream)
+
+		backtestExchanges = append(backtestExchanges, backtestExchange)
+		performanceRecorders = append(performanceRecorders, performanceRecorder)
+	}
+
+	trader := bbgo.NewTrader(environ)
+	trader.DisableLogging()
+
+	if userConfig.RiskControls != nil {
+		trader.SetRiskControls(userConfig.RiskControls)
+	}
+
+	// the simulated sessions share the clock, so the kline data of the sessions are dispatched in time order
+	var clock *backtest.Clock
+	if len(userConfig.Backtest.Sessions) > 0 {
+		clock = backtest.NewClock(backtestExchanges...)
+
+		for _, entry := range userConfig.ExchangeStrategies {
+			for _, mount := range entry.Mounts {
+				if err := trader.AttachStrategyOn(mount, entry.Strategy); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for _, strategy := range userConfig.CrossExchangeStrategies {
+			trader.AttachCrossExchangeStrategy(strategy)
+		}
+	} else {
+		for _, entry := range userConfig.ExchangeStrategies {
+			trader.AttachStrategyOn(sessionNames[0], entry.Strategy)
+		}
+	}
+
+	if err := trader.Run(ctx); err != nil {
+		return nil, err
+	}
+
+	if clock != nil {
+		clock.Run(ctx)
+		<-clock.Done()
+	} else {
+		<-backtestExchanges[0].Done()
+	}
+
+	shutdownCtx, cancelShutdown := context.WithDeadline(ctx, time.Now().Add(10*time.Second))
+	trader.Graceful.Shutdown(shutdownCtx)
+	cancelShutdown()
+
+	var reports []*backtest.PerformanceReport
+	for i, performanceRecorder := range performanceRecorders {
+		report := performanceRecorder.Report()
+		report.Margin = backtestExchanges[i].MarginReport()
+		reports = append(reports, report)
+	}
+
+	if len(reports) == 1 {
+		return reports[0], nil
+	}
+
+	return backtest.MergeReports(reports)
+}
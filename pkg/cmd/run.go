@@ -96,6 +96,10 @@ func BootstrapEnvironment(ctx context.Context, environ *bbgo.Environment, userCo
 		return errors.Wrap(err, "notification configure error")
 	}
 
+	if err := environ.ConfigureWithdrawal(userConfig); err != nil {
+		return errors.Wrap(err, "withdrawal configure error")
+	}
+
 	return nil
 }
 
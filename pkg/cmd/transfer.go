@@ -128,6 +128,25 @@ var TransferHistoryCmd = &cobra.Command{
 			})
 		}
 
+		// the internal transfers are only recorded in the database by the transfer command
+		if environ.InternalTransferService != nil {
+			transfers, err := environ.InternalTransferService.Query(session.Exchange.Name())
+			if err != nil {
+				return err
+			}
+
+			for _, t := range transfers {
+				if (len(asset) > 0 && t.Asset != asset) || t.EffectiveTime().Before(since) || t.EffectiveTime().After(until) {
+					continue
+				}
+
+				records = append(records, timeRecord{
+					Record: t,
+					Time:   t.EffectiveTime(),
+				})
+			}
+		}
+
 		sort.Sort(records)
 
 		for _, record := range records {
@@ -139,6 +158,9 @@ var TransferHistoryCmd = &cobra.Command{
 			case types.Withdraw:
 				logrus.Infof("%s: ---> WITHDRAW %f %s  [%s]", record.ApplyTime, record.Amount, record.Asset, record.Status)
 
+			case types.InternalTransfer:
+				logrus.Infof("%s: <--> TRANSFER %f %s %s -> %s [%s]", record.Time, record.Amount, record.Asset, record.From, record.To, record.Status)
+
 			default:
 				logrus.Infof("unknown record: %+v", record)
 
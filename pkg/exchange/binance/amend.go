@@ -0,0 +1,79 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/adshao/go-binance/v2"
+	"github.com/google/uuid"
+
+	"github.com/c9s/bbgo/pkg/types"
+	"github.com/c9s/bbgo/pkg/util"
+)
+
+var _ types.ExchangeAmendService = &Exchange{}
+
+// AmendOrder replaces the spot limit order by the cancel-replace api, the order is canceled and the new order is
+// placed in one request, the new order is not placed if the order can not be canceled.
+// The margin orders, the futures orders and the stop orders are canceled and submitted again.
+func (e *Exchange) AmendOrder(ctx context.Context, order types.Order, price, quantity float64) (*types.Order, error) {
+	if e.IsFutures || e.IsMargin || order.Type != types.OrderTypeLimit {
+		return e.cancelAndSubmitOrder(ctx, order, price, quantity)
+	}
+
+	submitOrder := order.Amend(price, quantity)
+	if submitOrder.PriceString == "" {
+		submitOrder.PriceString = util.FormatFloat(price, -1)
+		submitOrder.QuantityString = util.FormatFloat(quantity, -1)
+	}
+
+	timeInForce := string(submitOrder.TimeInForce)
+	if timeInForce == "" {
+		timeInForce = string(binance.TimeInForceTypeGTC)
+	}
+
+	params := url.Values{}
+	params.Set("symbol", order.Symbol)
+	params.Set("side", string(order.Side))
+	params.Set("type", string(binance.OrderTypeLimit))
+	params.Set("timeInForce", timeInForce)
+	params.Set("price", submitOrder.PriceString)
+	params.Set("quantity", submitOrder.QuantityString)
+	params.Set("cancelReplaceMode", "STOP_ON_FAILURE")
+	params.Set("cancelOrderId", strconv.FormatUint(order.OrderID, 10))
+	params.Set("newClientOrderId", uuid.New().String())
+
+	var resp struct {
+		CancelResult     string                       `json:"cancelResult"`
+		NewOrderResult   string                       `json:"newOrderResult"`
+		NewOrderResponse *binance.CreateOrderResponse `json:"newOrderResponse"`
+	}
+
+	log.Infof("amending order #%d %s %s price %s quantity %s", order.OrderID, order.Symbol, order.Side, submitOrder.PriceString, submitOrder.QuantityString)
+
+	if err := e.doSignedRequest(ctx, http.MethodPost, "/api/v3/order/cancelReplace", params, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.NewOrderResult != "SUCCESS" || resp.NewOrderResponse == nil {
+		return nil, fmt.Errorf("binance cancel-replace error, cancel result: %s, new order result: %s", resp.CancelResult, resp.NewOrderResult)
+	}
+
+	return toGlobalCreatedOrder(resp.NewOrderResponse)
+}
+
+func (e *Exchange) cancelAndSubmitOrder(ctx context.Context, order types.Order, price, quantity float64) (*types.Order, error) {
+	if err := e.CancelOrders(ctx, order); err != nil {
+		return nil, err
+	}
+
+	createdOrders, err := e.SubmitOrders(ctx, order.Amend(price, quantity))
+	if err != nil {
+		return nil, err
+	}
+
+	return &createdOrders[0], nil
+}
@@ -0,0 +1,89 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func TestExchange_AmendOrder(t *testing.T) {
+	server, e, requests := newMarginTestExchange(t, func(r marginRequest) (int, string) {
+		return http.StatusOK, `{
+			"cancelResult": "SUCCESS",
+			"newOrderResult": "SUCCESS",
+			"cancelResponse": {"symbol": "BTCUSDT", "orderId": 100, "status": "CANCELED"},
+			"newOrderResponse": {
+				"symbol": "BTCUSDT",
+				"orderId": 101,
+				"clientOrderId": "new-order",
+				"transactTime": 1616000000000,
+				"price": "30000.00",
+				"origQty": "0.02",
+				"executedQty": "0",
+				"cummulativeQuoteQty": "0",
+				"status": "NEW",
+				"timeInForce": "GTC",
+				"type": "LIMIT",
+				"side": "BUY"
+			}
+		}`
+	})
+	defer server.Close()
+
+	order := types.Order{
+		SubmitOrder: types.SubmitOrder{
+			Symbol:   "BTCUSDT",
+			Side:     types.SideTypeBuy,
+			Type:     types.OrderTypeLimit,
+			Price:    29000.0,
+			Quantity: 0.01,
+		},
+		OrderID: 100,
+		Status:  types.OrderStatusNew,
+	}
+
+	amendedOrder, err := e.AmendOrder(context.Background(), order, 30000.0, 0.02)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, uint64(101), amendedOrder.OrderID)
+	assert.Equal(t, 30000.0, amendedOrder.Price)
+	assert.Equal(t, 0.02, amendedOrder.Quantity)
+
+	if assert.Len(t, *requests, 1) {
+		req := (*requests)[0]
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/api/v3/order/cancelReplace", req.Path)
+		assert.Equal(t, "STOP_ON_FAILURE", req.Params.Get("cancelReplaceMode"))
+		assert.Equal(t, "100", req.Params.Get("cancelOrderId"))
+		assert.Equal(t, "30000", req.Params.Get("price"))
+		assert.Equal(t, "0.02", req.Params.Get("quantity"))
+		assert.Equal(t, "GTC", req.Params.Get("timeInForce"))
+	}
+}
+
+func TestExchange_AmendOrder_Failure(t *testing.T) {
+	server, e, _ := newMarginTestExchange(t, func(r marginRequest) (int, string) {
+		return http.StatusBadRequest, `{"code": -2022, "msg": "Order cancel-replace failed."}`
+	})
+	defer server.Close()
+
+	order := types.Order{
+		SubmitOrder: types.SubmitOrder{
+			Symbol:   "BTCUSDT",
+			Side:     types.SideTypeSell,
+			Type:     types.OrderTypeLimit,
+			Price:    31000.0,
+			Quantity: 0.01,
+		},
+		OrderID: 100,
+	}
+
+	_, err := e.AmendOrder(context.Background(), order, 32000.0, 0.01)
+	assert.Error(t, err)
+}
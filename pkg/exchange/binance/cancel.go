@@ -0,0 +1,93 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+var _ types.ExchangeCancelService = &Exchange{}
+
+// CancelAllOrders cancels the open orders of all symbols, binance only cancels the open orders by symbol,
+// so the open orders are queried first and canceled symbol by symbol.
+func (e *Exchange) CancelAllOrders(ctx context.Context) ([]types.Order, error) {
+	symbol := ""
+	if e.IsMargin && e.IsIsolatedMargin {
+		// the isolated margin open orders can only be queried by the isolated symbol
+		symbol = e.IsolatedMarginSymbol
+	}
+
+	openOrders, err := e.QueryOpenOrders(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []string
+	var ordersBySymbol = make(map[string][]types.Order)
+	for _, order := range openOrders {
+		if _, ok := ordersBySymbol[order.Symbol]; !ok {
+			symbols = append(symbols, order.Symbol)
+		}
+		ordersBySymbol[order.Symbol] = append(ordersBySymbol[order.Symbol], order)
+	}
+
+	var canceledOrders []types.Order
+	for _, symbol := range symbols {
+		if err := e.cancelOpenOrders(ctx, symbol); err != nil {
+			return canceledOrders, err
+		}
+
+		canceledOrders = append(canceledOrders, toCanceledOrders(ordersBySymbol[symbol])...)
+	}
+
+	return canceledOrders, nil
+}
+
+// CancelOrdersBySymbol cancels the open orders of the symbol,
+// the returned orders are the open orders queried before the cancellation.
+func (e *Exchange) CancelOrdersBySymbol(ctx context.Context, symbol string) ([]types.Order, error) {
+	openOrders, err := e.QueryOpenOrders(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(openOrders) == 0 {
+		return nil, nil
+	}
+
+	if err := e.cancelOpenOrders(ctx, symbol); err != nil {
+		return nil, err
+	}
+
+	return toCanceledOrders(openOrders), nil
+}
+
+func (e *Exchange) cancelOpenOrders(ctx context.Context, symbol string) error {
+	log.Infof("canceling open orders of %s", symbol)
+
+	if e.IsFutures {
+		return e.FuturesClient.NewCancelAllOpenOrdersService().Symbol(symbol).Do(ctx)
+	}
+
+	if e.IsMargin {
+		params := url.Values{}
+		params.Set("symbol", symbol)
+		if e.IsIsolatedMargin {
+			params.Set("isIsolated", "TRUE")
+		}
+
+		return e.doSignedRequest(ctx, http.MethodDelete, "/sapi/v1/margin/openOrders", params, nil)
+	}
+
+	_, err := e.Client.NewCancelOpenOrdersService().Symbol(symbol).Do(ctx)
+	return err
+}
+
+func toCanceledOrders(orders []types.Order) []types.Order {
+	for i := range orders {
+		orders[i].Status = types.OrderStatusCanceled
+	}
+	return orders
+}
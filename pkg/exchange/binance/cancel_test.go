@@ -0,0 +1,80 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+const (
+	testBTCOpenOrder = `{"symbol": "BTCUSDT", "orderId": 1, "clientOrderId": "a", "price": "30000", "origQty": "0.1", "executedQty": "0", "status": "NEW", "timeInForce": "GTC", "type": "LIMIT", "side": "BUY", "time": 1616000000000, "updateTime": 1616000000000, "isWorking": true}`
+	testETHOpenOrder = `{"symbol": "ETHUSDT", "orderId": 2, "clientOrderId": "b", "price": "2000", "origQty": "1", "executedQty": "0", "status": "NEW", "timeInForce": "GTC", "type": "LIMIT", "side": "SELL", "time": 1616000000000, "updateTime": 1616000000000, "isWorking": true}`
+)
+
+func TestExchange_CancelAllOrders(t *testing.T) {
+	server, e, requests := newMarginTestExchange(t, func(r marginRequest) (int, string) {
+		if r.Method == http.MethodGet {
+			return http.StatusOK, "[" + testBTCOpenOrder + "," + testETHOpenOrder + "]"
+		}
+
+		return http.StatusOK, `[]`
+	})
+	defer server.Close()
+
+	orders, err := e.CancelAllOrders(context.Background())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if assert.Len(t, orders, 2) {
+		assert.Equal(t, uint64(1), orders[0].OrderID)
+		assert.Equal(t, types.OrderStatusCanceled, orders[0].Status)
+		assert.Equal(t, uint64(2), orders[1].OrderID)
+	}
+
+	if assert.Len(t, *requests, 3) {
+		assert.Equal(t, "/api/v3/openOrders", (*requests)[0].Path)
+		assert.Equal(t, "", (*requests)[0].Params.Get("symbol"))
+
+		for i, symbol := range []string{"BTCUSDT", "ETHUSDT"} {
+			req := (*requests)[i+1]
+			assert.Equal(t, http.MethodDelete, req.Method)
+			assert.Equal(t, "/api/v3/openOrders", req.Path)
+			assert.Equal(t, symbol, req.Params.Get("symbol"))
+		}
+	}
+}
+
+func TestExchange_CancelOrdersBySymbol_Margin(t *testing.T) {
+	server, e, requests := newMarginTestExchange(t, func(r marginRequest) (int, string) {
+		if r.Method == http.MethodGet {
+			return http.StatusOK, "[" + testBTCOpenOrder + "]"
+		}
+
+		return http.StatusOK, `[]`
+	})
+	defer server.Close()
+
+	e.UseIsolatedMargin("BTCUSDT")
+
+	orders, err := e.CancelOrdersBySymbol(context.Background(), "BTCUSDT")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Len(t, orders, 1)
+
+	if assert.Len(t, *requests, 2) {
+		assert.Equal(t, "/sapi/v1/margin/openOrders", (*requests)[0].Path)
+
+		req := (*requests)[1]
+		assert.Equal(t, http.MethodDelete, req.Method)
+		assert.Equal(t, "/sapi/v1/margin/openOrders", req.Path)
+		assert.Equal(t, "BTCUSDT", req.Params.Get("symbol"))
+		assert.Equal(t, "TRUE", req.Params.Get("isIsolated"))
+	}
+}
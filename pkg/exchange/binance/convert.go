@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/futures"
 	"github.com/pkg/errors"
 
 	"github.com/c9s/bbgo/pkg/datatype"
@@ -284,3 +285,166 @@ func ConvertTrades(remoteTrades []*binance.TradeV3) (trades []types.Trade, err e
 
 	return trades, err
 }
+
+func toGlobalFuturesTicker(stats *futures.PriceChangeStats) types.Ticker {
+	return types.Ticker{
+		Volume: util.MustParseFloat(stats.Volume),
+		Last:   util.MustParseFloat(stats.LastPrice),
+		Open:   util.MustParseFloat(stats.OpenPrice),
+		High:   util.MustParseFloat(stats.HighPrice),
+		Low:    util.MustParseFloat(stats.LowPrice),
+		Time:   millisecondTime(stats.CloseTime),
+	}
+}
+
+func toLocalFuturesOrderType(orderType types.OrderType) (futures.OrderType, error) {
+	switch orderType {
+	case types.OrderTypeLimit, types.OrderTypeLimitMaker:
+		return futures.OrderTypeLimit, nil
+
+	case types.OrderTypeStopLimit:
+		return futures.OrderTypeStop, nil
+
+	case types.OrderTypeStopMarket:
+		return futures.OrderTypeStopMarket, nil
+
+	case types.OrderTypeMarket:
+		return futures.OrderTypeMarket, nil
+	}
+
+	return "", fmt.Errorf("futures order type %s not supported", orderType)
+}
+
+func toGlobalFuturesOrderType(orderType futures.OrderType, timeInForce futures.TimeInForceType) types.OrderType {
+	switch orderType {
+	case futures.OrderTypeLimit, futures.OrderTypeTakeProfit:
+		if timeInForce == futures.TimeInForceTypeGTX {
+			return types.OrderTypeLimitMaker
+		}
+
+		return types.OrderTypeLimit
+
+	case futures.OrderTypeMarket, futures.OrderTypeTakeProfitMarket, futures.OrderTypeTrailingStopMarket:
+		return types.OrderTypeMarket
+
+	case futures.OrderTypeStop:
+		return types.OrderTypeStopLimit
+
+	case futures.OrderTypeStopMarket:
+		return types.OrderTypeStopMarket
+
+	default:
+		log.Errorf("unsupported futures order type: %v", orderType)
+		return ""
+	}
+}
+
+func toGlobalFuturesOrders(futuresOrders []*futures.Order) (orders []types.Order, err error) {
+	for _, futuresOrder := range futuresOrders {
+		order, err := toGlobalFuturesOrder(futuresOrder)
+		if err != nil {
+			return orders, err
+		}
+
+		orders = append(orders, *order)
+	}
+
+	return orders, err
+}
+
+func toGlobalFuturesOrder(futuresOrder *futures.Order) (*types.Order, error) {
+	status := toGlobalOrderStatus(binance.OrderStatusType(futuresOrder.Status))
+	return &types.Order{
+		SubmitOrder: types.SubmitOrder{
+			ClientOrderID: futuresOrder.ClientOrderID,
+			Symbol:        futuresOrder.Symbol,
+			Side:          toGlobalSideType(binance.SideType(futuresOrder.Side)),
+			Type:          toGlobalFuturesOrderType(futuresOrder.Type, futuresOrder.TimeInForce),
+			Quantity:      util.MustParseFloat(futuresOrder.OrigQuantity),
+			Price:         util.MustParseFloat(futuresOrder.Price),
+			StopPrice:     util.MustParseFloat(futuresOrder.StopPrice),
+			TimeInForce:   string(futuresOrder.TimeInForce),
+			ReduceOnly:    futuresOrder.ReduceOnly,
+		},
+		Exchange:         types.ExchangeBinance.String(),
+		IsWorking:        status == types.OrderStatusNew || status == types.OrderStatusPartiallyFilled,
+		OrderID:          uint64(futuresOrder.OrderID),
+		Status:           status,
+		ExecutedQuantity: util.MustParseFloat(futuresOrder.ExecutedQuantity),
+		CreationTime:     datatype.Time(millisecondTime(futuresOrder.Time)),
+		UpdateTime:       datatype.Time(millisecondTime(futuresOrder.UpdateTime)),
+	}, nil
+}
+
+func toGlobalFuturesTrade(t futures.AccountTrade) (*types.Trade, error) {
+	price, err := strconv.ParseFloat(t.Price, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "price parse error, price: %+v", t.Price)
+	}
+
+	quantity, err := strconv.ParseFloat(t.Quantity, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "quantity parse error, quantity: %+v", t.Quantity)
+	}
+
+	var quoteQuantity = price * quantity
+	if len(t.QuoteQuantity) > 0 {
+		quoteQuantity, err = strconv.ParseFloat(t.QuoteQuantity, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "quote quantity parse error, quoteQuantity: %+v", t.QuoteQuantity)
+		}
+	}
+
+	fee, err := strconv.ParseFloat(t.Commission, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "commission parse error, commission: %+v", t.Commission)
+	}
+
+	return &types.Trade{
+		ID:            t.ID,
+		OrderID:       uint64(t.OrderID),
+		Price:         price,
+		Symbol:        t.Symbol,
+		Exchange:      "binance",
+		Quantity:      quantity,
+		QuoteQuantity: quoteQuantity,
+		Side:          toGlobalSideType(binance.SideType(t.Side)),
+		IsBuyer:       t.Buyer,
+		IsMaker:       t.Maker,
+		Fee:           fee,
+		FeeCurrency:   t.CommissionAsset,
+		Time:          datatype.Time(millisecondTime(t.Time)),
+	}, nil
+}
+
+func toGlobalFuturesPosition(risk futures.PositionRisk) (types.FuturesPosition, error) {
+	leverage, err := strconv.Atoi(risk.Leverage)
+	if err != nil {
+		return types.FuturesPosition{}, errors.Wrapf(err, "leverage parse error, leverage: %+v", risk.Leverage)
+	}
+
+	return types.FuturesPosition{
+		Symbol:           risk.Symbol,
+		Side:             types.PositionSide(risk.PositionSide),
+		Quantity:         fixedpoint.MustNewFromString(risk.PositionAmt),
+		EntryPrice:       fixedpoint.MustNewFromString(risk.EntryPrice),
+		MarkPrice:        fixedpoint.MustNewFromString(risk.MarkPrice),
+		LiquidationPrice: fixedpoint.MustNewFromString(risk.LiquidationPrice),
+		UnrealizedProfit: fixedpoint.MustNewFromString(risk.UnRealizedProfit),
+		Leverage:         leverage,
+		Isolated:         risk.MarginType == "isolated",
+	}, nil
+}
+
+func toGlobalFundingRate(rate futures.FundingRate) (types.FundingRate, error) {
+	fundingRate, err := fixedpoint.NewFromString(rate.FundingRate)
+	if err != nil {
+		return types.FundingRate{}, errors.Wrapf(err, "funding rate parse error, fundingRate: %+v", rate.FundingRate)
+	}
+
+	return types.FundingRate{
+		Symbol:      rate.Symbol,
+		FundingRate: fundingRate,
+		FundingTime: millisecondTime(rate.FundingTime),
+	}, nil
+}
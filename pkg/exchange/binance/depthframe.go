@@ -2,11 +2,15 @@ package binance
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/futures"
+
+	"github.com/c9s/bbgo/pkg/types"
 )
 
 //go:generate callbackgen -type DepthFrame
@@ -14,14 +18,18 @@ type DepthFrame struct {
 	client  *binance.Client
 	context context.Context
 
+	// futuresClient is used for fetching the depth snapshot of the futures market
+	futuresClient *futures.Client
+
 	mu            sync.Mutex
 	once          sync.Once
 	SnapshotDepth *DepthEvent
 	Symbol        string
 	BufEvents     []DepthEvent
 
-	readyCallbacks []func(snapshotDepth DepthEvent, bufEvents []DepthEvent)
-	pushCallbacks  []func(e DepthEvent)
+	readyCallbacks   []func(snapshotDepth DepthEvent, bufEvents []DepthEvent)
+	pushCallbacks    []func(e DepthEvent)
+	invalidCallbacks []func(err error)
 }
 
 func (f *DepthFrame) reset() {
@@ -137,8 +145,11 @@ func (f *DepthFrame) PushEvent(e DepthEvent) {
 				log.Warnf("event first update id %d > final update id + 1 (%d), resetting snapshot", e.FirstUpdateID, f.SnapshotDepth.FirstUpdateID+1)
 			}
 
+			err := fmt.Errorf("expected update id %d, got %d: %w", f.SnapshotDepth.FinalUpdateID+1, e.FirstUpdateID, types.ErrBookSequenceGap)
 			f.SnapshotDepth = nil
 			f.mu.Unlock()
+
+			f.EmitInvalid(err)
 			return
 		}
 
@@ -156,12 +167,40 @@ func (f *DepthFrame) fetch(ctx context.Context) (*DepthEvent, error) {
 		log.Infof("fetching %s depth snapshot", f.Symbol)
 	}
 
+	if f.futuresClient != nil {
+		return f.fetchFutures(ctx)
+	}
+
 	response, err := f.client.NewDepthService().Symbol(f.Symbol).Do(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	event := DepthEvent{
+		Symbol:        f.Symbol,
+		FirstUpdateID: 0,
+		FinalUpdateID: response.LastUpdateID,
+	}
+
+	for _, entry := range response.Bids {
+		event.Bids = append(event.Bids, DepthEntry{PriceLevel: entry.Price, Quantity: entry.Quantity})
+	}
+
+	for _, entry := range response.Asks {
+		event.Asks = append(event.Asks, DepthEntry{PriceLevel: entry.Price, Quantity: entry.Quantity})
+	}
+
+	return &event, nil
+}
+
+func (f *DepthFrame) fetchFutures(ctx context.Context) (*DepthEvent, error) {
+	response, err := f.futuresClient.NewDepthService().Symbol(f.Symbol).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	event := DepthEvent{
+		Symbol:        f.Symbol,
 		FirstUpdateID: 0,
 		FinalUpdateID: response.LastUpdateID,
 	}
@@ -23,3 +23,13 @@ func (f *DepthFrame) EmitPush(e DepthEvent) {
 		cb(e)
 	}
 }
+
+func (f *DepthFrame) OnInvalid(cb func(err error)) {
+	f.invalidCallbacks = append(f.invalidCallbacks, cb)
+}
+
+func (f *DepthFrame) EmitInvalid(err error) {
+	for _, cb := range f.invalidCallbacks {
+		cb(err)
+	}
+}
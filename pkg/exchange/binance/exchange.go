@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/futures"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
@@ -27,6 +28,9 @@ var log = logrus.WithFields(logrus.Fields{
 func init() {
 	_ = types.Exchange(&Exchange{})
 	_ = types.MarginExchange(&Exchange{})
+	_ = types.MarginBorrowRepayService(&Exchange{})
+	_ = types.FuturesExchange(&Exchange{})
+	_ = types.ExchangePositionService(&Exchange{})
 
 	if ok, _ := strconv.ParseBool(os.Getenv("DEBUG_BINANCE_STREAM")); ok {
 		log.Level = logrus.DebugLevel
@@ -35,14 +39,57 @@ func init() {
 
 type Exchange struct {
 	types.MarginSettings
+	types.FuturesSettings
 
 	Client *binance.Client
+
+	// FuturesClient is the USDT-M futures client, it's used when the futures mode is enabled
+	FuturesClient *futures.Client
+
+	endpoints Endpoints
+}
+
+// Endpoints overrides the api endpoints of the exchange, e.g., for the fake exchange server,
+// the production endpoints are used for the empty fields.
+type Endpoints struct {
+	RestURL             string
+	WebSocketURL        string
+	FuturesRestURL      string
+	FuturesWebSocketURL string
 }
 
 func New(key, secret string) *Exchange {
+	return NewWithEndpoints(key, secret, Endpoints{})
+}
+
+func NewWithEndpoints(key, secret string, endpoints Endpoints) *Exchange {
+	if len(endpoints.WebSocketURL) == 0 {
+		endpoints.WebSocketURL = WebSocketURL
+	}
+
+	if len(endpoints.FuturesWebSocketURL) == 0 {
+		endpoints.FuturesWebSocketURL = FuturesWebSocketURL
+	}
+
 	var client = binance.NewClient(key, secret)
+	if len(endpoints.RestURL) > 0 {
+		client.BaseURL = endpoints.RestURL
+	}
+
+	// all the requests go through the shared request weight budget
+	client.HTTPClient = spotLimiter.HTTPClient(client.HTTPClient)
+
+	var futuresClient = binance.NewFuturesClient(key, secret)
+	if len(endpoints.FuturesRestURL) > 0 {
+		futuresClient.BaseURL = endpoints.FuturesRestURL
+	}
+
+	futuresClient.HTTPClient = futuresLimiter.HTTPClient(futuresClient.HTTPClient)
+
 	return &Exchange{
-		Client: client,
+		Client:        client,
+		FuturesClient: futuresClient,
+		endpoints:     endpoints,
 	}
 }
 
@@ -51,6 +98,20 @@ func (e *Exchange) Name() types.ExchangeName {
 }
 
 func (e *Exchange) QueryTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
+	if e.IsFutures {
+		tickers, err := e.queryFuturesTickers(ctx, strings.ToUpper(symbol))
+		if err != nil {
+			return nil, err
+		}
+
+		ticker, ok := tickers[strings.ToUpper(symbol)]
+		if !ok {
+			return nil, fmt.Errorf("futures ticker %s not found", symbol)
+		}
+
+		return &ticker, nil
+	}
+
 	req := e.Client.NewListPriceChangeStatsService()
 	req.Symbol(strings.ToUpper(symbol))
 	stats, err := req.Do(ctx)
@@ -75,6 +136,25 @@ func (e *Exchange) QueryTickers(ctx context.Context, symbol ...string) (map[stri
 		return tickers, nil
 	}
 
+	if e.IsFutures {
+		futuresTickers, err := e.queryFuturesTickers(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range symbol {
+			if ticker, ok := futuresTickers[s]; ok {
+				tickers[s] = ticker
+			}
+		}
+
+		if len(symbol) == 0 {
+			tickers = futuresTickers
+		}
+
+		return tickers, nil
+	}
+
 	var req = e.Client.NewListPriceChangeStatsService()
 	changeStats, err := req.Do(ctx)
 	if err != nil {
@@ -113,6 +193,10 @@ func (e *Exchange) QueryTickers(ctx context.Context, symbol ...string) (map[stri
 func (e *Exchange) QueryMarkets(ctx context.Context) (types.MarketMap, error) {
 	log.Info("querying market info...")
 
+	if e.IsFutures {
+		return e.queryFuturesMarkets(ctx)
+	}
+
 	exchangeInfo, err := e.Client.NewExchangeInfoService().Do(ctx)
 	if err != nil {
 		return nil, err
@@ -157,6 +241,16 @@ func (e *Exchange) QueryMarkets(ctx context.Context) (types.MarketMap, error) {
 }
 
 func (e *Exchange) QueryAveragePrice(ctx context.Context, symbol string) (float64, error) {
+	if e.IsFutures {
+		// the futures api doesn't provide the average price, use the mark price instead
+		resp, err := e.FuturesClient.NewPremiumIndexService().Symbol(symbol).Do(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		return util.MustParseFloat(resp.MarkPrice), nil
+	}
+
 	resp, err := e.Client.NewAveragePriceService().Symbol(symbol).Do(ctx)
 	if err != nil {
 		return 0, err
@@ -166,8 +260,11 @@ func (e *Exchange) QueryAveragePrice(ctx context.Context, symbol string) (float6
 }
 
 func (e *Exchange) NewStream() types.Stream {
-	stream := NewStream(e.Client)
+	stream := NewStream(e.Client, e.endpoints.WebSocketURL, e.endpoints.FuturesWebSocketURL)
 	stream.MarginSettings = e.MarginSettings
+	stream.FuturesSettings = e.FuturesSettings
+	stream.FuturesClient = e.FuturesClient
+	stream.stateSyncer = types.NewStreamStateSyncer(e, &stream.StandardStream)
 	return stream
 }
 
@@ -363,6 +460,10 @@ func (e *Exchange) PlatformFeeCurrency() string {
 }
 
 func (e *Exchange) QueryAccount(ctx context.Context) (*types.Account, error) {
+	if e.IsFutures {
+		return e.queryFuturesAccount(ctx)
+	}
+
 	account, err := e.Client.NewGetAccountService().Do(ctx)
 	if err != nil {
 		return nil, err
@@ -387,6 +488,10 @@ func (e *Exchange) QueryAccount(ctx context.Context) (*types.Account, error) {
 }
 
 func (e *Exchange) QueryOpenOrders(ctx context.Context, symbol string) (orders []types.Order, err error) {
+	if e.IsFutures {
+		return e.queryFuturesOpenOrders(ctx, symbol)
+	}
+
 	if e.IsMargin {
 		req := e.Client.NewListMarginOpenOrdersService().Symbol(symbol)
 		req.IsIsolated(e.IsIsolatedMargin)
@@ -414,6 +519,10 @@ func (e *Exchange) QueryClosedOrders(ctx context.Context, symbol string, since,
 
 	log.Infof("querying closed orders %s from %s <=> %s ...", symbol, since, until)
 
+	if e.IsFutures {
+		return e.queryFuturesClosedOrders(ctx, symbol, since, until, lastOrderID)
+	}
+
 	if e.IsMargin {
 		req := e.Client.NewListMarginOrdersService().Symbol(symbol)
 		req.IsIsolated(e.IsIsolatedMargin)
@@ -452,6 +561,10 @@ func (e *Exchange) QueryClosedOrders(ctx context.Context, symbol string, since,
 }
 
 func (e *Exchange) CancelOrders(ctx context.Context, orders ...types.Order) (err2 error) {
+	if e.IsFutures {
+		return e.cancelFuturesOrders(ctx, orders...)
+	}
+
 	for _, o := range orders {
 		var req = e.Client.NewCancelOrderService()
 
@@ -623,7 +736,12 @@ func (e *Exchange) submitSpotOrder(ctx context.Context, order types.SubmitOrder)
 
 	log.Infof("order creation response: %+v", response)
 
-	createdOrder, err := ToGlobalOrder(&binance.Order{
+	return toGlobalCreatedOrder(response)
+}
+
+// toGlobalCreatedOrder converts the order creation response of the spot api to the global order
+func toGlobalCreatedOrder(response *binance.CreateOrderResponse) (*types.Order, error) {
+	return ToGlobalOrder(&binance.Order{
 		Symbol:                   response.Symbol,
 		OrderID:                  response.OrderID,
 		ClientOrderID:            response.ClientOrderID,
@@ -643,15 +761,15 @@ func (e *Exchange) submitSpotOrder(ctx context.Context, order types.SubmitOrder)
 		// UpdateTime:
 		// IsWorking:               ,
 	}, false)
-
-	return createdOrder, err
 }
 
 func (e *Exchange) SubmitOrders(ctx context.Context, orders ...types.SubmitOrder) (createdOrders types.OrderSlice, err error) {
 	for _, order := range orders {
 		var createdOrder *types.Order
 
-		if e.IsMargin {
+		if e.IsFutures {
+			createdOrder, err = e.submitFuturesOrder(ctx, order)
+		} else if e.IsMargin {
 			createdOrder, err = e.submitMarginOrder(ctx, order)
 		} else {
 			createdOrder, err = e.submitSpotOrder(ctx, order)
@@ -682,6 +800,10 @@ func (e *Exchange) QueryKLines(ctx context.Context, symbol string, interval type
 
 	log.Infof("querying kline %s %s %v", symbol, interval, options)
 
+	if e.IsFutures {
+		return e.queryFuturesKLines(ctx, symbol, interval, limit, options)
+	}
+
 	req := e.Client.NewKlinesService().
 		Symbol(symbol).
 		Interval(string(interval)).
@@ -723,6 +845,10 @@ func (e *Exchange) QueryKLines(ctx context.Context, symbol string, interval type
 }
 
 func (e *Exchange) QueryTrades(ctx context.Context, symbol string, options *types.TradeQueryOptions) (trades []types.Trade, err error) {
+	if e.IsFutures {
+		return e.queryFuturesTrades(ctx, symbol, options)
+	}
+
 	var remoteTrades []*binance.TradeV3
 
 	if e.IsMargin {
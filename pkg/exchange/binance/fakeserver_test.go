@@ -0,0 +1,205 @@
+package binance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/exchange/fakeserver"
+	"github.com/c9s/bbgo/pkg/exchange/paper"
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+var fakeServerMarkets = types.MarketMap{
+	"BTCUSDT": {
+		Symbol:          "BTCUSDT",
+		BaseCurrency:    "BTC",
+		QuoteCurrency:   "USDT",
+		PricePrecision:  2,
+		VolumePrecision: 6,
+		MinQuantity:     0.0001,
+		MaxQuantity:     1000.0,
+		MinNotional:     10.0,
+		MinPrice:        0.01,
+		MaxPrice:        1000000.0,
+		TickSize:        0.01,
+		StepSize:        0.000001,
+	},
+}
+
+func newFakeServerExchange(t *testing.T, secret string) (*fakeserver.Server, *Exchange) {
+	server := fakeserver.NewBinanceServer(fakeserver.Config{
+		APIKey:    "fake_key",
+		APISecret: "fake_secret",
+		Markets:   fakeServerMarkets,
+		Account: paper.AccountConfig{
+			Balances: map[string]fixedpoint.Value{
+				"BTC":  fixedpoint.NewFromFloat(1.0),
+				"USDT": fixedpoint.NewFromFloat(10000.0),
+			},
+			MakerFeeRate: fixedpoint.NewFromFloat(0.001),
+			TakerFeeRate: fixedpoint.NewFromFloat(0.001),
+		},
+	})
+	server.SetPrice("BTCUSDT", 10000.0)
+
+	return server, NewWithEndpoints("fake_key", secret, Endpoints{
+		RestURL:      server.BaseURL(),
+		WebSocketURL: server.WebSocketURL(),
+	})
+}
+
+func TestFakeServer_OrderFlow(t *testing.T) {
+	server, ex := newFakeServerExchange(t, "fake_secret")
+	defer server.Close()
+
+	ctx := context.Background()
+
+	markets, err := ex.QueryMarkets(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, fakeServerMarkets["BTCUSDT"].MinNotional, markets["BTCUSDT"].MinNotional)
+	assert.Equal(t, fakeServerMarkets["BTCUSDT"].TickSize, markets["BTCUSDT"].TickSize)
+
+	account, err := ex.QueryAccount(ctx)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.001, account.MakerCommission.Float64(), 1e-9)
+
+	createdOrders, err := ex.SubmitOrders(ctx, types.SubmitOrder{
+		Symbol:      "BTCUSDT",
+		Side:        types.SideTypeSell,
+		Type:        types.OrderTypeLimit,
+		Quantity:    0.5,
+		Price:       11000.0,
+		TimeInForce: "GTC",
+		Market:      markets["BTCUSDT"],
+	})
+	assert.NoError(t, err)
+	if !assert.Len(t, createdOrders, 1) {
+		return
+	}
+	assert.Equal(t, types.OrderStatusNew, createdOrders[0].Status)
+
+	openOrders, err := ex.QueryOpenOrders(ctx, "BTCUSDT")
+	assert.NoError(t, err)
+	assert.Len(t, openOrders, 1)
+
+	// the limit order is matched when the price goes above the order price
+	server.SetPrice("BTCUSDT", 11200.0)
+
+	openOrders, err = ex.QueryOpenOrders(ctx, "BTCUSDT")
+	assert.NoError(t, err)
+	assert.Len(t, openOrders, 0)
+
+	closedOrders, err := ex.QueryClosedOrders(ctx, "BTCUSDT", time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 0)
+	assert.NoError(t, err)
+	if assert.Len(t, closedOrders, 1) {
+		assert.Equal(t, types.OrderStatusFilled, closedOrders[0].Status)
+	}
+
+	trades, err := ex.QueryTrades(ctx, "BTCUSDT", &types.TradeQueryOptions{Limit: 100})
+	assert.NoError(t, err)
+	if assert.Len(t, trades, 1) {
+		assert.Equal(t, 11000.0, trades[0].Price)
+		assert.Equal(t, createdOrders[0].OrderID, trades[0].OrderID)
+	}
+
+	balances, err := ex.QueryAccountBalances(ctx)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.5, balances["BTC"].Available.Float64(), 1e-8)
+}
+
+func TestFakeServer_CancelOrders(t *testing.T) {
+	server, ex := newFakeServerExchange(t, "fake_secret")
+	defer server.Close()
+
+	ctx := context.Background()
+	createdOrders, err := ex.SubmitOrders(ctx, types.SubmitOrder{
+		Symbol:      "BTCUSDT",
+		Side:        types.SideTypeBuy,
+		Type:        types.OrderTypeLimit,
+		Quantity:    0.1,
+		Price:       9000.0,
+		TimeInForce: "GTC",
+		Market:      fakeServerMarkets["BTCUSDT"],
+	})
+	assert.NoError(t, err)
+	assert.Len(t, createdOrders, 1)
+
+	assert.NoError(t, ex.CancelOrders(ctx, createdOrders...))
+
+	// the canceled order can not be canceled again
+	assert.Error(t, ex.CancelOrders(ctx, createdOrders...))
+}
+
+func TestFakeServer_InvalidSignature(t *testing.T) {
+	server, ex := newFakeServerExchange(t, "wrong_secret")
+	defer server.Close()
+
+	_, err := ex.QueryAccount(context.Background())
+	assert.Error(t, err)
+}
+
+func TestFakeServer_UserDataStream(t *testing.T) {
+	server, ex := newFakeServerExchange(t, "fake_secret")
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	orderC := make(chan types.Order, 10)
+	tradeC := make(chan types.Trade, 10)
+	balanceC := make(chan types.BalanceMap, 10)
+
+	stream := ex.NewStream()
+	stream.OnOrderUpdate(func(order types.Order) { orderC <- order })
+	stream.OnTradeUpdate(func(trade types.Trade) { tradeC <- trade })
+	stream.OnBalanceSnapshot(func(balances types.BalanceMap) { balanceC <- balances })
+	assert.NoError(t, stream.Connect(ctx))
+
+	defer func() {
+		cancel()
+		_ = stream.Close()
+	}()
+
+	createdOrders, err := ex.SubmitOrders(ctx, types.SubmitOrder{
+		Symbol:      "BTCUSDT",
+		Side:        types.SideTypeBuy,
+		Type:        types.OrderTypeLimit,
+		Quantity:    0.1,
+		Price:       9000.0,
+		TimeInForce: "GTC",
+		Market:      fakeServerMarkets["BTCUSDT"],
+	})
+	assert.NoError(t, err)
+	assert.Len(t, createdOrders, 1)
+
+	select {
+	case order := <-orderC:
+		assert.Equal(t, createdOrders[0].OrderID, order.OrderID)
+		assert.Equal(t, types.OrderStatusNew, order.Status)
+	case <-time.After(3 * time.Second):
+		t.Fatal("order update is not received")
+	}
+
+	select {
+	case balances := <-balanceC:
+		assert.InDelta(t, 900.0, balances["USDT"].Locked.Float64(), 1e-8)
+	case <-time.After(3 * time.Second):
+		t.Fatal("balance snapshot is not received")
+	}
+
+	server.SetPrice("BTCUSDT", 8900.0)
+
+	select {
+	case trade := <-tradeC:
+		assert.Equal(t, createdOrders[0].OrderID, trade.OrderID)
+		assert.Equal(t, 9000.0, trade.Price)
+		assert.Equal(t, 0.1, trade.Quantity)
+		assert.True(t, trade.IsMaker)
+	case <-time.After(3 * time.Second):
+		t.Fatal("trade update is not received")
+	}
+}
@@ -0,0 +1,459 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+	"github.com/c9s/bbgo/pkg/util"
+)
+
+var errFuturesModeRequired = errors.New("the futures mode of the binance exchange is not enabled")
+
+func (e *Exchange) queryFuturesMarkets(ctx context.Context) (types.MarketMap, error) {
+	exchangeInfo, err := e.FuturesClient.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	markets := types.MarketMap{}
+	for _, symbol := range exchangeInfo.Symbols {
+		market := types.Market{
+			Symbol:          symbol.Symbol,
+			PricePrecision:  symbol.PricePrecision,
+			VolumePrecision: symbol.QuantityPrecision,
+			QuoteCurrency:   symbol.QuoteAsset,
+			BaseCurrency:    symbol.BaseAsset,
+		}
+
+		// the MIN_NOTIONAL filter of the futures markets uses the "notional" field
+		for _, filter := range symbol.Filters {
+			if filter["filterType"] == "MIN_NOTIONAL" {
+				if notional, ok := filter["notional"].(string); ok {
+					market.MinNotional = util.MustParseFloat(notional)
+					market.MinAmount = market.MinNotional
+				}
+			}
+		}
+
+		if f := symbol.LotSizeFilter(); f != nil {
+			market.MinQuantity = util.MustParseFloat(f.MinQuantity)
+			market.MaxQuantity = util.MustParseFloat(f.MaxQuantity)
+			market.StepSize = util.MustParseFloat(f.StepSize)
+		}
+
+		if f := symbol.PriceFilter(); f != nil {
+			market.MaxPrice = util.MustParseFloat(f.MaxPrice)
+			market.MinPrice = util.MustParseFloat(f.MinPrice)
+			market.TickSize = util.MustParseFloat(f.TickSize)
+		}
+
+		markets[symbol.Symbol] = market
+	}
+
+	return markets, nil
+}
+
+// queryFuturesTickers queries the 24hr price change statistics and the best bid/ask prices of the futures markets,
+// all the markets are queried if the symbol is empty.
+func (e *Exchange) queryFuturesTickers(ctx context.Context, symbol string) (map[string]types.Ticker, error) {
+	statsReq := e.FuturesClient.NewListPriceChangeStatsService()
+	bookReq := e.FuturesClient.NewListBookTickersService()
+	if len(symbol) > 0 {
+		statsReq.Symbol(symbol)
+		bookReq.Symbol(symbol)
+	}
+
+	changeStats, err := statsReq.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bookTickers, err := bookReq.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var tickers = make(map[string]types.Ticker)
+	for _, stats := range changeStats {
+		tickers[stats.Symbol] = toGlobalFuturesTicker(stats)
+	}
+
+	for _, bookTicker := range bookTickers {
+		ticker, ok := tickers[bookTicker.Symbol]
+		if !ok {
+			continue
+		}
+
+		ticker.Buy = util.MustParseFloat(bookTicker.BidPrice)
+		ticker.Sell = util.MustParseFloat(bookTicker.AskPrice)
+		tickers[bookTicker.Symbol] = ticker
+	}
+
+	return tickers, nil
+}
+
+func (e *Exchange) queryFuturesKLines(ctx context.Context, symbol string, interval types.Interval, limit int, options types.KLineQueryOptions) ([]types.KLine, error) {
+	req := e.FuturesClient.NewKlinesService().
+		Symbol(symbol).
+		Interval(string(interval)).
+		Limit(limit)
+
+	if options.StartTime != nil {
+		req.StartTime(options.StartTime.UnixNano() / int64(time.Millisecond))
+	}
+
+	if options.EndTime != nil {
+		req.EndTime(options.EndTime.UnixNano() / int64(time.Millisecond))
+	}
+
+	resp, err := req.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var kLines []types.KLine
+	for _, k := range resp {
+		kLines = append(kLines, types.KLine{
+			Exchange:       "binance",
+			Symbol:         symbol,
+			Interval:       interval,
+			StartTime:      millisecondTime(k.OpenTime),
+			EndTime:        millisecondTime(k.CloseTime),
+			Open:           util.MustParseFloat(k.Open),
+			Close:          util.MustParseFloat(k.Close),
+			High:           util.MustParseFloat(k.High),
+			Low:            util.MustParseFloat(k.Low),
+			Volume:         util.MustParseFloat(k.Volume),
+			QuoteVolume:    util.MustParseFloat(k.QuoteAssetVolume),
+			NumberOfTrades: uint64(k.TradeNum),
+			Closed:         true,
+		})
+	}
+
+	return kLines, nil
+}
+
+// queryFuturesAccount queries the futures account,
+// the available balance is the max withdraw amount and the rest of the wallet balance is used by the positions and the open orders.
+func (e *Exchange) queryFuturesAccount(ctx context.Context) (*types.Account, error) {
+	account, err := e.FuturesClient.NewGetAccountService().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the futures account doesn't return the commission rates
+	a := &types.Account{}
+	a.UpdateBalances(toGlobalFuturesBalances(account.Assets))
+	return a, nil
+}
+
+// toGlobalFuturesBalances converts the futures account assets to the balances,
+// the max withdraw amount is available and the rest of the wallet balance is locked as the margin.
+func toGlobalFuturesBalances(assets []*futures.AccountAsset) types.BalanceMap {
+	var balances = types.BalanceMap{}
+	for _, asset := range assets {
+		walletBalance := fixedpoint.Must(fixedpoint.NewFromString(asset.WalletBalance))
+		available := fixedpoint.Must(fixedpoint.NewFromString(asset.MaxWithdrawAmount))
+
+		locked := walletBalance.Sub(available)
+		if locked < 0 {
+			locked = 0
+		}
+
+		balances[asset.Asset] = types.Balance{
+			Currency:  asset.Asset,
+			Available: available,
+			Locked:    locked,
+		}
+	}
+
+	return balances
+}
+
+func (e *Exchange) queryFuturesOpenOrders(ctx context.Context, symbol string) (orders []types.Order, err error) {
+	futuresOrders, err := e.FuturesClient.NewListOpenOrdersService().Symbol(symbol).Do(ctx)
+	if err != nil {
+		return orders, err
+	}
+
+	return toGlobalFuturesOrders(futuresOrders)
+}
+
+func (e *Exchange) queryFuturesClosedOrders(ctx context.Context, symbol string, since, until time.Time, lastOrderID uint64) (orders []types.Order, err error) {
+	req := e.FuturesClient.NewListOrdersService().Symbol(symbol)
+
+	if lastOrderID > 0 {
+		req.OrderID(int64(lastOrderID))
+	} else {
+		req.StartTime(since.UnixNano() / int64(time.Millisecond)).
+			EndTime(until.UnixNano() / int64(time.Millisecond))
+	}
+
+	futuresOrders, err := req.Do(ctx)
+	if err != nil {
+		return orders, err
+	}
+
+	return toGlobalFuturesOrders(futuresOrders)
+}
+
+func (e *Exchange) cancelFuturesOrders(ctx context.Context, orders ...types.Order) (err2 error) {
+	for _, o := range orders {
+		var req = e.FuturesClient.NewCancelOrderService()
+
+		// Mandatory
+		req.Symbol(o.Symbol)
+
+		if o.OrderID > 0 {
+			req.OrderID(int64(o.OrderID))
+		} else if len(o.ClientOrderID) > 0 {
+			req.OrigClientOrderID(o.ClientOrderID)
+		}
+
+		_, err := req.Do(ctx)
+		if err != nil {
+			log.WithError(err).Errorf("futures order cancel error")
+			err2 = err
+		}
+	}
+
+	return err2
+}
+
+func (e *Exchange) submitFuturesOrder(ctx context.Context, order types.SubmitOrder) (*types.Order, error) {
+	orderType, err := toLocalFuturesOrderType(order.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	clientOrderID := uuid.New().String()
+	if len(order.ClientOrderID) > 0 {
+		clientOrderID = order.ClientOrderID
+	}
+
+	req := e.FuturesClient.NewCreateOrderService().
+		Symbol(order.Symbol).
+		Side(futures.SideType(order.Side)).
+		NewClientOrderID(clientOrderID).
+		Type(orderType)
+
+	if len(order.QuantityString) > 0 {
+		req.Quantity(order.QuantityString)
+	} else if order.Market.Symbol != "" {
+		req.Quantity(order.Market.FormatQuantity(order.Quantity))
+	} else {
+		req.Quantity(strconv.FormatFloat(order.Quantity, 'f', 8, 64))
+	}
+
+	// set price field for limit orders
+	switch order.Type {
+	case types.OrderTypeStopLimit, types.OrderTypeLimit, types.OrderTypeLimitMaker:
+		if len(order.PriceString) > 0 {
+			req.Price(order.PriceString)
+		} else if order.Market.Symbol != "" {
+			req.Price(order.Market.FormatPrice(order.Price))
+		} else {
+			req.Price(strconv.FormatFloat(order.Price, 'f', 8, 64))
+		}
+	}
+
+	switch order.Type {
+	case types.OrderTypeStopLimit, types.OrderTypeStopMarket:
+		if len(order.StopPriceString) > 0 {
+			req.StopPrice(order.StopPriceString)
+		} else if order.Market.Symbol != "" {
+			req.StopPrice(order.Market.FormatPrice(order.StopPrice))
+		} else {
+			req.StopPrice(strconv.FormatFloat(order.StopPrice, 'f', 8, 64))
+		}
+	}
+
+	// the futures limit orders require the time in force, and the limit maker order is a GTX (post only) limit order
+	switch order.Type {
+	case types.OrderTypeLimitMaker:
+		req.TimeInForce(futures.TimeInForceTypeGTX)
+
+	case types.OrderTypeLimit, types.OrderTypeStopLimit:
+		if len(order.TimeInForce) > 0 {
+			req.TimeInForce(futures.TimeInForceType(order.TimeInForce))
+		} else {
+			req.TimeInForce(futures.TimeInForceTypeGTC)
+		}
+	}
+
+	if order.ReduceOnly {
+		req.ReduceOnly(true)
+	}
+
+	response, err := req.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("futures order creation response: %+v", response)
+
+	return toGlobalFuturesOrder(&futures.Order{
+		Symbol:           response.Symbol,
+		OrderID:          response.OrderID,
+		ClientOrderID:    response.ClientOrderID,
+		Price:            response.Price,
+		ReduceOnly:       response.ReduceOnly,
+		OrigQuantity:     response.OrigQuantity,
+		ExecutedQuantity: response.ExecutedQuantity,
+		CumQuote:         response.CumQuote,
+		Status:           response.Status,
+		TimeInForce:      response.TimeInForce,
+		Type:             response.Type,
+		Side:             response.Side,
+		StopPrice:        response.StopPrice,
+		Time:             response.UpdateTime,
+		UpdateTime:       response.UpdateTime,
+		AvgPrice:         response.AvgPrice,
+		PositionSide:     response.PositionSide,
+	})
+}
+
+func (e *Exchange) queryFuturesTrades(ctx context.Context, symbol string, options *types.TradeQueryOptions) (trades []types.Trade, err error) {
+	req := e.FuturesClient.NewListAccountTradeService().
+		Symbol(symbol)
+
+	if options.Limit > 0 {
+		req.Limit(int(options.Limit))
+	} else {
+		req.Limit(1000)
+	}
+
+	if options.StartTime != nil {
+		req.StartTime(options.StartTime.UnixNano() / int64(time.Millisecond))
+	}
+
+	if options.EndTime != nil {
+		req.EndTime(options.EndTime.UnixNano() / int64(time.Millisecond))
+	}
+
+	// BINANCE uses inclusive last trade ID
+	if options.LastTradeID > 0 {
+		req.FromID(options.LastTradeID)
+	}
+
+	remoteTrades, err := req.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range remoteTrades {
+		localTrade, err := toGlobalFuturesTrade(*t)
+		if err != nil {
+			log.WithError(err).Errorf("can not convert binance futures trade: %+v", t)
+			continue
+		}
+
+		trades = append(trades, *localTrade)
+	}
+
+	return trades, nil
+}
+
+// QueryPositions queries the futures positions of the given symbols, the closed positions are skipped if no symbol is given
+func (e *Exchange) QueryPositions(ctx context.Context, symbols ...string) ([]types.FuturesPosition, error) {
+	if !e.IsFutures {
+		return nil, errFuturesModeRequired
+	}
+
+	risks, err := e.FuturesClient.NewGetPositionRiskService().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbolSet = make(map[string]struct{})
+	for _, symbol := range symbols {
+		symbolSet[symbol] = struct{}{}
+	}
+
+	var positions []types.FuturesPosition
+	for _, risk := range risks {
+		position, err := toGlobalFuturesPosition(*risk)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(symbolSet) > 0 {
+			if _, ok := symbolSet[position.Symbol]; !ok {
+				continue
+			}
+		} else if position.IsClosed() {
+			continue
+		}
+
+		positions = append(positions, position)
+	}
+
+	return positions, nil
+}
+
+// SetLeverage changes the initial leverage of the futures symbol
+func (e *Exchange) SetLeverage(ctx context.Context, symbol string, leverage int) error {
+	if !e.IsFutures {
+		return errFuturesModeRequired
+	}
+
+	if leverage <= 0 {
+		return fmt.Errorf("invalid leverage %d", leverage)
+	}
+
+	resp, err := e.FuturesClient.NewChangeLeverageService().
+		Symbol(symbol).
+		Leverage(leverage).
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("%s leverage is changed to %d", resp.Symbol, resp.Leverage)
+	return nil
+}
+
+// QueryFundingRateHistory queries the funding rates of the futures symbol between since and until
+func (e *Exchange) QueryFundingRateHistory(ctx context.Context, symbol string, since, until time.Time) (fundingRates []types.FundingRate, err error) {
+	if !e.IsFutures {
+		return nil, errFuturesModeRequired
+	}
+
+	const limit = 1000
+
+	for since.Before(until) {
+		rates, err := e.FuturesClient.NewFundingRateService().
+			Symbol(symbol).
+			StartTime(since.UnixNano() / int64(time.Millisecond)).
+			EndTime(until.UnixNano() / int64(time.Millisecond)).
+			Limit(limit).
+			Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rate := range rates {
+			fundingRate, err := toGlobalFundingRate(*rate)
+			if err != nil {
+				return nil, err
+			}
+
+			fundingRates = append(fundingRates, fundingRate)
+			since = fundingRate.FundingTime.Add(time.Millisecond)
+		}
+
+		if len(rates) < limit {
+			break
+		}
+	}
+
+	return fundingRates, nil
+}
@@ -0,0 +1,67 @@
+package binance
+
+import (
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func TestFuturesOrderTypeConversion(t *testing.T) {
+	for _, orderType := range []types.OrderType{
+		types.OrderTypeLimit,
+		types.OrderTypeMarket,
+		types.OrderTypeStopLimit,
+		types.OrderTypeStopMarket,
+	} {
+		localType, err := toLocalFuturesOrderType(orderType)
+		assert.NoError(t, err)
+		assert.Equal(t, orderType, toGlobalFuturesOrderType(localType, futures.TimeInForceTypeGTC))
+	}
+
+	localType, err := toLocalFuturesOrderType(types.OrderTypeLimitMaker)
+	assert.NoError(t, err)
+	assert.Equal(t, futures.OrderTypeLimit, localType)
+	assert.Equal(t, types.OrderTypeLimitMaker, toGlobalFuturesOrderType(localType, futures.TimeInForceTypeGTX))
+}
+
+func TestToGlobalFuturesPosition(t *testing.T) {
+	position, err := toGlobalFuturesPosition(futures.PositionRisk{
+		EntryPrice:       "50000.0",
+		MarginType:       "cross",
+		IsAutoAddMargin:  "false",
+		IsolatedMargin:   "0.00000000",
+		Leverage:         "10",
+		LiquidationPrice: "45000.5",
+		MarkPrice:        "51000.0",
+		MaxNotionalValue: "250000",
+		PositionAmt:      "0.010",
+		Symbol:           "BTCUSDT",
+		UnRealizedProfit: "10.00000000",
+		PositionSide:     "BOTH",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "BTCUSDT", position.Symbol)
+	assert.Equal(t, types.PositionSideBoth, position.Side)
+	assert.True(t, position.IsLong())
+	assert.Equal(t, fixedpoint.MustNewFromString("0.01"), position.Quantity)
+	assert.Equal(t, fixedpoint.MustNewFromString("45000.5"), position.LiquidationPrice)
+	assert.Equal(t, 10, position.Leverage)
+	assert.False(t, position.Isolated)
+}
+
+func TestToGlobalFuturesBalances(t *testing.T) {
+	balances := toGlobalFuturesBalances([]*futures.AccountAsset{
+		{Asset: "USDT", WalletBalance: "1000.0", MaxWithdrawAmount: "750.0"},
+		{Asset: "BNB", WalletBalance: "1.0", MaxWithdrawAmount: "1.5"},
+	})
+	assert.Equal(t, fixedpoint.MustNewFromString("750"), balances["USDT"].Available)
+	assert.Equal(t, fixedpoint.MustNewFromString("250"), balances["USDT"].Locked)
+
+	// the locked balance is never negative
+	assert.Equal(t, fixedpoint.MustNewFromString("1.5"), balances["BNB"].Available)
+	assert.Equal(t, fixedpoint.Value(0), balances["BNB"].Locked)
+}
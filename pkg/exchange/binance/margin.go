@@ -0,0 +1,368 @@
+package binance
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+	"github.com/c9s/bbgo/pkg/util"
+)
+
+var errMarginModeRequired = errors.New("the margin mode of the binance exchange is not enabled")
+
+// the loan, repay and interest history apis only accept the time range within 30 days
+const marginHistoryWindow = 30 * 24 * time.Hour
+
+// marginHistoryPageSize is the max page size of the margin history apis
+const marginHistoryPageSize = 100
+
+// doSignedRequest sends the signed request to the margin apis that are not covered by the go-binance client,
+// like the isolated margin loan, repay and transfer apis.
+func (e *Exchange) doSignedRequest(ctx context.Context, method, endpoint string, params url.Values, result interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+
+	params.Set("timestamp", strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond)-e.Client.TimeOffset, 10))
+
+	query := params.Encode()
+	mac := hmac.New(sha256.New, []byte(e.Client.SecretKey))
+	if _, err := mac.Write([]byte(query)); err != nil {
+		return err
+	}
+
+	query += "&signature=" + hex.EncodeToString(mac.Sum(nil))
+
+	req, err := http.NewRequestWithContext(ctx, method, e.Client.BaseURL+endpoint+"?"+query, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-MBX-APIKEY", e.Client.APIKey)
+
+	httpClient := e.Client.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := new(common.APIError)
+		if err := json.Unmarshal(data, apiErr); err != nil {
+			return fmt.Errorf("binance api %s %s error: status %d, body: %s", method, endpoint, resp.StatusCode, data)
+		}
+
+		return apiErr
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+func (e *Exchange) marginAssetParams(asset string, amount fixedpoint.Value) url.Values {
+	params := url.Values{}
+	params.Set("asset", asset)
+	params.Set("amount", util.FormatFloat(amount.Float64(), -1))
+	if e.IsIsolatedMargin {
+		params.Set("isIsolated", "TRUE")
+		params.Set("symbol", e.IsolatedMarginSymbol)
+	}
+
+	return params
+}
+
+func (e *Exchange) BorrowMarginAsset(ctx context.Context, asset string, amount fixedpoint.Value) error {
+	if !e.IsMargin {
+		return errMarginModeRequired
+	}
+
+	log.Infof("borrowing margin asset %s %f", asset, amount.Float64())
+	return e.doSignedRequest(ctx, http.MethodPost, "/sapi/v1/margin/loan", e.marginAssetParams(asset, amount), nil)
+}
+
+func (e *Exchange) RepayMarginAsset(ctx context.Context, asset string, amount fixedpoint.Value) error {
+	if !e.IsMargin {
+		return errMarginModeRequired
+	}
+
+	log.Infof("repaying margin asset %s %f", asset, amount.Float64())
+	return e.doSignedRequest(ctx, http.MethodPost, "/sapi/v1/margin/repay", e.marginAssetParams(asset, amount), nil)
+}
+
+func (e *Exchange) QueryMarginAssetMaxBorrowable(ctx context.Context, asset string) (fixedpoint.Value, error) {
+	if !e.IsMargin {
+		return 0, errMarginModeRequired
+	}
+
+	params := url.Values{}
+	params.Set("asset", asset)
+	if e.IsIsolatedMargin {
+		params.Set("isolatedSymbol", e.IsolatedMarginSymbol)
+	}
+
+	var resp struct {
+		Amount string `json:"amount"`
+	}
+
+	if err := e.doSignedRequest(ctx, http.MethodGet, "/sapi/v1/margin/maxBorrowable", params, &resp); err != nil {
+		return 0, err
+	}
+
+	return fixedpoint.NewFromString(resp.Amount)
+}
+
+func (e *Exchange) TransferMarginAccountAsset(ctx context.Context, asset string, amount fixedpoint.Value, direction types.TransferDirection) error {
+	if !e.IsMargin {
+		return errMarginModeRequired
+	}
+
+	params := url.Values{}
+	params.Set("asset", asset)
+	params.Set("amount", util.FormatFloat(amount.Float64(), -1))
+
+	if e.IsIsolatedMargin {
+		params.Set("symbol", e.IsolatedMarginSymbol)
+
+		switch direction {
+		case types.TransferIn:
+			params.Set("transFrom", "SPOT")
+			params.Set("transTo", "ISOLATED_MARGIN")
+		case types.TransferOut:
+			params.Set("transFrom", "ISOLATED_MARGIN")
+			params.Set("transTo", "SPOT")
+		default:
+			return fmt.Errorf("unsupported transfer direction %d", direction)
+		}
+
+		log.Infof("transferring isolated margin asset %s %f, direction %d", asset, amount.Float64(), direction)
+		return e.doSignedRequest(ctx, http.MethodPost, "/sapi/v1/margin/isolated/transfer", params, nil)
+	}
+
+	// 1 for transferring from the main account to the margin account, 2 for transferring back
+	switch direction {
+	case types.TransferIn:
+		params.Set("type", "1")
+	case types.TransferOut:
+		params.Set("type", "2")
+	default:
+		return fmt.Errorf("unsupported transfer direction %d", direction)
+	}
+
+	log.Infof("transferring margin asset %s %f, direction %d", asset, amount.Float64(), direction)
+	return e.doSignedRequest(ctx, http.MethodPost, "/sapi/v1/margin/transfer", params, nil)
+}
+
+// queryMarginHistory iterates the 30-day time windows and the pages of the margin history api,
+// the rows of each page are passed to the callback.
+func (e *Exchange) queryMarginHistory(ctx context.Context, endpoint, asset string, since, until time.Time, callback func(data json.RawMessage) (int, error)) error {
+	if !e.IsMargin {
+		return errMarginModeRequired
+	}
+
+	for startTime := since; startTime.Before(until); startTime = startTime.Add(marginHistoryWindow) {
+		endTime := startTime.Add(marginHistoryWindow)
+		if endTime.After(until) {
+			endTime = until
+		}
+
+		for current := 1; ; current++ {
+			params := url.Values{}
+			params.Set("asset", asset)
+			params.Set("startTime", strconv.FormatInt(startTime.UnixNano()/int64(time.Millisecond), 10))
+			params.Set("endTime", strconv.FormatInt(endTime.UnixNano()/int64(time.Millisecond), 10))
+			params.Set("current", strconv.Itoa(current))
+			params.Set("size", strconv.Itoa(marginHistoryPageSize))
+			if e.IsIsolatedMargin {
+				params.Set("isolatedSymbol", e.IsolatedMarginSymbol)
+			}
+
+			var resp struct {
+				Rows json.RawMessage `json:"rows"`
+			}
+
+			if err := e.doSignedRequest(ctx, http.MethodGet, endpoint, params, &resp); err != nil {
+				return err
+			}
+
+			if len(resp.Rows) == 0 {
+				break
+			}
+
+			numRows, err := callback(resp.Rows)
+			if err != nil {
+				return err
+			}
+
+			if numRows < marginHistoryPageSize {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e *Exchange) QueryLoanHistory(ctx context.Context, asset string, since, until time.Time) (loans []types.MarginLoan, err error) {
+	err = e.queryMarginHistory(ctx, "/sapi/v1/margin/loan", asset, since, until, func(data json.RawMessage) (int, error) {
+		var rows []struct {
+			IsolatedSymbol string `json:"isolatedSymbol"`
+			TxID           uint64 `json:"txId"`
+			Asset          string `json:"asset"`
+			Principal      string `json:"principal"`
+			Timestamp      int64  `json:"timestamp"`
+			Status         string `json:"status"`
+		}
+
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return 0, err
+		}
+
+		for _, row := range rows {
+			// skip the pending and the failed loans
+			if row.Status != "CONFIRMED" {
+				continue
+			}
+
+			principal, err := fixedpoint.NewFromString(row.Principal)
+			if err != nil {
+				return 0, err
+			}
+
+			loans = append(loans, types.MarginLoan{
+				TransactionID:  row.TxID,
+				Exchange:       types.ExchangeBinance,
+				Asset:          row.Asset,
+				Principal:      principal,
+				Time:           millisecondTime(row.Timestamp),
+				IsolatedSymbol: row.IsolatedSymbol,
+			})
+		}
+
+		return len(rows), nil
+	})
+
+	return loans, err
+}
+
+func (e *Exchange) QueryRepayHistory(ctx context.Context, asset string, since, until time.Time) (repays []types.MarginRepay, err error) {
+	err = e.queryMarginHistory(ctx, "/sapi/v1/margin/repay", asset, since, until, func(data json.RawMessage) (int, error) {
+		var rows []struct {
+			IsolatedSymbol string `json:"isolatedSymbol"`
+			TxID           uint64 `json:"txId"`
+			Asset          string `json:"asset"`
+			Principal      string `json:"principal"`
+			Interest       string `json:"interest"`
+			Timestamp      int64  `json:"timestamp"`
+			Status         string `json:"status"`
+		}
+
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return 0, err
+		}
+
+		for _, row := range rows {
+			if row.Status != "CONFIRMED" {
+				continue
+			}
+
+			principal, err := fixedpoint.NewFromString(row.Principal)
+			if err != nil {
+				return 0, err
+			}
+
+			interest, err := fixedpoint.NewFromString(row.Interest)
+			if err != nil {
+				return 0, err
+			}
+
+			repays = append(repays, types.MarginRepay{
+				TransactionID:  row.TxID,
+				Exchange:       types.ExchangeBinance,
+				Asset:          row.Asset,
+				Principal:      principal,
+				Interest:       interest,
+				Time:           millisecondTime(row.Timestamp),
+				IsolatedSymbol: row.IsolatedSymbol,
+			})
+		}
+
+		return len(rows), nil
+	})
+
+	return repays, err
+}
+
+func (e *Exchange) QueryInterestHistory(ctx context.Context, asset string, since, until time.Time) (interests []types.MarginInterest, err error) {
+	err = e.queryMarginHistory(ctx, "/sapi/v1/margin/interestHistory", asset, since, until, func(data json.RawMessage) (int, error) {
+		var rows []struct {
+			IsolatedSymbol      string `json:"isolatedSymbol"`
+			Asset               string `json:"asset"`
+			Principal           string `json:"principal"`
+			Interest            string `json:"interest"`
+			InterestRate        string `json:"interestRate"`
+			InterestAccuredTime int64  `json:"interestAccuredTime"`
+		}
+
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return 0, err
+		}
+
+		for _, row := range rows {
+			principal, err := fixedpoint.NewFromString(row.Principal)
+			if err != nil {
+				return 0, err
+			}
+
+			interest, err := fixedpoint.NewFromString(row.Interest)
+			if err != nil {
+				return 0, err
+			}
+
+			interestRate, err := fixedpoint.NewFromString(row.InterestRate)
+			if err != nil {
+				return 0, err
+			}
+
+			interests = append(interests, types.MarginInterest{
+				Exchange:       types.ExchangeBinance,
+				Asset:          row.Asset,
+				Principal:      principal,
+				Interest:       interest,
+				InterestRate:   interestRate,
+				Time:           millisecondTime(row.InterestAccuredTime),
+				IsolatedSymbol: row.IsolatedSymbol,
+			})
+		}
+
+		return len(rows), nil
+	})
+
+	return interests, err
+}
@@ -0,0 +1,200 @@
+package binance
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+type marginRequest struct {
+	Method string
+	Path   string
+	Params url.Values
+}
+
+func newMarginTestExchange(t *testing.T, handler func(r marginRequest) (int, string)) (*httptest.Server, *Exchange, *[]marginRequest) {
+	var requests []marginRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.RawQuery
+		i := strings.LastIndex(query, "&signature=")
+		if !assert.True(t, i > 0, "signature is required") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mac := hmac.New(sha256.New, []byte("secret"))
+		_, _ = mac.Write([]byte(query[:i]))
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), query[i+len("&signature="):])
+		assert.Equal(t, "key", r.Header.Get("X-MBX-APIKEY"))
+
+		req := marginRequest{Method: r.Method, Path: r.URL.Path, Params: r.URL.Query()}
+		requests = append(requests, req)
+
+		status, body := handler(req)
+		w.WriteHeader(status)
+		_, _ = fmt.Fprint(w, body)
+	}))
+
+	e := New("key", "secret")
+	e.Client.BaseURL = server.URL
+	return server, e, &requests
+}
+
+func TestMarginBorrowRepayService_MarginModeRequired(t *testing.T) {
+	e := New("key", "secret")
+	err := e.BorrowMarginAsset(context.Background(), "USDT", fixedpoint.NewFromFloat(10.0))
+	assert.Equal(t, errMarginModeRequired, err)
+}
+
+func TestMarginBorrowRepayService_IsolatedBorrowRepay(t *testing.T) {
+	server, e, requests := newMarginTestExchange(t, func(r marginRequest) (int, string) {
+		if r.Path == "/sapi/v1/margin/maxBorrowable" {
+			return http.StatusOK, `{"amount":"125.5","borrowLimit":"200"}`
+		}
+
+		return http.StatusOK, `{"tranId":100000001}`
+	})
+	defer server.Close()
+
+	e.UseIsolatedMargin("BTCUSDT")
+
+	ctx := context.Background()
+	assert.NoError(t, e.BorrowMarginAsset(ctx, "USDT", fixedpoint.NewFromFloat(100.5)))
+	assert.NoError(t, e.RepayMarginAsset(ctx, "USDT", fixedpoint.NewFromFloat(50.0)))
+
+	amount, err := e.QueryMarginAssetMaxBorrowable(ctx, "USDT")
+	assert.NoError(t, err)
+	assert.Equal(t, fixedpoint.NewFromFloat(125.5), amount)
+
+	if assert.Len(t, *requests, 3) {
+		loan := (*requests)[0]
+		assert.Equal(t, http.MethodPost, loan.Method)
+		assert.Equal(t, "/sapi/v1/margin/loan", loan.Path)
+		assert.Equal(t, "100.5", loan.Params.Get("amount"))
+		assert.Equal(t, "TRUE", loan.Params.Get("isIsolated"))
+		assert.Equal(t, "BTCUSDT", loan.Params.Get("symbol"))
+
+		repay := (*requests)[1]
+		assert.Equal(t, "/sapi/v1/margin/repay", repay.Path)
+		assert.Equal(t, "50", repay.Params.Get("amount"))
+
+		maxBorrowable := (*requests)[2]
+		assert.Equal(t, http.MethodGet, maxBorrowable.Method)
+		assert.Equal(t, "BTCUSDT", maxBorrowable.Params.Get("isolatedSymbol"))
+	}
+}
+
+func TestMarginBorrowRepayService_Transfer(t *testing.T) {
+	server, e, requests := newMarginTestExchange(t, func(r marginRequest) (int, string) {
+		return http.StatusOK, `{"tranId":100000001}`
+	})
+	defer server.Close()
+
+	e.UseMargin()
+
+	ctx := context.Background()
+	assert.NoError(t, e.TransferMarginAccountAsset(ctx, "BTC", fixedpoint.NewFromFloat(0.1), types.TransferIn))
+	assert.NoError(t, e.TransferMarginAccountAsset(ctx, "BTC", fixedpoint.NewFromFloat(0.1), types.TransferOut))
+
+	e.UseIsolatedMargin("BTCUSDT")
+	assert.NoError(t, e.TransferMarginAccountAsset(ctx, "BTC", fixedpoint.NewFromFloat(0.1), types.TransferOut))
+
+	if assert.Len(t, *requests, 3) {
+		assert.Equal(t, "/sapi/v1/margin/transfer", (*requests)[0].Path)
+		assert.Equal(t, "1", (*requests)[0].Params.Get("type"))
+		assert.Equal(t, "2", (*requests)[1].Params.Get("type"))
+
+		isolated := (*requests)[2]
+		assert.Equal(t, "/sapi/v1/margin/isolated/transfer", isolated.Path)
+		assert.Equal(t, "ISOLATED_MARGIN", isolated.Params.Get("transFrom"))
+		assert.Equal(t, "SPOT", isolated.Params.Get("transTo"))
+		assert.Equal(t, "BTCUSDT", isolated.Params.Get("symbol"))
+	}
+}
+
+func TestMarginBorrowRepayService_APIError(t *testing.T) {
+	server, e, _ := newMarginTestExchange(t, func(r marginRequest) (int, string) {
+		return http.StatusBadRequest, `{"code":-3045,"msg":"The system does not have enough asset now."}`
+	})
+	defer server.Close()
+
+	e.UseMargin()
+
+	err := e.BorrowMarginAsset(context.Background(), "USDT", fixedpoint.NewFromFloat(10.0))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "-3045")
+	}
+}
+
+func TestMarginHistoryService_QueryLoanHistory(t *testing.T) {
+	server, e, requests := newMarginTestExchange(t, func(r marginRequest) (int, string) {
+		// the first page is full, the second page is the last page
+		if r.Params.Get("current") == "1" {
+			var rows []string
+			for i := 0; i < marginHistoryPageSize-1; i++ {
+				rows = append(rows, fmt.Sprintf(`{"txId":%d,"asset":"USDT","principal":"1.5","timestamp":1555056425000,"status":"CONFIRMED"}`, i+1))
+			}
+
+			rows = append(rows, `{"txId":1000,"asset":"USDT","principal":"1.5","timestamp":1555056425000,"status":"FAILED"}`)
+			return http.StatusOK, `{"rows":[` + strings.Join(rows, ",") + `],"total":101}`
+		}
+
+		return http.StatusOK, `{"rows":[{"isolatedSymbol":"BTCUSDT","txId":2000,"asset":"USDT","principal":"10","timestamp":1555056425000,"status":"CONFIRMED"}],"total":101}`
+	})
+	defer server.Close()
+
+	e.UseIsolatedMargin("BTCUSDT")
+
+	since := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	loans, err := e.QueryLoanHistory(context.Background(), "USDT", since, since.Add(7*24*time.Hour))
+	assert.NoError(t, err)
+	if assert.Len(t, loans, marginHistoryPageSize) {
+		last := loans[len(loans)-1]
+		assert.Equal(t, uint64(2000), last.TransactionID)
+		assert.Equal(t, types.ExchangeBinance, last.Exchange)
+		assert.Equal(t, fixedpoint.NewFromFloat(10.0), last.Principal)
+		assert.Equal(t, "BTCUSDT", last.IsolatedSymbol)
+	}
+
+	if assert.Len(t, *requests, 2) {
+		assert.Equal(t, "BTCUSDT", (*requests)[0].Params.Get("isolatedSymbol"))
+		assert.Equal(t, "2", (*requests)[1].Params.Get("current"))
+	}
+}
+
+func TestMarginHistoryService_QueryInterestHistory(t *testing.T) {
+	server, e, requests := newMarginTestExchange(t, func(r marginRequest) (int, string) {
+		return http.StatusOK, `{"rows":[{"asset":"BNB","interest":"0.02414667","interestAccuredTime":1566813600000,"interestRate":"0.01600000","principal":"36.22000000","type":"ON_BORROW"}],"total":1}`
+	})
+	defer server.Close()
+
+	e.UseMargin()
+
+	// the time range is split into the 30-day windows
+	since := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	interests, err := e.QueryInterestHistory(context.Background(), "BNB", since, since.Add(45*24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, interests, 2)
+	assert.Equal(t, fixedpoint.MustNewFromString("0.02414667"), interests[0].Interest)
+	assert.Equal(t, fixedpoint.MustNewFromString("36.22"), interests[0].Principal)
+	assert.Equal(t, int64(1566813600000), interests[0].Time.UnixNano()/int64(time.Millisecond))
+
+	if assert.Len(t, *requests, 2) {
+		assert.Equal(t, "/sapi/v1/margin/interestHistory", (*requests)[0].Path)
+		assert.Equal(t, (*requests)[0].Params.Get("endTime"), (*requests)[1].Params.Get("startTime"))
+		assert.Empty(t, (*requests)[0].Params.Get("isolatedSymbol"))
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/futures"
 	"github.com/valyala/fastjson"
 
 	"github.com/c9s/bbgo/pkg/datatype"
@@ -237,6 +238,230 @@ type OutboundAccountInfoEvent struct {
 	Permissions []string  `json:"P,omitempty"`
 }
 
+/*
+
+ORDER_TRADE_UPDATE (futures)
+
+{
+  "e": "ORDER_TRADE_UPDATE",     // Event Type
+  "E": 1568879465651,            // Event Time
+  "T": 1568879465650,            // Transaction Time
+  "o": {
+    "s": "BTCUSDT",              // Symbol
+    "c": "TEST",                 // Client Order Id
+    "S": "SELL",                 // Side
+    "o": "TRAILING_STOP_MARKET", // Order Type
+    "f": "GTC",                  // Time in Force
+    "q": "0.001",                // Original Quantity
+    "p": "0",                    // Original Price
+    "ap": "0",                   // Average Price
+    "sp": "7103.04",             // Stop Price
+    "x": "NEW",                  // Execution Type
+    "X": "NEW",                  // Order Status
+    "i": 8886774,                // Order Id
+    "l": "0",                    // Order Last Filled Quantity
+    "z": "0",                    // Order Filled Accumulated Quantity
+    "L": "0",                    // Last Filled Price
+    "N": "USDT",                 // Commission Asset
+    "n": "0",                    // Commission
+    "T": 1568879465651,          // Order Trade Time
+    "t": 0,                      // Trade Id
+    "m": false,                  // Is this trade the maker side?
+    "R": false,                  // Is this reduce only
+    "ps": "LONG",                // Position Side
+    "rp": "0"                    // Realized Profit of the trade
+  }
+}
+*/
+type OrderTradeUpdate struct {
+	Symbol        string `json:"s"`
+	ClientOrderID string `json:"c"`
+	Side          string `json:"S"`
+	OrderType     string `json:"o"`
+	TimeInForce   string `json:"f"`
+
+	OrderQuantity string `json:"q"`
+	OrderPrice    string `json:"p"`
+	AveragePrice  string `json:"ap"`
+	StopPrice     string `json:"sp"`
+
+	CurrentExecutionType string `json:"x"`
+	CurrentOrderStatus   string `json:"X"`
+
+	OrderID int64 `json:"i"`
+
+	LastExecutedQuantity     string `json:"l"`
+	CumulativeFilledQuantity string `json:"z"`
+	LastExecutedPrice        string `json:"L"`
+
+	CommissionAsset  string `json:"N"`
+	CommissionAmount string `json:"n"`
+
+	OrderTradeTime int64 `json:"T"`
+	TradeID        int64 `json:"t"`
+
+	IsMaker      bool   `json:"m"`
+	IsReduceOnly bool   `json:"R"`
+	PositionSide string `json:"ps"`
+	RealizedPnL  string `json:"rp"`
+}
+
+type OrderTradeUpdateEvent struct {
+	EventBase
+
+	TransactionTime int64            `json:"T"`
+	OrderTrade      OrderTradeUpdate `json:"o"`
+}
+
+func (e *OrderTradeUpdateEvent) Order() (*types.Order, error) {
+	o := e.OrderTrade
+
+	switch o.CurrentExecutionType {
+	case "NEW", "CANCELED", "CALCULATED", "EXPIRED", "AMENDMENT":
+	default:
+		return nil, errors.New("order trade update type is not for order")
+	}
+
+	status := toGlobalOrderStatus(binance.OrderStatusType(o.CurrentOrderStatus))
+	return &types.Order{
+		SubmitOrder: types.SubmitOrder{
+			Symbol:        o.Symbol,
+			ClientOrderID: o.ClientOrderID,
+			Side:          toGlobalSideType(binance.SideType(o.Side)),
+			Type:          toGlobalFuturesOrderType(futures.OrderType(o.OrderType), futures.TimeInForceType(o.TimeInForce)),
+			Quantity:      util.MustParseFloat(o.OrderQuantity),
+			Price:         util.MustParseFloat(o.OrderPrice),
+			StopPrice:     util.MustParseFloat(o.StopPrice),
+			TimeInForce:   o.TimeInForce,
+			ReduceOnly:    o.IsReduceOnly,
+		},
+		Exchange:         types.ExchangeBinance.String(),
+		IsWorking:        status == types.OrderStatusNew || status == types.OrderStatusPartiallyFilled,
+		OrderID:          uint64(o.OrderID),
+		Status:           status,
+		ExecutedQuantity: util.MustParseFloat(o.CumulativeFilledQuantity),
+		UpdateTime:       datatype.Time(millisecondTime(e.TransactionTime)),
+	}, nil
+}
+
+func (e *OrderTradeUpdateEvent) Trade() (*types.Trade, error) {
+	o := e.OrderTrade
+	if o.CurrentExecutionType != "TRADE" {
+		return nil, errors.New("order trade update is not a trade")
+	}
+
+	price := util.MustParseFloat(o.LastExecutedPrice)
+	quantity := util.MustParseFloat(o.LastExecutedQuantity)
+	return &types.Trade{
+		ID:            o.TradeID,
+		Exchange:      types.ExchangeBinance.String(),
+		Symbol:        o.Symbol,
+		OrderID:       uint64(o.OrderID),
+		Side:          toGlobalSideType(binance.SideType(o.Side)),
+		Price:         price,
+		Quantity:      quantity,
+		QuoteQuantity: price * quantity,
+		IsBuyer:       o.Side == "BUY",
+		IsMaker:       o.IsMaker,
+		Time:          datatype.Time(millisecondTime(o.OrderTradeTime)),
+		Fee:           util.MustParseFloat(o.CommissionAmount),
+		FeeCurrency:   o.CommissionAsset,
+	}, nil
+}
+
+/*
+
+ACCOUNT_UPDATE (futures)
+
+{
+  "e": "ACCOUNT_UPDATE",            // Event Type
+  "E": 1564745798939,               // Event Time
+  "T": 1564745798938,               // Transaction
+  "a": {
+    "m": "ORDER",                   // Event reason type
+    "B": [                          // Balances
+      {
+        "a": "USDT",                // Asset
+        "wb": "122624.12345678",    // Wallet Balance
+        "cw": "100.12345678"        // Cross Wallet Balance
+      }
+    ],
+    "P": [                          // Positions
+      {
+        "s": "BTCUSDT",             // Symbol
+        "pa": "0",                  // Position Amount
+        "ep": "0.00000",            // Entry Price
+        "cr": "200",                // (Pre-fee) Accumulated Realized
+        "up": "0",                  // Unrealized PnL
+        "mt": "isolated",           // Margin Type
+        "iw": "0.00000000",         // Isolated Wallet (if isolated position)
+        "ps": "BOTH"                // Position Side
+      }
+    ]
+  }
+}
+*/
+type FuturesBalance struct {
+	Asset              string `json:"a"`
+	WalletBalance      string `json:"wb"`
+	CrossWalletBalance string `json:"cw"`
+}
+
+type FuturesPosition struct {
+	Symbol           string `json:"s"`
+	PositionAmount   string `json:"pa"`
+	EntryPrice       string `json:"ep"`
+	UnrealizedProfit string `json:"up"`
+	MarginType       string `json:"mt"`
+	IsolatedWallet   string `json:"iw"`
+	PositionSide     string `json:"ps"`
+}
+
+type AccountUpdate struct {
+	EventReasonType string            `json:"m"`
+	Balances        []FuturesBalance  `json:"B,omitempty"`
+	Positions       []FuturesPosition `json:"P,omitempty"`
+}
+
+type AccountUpdateEvent struct {
+	EventBase
+
+	TransactionTime int64         `json:"T"`
+	AccountUpdate   AccountUpdate `json:"a"`
+}
+
+func (e *AccountUpdateEvent) Positions() (positions []types.FuturesPosition, err error) {
+	updateTime := millisecondTime(e.TransactionTime)
+	for _, p := range e.AccountUpdate.Positions {
+		quantity, err := fixedpoint.NewFromString(p.PositionAmount)
+		if err != nil {
+			return nil, err
+		}
+
+		entryPrice, err := fixedpoint.NewFromString(p.EntryPrice)
+		if err != nil {
+			return nil, err
+		}
+
+		unrealizedProfit, err := fixedpoint.NewFromString(p.UnrealizedProfit)
+		if err != nil {
+			return nil, err
+		}
+
+		positions = append(positions, types.FuturesPosition{
+			Symbol:           p.Symbol,
+			Side:             types.PositionSide(p.PositionSide),
+			Quantity:         quantity,
+			EntryPrice:       entryPrice,
+			UnrealizedProfit: unrealizedProfit,
+			Isolated:         p.MarginType == "isolated",
+			UpdateTime:       updateTime,
+		})
+	}
+
+	return positions, nil
+}
+
 type ResultEvent struct {
 	Result interface{} `json:"result,omitempty"`
 	ID     int         `json:"id"`
@@ -276,19 +501,163 @@ func ParseEvent(message string) (interface{}, error) {
 		err := json.Unmarshal([]byte(message), &event)
 		return &event, err
 
+	case "ORDER_TRADE_UPDATE":
+		var event OrderTradeUpdateEvent
+		err := json.Unmarshal([]byte(message), &event)
+		return &event, err
+
+	case "ACCOUNT_UPDATE":
+		var event AccountUpdateEvent
+		err := json.Unmarshal([]byte(message), &event)
+		return &event, err
+
 	case "depthUpdate":
 		return parseDepthEvent(val)
 
+	case "trade", "aggTrade":
+		var event MarketTradeEvent
+		err := json.Unmarshal([]byte(message), &event)
+		return &event, err
+
+	case "bookTicker":
+		var event BookTickerEvent
+		err := json.Unmarshal([]byte(message), &event)
+		return &event, err
+
 	default:
 		id := val.GetInt("id")
 		if id > 0 {
 			return &ResultEvent{ID: id}, nil
 		}
+
+		// the spot book ticker event doesn't have the event type
+		if val.Exists("u") && val.Exists("b") && val.Exists("a") {
+			var event BookTickerEvent
+			err := json.Unmarshal([]byte(message), &event)
+			return &event, err
+		}
 	}
 
 	return nil, fmt.Errorf("unsupported message: %s", message)
 }
 
+/*
+trade
+
+{
+  "e": "trade",     // Event type
+  "E": 123456789,   // Event time
+  "s": "BNBBTC",    // Symbol
+  "t": 12345,       // Trade ID
+  "p": "0.001",     // Price
+  "q": "100",       // Quantity
+  "b": 88,          // Buyer order ID
+  "a": 50,          // Seller order ID
+  "T": 123456785,   // Trade time
+  "m": true,        // Is the buyer the market maker?
+  "M": true         // Ignore
+}
+
+aggTrade
+
+{
+  "e": "aggTrade",  // Event type
+  "E": 123456789,   // Event time
+  "s": "BNBBTC",    // Symbol
+  "a": 12345,       // Aggregate trade ID
+  "p": "0.001",     // Price
+  "q": "100",       // Quantity
+  "f": 100,         // First trade ID
+  "l": 105,         // Last trade ID
+  "T": 123456785,   // Trade time
+  "m": true,        // Is the buyer the market maker?
+  "M": true         // Ignore
+}
+*/
+type MarketTradeEvent struct {
+	EventBase
+
+	Symbol  string `json:"s"`
+	TradeID int64  `json:"t"`
+
+	// AggregateID is the aggregate trade ID of the aggTrade event, or the seller order ID of the trade event
+	AggregateID int64 `json:"a"`
+
+	Price        string `json:"p"`
+	Quantity     string `json:"q"`
+	TradeTime    int64  `json:"T"`
+	IsBuyerMaker bool   `json:"m"`
+}
+
+// Trade converts the market trade event to the trade of the taker side
+func (e *MarketTradeEvent) Trade() types.Trade {
+	id := e.TradeID
+	if e.Event == "aggTrade" {
+		id = e.AggregateID
+	}
+
+	side := types.SideTypeBuy
+	if e.IsBuyerMaker {
+		side = types.SideTypeSell
+	}
+
+	price := util.MustParseFloat(e.Price)
+	quantity := util.MustParseFloat(e.Quantity)
+	return types.Trade{
+		ID:            id,
+		Exchange:      types.ExchangeBinance.String(),
+		Symbol:        e.Symbol,
+		Side:          side,
+		IsBuyer:       side == types.SideTypeBuy,
+		Price:         price,
+		Quantity:      quantity,
+		QuoteQuantity: price * quantity,
+		Time:          datatype.Time(time.Unix(0, e.TradeTime*int64(time.Millisecond))),
+	}
+}
+
+/*
+bookTicker
+
+{
+  "u": 400900217,     // order book updateId
+  "s": "BNBUSDT",     // symbol
+  "b": "25.35190000", // best bid price
+  "B": "31.21000000", // best bid qty
+  "a": "25.36520000", // best ask price
+  "A": "40.66000000"  // best ask qty
+}
+
+the futures book ticker event has the event type "bookTicker", the event time "E" and the transaction time "T"
+*/
+type BookTickerEvent struct {
+	EventBase
+
+	UpdateID        int64  `json:"u"`
+	Symbol          string `json:"s"`
+	BuyPrice        string `json:"b"`
+	BuySize         string `json:"B"`
+	SellPrice       string `json:"a"`
+	SellSize        string `json:"A"`
+	TransactionTime int64  `json:"T"`
+}
+
+func (e *BookTickerEvent) BookTicker() types.BookTicker {
+	t := time.Now()
+	if e.TransactionTime > 0 {
+		t = time.Unix(0, e.TransactionTime*int64(time.Millisecond))
+	}
+
+	return types.BookTicker{
+		Time:     t,
+		Symbol:   e.Symbol,
+		Buy:      util.MustParseFloat(e.BuyPrice),
+		BuySize:  util.MustParseFloat(e.BuySize),
+		Sell:     util.MustParseFloat(e.SellPrice),
+		SellSize: util.MustParseFloat(e.SellSize),
+	}
+}
+
 type DepthEntry struct {
 	PriceLevel string
 	Quantity   string
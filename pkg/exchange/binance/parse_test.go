@@ -5,6 +5,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
 )
 
 var jsCommentTrimmer = regexp.MustCompile("(?m)//.*$")
@@ -183,3 +186,165 @@ func TestParseOrderUpdate(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, orderUpdate)
 }
+
+func TestParseOrderTradeUpdateEvent(t *testing.T) {
+	payload := `{
+  "e": "ORDER_TRADE_UPDATE",
+  "E": 1568879465651,
+  "T": 1568879465650,
+  "o": {
+    "s": "BTCUSDT",
+    "c": "TEST",
+    "S": "SELL",
+    "o": "LIMIT",
+    "f": "GTX",
+    "q": "0.002",
+    "p": "9000",
+    "ap": "9000",
+    "sp": "0",
+    "x": "TRADE",
+    "X": "PARTIALLY_FILLED",
+    "i": 8886774,
+    "l": "0.001",
+    "z": "0.001",
+    "L": "9000",
+    "N": "USDT",
+    "n": "0.0018",
+    "T": 1568879465651,
+    "t": 12345,
+    "m": true,
+    "R": true,
+    "ps": "BOTH",
+    "rp": "0.5"
+  }
+}`
+
+	event, err := ParseEvent(payload)
+	assert.NoError(t, err)
+
+	orderTradeUpdate, ok := event.(*OrderTradeUpdateEvent)
+	if assert.True(t, ok) {
+		trade, err := orderTradeUpdate.Trade()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12345), trade.ID)
+		assert.Equal(t, uint64(8886774), trade.OrderID)
+		assert.Equal(t, types.SideTypeSell, trade.Side)
+		assert.Equal(t, 9000.0, trade.Price)
+		assert.Equal(t, 0.001, trade.Quantity)
+		assert.Equal(t, 9.0, trade.QuoteQuantity)
+		assert.True(t, trade.IsMaker)
+		assert.Equal(t, "USDT", trade.FeeCurrency)
+
+		// the trade execution is not an order update
+		_, err = orderTradeUpdate.Order()
+		assert.Error(t, err)
+
+		orderTradeUpdate.OrderTrade.CurrentExecutionType = "CANCELED"
+		orderTradeUpdate.OrderTrade.CurrentOrderStatus = "CANCELED"
+		order, err := orderTradeUpdate.Order()
+		assert.NoError(t, err)
+		assert.Equal(t, types.OrderTypeLimitMaker, order.Type)
+		assert.Equal(t, types.OrderStatusCanceled, order.Status)
+		assert.True(t, order.ReduceOnly)
+		assert.False(t, order.IsWorking)
+		assert.Equal(t, 0.001, order.ExecutedQuantity)
+	}
+}
+
+func TestParseAccountUpdateEvent(t *testing.T) {
+	payload := `{
+  "e": "ACCOUNT_UPDATE",
+  "E": 1564745798939,
+  "T": 1564745798938,
+  "a": {
+    "m": "ORDER",
+    "B": [
+      { "a": "USDT", "wb": "122624.12345678", "cw": "100.12345678" }
+    ],
+    "P": [
+      { "s": "BTCUSDT", "pa": "-0.5", "ep": "9000.5", "cr": "200", "up": "-1.25", "mt": "isolated", "iw": "0.00000000", "ps": "BOTH" }
+    ]
+  }
+}`
+
+	event, err := ParseEvent(payload)
+	assert.NoError(t, err)
+
+	accountUpdate, ok := event.(*AccountUpdateEvent)
+	if assert.True(t, ok) {
+		if assert.Len(t, accountUpdate.AccountUpdate.Balances, 1) {
+			balance := accountUpdate.AccountUpdate.Balances[0]
+			assert.Equal(t, "USDT", balance.Asset)
+			assert.Equal(t, "122624.12345678", balance.WalletBalance)
+		}
+
+		positions, err := accountUpdate.Positions()
+		assert.NoError(t, err)
+		if assert.Len(t, positions, 1) {
+			position := positions[0]
+			assert.Equal(t, "BTCUSDT", position.Symbol)
+			assert.Equal(t, types.PositionSideBoth, position.Side)
+			assert.True(t, position.IsShort())
+			assert.Equal(t, fixedpoint.MustNewFromString("-0.5"), position.Quantity)
+			assert.Equal(t, fixedpoint.MustNewFromString("9000.5"), position.EntryPrice)
+			assert.Equal(t, fixedpoint.MustNewFromString("-1.25"), position.UnrealizedProfit)
+			assert.True(t, position.Isolated)
+		}
+	}
+}
+
+func TestParseMarketTradeEvent(t *testing.T) {
+	t.Run("trade", func(t *testing.T) {
+		payload := `{"e": "trade", "E": 1616000000100, "s": "BTCUSDT", "t": 12345, "p": "58000.10", "q": "0.002", "b": 88, "a": 50, "T": 1616000000000, "m": true, "M": true}`
+
+		event, err := ParseEvent(payload)
+		assert.NoError(t, err)
+
+		tradeEvent, ok := event.(*MarketTradeEvent)
+		if assert.True(t, ok) {
+			trade := tradeEvent.Trade()
+			assert.Equal(t, int64(12345), trade.ID)
+			assert.Equal(t, "BTCUSDT", trade.Symbol)
+			assert.Equal(t, 58000.1, trade.Price)
+			assert.Equal(t, 0.002, trade.Quantity)
+			// the buyer is the maker, the taker is the seller
+			assert.Equal(t, types.SideTypeSell, trade.Side)
+			assert.False(t, trade.IsBuyer)
+		}
+	})
+
+	t.Run("aggTrade", func(t *testing.T) {
+		payload := `{"e": "aggTrade", "E": 1616000000100, "s": "BTCUSDT", "a": 6789, "p": "58000.10", "q": "0.5", "f": 100, "l": 105, "T": 1616000000000, "m": false}`
+
+		event, err := ParseEvent(payload)
+		assert.NoError(t, err)
+
+		tradeEvent, ok := event.(*MarketTradeEvent)
+		if assert.True(t, ok) {
+			trade := tradeEvent.Trade()
+			assert.Equal(t, int64(6789), trade.ID)
+			assert.Equal(t, types.SideTypeBuy, trade.Side)
+			assert.True(t, trade.IsBuyer)
+		}
+	})
+}
+
+func TestParseBookTickerEvent(t *testing.T) {
+	for _, payload := range []string{
+		`{"u": 400900217, "s": "BNBUSDT", "b": "25.35190000", "B": "31.21000000", "a": "25.36520000", "A": "40.66000000"}`,
+		`{"e": "bookTicker", "u": 400900217, "E": 1568014460893, "T": 1568014460891, "s": "BNBUSDT", "b": "25.35190000", "B": "31.21000000", "a": "25.36520000", "A": "40.66000000"}`,
+	} {
+		event, err := ParseEvent(payload)
+		assert.NoError(t, err)
+
+		bookTickerEvent, ok := event.(*BookTickerEvent)
+		if assert.True(t, ok) {
+			bookTicker := bookTickerEvent.BookTicker()
+			assert.Equal(t, "BNBUSDT", bookTicker.Symbol)
+			assert.Equal(t, 25.3519, bookTicker.Buy)
+			assert.Equal(t, 31.21, bookTicker.BuySize)
+			assert.Equal(t, 25.3652, bookTicker.Sell)
+			assert.Equal(t, 40.66, bookTicker.SellSize)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package binance
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/exchange/ratelimit"
+)
+
+const usedWeightHeader = "X-MBX-USED-WEIGHT-1M"
+
+// spotLimiter is shared by the spot and the margin clients, binance allows 1200 request weight per minute for each IP
+var spotLimiter = ratelimit.Register("binance", ratelimit.Config{
+	Weight:           1200,
+	Interval:         time.Minute,
+	UsedWeightHeader: usedWeightHeader,
+	WeightFunc:       spotRequestWeight,
+})
+
+// futuresLimiter is for the USDT-M futures api, which has its own budget of 2400 request weight per minute
+var futuresLimiter = ratelimit.Register("binance-futures", ratelimit.Config{
+	Weight:           2400,
+	Interval:         time.Minute,
+	UsedWeightHeader: usedWeightHeader,
+	WeightFunc:       futuresRequestWeight,
+})
+
+// spotRequestWeight returns the weight of the spot api request, see https://binance-docs.github.io/apidocs/spot/en/
+func spotRequestWeight(req *http.Request) int {
+	query := req.URL.Query()
+	hasSymbol := query.Get("symbol") != ""
+
+	switch req.URL.Path {
+	case "/api/v3/exchangeInfo", "/api/v3/allOrders", "/api/v3/myTrades", "/api/v3/account":
+		return 10
+
+	case "/api/v3/ticker/24hr":
+		if hasSymbol {
+			return 1
+		}
+		return 40
+
+	case "/api/v3/openOrders":
+		if hasSymbol {
+			return 3
+		}
+		return 40
+
+	case "/api/v3/depth":
+		limit := queryLimit(query.Get("limit"), 100)
+		switch {
+		case limit <= 100:
+			return 1
+		case limit <= 500:
+			return 5
+		case limit <= 1000:
+			return 10
+		default:
+			return 50
+		}
+	}
+
+	return 1
+}
+
+// futuresRequestWeight returns the weight of the futures api request, see https://binance-docs.github.io/apidocs/futures/en/
+func futuresRequestWeight(req *http.Request) int {
+	query := req.URL.Query()
+	hasSymbol := query.Get("symbol") != ""
+
+	switch req.URL.Path {
+	case "/fapi/v1/allOrders", "/fapi/v1/userTrades", "/fapi/v2/account", "/fapi/v2/positionRisk":
+		return 5
+
+	case "/fapi/v1/ticker/24hr", "/fapi/v1/openOrders":
+		if hasSymbol {
+			return 1
+		}
+		return 40
+
+	case "/fapi/v1/ticker/bookTicker":
+		if hasSymbol {
+			return 1
+		}
+		return 2
+
+	case "/fapi/v1/klines":
+		limit := queryLimit(query.Get("limit"), 500)
+		switch {
+		case limit < 100:
+			return 1
+		case limit < 500:
+			return 2
+		case limit <= 1000:
+			return 5
+		default:
+			return 10
+		}
+
+	case "/fapi/v1/depth":
+		limit := queryLimit(query.Get("limit"), 500)
+		switch {
+		case limit <= 50:
+			return 2
+		case limit <= 100:
+			return 5
+		case limit <= 500:
+			return 10
+		default:
+			return 20
+		}
+	}
+
+	return 1
+}
+
+// queryLimit parses the limit parameter, the default limit of the api is used when it's not given
+func queryLimit(s string, defaultLimit int) int {
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit <= 0 {
+		return defaultLimit
+	}
+
+	return limit
+}
@@ -0,0 +1,39 @@
+package binance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func requestWeight(weightFunc func(req *http.Request) int) func(url string) int {
+	return func(url string) int {
+		return weightFunc(httptest.NewRequest(http.MethodGet, url, nil))
+	}
+}
+
+func TestRequestWeight(t *testing.T) {
+	var testcases = []struct {
+		url      string
+		weight   func(url string) int
+		expected int
+	}{
+		{"/api/v3/exchangeInfo", requestWeight(spotRequestWeight), 10},
+		{"/api/v3/ticker/24hr", requestWeight(spotRequestWeight), 40},
+		{"/api/v3/ticker/24hr?symbol=BTCUSDT", requestWeight(spotRequestWeight), 1},
+		{"/api/v3/openOrders?symbol=BTCUSDT", requestWeight(spotRequestWeight), 3},
+		{"/api/v3/depth?symbol=BTCUSDT", requestWeight(spotRequestWeight), 1},
+		{"/api/v3/depth?symbol=BTCUSDT&limit=1000", requestWeight(spotRequestWeight), 10},
+		{"/api/v3/klines?symbol=BTCUSDT&limit=1000", requestWeight(spotRequestWeight), 1},
+		{"/fapi/v1/klines?symbol=BTCUSDT", requestWeight(futuresRequestWeight), 5},
+		{"/fapi/v1/klines?symbol=BTCUSDT&limit=99", requestWeight(futuresRequestWeight), 1},
+		{"/fapi/v1/openOrders", requestWeight(futuresRequestWeight), 40},
+		{"/fapi/v2/positionRisk", requestWeight(futuresRequestWeight), 5},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.expected, tc.weight(tc.url), tc.url)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/futures"
 	"github.com/gorilla/websocket"
 
 	"github.com/c9s/bbgo/pkg/fixedpoint"
@@ -18,6 +19,10 @@ import (
 	"github.com/c9s/bbgo/pkg/types"
 )
 
+var WebSocketURL = "wss://stream.binance.com:9443/ws"
+
+var FuturesWebSocketURL = "wss://fstream.binance.com/ws"
+
 var debugBinanceDepth bool
 
 func init() {
@@ -47,13 +52,19 @@ type StreamRequest struct {
 //go:generate callbackgen -type Stream -interface
 type Stream struct {
 	types.MarginSettings
+	types.FuturesSettings
 
 	types.StandardStream
 
-	Client    *binance.Client
-	ListenKey string
-	Conn      *websocket.Conn
-	connLock  sync.Mutex
+	Client        *binance.Client
+	FuturesClient *futures.Client
+	ListenKey     string
+	baseURL       string
+	Conn          *websocket.Conn
+	connLock      sync.Mutex
+
+	// futuresBaseURL is used instead of baseURL when the futures mode is enabled
+	futuresBaseURL string
 
 	publicOnly bool
 
@@ -61,22 +72,57 @@ type Stream struct {
 	depthEventCallbacks       []func(e *DepthEvent)
 	kLineEventCallbacks       []func(e *KLineEvent)
 	kLineClosedEventCallbacks []func(e *KLineEvent)
+	marketTradeEventCallbacks []func(e *MarketTradeEvent)
+	bookTickerEventCallbacks  []func(e *BookTickerEvent)
 
 	balanceUpdateEventCallbacks           []func(event *BalanceUpdateEvent)
 	outboundAccountInfoEventCallbacks     []func(event *OutboundAccountInfoEvent)
 	outboundAccountPositionEventCallbacks []func(event *OutboundAccountPositionEvent)
 	executionReportEventCallbacks         []func(event *ExecutionReportEvent)
 
-	depthFrames map[string]*DepthFrame
+	// futures user data stream callbacks
+	orderTradeUpdateEventCallbacks []func(event *OrderTradeUpdateEvent)
+	accountUpdateEventCallbacks    []func(event *AccountUpdateEvent)
+
+	positionUpdateCallbacks []func(positions []types.FuturesPosition)
+
+	// depthFrames is guarded by depthFramesMu since the book resync is requested from the stale checker
+	depthFrames   map[string]*DepthFrame
+	depthFramesMu sync.Mutex
+
+	bookSync *types.BookSynchronizer
+
+	// stateSyncer resyncs the orders, the trades and the balances after reconnecting the user data stream
+	stateSyncer *types.StreamStateSyncer
 }
 
-func NewStream(client *binance.Client) *Stream {
+func NewStream(client *binance.Client, baseURL, futuresBaseURL string) *Stream {
 	stream := &Stream{
-		Client:      client,
-		depthFrames: make(map[string]*DepthFrame),
+		Client:         client,
+		baseURL:        baseURL,
+		futuresBaseURL: futuresBaseURL,
+		depthFrames:    make(map[string]*DepthFrame),
 	}
 
+	stream.bookSync = types.NewBookSynchronizer(&stream.StandardStream)
+	stream.bookSync.OnResync(func(symbol string) {
+		stream.depthFramesMu.Lock()
+		f, ok := stream.depthFrames[symbol]
+		stream.depthFramesMu.Unlock()
+
+		if !ok {
+			return
+		}
+
+		// reload the snapshot from the restful api, the buffered events are filtered by the snapshot
+		go func() {
+			f.reset()
+			f.loadDepthSnapshot()
+		}()
+	})
+
 	stream.OnDepthEvent(func(e *DepthEvent) {
+		stream.depthFramesMu.Lock()
 		f, ok := stream.depthFrames[e.Symbol]
 		if !ok {
 			f = &DepthFrame{
@@ -85,7 +131,12 @@ func NewStream(client *binance.Client) *Stream {
 				Symbol:  e.Symbol,
 			}
 
+			if stream.IsFutures {
+				f.futuresClient = stream.FuturesClient
+			}
+
 			stream.depthFrames[e.Symbol] = f
+			stream.depthFramesMu.Unlock()
 
 			f.OnReady(func(e DepthEvent, bufEvents []DepthEvent) {
 				snapshot, err := e.OrderBook()
@@ -94,11 +145,7 @@ func NewStream(client *binance.Client) *Stream {
 					return
 				}
 
-				if valid, err := snapshot.IsValid(); !valid {
-					log.Warnf("depth snapshot is invalid, event: %+v, error: %v", e, err)
-				}
-
-				stream.EmitBookSnapshot(snapshot)
+				stream.bookSync.LoadSnapshot(snapshot, uint64(e.FinalUpdateID))
 
 				for _, e := range bufEvents {
 					book, err := e.OrderBook()
@@ -107,7 +154,7 @@ func NewStream(client *binance.Client) *Stream {
 						return
 					}
 
-					stream.EmitBookUpdate(book)
+					stream.bookSync.Update(book, uint64(e.FirstUpdateID), uint64(e.FinalUpdateID))
 				}
 			})
 
@@ -118,9 +165,15 @@ func NewStream(client *binance.Client) *Stream {
 					return
 				}
 
-				stream.EmitBookUpdate(book)
+				stream.bookSync.Update(book, uint64(e.FirstUpdateID), uint64(e.FinalUpdateID))
+			})
+
+			// the depth frame reloads the snapshot by itself when the next event is pushed
+			f.OnInvalid(func(err error) {
+				stream.bookSync.Invalidate(f.Symbol, err)
 			})
 		} else {
+			stream.depthFramesMu.Unlock()
 			f.PushEvent(*e)
 		}
 	})
@@ -149,6 +202,14 @@ func NewStream(client *binance.Client) *Stream {
 		}
 	})
 
+	stream.OnMarketTradeEvent(func(e *MarketTradeEvent) {
+		stream.EmitMarketTrade(e.Trade())
+	})
+
+	stream.OnBookTickerEvent(func(e *BookTickerEvent) {
+		stream.EmitBookTickerUpdate(e.BookTicker())
+	})
+
 	stream.OnExecutionReportEvent(func(e *ExecutionReportEvent) {
 		switch e.CurrentExecutionType {
 
@@ -172,16 +233,63 @@ func NewStream(client *binance.Client) *Stream {
 		}
 	})
 
+	stream.OnOrderTradeUpdateEvent(func(e *OrderTradeUpdateEvent) {
+		switch e.OrderTrade.CurrentExecutionType {
+
+		case "NEW", "CANCELED", "CALCULATED", "EXPIRED", "AMENDMENT":
+			order, err := e.Order()
+			if err != nil {
+				log.WithError(err).Error("order convert error")
+				return
+			}
+
+			stream.EmitOrderUpdate(*order)
+
+		case "TRADE":
+			trade, err := e.Trade()
+			if err != nil {
+				log.WithError(err).Error("trade convert error")
+				return
+			}
+
+			stream.EmitTradeUpdate(*trade)
+		}
+	})
+
+	stream.OnAccountUpdateEvent(func(e *AccountUpdateEvent) {
+		// the account update event only carries the wallet balances without the max withdraw amount,
+		// so the balances are queried to split the available and the locked balances the same way as QueryAccount
+		if len(e.AccountUpdate.Balances) > 0 {
+			go stream.syncFuturesBalances()
+		}
+
+		if len(e.AccountUpdate.Positions) > 0 {
+			positions, err := e.Positions()
+			if err != nil {
+				log.WithError(err).Error("position convert error")
+				return
+			}
+
+			stream.EmitPositionUpdate(positions)
+		}
+	})
+
 	stream.OnConnect(func() {
 		// reset the previous frames
+		stream.depthFramesMu.Lock()
 		for _, f := range stream.depthFrames {
 			f.reset()
 			f.loadDepthSnapshot()
 		}
+		stream.depthFramesMu.Unlock()
+
+		if !stream.publicOnly && stream.stateSyncer != nil {
+			stream.stateSyncer.HandleConnect()
+		}
 
 		var params []string
 		for _, subscription := range stream.Subscriptions {
-			params = append(params, convertSubscription(subscription))
+			params = append(params, stream.convertSubscription(subscription))
 		}
 
 		if len(params) == 0 {
@@ -208,11 +316,16 @@ func (s *Stream) SetPublicOnly() {
 }
 
 func (s *Stream) dial(listenKey string) (*websocket.Conn, error) {
+	var baseURL = s.baseURL
+	if s.IsFutures {
+		baseURL = s.futuresBaseURL
+	}
+
 	var url string
 	if s.publicOnly {
-		url = "wss://stream.binance.com:9443/ws"
+		url = baseURL
 	} else {
-		url = "wss://stream.binance.com:9443/ws/" + listenKey
+		url = baseURL + "/" + listenKey
 	}
 
 	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
@@ -226,7 +339,26 @@ func (s *Stream) dial(listenKey string) (*websocket.Conn, error) {
 	return conn, nil
 }
 
+// syncFuturesBalances queries the futures account and emits the balance snapshot
+func (s *Stream) syncFuturesBalances() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	account, err := s.FuturesClient.NewGetAccountService().Do(ctx)
+	if err != nil {
+		log.WithError(err).Error("futures account query error")
+		return
+	}
+
+	s.EmitBalanceSnapshot(toGlobalFuturesBalances(account.Assets))
+}
+
 func (s *Stream) fetchListenKey(ctx context.Context) (string, error) {
+	if s.IsFutures {
+		log.Infof("futures mode is enabled, requesting futures user stream listen key...")
+		return s.FuturesClient.NewStartUserStreamService().Do(ctx)
+	}
+
 	if s.IsMargin {
 		if s.IsIsolatedMargin {
 			log.Infof("isolated margin %s is enabled, requesting margin user stream listen key...", s.IsolatedMarginSymbol)
@@ -244,6 +376,10 @@ func (s *Stream) fetchListenKey(ctx context.Context) (string, error) {
 }
 
 func (s *Stream) keepaliveListenKey(ctx context.Context, listenKey string) error {
+	if s.IsFutures {
+		return s.FuturesClient.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(ctx)
+	}
+
 	if s.IsMargin {
 		if s.IsIsolatedMargin {
 			req := s.Client.NewKeepaliveIsolatedMarginUserStreamService().ListenKey(listenKey)
@@ -288,19 +424,28 @@ func (s *Stream) connect(ctx context.Context) error {
 	return nil
 }
 
-func convertSubscription(s types.Subscription) string {
+func (s *Stream) convertSubscription(sub types.Subscription) string {
 	// binance uses lower case symbol name,
 	// for kline, it's "<symbol>@kline_<interval>"
 	// for depth, it's "<symbol>@depth OR <symbol>@depth@100ms"
-	switch s.Channel {
+	// for market trade, it's "<symbol>@trade", the futures stream only provides "<symbol>@aggTrade"
+	// for book ticker, it's "<symbol>@bookTicker"
+	switch sub.Channel {
 	case types.KLineChannel:
-		return fmt.Sprintf("%s@%s_%s", strings.ToLower(s.Symbol), s.Channel, s.Options.String())
+		return fmt.Sprintf("%s@%s_%s", strings.ToLower(sub.Symbol), sub.Channel, sub.Options.String())
 
 	case types.BookChannel:
-		return fmt.Sprintf("%s@depth", strings.ToLower(s.Symbol))
+		return fmt.Sprintf("%s@depth", strings.ToLower(sub.Symbol))
+
+	case types.MarketTradeChannel:
+		if s.IsFutures {
+			return fmt.Sprintf("%s@aggTrade", strings.ToLower(sub.Symbol))
+		}
+
+		return fmt.Sprintf("%s@trade", strings.ToLower(sub.Symbol))
 	}
 
-	return fmt.Sprintf("%s@%s", strings.ToLower(s.Symbol), s.Channel)
+	return fmt.Sprintf("%s@%s", strings.ToLower(sub.Symbol), sub.Channel)
 }
 
 func (s *Stream) Connect(ctx context.Context) error {
@@ -310,6 +455,7 @@ func (s *Stream) Connect(ctx context.Context) error {
 	}
 
 	go s.read(ctx)
+	go s.bookSync.Run(ctx)
 
 	s.EmitStart()
 	return nil
@@ -425,9 +571,23 @@ func (s *Stream) read(ctx context.Context) {
 			case *DepthEvent:
 				s.EmitDepthEvent(e)
 
+			case *MarketTradeEvent:
+				s.EmitMarketTradeEvent(e)
+
+			case *BookTickerEvent:
+				s.EmitBookTickerEvent(e)
+
 			case *ExecutionReportEvent:
 				log.Info(e.Event, " ", e)
 				s.EmitExecutionReportEvent(e)
+
+			case *OrderTradeUpdateEvent:
+				log.Info(e.Event, " ", e.OrderTrade)
+				s.EmitOrderTradeUpdateEvent(e)
+
+			case *AccountUpdateEvent:
+				log.Info(e.Event, " ", e.AccountUpdate)
+				s.EmitAccountUpdateEvent(e)
 			}
 		}
 	}
@@ -437,7 +597,9 @@ func (s *Stream) invalidateListenKey(ctx context.Context, listenKey string) (err
 	// should use background context to invalidate the user stream
 	log.Info("closing listen key")
 
-	if s.IsMargin {
+	if s.IsFutures {
+		err = s.FuturesClient.NewCloseUserStreamService().ListenKey(listenKey).Do(ctx)
+	} else if s.IsMargin {
 		if s.IsIsolatedMargin {
 			req := s.Client.NewCloseIsolatedMarginUserStreamService().ListenKey(listenKey)
 			req.Symbol(s.IsolatedMarginSymbol)
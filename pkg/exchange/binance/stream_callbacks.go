@@ -2,7 +2,9 @@
 
 package binance
 
-import ()
+import (
+	"github.com/c9s/bbgo/pkg/types"
+)
 
 func (s *Stream) OnDepthEvent(cb func(e *DepthEvent)) {
 	s.depthEventCallbacks = append(s.depthEventCallbacks, cb)
@@ -34,6 +36,26 @@ func (s *Stream) EmitKLineClosedEvent(e *KLineEvent) {
 	}
 }
 
+func (s *Stream) OnMarketTradeEvent(cb func(e *MarketTradeEvent)) {
+	s.marketTradeEventCallbacks = append(s.marketTradeEventCallbacks, cb)
+}
+
+func (s *Stream) EmitMarketTradeEvent(e *MarketTradeEvent) {
+	for _, cb := range s.marketTradeEventCallbacks {
+		cb(e)
+	}
+}
+
+func (s *Stream) OnBookTickerEvent(cb func(e *BookTickerEvent)) {
+	s.bookTickerEventCallbacks = append(s.bookTickerEventCallbacks, cb)
+}
+
+func (s *Stream) EmitBookTickerEvent(e *BookTickerEvent) {
+	for _, cb := range s.bookTickerEventCallbacks {
+		cb(e)
+	}
+}
+
 func (s *Stream) OnBalanceUpdateEvent(cb func(event *BalanceUpdateEvent)) {
 	s.balanceUpdateEventCallbacks = append(s.balanceUpdateEventCallbacks, cb)
 }
@@ -74,6 +96,36 @@ func (s *Stream) EmitExecutionReportEvent(event *ExecutionReportEvent) {
 	}
 }
 
+func (s *Stream) OnOrderTradeUpdateEvent(cb func(event *OrderTradeUpdateEvent)) {
+	s.orderTradeUpdateEventCallbacks = append(s.orderTradeUpdateEventCallbacks, cb)
+}
+
+func (s *Stream) EmitOrderTradeUpdateEvent(event *OrderTradeUpdateEvent) {
+	for _, cb := range s.orderTradeUpdateEventCallbacks {
+		cb(event)
+	}
+}
+
+func (s *Stream) OnAccountUpdateEvent(cb func(event *AccountUpdateEvent)) {
+	s.accountUpdateEventCallbacks = append(s.accountUpdateEventCallbacks, cb)
+}
+
+func (s *Stream) EmitAccountUpdateEvent(event *AccountUpdateEvent) {
+	for _, cb := range s.accountUpdateEventCallbacks {
+		cb(event)
+	}
+}
+
+func (s *Stream) OnPositionUpdate(cb func(positions []types.FuturesPosition)) {
+	s.positionUpdateCallbacks = append(s.positionUpdateCallbacks, cb)
+}
+
+func (s *Stream) EmitPositionUpdate(positions []types.FuturesPosition) {
+	for _, cb := range s.positionUpdateCallbacks {
+		cb(positions)
+	}
+}
+
 type StreamEventHub interface {
 	OnDepthEvent(cb func(e *DepthEvent))
 
@@ -81,6 +133,10 @@ type StreamEventHub interface {
 
 	OnKLineClosedEvent(cb func(e *KLineEvent))
 
+	OnMarketTradeEvent(cb func(e *MarketTradeEvent))
+
+	OnBookTickerEvent(cb func(e *BookTickerEvent))
+
 	OnBalanceUpdateEvent(cb func(event *BalanceUpdateEvent))
 
 	OnOutboundAccountInfoEvent(cb func(event *OutboundAccountInfoEvent))
@@ -88,4 +144,10 @@ type StreamEventHub interface {
 	OnOutboundAccountPositionEvent(cb func(event *OutboundAccountPositionEvent))
 
 	OnExecutionReportEvent(cb func(event *ExecutionReportEvent))
+
+	OnOrderTradeUpdateEvent(cb func(event *OrderTradeUpdateEvent))
+
+	OnAccountUpdateEvent(cb func(event *AccountUpdateEvent))
+
+	OnPositionUpdate(cb func(positions []types.FuturesPosition))
 }
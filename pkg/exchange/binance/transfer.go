@@ -0,0 +1,159 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/datatype"
+	"github.com/c9s/bbgo/pkg/types"
+	"github.com/c9s/bbgo/pkg/util"
+)
+
+var _ types.ExchangeInternalTransferService = &Exchange{}
+
+// InternalTransfer transfers the asset between the spot, the cross margin, the isolated margin and the futures wallets.
+// The transfers between the spot wallet and the isolated margin wallets are made by the isolated margin transfer api
+// since the universal transfer api doesn't support them, the others are made by the universal transfer api.
+func (e *Exchange) InternalTransfer(ctx context.Context, asset string, amount float64, from, to types.TransferAccount) (*types.InternalTransfer, error) {
+	if from == to {
+		return nil, fmt.Errorf("can not transfer %s to the same wallet %s", asset, from)
+	}
+
+	fromType, fromSymbol, err := toLocalTransferAccount(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toType, toSymbol, err := toLocalTransferAccount(to)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("asset", asset)
+	params.Set("amount", util.FormatFloat(amount, -1))
+
+	endpoint := "/sapi/v1/asset/transfer"
+	switch {
+	case fromType == "MAIN" && toType == "ISOLATEDMARGIN":
+		endpoint = "/sapi/v1/margin/isolated/transfer"
+		params.Set("symbol", toSymbol)
+		params.Set("transFrom", "SPOT")
+		params.Set("transTo", "ISOLATED_MARGIN")
+
+	case fromType == "ISOLATEDMARGIN" && toType == "MAIN":
+		endpoint = "/sapi/v1/margin/isolated/transfer"
+		params.Set("symbol", fromSymbol)
+		params.Set("transFrom", "ISOLATED_MARGIN")
+		params.Set("transTo", "SPOT")
+
+	default:
+		params.Set("type", fromType+"_"+toType)
+		if fromSymbol != "" {
+			params.Set("fromSymbol", fromSymbol)
+		}
+		if toSymbol != "" {
+			params.Set("toSymbol", toSymbol)
+		}
+	}
+
+	var resp struct {
+		TranID int64 `json:"tranId"`
+	}
+
+	log.Infof("transferring %f %s from %s to %s", amount, asset, from, to)
+
+	submitTime := time.Now()
+	if err := e.doSignedRequest(ctx, http.MethodPost, endpoint, params, &resp); err != nil {
+		return nil, err
+	}
+
+	// binance only returns the transfer id, the status and the time are queried from the transfer history.
+	// the transfer is pending with the submit time if it's not in the history yet.
+	transfer := &types.InternalTransfer{
+		Exchange:   types.ExchangeBinance,
+		TransferID: strconv.FormatInt(resp.TranID, 10),
+		Asset:      asset,
+		Amount:     amount,
+		From:       from,
+		To:         to,
+		Status:     "pending",
+		Time:       datatype.Time(submitTime),
+	}
+
+	record, err := e.queryTransferRecord(ctx, endpoint, params, resp.TranID, submitTime)
+	if err != nil {
+		log.WithError(err).Warnf("can not query the status of the binance transfer %d", resp.TranID)
+	} else if record != nil {
+		transfer.Status = strings.ToLower(record.Status)
+		transfer.Time = datatype.Time(time.Unix(0, record.Timestamp*int64(time.Millisecond)))
+	}
+
+	return transfer, nil
+}
+
+type transferRecord struct {
+	// TranID is the transfer id of the universal transfer history
+	TranID int64 `json:"tranId"`
+
+	// TxID is the transfer id of the isolated margin transfer history
+	TxID int64 `json:"txId"`
+
+	// Status is one of PENDING, CONFIRMED and FAILED
+	Status    string `json:"status"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// queryTransferRecord queries the transfer history of the transfer endpoint with the transfer params since the given
+// time, nil is returned if the transfer is not found.
+func (e *Exchange) queryTransferRecord(ctx context.Context, endpoint string, transferParams url.Values, tranID int64, since time.Time) (*transferRecord, error) {
+	params := url.Values{}
+	for _, key := range []string{"asset", "symbol", "transFrom", "transTo", "type", "fromSymbol", "toSymbol"} {
+		if v := transferParams.Get(key); v != "" {
+			params.Set(key, v)
+		}
+	}
+
+	// the server time could be behind the local time
+	params.Set("startTime", strconv.FormatInt(since.Add(-time.Minute).UnixNano()/int64(time.Millisecond), 10))
+
+	var resp struct {
+		Rows []transferRecord `json:"rows"`
+	}
+
+	if err := e.doSignedRequest(ctx, http.MethodGet, endpoint, params, &resp); err != nil {
+		return nil, err
+	}
+
+	for _, row := range resp.Rows {
+		if row.TranID == tranID || row.TxID == tranID {
+			return &row, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// toLocalTransferAccount converts the transfer account to the account type of the universal transfer api,
+// the symbol is only returned for the isolated margin wallets.
+func toLocalTransferAccount(account types.TransferAccount) (accountType string, symbol string, err error) {
+	if symbol, ok := account.IsolatedMarginSymbol(); ok {
+		return "ISOLATEDMARGIN", symbol, nil
+	}
+
+	switch account {
+	case types.TransferAccountSpot, types.TransferAccountMain:
+		return "MAIN", "", nil
+	case types.TransferAccountMargin:
+		return "MARGIN", "", nil
+	case types.TransferAccountFutures:
+		return "UMFUTURE", "", nil
+	}
+
+	return "", "", fmt.Errorf("unsupported binance transfer account %s", account)
+}
@@ -0,0 +1,86 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func TestExchange_InternalTransfer(t *testing.T) {
+	server, e, requests := newMarginTestExchange(t, func(r marginRequest) (int, string) {
+		if r.Method == http.MethodPost {
+			return http.StatusOK, `{"tranId":13526853623}`
+		}
+
+		switch r.Params.Get("type") {
+		case "MAIN_MARGIN":
+			return http.StatusOK, `{"total":1,"rows":[{"asset":"USDT","amount":"100","type":"MAIN_MARGIN","status":"CONFIRMED","tranId":13526853623,"timestamp":1616000000000}]}`
+		case "":
+			return http.StatusOK, `{"total":1,"rows":[{"asset":"USDT","amount":"100","status":"PENDING","txId":13526853623,"timestamp":1616000000000,"transFrom":"SPOT","transTo":"ISOLATED_MARGIN"}]}`
+		default:
+			return http.StatusOK, `{"total":0,"rows":[]}`
+		}
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+
+	transfer, err := e.InternalTransfer(ctx, "USDT", 100.0, types.TransferAccountSpot, types.TransferAccountMargin)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "13526853623", transfer.TransferID)
+		assert.Equal(t, types.TransferAccountSpot, transfer.From)
+		assert.Equal(t, types.TransferAccountMargin, transfer.To)
+		assert.Equal(t, "confirmed", transfer.Status)
+		assert.Equal(t, int64(1616000000), transfer.Time.Time().Unix())
+	}
+
+	transfer, err = e.InternalTransfer(ctx, "USDT", 100.0, types.TransferAccountSpot, types.IsolatedMarginTransferAccount("BTCUSDT"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "pending", transfer.Status)
+		assert.Equal(t, int64(1616000000), transfer.Time.Time().Unix())
+	}
+
+	// the transfer is pending if it's not in the history yet
+	transfer, err = e.InternalTransfer(ctx, "USDT", 100.0, types.TransferAccountMargin, types.IsolatedMarginTransferAccount("BTCUSDT"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "pending", transfer.Status)
+	}
+
+	_, err = e.InternalTransfer(ctx, "USDT", 100.0, types.TransferAccountSpot, types.TransferAccount("savings"))
+	assert.Error(t, err)
+
+	if assert.Len(t, *requests, 6) {
+		req := (*requests)[0]
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/sapi/v1/asset/transfer", req.Path)
+		assert.Equal(t, "MAIN_MARGIN", req.Params.Get("type"))
+		assert.Equal(t, "USDT", req.Params.Get("asset"))
+		assert.Equal(t, "100", req.Params.Get("amount"))
+
+		req = (*requests)[1]
+		assert.Equal(t, http.MethodGet, req.Method)
+		assert.Equal(t, "/sapi/v1/asset/transfer", req.Path)
+		assert.Equal(t, "MAIN_MARGIN", req.Params.Get("type"))
+		assert.NotEmpty(t, req.Params.Get("startTime"))
+
+		req = (*requests)[2]
+		assert.Equal(t, "/sapi/v1/margin/isolated/transfer", req.Path)
+		assert.Equal(t, "BTCUSDT", req.Params.Get("symbol"))
+		assert.Equal(t, "SPOT", req.Params.Get("transFrom"))
+		assert.Equal(t, "ISOLATED_MARGIN", req.Params.Get("transTo"))
+
+		req = (*requests)[3]
+		assert.Equal(t, http.MethodGet, req.Method)
+		assert.Equal(t, "/sapi/v1/margin/isolated/transfer", req.Path)
+		assert.Equal(t, "BTCUSDT", req.Params.Get("symbol"))
+
+		req = (*requests)[4]
+		assert.Equal(t, "/sapi/v1/asset/transfer", req.Path)
+		assert.Equal(t, "MARGIN_ISOLATEDMARGIN", req.Params.Get("type"))
+		assert.Equal(t, "BTCUSDT", req.Params.Get("toSymbol"))
+	}
+}
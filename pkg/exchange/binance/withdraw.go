@@ -0,0 +1,47 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/datatype"
+	"github.com/c9s/bbgo/pkg/types"
+	"github.com/c9s/bbgo/pkg/util"
+)
+
+var _ types.ExchangeWithdrawService = &Exchange{}
+
+// Withdraw submits the withdrawal by the capital withdraw api, the withdraw ID returned by binance is set to the
+// WithdrawOrderID of the returned withdraw since the transaction ID is not known until the withdrawal is processed.
+func (e *Exchange) Withdraw(ctx context.Context, asset string, amount float64, address string, network string) (*types.Withdraw, error) {
+	params := url.Values{}
+	params.Set("coin", asset)
+	params.Set("address", address)
+	params.Set("amount", util.FormatFloat(amount, -1))
+	if network != "" {
+		params.Set("network", network)
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+
+	log.Infof("withdrawing %f %s to %s via network %q", amount, asset, address, network)
+
+	if err := e.doSignedRequest(ctx, http.MethodPost, "/sapi/v1/capital/withdraw/apply", params, &resp); err != nil {
+		return nil, err
+	}
+
+	return &types.Withdraw{
+		Exchange:        types.ExchangeBinance,
+		ApplyTime:       datatype.Time(time.Now()),
+		Asset:           asset,
+		Amount:          amount,
+		Address:         address,
+		Network:         network,
+		WithdrawOrderID: resp.ID,
+		Status:          "pending",
+	}, nil
+}
@@ -0,0 +1,37 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func TestExchange_Withdraw(t *testing.T) {
+	server, e, requests := newMarginTestExchange(t, func(r marginRequest) (int, string) {
+		return http.StatusOK, `{"id":"7213fea8e94b4a5593d507237e5a555b"}`
+	})
+	defer server.Close()
+
+	withdraw, err := e.Withdraw(context.Background(), "USDT", 100.5, "0xabc", "ETH")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, types.ExchangeBinance, withdraw.Exchange)
+	assert.Equal(t, "7213fea8e94b4a5593d507237e5a555b", withdraw.WithdrawOrderID)
+	assert.Equal(t, 100.5, withdraw.Amount)
+
+	if assert.Len(t, *requests, 1) {
+		req := (*requests)[0]
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/sapi/v1/capital/withdraw/apply", req.Path)
+		assert.Equal(t, "USDT", req.Params.Get("coin"))
+		assert.Equal(t, "0xabc", req.Params.Get("address"))
+		assert.Equal(t, "100.5", req.Params.Get("amount"))
+		assert.Equal(t, "ETH", req.Params.Get("network"))
+	}
+}
@@ -0,0 +1,820 @@
+package fakeserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+const (
+	binanceErrorCodeUnknownOrder      = -2011
+	binanceErrorCodeNewOrderRejected  = -2010
+	binanceErrorCodeInvalidSignature  = -1022
+	binanceErrorCodeInvalidAPIKey     = -2015
+	binanceErrorCodeInvalidListenKey  = -1125
+	binanceErrorCodeInvalidParameter  = -1102
+	binanceErrorCodeUnknownSymbol     = -1121
+	binanceErrorCodeUnsupportedMethod = -1000
+)
+
+// NewBinanceServer starts a fake Binance spot exchange server.
+// The Binance exchange connects to it when it's created by binance.NewWithEndpoints with BaseURL() and WebSocketURL().
+func NewBinanceServer(config Config) *Server {
+	s := newServer(config, types.ExchangeBinance, "")
+	s.start(&binanceProtocol{
+		server:     s,
+		orders:     make(map[uint64]types.Order),
+		listenKeys: make(map[string]struct{}),
+	})
+	return s
+}
+
+type binanceProtocol struct {
+	server *Server
+
+	mu sync.Mutex
+
+	// orders are the orders received from the order updates, they are used for building the trade execution reports
+	orders map[uint64]types.Order
+
+	listenKeys map[string]struct{}
+}
+
+type binanceError struct {
+	Code    int    `json:"code"`
+	Message string `json:"msg"`
+}
+
+type binanceOrder struct {
+	Symbol                   string `json:"symbol"`
+	OrderID                  int64  `json:"orderId"`
+	OrderListID              int64  `json:"orderListId"`
+	ClientOrderID            string `json:"clientOrderId"`
+	OrigClientOrderID        string `json:"origClientOrderId,omitempty"`
+	Price                    string `json:"price"`
+	OrigQuantity             string `json:"origQty"`
+	ExecutedQuantity         string `json:"executedQty"`
+	CummulativeQuoteQuantity string `json:"cummulativeQuoteQty"`
+	Status                   string `json:"status"`
+	TimeInForce              string `json:"timeInForce"`
+	Type                     string `json:"type"`
+	Side                     string `json:"side"`
+	StopPrice                string `json:"stopPrice"`
+	Time                     int64  `json:"time"`
+	UpdateTime               int64  `json:"updateTime"`
+	TransactTime             int64  `json:"transactTime,omitempty"`
+	IsWorking                bool   `json:"isWorking"`
+}
+
+type binanceTrade struct {
+	ID              int64  `json:"id"`
+	Symbol          string `json:"symbol"`
+	OrderID         int64  `json:"orderId"`
+	OrderListID     int64  `json:"orderListId"`
+	Price           string `json:"price"`
+	Quantity        string `json:"qty"`
+	QuoteQuantity   string `json:"quoteQty"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commissionAsset"`
+	Time            int64  `json:"time"`
+	IsBuyer         bool   `json:"isBuyer"`
+	IsMaker         bool   `json:"isMaker"`
+	IsBestMatch     bool   `json:"isBestMatch"`
+}
+
+type binanceBalance struct {
+	Asset  string `json:"asset"`
+	Free   string `json:"free"`
+	Locked string `json:"locked"`
+}
+
+// binanceStreamRequest is the subscribe and unsubscribe request sent by the client
+type binanceStreamRequest struct {
+	ID     int      `json:"id"`
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+}
+
+type binanceHandlerFunc func(w http.ResponseWriter, r *http.Request, params url.Values)
+
+func (p *binanceProtocol) routes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v3/ping", p.public(p.handlePing))
+	mux.HandleFunc("/api/v3/time", p.public(p.handleTime))
+	mux.HandleFunc("/api/v3/exchangeInfo", p.public(p.handleExchangeInfo))
+	mux.HandleFunc("/api/v3/ticker/24hr", p.public(p.handleTicker))
+	mux.HandleFunc("/api/v3/account", p.signed(p.handleAccount))
+	mux.HandleFunc("/api/v3/order", p.signed(p.handleOrder))
+	mux.HandleFunc("/api/v3/openOrders", p.signed(p.handleOpenOrders))
+	mux.HandleFunc("/api/v3/allOrders", p.signed(p.handleAllOrders))
+	mux.HandleFunc("/api/v3/myTrades", p.signed(p.handleMyTrades))
+	mux.HandleFunc("/api/v3/userDataStream", p.apiKey(p.handleUserDataStream))
+	mux.HandleFunc("/ws", p.handleWebSocket)
+	mux.HandleFunc("/ws/", p.handleWebSocket)
+}
+
+func (p *binanceProtocol) writeError(w http.ResponseWriter, status int, code int, err error) {
+	writeJSON(w, status, binanceError{Code: code, Message: err.Error()})
+}
+
+// readParams reads the query parameters and the form parameters,
+// the raw parameters without the signature are returned for verifying the signature.
+func (p *binanceProtocol) readParams(r *http.Request) (params url.Values, raw string, signature string, err error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	// the signature is always the last query parameter
+	query := r.URL.RawQuery
+	if idx := strings.LastIndex(query, "signature="); idx >= 0 {
+		signature = query[idx+len("signature="):]
+		query = strings.TrimSuffix(query[:idx], "&")
+	}
+
+	params, err = url.ParseQuery(query)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	for key, values := range form {
+		params[key] = append(params[key], values...)
+	}
+
+	return params, query + string(body), signature, nil
+}
+
+func (p *binanceProtocol) public(handler binanceHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params, _, _, err := p.readParams(r)
+		if err != nil {
+			p.writeError(w, http.StatusBadRequest, binanceErrorCodeInvalidParameter, err)
+			return
+		}
+
+		handler(w, r, params)
+	}
+}
+
+// apiKey checks the api key header of the user data stream endpoints
+func (p *binanceProtocol) apiKey(handler binanceHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-MBX-APIKEY") != p.server.config.APIKey {
+			p.writeError(w, http.StatusUnauthorized, binanceErrorCodeInvalidAPIKey, fmt.Errorf("invalid api-key, ip, or permissions for action"))
+			return
+		}
+
+		p.public(handler)(w, r)
+	}
+}
+
+// signed checks the api key header and the signature of the parameters
+func (p *binanceProtocol) signed(handler binanceHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-MBX-APIKEY") != p.server.config.APIKey {
+			p.writeError(w, http.StatusUnauthorized, binanceErrorCodeInvalidAPIKey, fmt.Errorf("invalid api-key, ip, or permissions for action"))
+			return
+		}
+
+		params, raw, signature, err := p.readParams(r)
+		if err != nil {
+			p.writeError(w, http.StatusBadRequest, binanceErrorCodeInvalidParameter, err)
+			return
+		}
+
+		if !verifySignature(raw, p.server.config.APISecret, signature) {
+			p.writeError(w, http.StatusUnauthorized, binanceErrorCodeInvalidSignature, fmt.Errorf("signature for this request is not valid"))
+			return
+		}
+
+		handler(w, r, params)
+	}
+}
+
+func (p *binanceProtocol) handlePing(w http.ResponseWriter, r *http.Request, params url.Values) {
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (p *binanceProtocol) handleTime(w http.ResponseWriter, r *http.Request, params url.Values) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"serverTime": milliseconds(time.Now())})
+}
+
+func (p *binanceProtocol) handleExchangeInfo(w http.ResponseWriter, r *http.Request, params url.Values) {
+	var symbols []map[string]interface{}
+	for _, symbol := range p.server.symbols() {
+		market := p.server.config.Markets[symbol]
+		symbols = append(symbols, map[string]interface{}{
+			"symbol":                 symbol,
+			"status":                 "TRADING",
+			"baseAsset":              market.BaseCurrency,
+			"baseAssetPrecision":     market.VolumePrecision,
+			"quoteAsset":             market.QuoteCurrency,
+			"quotePrecision":         market.PricePrecision,
+			"orderTypes":             []string{"LIMIT", "LIMIT_MAKER", "MARKET", "STOP_LOSS", "STOP_LOSS_LIMIT"},
+			"isSpotTradingAllowed":   true,
+			"isMarginTradingAllowed": false,
+			"filters": []map[string]interface{}{
+				{
+					"filterType": "PRICE_FILTER",
+					"minPrice":   formatFloat(market.MinPrice),
+					"maxPrice":   formatFloat(market.MaxPrice),
+					"tickSize":   formatFloat(market.TickSize),
+				},
+				{
+					"filterType": "LOT_SIZE",
+					"minQty":     formatFloat(market.MinQuantity),
+					"maxQty":     formatFloat(market.MaxQuantity),
+					"stepSize":   formatFloat(market.StepSize),
+				},
+				{
+					"filterType":    "MIN_NOTIONAL",
+					"minNotional":   formatFloat(market.MinNotional),
+					"applyToMarket": true,
+					"avgPriceMins":  5,
+				},
+			},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"timezone":        "UTC",
+		"serverTime":      milliseconds(time.Now()),
+		"rateLimits":      []interface{}{},
+		"exchangeFilters": []interface{}{},
+		"symbols":         symbols,
+	})
+}
+
+func (p *binanceProtocol) ticker(symbol string) map[string]interface{} {
+	now := time.Now()
+	price := formatFloat(p.server.source.price(symbol))
+	return map[string]interface{}{
+		"symbol":      symbol,
+		"lastPrice":   price,
+		"bidPrice":    price,
+		"askPrice":    price,
+		"openPrice":   price,
+		"highPrice":   price,
+		"lowPrice":    price,
+		"volume":      "0",
+		"quoteVolume": "0",
+		"openTime":    milliseconds(now.Add(-24 * time.Hour)),
+		"closeTime":   milliseconds(now),
+	}
+}
+
+func (p *binanceProtocol) handleTicker(w http.ResponseWriter, r *http.Request, params url.Values) {
+	if symbol := params.Get("symbol"); symbol != "" {
+		if _, ok := p.server.config.Markets[symbol]; !ok {
+			p.writeError(w, http.StatusBadRequest, binanceErrorCodeUnknownSymbol, fmt.Errorf("invalid symbol"))
+			return
+		}
+
+		writeJSON(w, http.StatusOK, p.ticker(symbol))
+		return
+	}
+
+	var tickers []map[string]interface{}
+	for _, symbol := range p.server.symbols() {
+		tickers = append(tickers, p.ticker(symbol))
+	}
+
+	writeJSON(w, http.StatusOK, tickers)
+}
+
+func (p *binanceProtocol) handleAccount(w http.ResponseWriter, r *http.Request, params url.Values) {
+	account, err := p.server.exchange.QueryAccount(context.Background())
+	if err != nil {
+		p.writeError(w, http.StatusInternalServerError, binanceErrorCodeInvalidParameter, err)
+		return
+	}
+
+	// both the commissions of the paper account and binance are in basis points
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"makerCommission":  int64(math.Round(account.MakerCommission.Float64())),
+		"takerCommission":  int64(math.Round(account.TakerCommission.Float64())),
+		"buyerCommission":  0,
+		"sellerCommission": 0,
+		"canTrade":         true,
+		"canWithdraw":      true,
+		"canDeposit":       true,
+		"updateTime":       milliseconds(time.Now()),
+		"accountType":      "SPOT",
+		"balances":         p.balances(account.Balances()),
+		"permissions":      []string{"SPOT"},
+	})
+}
+
+func (p *binanceProtocol) handleOrder(w http.ResponseWriter, r *http.Request, params url.Values) {
+	switch r.Method {
+	case http.MethodPost:
+		p.handleCreateOrder(w, r, params)
+	case http.MethodDelete:
+		p.handleCancelOrder(w, r, params)
+	case http.MethodGet:
+		order, ok := p.findOrder(params)
+		if !ok {
+			p.writeError(w, http.StatusBadRequest, binanceErrorCodeUnknownOrder, fmt.Errorf("order does not exist"))
+			return
+		}
+
+		writeJSON(w, http.StatusOK, p.order(order))
+	default:
+		p.writeError(w, http.StatusMethodNotAllowed, binanceErrorCodeUnsupportedMethod, fmt.Errorf("unsupported method %s", r.Method))
+	}
+}
+
+func (p *binanceProtocol) handleCreateOrder(w http.ResponseWriter, r *http.Request, params url.Values) {
+	symbol := params.Get("symbol")
+	market, ok := p.server.config.Markets[symbol]
+	if !ok {
+		p.writeError(w, http.StatusBadRequest, binanceErrorCodeUnknownSymbol, fmt.Errorf("invalid symbol"))
+		return
+	}
+
+	orderType, err := p.toGlobalOrderType(params.Get("type"))
+	if err != nil {
+		p.writeError(w, http.StatusBadRequest, binanceErrorCodeInvalidParameter, err)
+		return
+	}
+
+	clientOrderID := params.Get("newClientOrderId")
+	if clientOrderID == "" {
+		clientOrderID = uuid.New().String()
+	}
+
+	createdOrders, err := p.server.exchange.SubmitOrders(context.Background(), types.SubmitOrder{
+		ClientOrderID: clientOrderID,
+		Symbol:        symbol,
+		Side:          types.SideType(params.Get("side")),
+		Type:          orderType,
+		Quantity:      parseFloat(params.Get("quantity")),
+		Price:         parseFloat(params.Get("price")),
+		StopPrice:     parseFloat(params.Get("stopPrice")),
+		TimeInForce:   params.Get("timeInForce"),
+		Market:        market,
+	})
+	if err != nil {
+		p.writeError(w, http.StatusBadRequest, binanceErrorCodeNewOrderRejected, err)
+		return
+	}
+
+	order := p.order(createdOrders[0])
+	order.TransactTime = order.UpdateTime
+	writeJSON(w, http.StatusOK, order)
+}
+
+// findOrder finds the order by the order ID or the original client order ID
+func (p *binanceProtocol) findOrder(params url.Values) (types.Order, bool) {
+	orderID, _ := strconv.ParseUint(params.Get("orderId"), 10, 64)
+	clientOrderID := params.Get("origClientOrderId")
+	return p.server.findOrder(params.Get("symbol"), func(o types.Order) bool {
+		if orderID > 0 {
+			return o.OrderID == orderID
+		}
+		return clientOrderID != "" && o.ClientOrderID == clientOrderID
+	})
+}
+
+func (p *binanceProtocol) handleCancelOrder(w http.ResponseWriter, r *http.Request, params url.Values) {
+	order, ok := p.findOrder(params)
+	if !ok || order.Status != types.OrderStatusNew {
+		p.writeError(w, http.StatusBadRequest, binanceErrorCodeUnknownOrder, fmt.Errorf("unknown order sent"))
+		return
+	}
+
+	canceledOrder, err := p.server.cancelOrder(order)
+	if err != nil {
+		p.writeError(w, http.StatusBadRequest, binanceErrorCodeUnknownOrder, err)
+		return
+	}
+
+	response := p.order(canceledOrder)
+	response.OrigClientOrderID = response.ClientOrderID
+	response.TransactTime = response.UpdateTime
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (p *binanceProtocol) handleOpenOrders(w http.ResponseWriter, r *http.Request, params url.Values) {
+	symbol := params.Get("symbol")
+	if _, ok := p.server.config.Markets[symbol]; symbol != "" && !ok {
+		p.writeError(w, http.StatusBadRequest, binanceErrorCodeUnknownSymbol, fmt.Errorf("invalid symbol"))
+		return
+	}
+
+	var orders = []binanceOrder{}
+	for _, o := range p.server.openOrders(symbol) {
+		orders = append(orders, p.order(o))
+	}
+
+	writeJSON(w, http.StatusOK, orders)
+}
+
+func (p *binanceProtocol) handleAllOrders(w http.ResponseWriter, r *http.Request, params url.Values) {
+	symbol := params.Get("symbol")
+	if _, ok := p.server.config.Markets[symbol]; !ok {
+		p.writeError(w, http.StatusBadRequest, binanceErrorCodeUnknownSymbol, fmt.Errorf("invalid symbol"))
+		return
+	}
+
+	orderID, _ := strconv.ParseInt(params.Get("orderId"), 10, 64)
+	startTime, _ := strconv.ParseInt(params.Get("startTime"), 10, 64)
+	endTime, _ := strconv.ParseInt(params.Get("endTime"), 10, 64)
+
+	var orders = []binanceOrder{}
+	for _, o := range append(p.server.closedOrders(symbol), p.server.openOrders(symbol)...) {
+		order := p.order(o)
+		if order.OrderID < orderID {
+			continue
+		}
+
+		if (startTime > 0 && order.Time < startTime) || (endTime > 0 && order.Time > endTime) {
+			continue
+		}
+
+		orders = append(orders, order)
+	}
+
+	writeJSON(w, http.StatusOK, orders)
+}
+
+func (p *binanceProtocol) handleMyTrades(w http.ResponseWriter, r *http.Request, params url.Values) {
+	symbol := params.Get("symbol")
+	if _, ok := p.server.config.Markets[symbol]; !ok {
+		p.writeError(w, http.StatusBadRequest, binanceErrorCodeUnknownSymbol, fmt.Errorf("invalid symbol"))
+		return
+	}
+
+	var options types.TradeQueryOptions
+
+	// the from ID of binance is inclusive
+	if fromID, _ := strconv.ParseInt(params.Get("fromId"), 10, 64); fromID > 0 {
+		options.LastTradeID = fromID - 1
+	}
+
+	if startTime, _ := strconv.ParseInt(params.Get("startTime"), 10, 64); startTime > 0 {
+		t := time.Unix(0, startTime*int64(time.Millisecond))
+		options.StartTime = &t
+	}
+
+	if endTime, _ := strconv.ParseInt(params.Get("endTime"), 10, 64); endTime > 0 {
+		t := time.Unix(0, endTime*int64(time.Millisecond))
+		options.EndTime = &t
+	}
+
+	options.Limit, _ = strconv.ParseInt(params.Get("limit"), 10, 64)
+
+	trades, err := p.server.exchange.QueryTrades(context.Background(), symbol, &options)
+	if err != nil {
+		p.writeError(w, http.StatusBadRequest, binanceErrorCodeInvalidParameter, err)
+		return
+	}
+
+	var binanceTrades = []binanceTrade{}
+	for _, t := range trades {
+		binanceTrades = append(binanceTrades, binanceTrade{
+			ID:              t.ID,
+			Symbol:          t.Symbol,
+			OrderID:         int64(t.OrderID),
+			OrderListID:     -1,
+			Price:           formatFloat(t.Price),
+			Quantity:        formatFloat(t.Quantity),
+			QuoteQuantity:   formatFloat(t.QuoteQuantity),
+			Commission:      formatFloat(t.Fee),
+			CommissionAsset: t.FeeCurrency,
+			Time:            milliseconds(t.Time.Time()),
+			IsBuyer:         t.IsBuyer,
+			IsMaker:         t.IsMaker,
+			IsBestMatch:     true,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, binanceTrades)
+}
+
+func (p *binanceProtocol) handleUserDataStream(w http.ResponseWriter, r *http.Request, params url.Values) {
+	if r.Method == http.MethodPost {
+		listenKey := strings.Replace(uuid.New().String()+uuid.New().String(), "-", "", -1)
+
+		p.mu.Lock()
+		p.listenKeys[listenKey] = struct{}{}
+		p.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, map[string]string{"listenKey": listenKey})
+		return
+	}
+
+	listenKey := params.Get("listenKey")
+	if !p.isListenKey(listenKey) {
+		p.writeError(w, http.StatusBadRequest, binanceErrorCodeInvalidListenKey, fmt.Errorf("this listenKey does not exist"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		// the listen keys never expire
+
+	case http.MethodDelete:
+		p.mu.Lock()
+		delete(p.listenKeys, listenKey)
+		p.mu.Unlock()
+
+		p.server.closeUserConnections(func(c *conn) bool {
+			return c.listenKey == listenKey
+		})
+
+	default:
+		p.writeError(w, http.StatusMethodNotAllowed, binanceErrorCodeUnsupportedMethod, fmt.Errorf("unsupported method %s", r.Method))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (p *binanceProtocol) isListenKey(listenKey string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.listenKeys[listenKey]
+	return ok
+}
+
+// handleWebSocket serves the public stream at /ws and the user data stream at /ws/<listenKey>
+func (p *binanceProtocol) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	listenKey := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/ws"), "/")
+	if listenKey != "" && !p.isListenKey(listenKey) {
+		p.writeError(w, http.StatusBadRequest, binanceErrorCodeInvalidListenKey, fmt.Errorf("this listenKey does not exist"))
+		return
+	}
+
+	p.server.serveWebSocket(w, r, listenKey, p.handleWebSocketMessage)
+}
+
+func (p *binanceProtocol) handleWebSocketMessage(c *conn, message []byte) {
+	var request binanceStreamRequest
+	if err := json.Unmarshal(message, &request); err != nil {
+		p.writeWebSocket(c, map[string]interface{}{"code": 3, "msg": err.Error(), "id": request.ID})
+		return
+	}
+
+	switch request.Method {
+	case "SUBSCRIBE":
+		for _, param := range request.Params {
+			c.subscribe(param)
+		}
+
+	case "UNSUBSCRIBE":
+		for _, param := range request.Params {
+			c.unsubscribe(param)
+		}
+
+	default:
+		p.writeWebSocket(c, map[string]interface{}{"code": 1, "msg": "unsupported method " + request.Method, "id": request.ID})
+		return
+	}
+
+	p.writeWebSocket(c, map[string]interface{}{"result": nil, "id": request.ID})
+}
+
+func (p *binanceProtocol) writeWebSocket(c *conn, message interface{}) {
+	if err := c.writeJSON(message); err != nil {
+		log.WithError(err).Warn("websocket write error")
+	}
+}
+
+func (p *binanceProtocol) klineMessage(kline types.KLine) (string, interface{}) {
+	key := fmt.Sprintf("%s@kline_%s", strings.ToLower(kline.Symbol), kline.Interval)
+	return key, map[string]interface{}{
+		"e": "kline",
+		"E": milliseconds(time.Now()),
+		"s": kline.Symbol,
+		"k": map[string]interface{}{
+			"t": milliseconds(kline.StartTime),
+			"T": milliseconds(kline.EndTime),
+			"s": kline.Symbol,
+			"i": string(kline.Interval),
+			"o": formatFloat(kline.Open),
+			"c": formatFloat(kline.Close),
+			"h": formatFloat(kline.High),
+			"l": formatFloat(kline.Low),
+			"v": formatFloat(kline.Volume),
+			"q": formatFloat(kline.QuoteVolume),
+			"V": "0",
+			"Q": "0",
+			"n": 0,
+			"x": kline.Closed,
+		},
+	}
+}
+
+func (p *binanceProtocol) userMessages(event interface{}) []interface{} {
+	now := milliseconds(time.Now())
+	switch e := event.(type) {
+	case types.Order:
+		p.mu.Lock()
+		p.orders[e.OrderID] = e
+		p.mu.Unlock()
+
+		// the filled order is reported by the trade execution report
+		executionType := ""
+		switch e.Status {
+		case types.OrderStatusNew:
+			executionType = "NEW"
+		case types.OrderStatusCanceled:
+			executionType = "CANCELED"
+		case types.OrderStatusRejected:
+			executionType = "REJECTED"
+		default:
+			return nil
+		}
+
+		return []interface{}{p.executionReport(e, executionType, nil)}
+
+	case types.Trade:
+		p.mu.Lock()
+		order, ok := p.orders[e.OrderID]
+		p.mu.Unlock()
+
+		if !ok {
+			log.Warnf("order %d of the trade %d is not found", e.OrderID, e.ID)
+			return nil
+		}
+
+		order.Status = types.OrderStatusFilled
+		order.ExecutedQuantity = order.Quantity
+		return []interface{}{p.executionReport(order, "TRADE", &e)}
+
+	case types.BalanceMap:
+		return []interface{}{map[string]interface{}{
+			"e": "outboundAccountPosition",
+			"E": now,
+			"u": now,
+			"B": p.balanceUpdates(e),
+		}}
+	}
+
+	return nil
+}
+
+func (p *binanceProtocol) executionReport(o types.Order, executionType string, trade *types.Trade) map[string]interface{} {
+	report := map[string]interface{}{
+		"e": "executionReport",
+		"E": milliseconds(time.Now()),
+		"s": o.Symbol,
+		"c": o.ClientOrderID,
+		"S": string(o.Side),
+		"o": p.toLocalOrderType(o.Type),
+		"f": p.timeInForce(o),
+		"q": formatFloat(o.Quantity),
+		"p": formatFloat(o.Price),
+		"P": formatFloat(o.StopPrice),
+		"x": executionType,
+		"X": p.toLocalOrderStatus(o.Status),
+		"i": o.OrderID,
+		"l": "0",
+		"z": formatFloat(o.ExecutedQuantity),
+		"L": "0",
+		"n": "0",
+		"N": nil,
+		"T": milliseconds(o.UpdateTime.Time()),
+		"t": -1,
+		"w": o.Status == types.OrderStatusNew,
+		"m": false,
+		"O": milliseconds(o.CreationTime.Time()),
+		"Y": "0",
+	}
+
+	if trade != nil {
+		report["l"] = formatFloat(trade.Quantity)
+		report["L"] = formatFloat(trade.Price)
+		report["Y"] = formatFloat(trade.QuoteQuantity)
+		report["n"] = formatFloat(trade.Fee)
+		report["N"] = trade.FeeCurrency
+		report["T"] = milliseconds(trade.Time.Time())
+		report["t"] = trade.ID
+		report["m"] = trade.IsMaker
+	}
+
+	return report
+}
+
+func (p *binanceProtocol) order(o types.Order) binanceOrder {
+	_, quoteQuantity := p.server.averagePrice(o)
+	return binanceOrder{
+		Symbol:                   o.Symbol,
+		OrderID:                  int64(o.OrderID),
+		OrderListID:              -1,
+		ClientOrderID:            o.ClientOrderID,
+		Price:                    formatFloat(o.Price),
+		OrigQuantity:             formatFloat(o.Quantity),
+		ExecutedQuantity:         formatFloat(o.ExecutedQuantity),
+		CummulativeQuoteQuantity: formatFloat(quoteQuantity),
+		Status:                   p.toLocalOrderStatus(o.Status),
+		TimeInForce:              p.timeInForce(o),
+		Type:                     p.toLocalOrderType(o.Type),
+		Side:                     string(o.Side),
+		StopPrice:                formatFloat(o.StopPrice),
+		Time:                     milliseconds(o.CreationTime.Time()),
+		UpdateTime:               milliseconds(o.UpdateTime.Time()),
+		IsWorking:                o.IsWorking,
+	}
+}
+
+func (p *binanceProtocol) balances(balances types.BalanceMap) (binanceBalances []binanceBalance) {
+	for _, currency := range sortedCurrencies(balances) {
+		balance := balances[currency]
+		binanceBalances = append(binanceBalances, binanceBalance{
+			Asset:  currency,
+			Free:   formatFloat(balance.Available.Float64()),
+			Locked: formatFloat(balance.Locked.Float64()),
+		})
+	}
+
+	return binanceBalances
+}
+
+func (p *binanceProtocol) balanceUpdates(balances types.BalanceMap) (messages []map[string]interface{}) {
+	for _, balance := range p.balances(balances) {
+		messages = append(messages, map[string]interface{}{
+			"a": balance.Asset,
+			"f": balance.Free,
+			"l": balance.Locked,
+		})
+	}
+
+	return messages
+}
+
+func (p *binanceProtocol) timeInForce(o types.Order) string {
+	if o.TimeInForce != "" {
+		return o.TimeInForce
+	}
+
+	return "GTC"
+}
+
+func (p *binanceProtocol) toGlobalOrderType(orderType string) (types.OrderType, error) {
+	switch orderType {
+	case "MARKET":
+		return types.OrderTypeMarket, nil
+	case "LIMIT":
+		return types.OrderTypeLimit, nil
+	case "LIMIT_MAKER":
+		return types.OrderTypeLimitMaker, nil
+	case "STOP_LOSS_LIMIT":
+		return types.OrderTypeStopLimit, nil
+	case "STOP_LOSS":
+		return types.OrderTypeStopMarket, nil
+	}
+
+	return "", fmt.Errorf("order type %q is not supported", orderType)
+}
+
+func (p *binanceProtocol) toLocalOrderType(orderType types.OrderType) string {
+	switch orderType {
+	case types.OrderTypeMarket:
+		return "MARKET"
+	case types.OrderTypeLimit:
+		return "LIMIT"
+	case types.OrderTypeLimitMaker:
+		return "LIMIT_MAKER"
+	case types.OrderTypeStopLimit:
+		return "STOP_LOSS_LIMIT"
+	case types.OrderTypeStopMarket:
+		return "STOP_LOSS"
+	}
+
+	return string(orderType)
+}
+
+func (p *binanceProtocol) toLocalOrderStatus(status types.OrderStatus) string {
+	switch status {
+	case types.OrderStatusNew:
+		return "NEW"
+	case types.OrderStatusPartiallyFilled:
+		return "PARTIALLY_FILLED"
+	case types.OrderStatusFilled:
+		return "FILLED"
+	case types.OrderStatusCanceled:
+		return "CANCELED"
+	case types.OrderStatusRejected:
+		return "REJECTED"
+	}
+
+	return string(status)
+}
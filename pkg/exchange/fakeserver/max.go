@@ -0,0 +1,875 @@
+package fakeserver
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+const (
+	maxErrorCodeInvalidParameter = 2002
+	maxErrorCodeInvalidSignature = 2005
+	maxErrorCodeOrderNotFound    = 2011
+	maxErrorCodeOrderRejected    = 2016
+)
+
+// NewMaxServer starts a fake MAX exchange server.
+// The MAX exchange connects to it when it's created by max.NewWithEndpoints with BaseURL() and WebSocketURL().
+func NewMaxServer(config Config) *Server {
+	s := newServer(config, types.ExchangeMax, "/api/v2")
+	s.start(&maxProtocol{server: s})
+	return s
+}
+
+type maxProtocol struct {
+	server *Server
+}
+
+type maxError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type maxMarket struct {
+	ID                 string  `json:"id"`
+	Name               string  `json:"name"`
+	BaseUnit           string  `json:"base_unit"`
+	BaseUnitPrecision  int     `json:"base_unit_precision"`
+	QuoteUnit          string  `json:"quote_unit"`
+	QuoteUnitPrecision int     `json:"quote_unit_precision"`
+	MinBaseAmount      float64 `json:"min_base_amount"`
+	MinQuoteAmount     float64 `json:"min_quote_amount"`
+}
+
+type maxTicker struct {
+	At     int64  `json:"at"`
+	Buy    string `json:"buy"`
+	Sell   string `json:"sell"`
+	Open   string `json:"open"`
+	High   string `json:"high"`
+	Low    string `json:"low"`
+	Last   string `json:"last"`
+	Volume string `json:"vol"`
+}
+
+type maxAccount struct {
+	Currency string `json:"currency"`
+	Balance  string `json:"balance"`
+	Locked   string `json:"locked"`
+}
+
+type maxOrder struct {
+	ID              uint64 `json:"id"`
+	Side            string `json:"side"`
+	OrderType       string `json:"ord_type"`
+	Price           string `json:"price,omitempty"`
+	StopPrice       string `json:"stop_price,omitempty"`
+	AveragePrice    string `json:"avg_price"`
+	State           string `json:"state"`
+	Market          string `json:"market"`
+	Volume          string `json:"volume"`
+	RemainingVolume string `json:"remaining_volume"`
+	ExecutedVolume  string `json:"executed_volume"`
+	TradesCount     int    `json:"trades_count"`
+	GroupID         uint32 `json:"group_id,omitempty"`
+	ClientOID       string `json:"client_oid,omitempty"`
+	CreatedAtMs     int64  `json:"created_at_in_ms"`
+}
+
+type maxTrade struct {
+	ID                    int64        `json:"id"`
+	Price                 string       `json:"price"`
+	Volume                string       `json:"volume"`
+	Funds                 string       `json:"funds"`
+	Market                string       `json:"market"`
+	MarketName            string       `json:"market_name"`
+	CreatedAt             int64        `json:"created_at"`
+	CreatedAtMilliSeconds int64        `json:"created_at_in_ms"`
+	Side                  string       `json:"side"`
+	OrderID               uint64       `json:"order_id"`
+	Fee                   string       `json:"fee"`
+	FeeCurrency           string       `json:"fee_currency"`
+	Info                  maxTradeInfo `json:"info"`
+}
+
+type maxTradeInfo struct {
+	Maker string `json:"maker"`
+}
+
+type maxOrderParams struct {
+	Market    string `json:"market"`
+	Side      string `json:"side"`
+	OrderType string `json:"ord_type"`
+	Volume    string `json:"volume"`
+	Price     string `json:"price"`
+	StopPrice string `json:"stop_price"`
+	ClientOID string `json:"client_oid"`
+	GroupID   uint32 `json:"group_id"`
+}
+
+type maxMultiOrderResult struct {
+	Error string    `json:"error,omitempty"`
+	Order *maxOrder `json:"order,omitempty"`
+}
+
+// maxWebSocketCommand is the auth, subscribe and unsubscribe command sent by the client
+type maxWebSocketCommand struct {
+	Action        string                  `json:"action"`
+	APIKey        string                  `json:"apiKey"`
+	Nonce         int64                   `json:"nonce"`
+	Signature     string                  `json:"signature"`
+	ID            string                  `json:"id"`
+	Subscriptions []maxWebSocketSubscribe `json:"subscriptions"`
+}
+
+type maxWebSocketSubscribe struct {
+	Channel    string `json:"channel"`
+	Market     string `json:"market"`
+	Depth      int    `json:"depth,omitempty"`
+	Resolution string `json:"resolution,omitempty"`
+}
+
+type maxHandlerFunc func(w http.ResponseWriter, r *http.Request, payload []byte)
+
+func (p *maxProtocol) routes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v2/timestamp", p.handleTimestamp)
+	mux.HandleFunc("/api/v2/markets", p.handleMarkets)
+	mux.HandleFunc("/api/v2/tickers", p.handleTickers)
+	mux.HandleFunc("/api/v2/tickers/", p.handleTicker)
+	mux.HandleFunc("/api/v2/depth", p.handleDepth)
+	mux.HandleFunc("/api/v2/members/me", p.authenticated(p.handleMe))
+	mux.HandleFunc("/api/v2/members/accounts", p.authenticated(p.handleAccounts))
+	mux.HandleFunc("/api/v2/members/vip_level", p.authenticated(p.handleVipLevel))
+	mux.HandleFunc("/api/v2/orders", p.authenticated(p.handleOrders))
+	mux.HandleFunc("/api/v2/orders/multi/onebyone", p.authenticated(p.handleCreateMultiOrders))
+	mux.HandleFunc("/api/v2/orders/clear", p.authenticated(p.handleClearOrders))
+	mux.HandleFunc("/api/v2/order/delete", p.authenticated(p.handleCancelOrder))
+	mux.HandleFunc("/api/v2/trades/my", p.authenticated(p.handleMyTrades))
+	mux.HandleFunc("/ws", p.handleWebSocket)
+}
+
+func (p *maxProtocol) writeError(w http.ResponseWriter, status int, code int, err error) {
+	writeJSON(w, status, struct {
+		Error maxError `json:"error"`
+	}{Error: maxError{Code: code, Message: err.Error()}})
+}
+
+// authenticated verifies the signature of the payload header and passes the decoded payload to the handler
+func (p *maxProtocol) authenticated(handler maxHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		encoded := r.Header.Get("X-MAX-PAYLOAD")
+		if r.Header.Get("X-MAX-ACCESSKEY") != p.server.config.APIKey ||
+			!verifySignature(encoded, p.server.config.APISecret, r.Header.Get("X-MAX-SIGNATURE")) {
+			p.writeError(w, http.StatusUnauthorized, maxErrorCodeInvalidSignature, fmt.Errorf("signature is incorrect"))
+			return
+		}
+
+		payload, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			p.writeError(w, http.StatusBadRequest, maxErrorCodeInvalidParameter, err)
+			return
+		}
+
+		handler(w, r, payload)
+	}
+}
+
+func (p *maxProtocol) handleTimestamp(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, time.Now().Unix())
+}
+
+func (p *maxProtocol) handleMarkets(w http.ResponseWriter, r *http.Request) {
+	var markets []maxMarket
+	for _, symbol := range p.server.symbols() {
+		market := p.server.config.Markets[symbol]
+		markets = append(markets, maxMarket{
+			ID:                 strings.ToLower(symbol),
+			Name:               market.BaseCurrency + "/" + market.QuoteCurrency,
+			BaseUnit:           strings.ToLower(market.BaseCurrency),
+			BaseUnitPrecision:  market.VolumePrecision,
+			QuoteUnit:          strings.ToLower(market.QuoteCurrency),
+			QuoteUnitPrecision: market.PricePrecision,
+			MinBaseAmount:      market.MinQuantity,
+			MinQuoteAmount:     market.MinNotional,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, markets)
+}
+
+func (p *maxProtocol) ticker(symbol string) maxTicker {
+	price := formatFloat(p.server.source.price(symbol))
+	return maxTicker{
+		At:     time.Now().Unix(),
+		Buy:    price,
+		Sell:   price,
+		Open:   price,
+		High:   price,
+		Low:    price,
+		Last:   price,
+		Volume: "0",
+	}
+}
+
+func (p *maxProtocol) handleTickers(w http.ResponseWriter, r *http.Request) {
+	tickers := make(map[string]maxTicker)
+	for _, symbol := range p.server.symbols() {
+		tickers[strings.ToLower(symbol)] = p.ticker(symbol)
+	}
+
+	writeJSON(w, http.StatusOK, tickers)
+}
+
+func (p *maxProtocol) handleTicker(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(strings.TrimPrefix(r.URL.Path, "/api/v2/tickers/"))
+	if _, ok := p.server.config.Markets[symbol]; !ok {
+		p.writeError(w, http.StatusNotFound, maxErrorCodeInvalidParameter, fmt.Errorf("market %s not found", symbol))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p.ticker(symbol))
+}
+
+// handleDepth returns the book snapshot aggregated from the open orders of the market
+func (p *maxProtocol) handleDepth(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.URL.Query().Get("market"))
+	if _, ok := p.server.config.Markets[symbol]; !ok {
+		p.writeError(w, http.StatusNotFound, maxErrorCodeInvalidParameter, fmt.Errorf("market %s not found", symbol))
+		return
+	}
+
+	var book = types.OrderBook{Symbol: symbol}
+	for _, order := range p.server.openOrders(symbol) {
+		pv := types.PriceVolume{
+			Price:  fixedpoint.NewFromFloat(order.Price),
+			Volume: fixedpoint.NewFromFloat(order.Quantity - order.ExecutedQuantity),
+		}
+
+		switch order.Side {
+		case types.SideTypeBuy:
+			if _, idx := book.Bids.Find(pv.Price, true); idx < len(book.Bids) && book.Bids[idx].Price == pv.Price {
+				pv.Volume += book.Bids[idx].Volume
+			}
+			book.Bids = book.Bids.Upsert(pv, true)
+
+		case types.SideTypeSell:
+			if _, idx := book.Asks.Find(pv.Price, false); idx < len(book.Asks) && book.Asks[idx].Price == pv.Price {
+				pv.Volume += book.Asks[idx].Volume
+			}
+			book.Asks = book.Asks.Upsert(pv, false)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Timestamp int64      `json:"timestamp"`
+		Asks      [][]string `json:"asks"`
+		Bids      [][]string `json:"bids"`
+	}{
+		Timestamp: time.Now().Unix(),
+		Asks:      maxBookEntries(book.Asks),
+		Bids:      maxBookEntries(book.Bids),
+	})
+}
+
+func maxBookEntries(pvs types.PriceVolumeSlice) (entries [][]string) {
+	entries = [][]string{}
+	for _, pv := range pvs {
+		entries = append(entries, []string{formatFloat(pv.Price.Float64()), formatFloat(pv.Volume.Float64())})
+	}
+
+	return entries
+}
+
+func (p *maxProtocol) accounts() (accounts []maxAccount) {
+	balances := p.server.balances()
+
+	currencies := sortedCurrencies(balances)
+
+	for _, currency := range currencies {
+		balance := balances[currency]
+		accounts = append(accounts, maxAccount{
+			Currency: strings.ToLower(currency),
+			Balance:  formatFloat(balance.Available.Float64()),
+			Locked:   formatFloat(balance.Locked.Float64()),
+		})
+	}
+
+	return accounts
+}
+
+func (p *maxProtocol) handleMe(w http.ResponseWriter, r *http.Request, payload []byte) {
+	writeJSON(w, http.StatusOK, struct {
+		Sn       string       `json:"sn"`
+		Name     string       `json:"name"`
+		Accounts []maxAccount `json:"accounts"`
+	}{
+		Sn:       "FAKE",
+		Name:     "fake",
+		Accounts: p.accounts(),
+	})
+}
+
+func (p *maxProtocol) handleAccounts(w http.ResponseWriter, r *http.Request, payload []byte) {
+	writeJSON(w, http.StatusOK, p.accounts())
+}
+
+func (p *maxProtocol) handleVipLevel(w http.ResponseWriter, r *http.Request, payload []byte) {
+	account, err := p.server.exchange.QueryAccount(context.Background())
+	if err != nil {
+		p.writeError(w, http.StatusInternalServerError, maxErrorCodeInvalidParameter, err)
+		return
+	}
+
+	type vipLevelSettings struct {
+		Level    int     `json:"level"`
+		MakerFee float64 `json:"maker_fee"`
+		TakerFee float64 `json:"taker_fee"`
+	}
+
+	// the commissions of the paper account are in basis points
+	current := vipLevelSettings{
+		MakerFee: account.MakerCommission.Float64() / 10000.0,
+		TakerFee: account.TakerCommission.Float64() / 10000.0,
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Current vipLevelSettings `json:"current_vip_level"`
+		Next    vipLevelSettings `json:"next_vip_level"`
+	}{Current: current, Next: current})
+}
+
+func (p *maxProtocol) handleOrders(w http.ResponseWriter, r *http.Request, payload []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		p.handleQueryOrders(w, r, payload)
+	case http.MethodPost:
+		p.handleCreateOrder(w, r, payload)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (p *maxProtocol) handleQueryOrders(w http.ResponseWriter, r *http.Request, payload []byte) {
+	var params struct {
+		Market  string          `json:"market"`
+		State   json.RawMessage `json:"state"`
+		GroupID uint32          `json:"group_id"`
+	}
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		p.writeError(w, http.StatusBadRequest, maxErrorCodeInvalidParameter, err)
+		return
+	}
+
+	// the state could be a string or a list of strings, the open orders are returned by default
+	var states []string
+	if len(params.State) > 0 {
+		var state string
+		if err := json.Unmarshal(params.State, &state); err == nil {
+			states = []string{state}
+		} else if err := json.Unmarshal(params.State, &states); err != nil {
+			p.writeError(w, http.StatusBadRequest, maxErrorCodeInvalidParameter, err)
+			return
+		}
+	}
+
+	if len(states) == 0 {
+		states = []string{"wait", "convert"}
+	}
+
+	var orders = []maxOrder{}
+	for _, o := range append(p.server.openOrders(""), p.server.closedOrders("")...) {
+		if params.Market != "" && o.Symbol != strings.ToUpper(params.Market) {
+			continue
+		}
+
+		if params.GroupID > 0 && o.GroupID != params.GroupID {
+			continue
+		}
+
+		order := p.order(o)
+		for _, state := range states {
+			if order.State == state {
+				orders = append(orders, order)
+				break
+			}
+		}
+	}
+
+	// the orders are sorted in the descending order by default
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].ID > orders[j].ID
+	})
+
+	writeJSON(w, http.StatusOK, orders)
+}
+
+func (p *maxProtocol) submitOrder(params maxOrderParams) (*maxOrder, error) {
+	symbol := strings.ToUpper(params.Market)
+	market, ok := p.server.config.Markets[symbol]
+	if !ok {
+		return nil, fmt.Errorf("market %s not found", params.Market)
+	}
+
+	orderType, err := p.toGlobalOrderType(params.OrderType)
+	if err != nil {
+		return nil, err
+	}
+
+	createdOrders, err := p.server.exchange.SubmitOrders(context.Background(), types.SubmitOrder{
+		ClientOrderID: params.ClientOID,
+		Symbol:        symbol,
+		Side:          p.toGlobalSideType(params.Side),
+		Type:          orderType,
+		Quantity:      parseFloat(params.Volume),
+		Price:         parseFloat(params.Price),
+		StopPrice:     parseFloat(params.StopPrice),
+		Market:        market,
+		GroupID:       params.GroupID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	order := p.order(createdOrders[0])
+	return &order, nil
+}
+
+func (p *maxProtocol) handleCreateOrder(w http.ResponseWriter, r *http.Request, payload []byte) {
+	var params maxOrderParams
+	if err := json.Unmarshal(payload, &params); err != nil {
+		p.writeError(w, http.StatusBadRequest, maxErrorCodeInvalidParameter, err)
+		return
+	}
+
+	order, err := p.submitOrder(params)
+	if err != nil {
+		p.writeError(w, http.StatusBadRequest, maxErrorCodeOrderRejected, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+func (p *maxProtocol) handleCreateMultiOrders(w http.ResponseWriter, r *http.Request, payload []byte) {
+	var params struct {
+		Market  string           `json:"market"`
+		GroupID uint32           `json:"group_id"`
+		Orders  []maxOrderParams `json:"orders"`
+	}
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		p.writeError(w, http.StatusBadRequest, maxErrorCodeInvalidParameter, err)
+		return
+	}
+
+	var results []maxMultiOrderResult
+	for _, orderParams := range params.Orders {
+		orderParams.Market = params.Market
+		if params.GroupID > 0 {
+			orderParams.GroupID = params.GroupID
+		}
+
+		order, err := p.submitOrder(orderParams)
+		if err != nil {
+			results = append(results, maxMultiOrderResult{Error: err.Error()})
+			continue
+		}
+
+		results = append(results, maxMultiOrderResult{Order: order})
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (p *maxProtocol) handleCancelOrder(w http.ResponseWriter, r *http.Request, payload []byte) {
+	var params struct {
+		ID        uint64 `json:"id"`
+		ClientOID string `json:"client_oid"`
+	}
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		p.writeError(w, http.StatusBadRequest, maxErrorCodeInvalidParameter, err)
+		return
+	}
+
+	order, ok := p.server.findOrder("", func(o types.Order) bool {
+		if params.ID > 0 {
+			return o.OrderID == params.ID
+		}
+		return params.ClientOID != "" && o.ClientOrderID == params.ClientOID
+	})
+	if !ok {
+		p.writeError(w, http.StatusNotFound, maxErrorCodeOrderNotFound, fmt.Errorf("order not found"))
+		return
+	}
+
+	canceledOrder, err := p.server.cancelOrder(order)
+	if err != nil {
+		p.writeError(w, http.StatusBadRequest, maxErrorCodeOrderNotFound, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p.order(canceledOrder))
+}
+
+func (p *maxProtocol) handleClearOrders(w http.ResponseWriter, r *http.Request, payload []byte) {
+	var params struct {
+		Market  string `json:"market"`
+		Side    string `json:"side"`
+		GroupID uint32 `json:"groupID"`
+	}
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		p.writeError(w, http.StatusBadRequest, maxErrorCodeInvalidParameter, err)
+		return
+	}
+
+	var orders = []maxOrder{}
+	for _, o := range p.server.openOrders("") {
+		if params.Market != "" && o.Symbol != strings.ToUpper(params.Market) {
+			continue
+		}
+
+		if params.Side != "" && o.Side != p.toGlobalSideType(params.Side) {
+			continue
+		}
+
+		if params.GroupID > 0 && o.GroupID != params.GroupID {
+			continue
+		}
+
+		canceledOrder, err := p.server.cancelOrder(o)
+		if err != nil {
+			log.WithError(err).Errorf("can not cancel order %d", o.OrderID)
+			continue
+		}
+
+		orders = append(orders, p.order(canceledOrder))
+	}
+
+	writeJSON(w, http.StatusOK, orders)
+}
+
+func (p *maxProtocol) handleMyTrades(w http.ResponseWriter, r *http.Request, payload []byte) {
+	var params struct {
+		Market  string `json:"market"`
+		From    int64  `json:"from"`
+		Limit   int64  `json:"limit"`
+		OrderBy string `json:"order_by"`
+	}
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		p.writeError(w, http.StatusBadRequest, maxErrorCodeInvalidParameter, err)
+		return
+	}
+
+	// the from trade ID is exclusive
+	trades, err := p.server.exchange.QueryTrades(context.Background(), strings.ToUpper(params.Market), &types.TradeQueryOptions{
+		LastTradeID: params.From,
+	})
+	if err != nil {
+		p.writeError(w, http.StatusBadRequest, maxErrorCodeInvalidParameter, err)
+		return
+	}
+
+	if params.OrderBy != "asc" {
+		sort.Slice(trades, func(i, j int) bool {
+			return trades[i].ID > trades[j].ID
+		})
+	}
+
+	if params.Limit > 0 && int64(len(trades)) > params.Limit {
+		trades = trades[:params.Limit]
+	}
+
+	var maxTrades = []maxTrade{}
+	for _, trade := range trades {
+		maxTrades = append(maxTrades, p.trade(trade))
+	}
+
+	writeJSON(w, http.StatusOK, maxTrades)
+}
+
+func (p *maxProtocol) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	p.server.serveWebSocket(w, r, "", p.handleWebSocketMessage)
+}
+
+func (p *maxProtocol) handleWebSocketMessage(c *conn, message []byte) {
+	var command maxWebSocketCommand
+	if err := json.Unmarshal(message, &command); err != nil {
+		p.writeWebSocketError(c, command.ID, err)
+		return
+	}
+
+	now := milliseconds(time.Now())
+	switch command.Action {
+	case "auth":
+		if command.APIKey != p.server.config.APIKey ||
+			!verifySignature(fmt.Sprintf("%d", command.Nonce), p.server.config.APISecret, command.Signature) {
+			p.writeWebSocketError(c, command.ID, fmt.Errorf("signature is incorrect"))
+			return
+		}
+
+		c.setUser(true)
+		p.writeWebSocket(c, map[string]interface{}{"e": "authenticated", "i": command.ID, "T": now})
+
+		// send the snapshots of the open orders and the balances after the authentication
+		var orders []interface{}
+		for _, o := range p.server.openOrders("") {
+			orders = append(orders, p.orderUpdate(o))
+		}
+
+		p.writeWebSocket(c, map[string]interface{}{"c": "user", "e": "order_snapshot", "o": orders, "T": now})
+		p.writeWebSocket(c, map[string]interface{}{"c": "user", "e": "account_snapshot", "B": p.balances(p.server.balances()), "T": now})
+
+	case "subscribe", "unsubscribe":
+		for _, subscription := range command.Subscriptions {
+			key := p.subscriptionKey(subscription.Channel, subscription.Market, subscription.Resolution)
+			if command.Action == "subscribe" {
+				c.subscribe(key)
+			} else {
+				c.unsubscribe(key)
+			}
+		}
+
+		p.writeWebSocket(c, map[string]interface{}{"e": command.Action + "d", "s": command.Subscriptions, "i": command.ID, "T": now})
+
+	default:
+		p.writeWebSocketError(c, command.ID, fmt.Errorf("unsupported action %q", command.Action))
+	}
+}
+
+func (p *maxProtocol) writeWebSocket(c *conn, message interface{}) {
+	if err := c.writeJSON(message); err != nil {
+		log.WithError(err).Warn("websocket write error")
+	}
+}
+
+func (p *maxProtocol) writeWebSocketError(c *conn, id string, err error) {
+	p.writeWebSocket(c, map[string]interface{}{
+		"e": "error",
+		"E": []string{err.Error()},
+		"i": id,
+		"T": milliseconds(time.Now()),
+	})
+}
+
+func (p *maxProtocol) subscriptionKey(channel, market, resolution string) string {
+	return channel + ":" + strings.ToLower(market) + ":" + resolution
+}
+
+func (p *maxProtocol) klineMessage(kline types.KLine) (string, interface{}) {
+	market := strings.ToLower(kline.Symbol)
+	return p.subscriptionKey("kline", market, string(kline.Interval)), map[string]interface{}{
+		"c": "kline",
+		"e": "update",
+		"M": market,
+		"T": milliseconds(time.Now()),
+		"k": map[string]interface{}{
+			"ST": milliseconds(kline.StartTime),
+			"ET": milliseconds(kline.EndTime),
+			"M":  market,
+			"R":  string(kline.Interval),
+			"O":  formatFloat(kline.Open),
+			"H":  formatFloat(kline.High),
+			"L":  formatFloat(kline.Low),
+			"C":  formatFloat(kline.Close),
+			"v":  formatFloat(kline.Volume),
+			"x":  kline.Closed,
+		},
+	}
+}
+
+func (p *maxProtocol) userMessages(event interface{}) []interface{} {
+	now := milliseconds(time.Now())
+	switch e := event.(type) {
+	case types.Order:
+		return []interface{}{map[string]interface{}{"c": "user", "e": "order_update", "o": []interface{}{p.orderUpdate(e)}, "T": now}}
+
+	case types.Trade:
+		return []interface{}{map[string]interface{}{"c": "user", "e": "trade_update", "t": []interface{}{p.tradeUpdate(e)}, "T": now}}
+
+	case types.BalanceMap:
+		return []interface{}{map[string]interface{}{"c": "user", "e": "account_update", "B": p.balances(e), "T": now}}
+	}
+
+	return nil
+}
+
+func (p *maxProtocol) order(o types.Order) maxOrder {
+	averagePrice, _ := p.server.averagePrice(o)
+	order := maxOrder{
+		ID:              o.OrderID,
+		Side:            strings.ToLower(string(o.Side)),
+		OrderType:       p.toLocalOrderType(o.Type),
+		AveragePrice:    formatFloat(averagePrice),
+		State:           p.toLocalOrderState(o.Status),
+		Market:          strings.ToLower(o.Symbol),
+		Volume:          formatFloat(o.Quantity),
+		RemainingVolume: formatFloat(o.Quantity - o.ExecutedQuantity),
+		ExecutedVolume:  formatFloat(o.ExecutedQuantity),
+		TradesCount:     len(p.server.orderTrades(o)),
+		GroupID:         o.GroupID,
+		ClientOID:       o.ClientOrderID,
+		CreatedAtMs:     milliseconds(o.CreationTime.Time()),
+	}
+
+	if o.Price > 0 {
+		order.Price = formatFloat(o.Price)
+	}
+
+	if o.StopPrice > 0 {
+		order.StopPrice = formatFloat(o.StopPrice)
+	}
+
+	return order
+}
+
+func (p *maxProtocol) orderUpdate(o types.Order) map[string]interface{} {
+	order := p.order(o)
+	return map[string]interface{}{
+		"i":  order.ID,
+		"sd": order.Side,
+		"ot": order.OrderType,
+		"p":  order.Price,
+		"sp": order.StopPrice,
+		"v":  order.Volume,
+		"ap": order.AveragePrice,
+		"S":  order.State,
+		"M":  order.Market,
+		"rv": order.RemainingVolume,
+		"ev": order.ExecutedVolume,
+		"tc": order.TradesCount,
+		"gi": order.GroupID,
+		"ci": order.ClientOID,
+		"T":  order.CreatedAtMs,
+	}
+}
+
+func (p *maxProtocol) trade(t types.Trade) maxTrade {
+	side, makerSide := "bid", "ask"
+	if t.Side == types.SideTypeSell {
+		side, makerSide = "ask", "bid"
+	}
+
+	if t.IsMaker {
+		makerSide = side
+	}
+
+	market := p.server.config.Markets[t.Symbol]
+	return maxTrade{
+		ID:                    t.ID,
+		Price:                 formatFloat(t.Price),
+		Volume:                formatFloat(t.Quantity),
+		Funds:                 formatFloat(t.QuoteQuantity),
+		Market:                strings.ToLower(t.Symbol),
+		MarketName:            market.BaseCurrency + "/" + market.QuoteCurrency,
+		CreatedAt:             t.Time.Time().Unix(),
+		CreatedAtMilliSeconds: milliseconds(t.Time.Time()),
+		Side:                  side,
+		OrderID:               t.OrderID,
+		Fee:                   formatFloat(t.Fee),
+		FeeCurrency:           strings.ToLower(t.FeeCurrency),
+		Info:                  maxTradeInfo{Maker: makerSide},
+	}
+}
+
+func (p *maxProtocol) tradeUpdate(t types.Trade) map[string]interface{} {
+	trade := p.trade(t)
+	return map[string]interface{}{
+		"i":  trade.ID,
+		"sd": trade.Side,
+		"p":  trade.Price,
+		"v":  trade.Volume,
+		"M":  trade.Market,
+		"f":  trade.Fee,
+		"fc": trade.FeeCurrency,
+		"T":  trade.CreatedAtMilliSeconds,
+		"oi": trade.OrderID,
+		"m":  t.IsMaker,
+	}
+}
+
+func (p *maxProtocol) balances(balances types.BalanceMap) (messages []map[string]interface{}) {
+	currencies := sortedCurrencies(balances)
+
+	for _, currency := range currencies {
+		balance := balances[currency]
+		messages = append(messages, map[string]interface{}{
+			"cu": strings.ToLower(currency),
+			"av": formatFloat(balance.Available.Float64()),
+			"l":  formatFloat(balance.Locked.Float64()),
+		})
+	}
+
+	return messages
+}
+
+func (p *maxProtocol) toGlobalSideType(side string) types.SideType {
+	switch strings.ToLower(side) {
+	case "buy", "bid":
+		return types.SideTypeBuy
+	case "sell", "ask":
+		return types.SideTypeSell
+	}
+
+	return types.SideType(side)
+}
+
+func (p *maxProtocol) toGlobalOrderType(orderType string) (types.OrderType, error) {
+	switch orderType {
+	case "market":
+		return types.OrderTypeMarket, nil
+	case "limit":
+		return types.OrderTypeLimit, nil
+	case "post_only":
+		return types.OrderTypeLimitMaker, nil
+	case "stop_limit":
+		return types.OrderTypeStopLimit, nil
+	case "stop_market":
+		return types.OrderTypeStopMarket, nil
+	}
+
+	return "", fmt.Errorf("order type %q is not supported", orderType)
+}
+
+func (p *maxProtocol) toLocalOrderType(orderType types.OrderType) string {
+	switch orderType {
+	case types.OrderTypeMarket:
+		return "market"
+	case types.OrderTypeLimit:
+		return "limit"
+	case types.OrderTypeLimitMaker:
+		return "post_only"
+	case types.OrderTypeStopLimit:
+		return "stop_limit"
+	case types.OrderTypeStopMarket:
+		return "stop_market"
+	}
+
+	return string(orderType)
+}
+
+func (p *maxProtocol) toLocalOrderState(status types.OrderStatus) string {
+	switch status {
+	case types.OrderStatusNew, types.OrderStatusPartiallyFilled:
+		return "wait"
+	case types.OrderStatusFilled:
+		return "done"
+	case types.OrderStatusCanceled:
+		return "cancel"
+	case types.OrderStatusRejected:
+		return "failed"
+	}
+
+	return string(status)
+}
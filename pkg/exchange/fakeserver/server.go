@@ -0,0 +1,494 @@
+package fakeserver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+
+	"github.com/c9s/bbgo/pkg/datatype"
+	"github.com/c9s/bbgo/pkg/exchange/paper"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+var log = logrus.WithField("component", "fakeserver")
+
+// Config defines the markets and the account of the fake exchange server
+type Config struct {
+	// APIKey and APISecret are the credentials accepted by the server
+	APIKey    string
+	APISecret string
+
+	Markets types.MarketMap
+
+	// Account defines the initial balances and the fee rates of the account
+	Account paper.AccountConfig
+}
+
+// protocol implements the REST API and the websocket messages of an exchange
+type protocol interface {
+	// routes registers the REST API and the websocket endpoints
+	routes(mux *http.ServeMux)
+
+	// userMessages converts the order update, the trade update or the balance update into the user data messages
+	userMessages(event interface{}) []interface{}
+
+	// klineMessage converts the kline into the websocket message and returns the subscription key of the kline
+	klineMessage(kline types.KLine) (string, interface{})
+}
+
+// Server is a local exchange server for the integration tests of the exchange adapters.
+// The submitted orders are matched by the paper exchange with the prices set by SetPrice,
+// and the order updates, the trade updates and the balance updates are pushed to the authenticated websocket connections.
+type Server struct {
+	*httptest.Server
+
+	config   Config
+	basePath string
+	protocol protocol
+
+	source   *sourceExchange
+	exchange *paper.Exchange
+
+	mu    sync.Mutex
+	conns map[*conn]struct{}
+}
+
+func newServer(config Config, name types.ExchangeName, basePath string) *Server {
+	source := newSourceExchange(name, config.Markets)
+	return &Server{
+		config:   config,
+		basePath: basePath,
+		source:   source,
+		exchange: paper.New(source, config.Account),
+		conns:    make(map[*conn]struct{}),
+	}
+}
+
+func (s *Server) start(p protocol) {
+	s.protocol = p
+
+	// the paper stream emits the user data of the paper exchange
+	stream := s.exchange.NewStream()
+	stream.OnOrderUpdate(func(order types.Order) { s.pushUserEvent(order) })
+	stream.OnTradeUpdate(func(trade types.Trade) { s.pushUserEvent(trade) })
+	stream.OnBalanceUpdate(func(balances types.BalanceMap) { s.pushUserEvent(balances) })
+
+	mux := http.NewServeMux()
+	p.routes(mux)
+	s.Server = httptest.NewServer(mux)
+}
+
+// BaseURL returns the REST API base URL for the exchange client
+func (s *Server) BaseURL() string {
+	return s.URL + s.basePath
+}
+
+// WebSocketURL returns the websocket URL for the exchange stream
+func (s *Server) WebSocketURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + "/ws"
+}
+
+// SetPrice updates the last price of the symbol, matches the open orders with the new price
+// and pushes the closed 1m kline to the connections subscribed to it.
+func (s *Server) SetPrice(symbol string, price float64) {
+	kline := s.source.setPrice(symbol, price)
+	s.source.stream.EmitKLineClosed(kline)
+
+	key, message := s.protocol.klineMessage(kline)
+	s.broadcast(message, func(c *conn) bool {
+		return c.isSubscribed(key)
+	})
+}
+
+// CloseConnections drops all the websocket connections, it's used for testing the reconnection of the streams.
+func (s *Server) CloseConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.conns {
+		_ = c.Close()
+		delete(s.conns, c)
+	}
+}
+
+func (s *Server) Close() {
+	s.CloseConnections()
+	s.Server.Close()
+}
+
+func (s *Server) pushUserEvent(event interface{}) {
+	for _, message := range s.protocol.userMessages(event) {
+		s.broadcast(message, func(c *conn) bool {
+			return c.isUser()
+		})
+	}
+}
+
+func (s *Server) broadcast(message interface{}, filter func(c *conn) bool) {
+	s.mu.Lock()
+	var conns []*conn
+	for c := range s.conns {
+		if filter(c) {
+			conns = append(conns, c)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		if err := c.writeJSON(message); err != nil {
+			log.WithError(err).Warn("websocket write error")
+		}
+	}
+}
+
+var upgrader = websocket.Upgrader{}
+
+// serveWebSocket upgrades the connection and dispatches the received messages to the handler until the connection is closed
+// the connection is a user data connection if the listen key is given.
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request, listenKey string, handler func(c *conn, message []byte)) {
+	wsConn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.WithError(err).Error("websocket upgrade error")
+		return
+	}
+
+	c := &conn{
+		Conn:          wsConn,
+		user:          listenKey != "",
+		listenKey:     listenKey,
+		subscriptions: make(map[string]struct{}),
+	}
+
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		_ = c.Close()
+	}()
+
+	for {
+		mt, message, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if mt != websocket.TextMessage {
+			continue
+		}
+
+		handler(c, message)
+	}
+}
+
+// closeUserConnections closes the user data connections matching the filter
+func (s *Server) closeUserConnections(filter func(c *conn) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.conns {
+		if c.isUser() && filter(c) {
+			_ = c.Close()
+			delete(s.conns, c)
+		}
+	}
+}
+
+// symbols returns the symbols of the markets in order
+func (s *Server) symbols() []string {
+	var symbols []string
+	for symbol := range s.config.Markets {
+		symbols = append(symbols, symbol)
+	}
+
+	sort.Strings(symbols)
+	return symbols
+}
+
+// openOrders returns the open orders of the symbol, the open orders of all the markets are returned if the symbol is empty
+func (s *Server) openOrders(symbol string) (orders []types.Order) {
+	symbols := []string{symbol}
+	if symbol == "" {
+		symbols = s.symbols()
+	}
+
+	for _, symbol := range symbols {
+		openOrders, _ := s.exchange.QueryOpenOrders(context.Background(), symbol)
+		orders = append(orders, openOrders...)
+	}
+
+	return orders
+}
+
+// closedOrders returns the closed orders of the symbol, the closed orders of all the markets are returned if the symbol is empty
+func (s *Server) closedOrders(symbol string) (orders []types.Order) {
+	symbols := []string{symbol}
+	if symbol == "" {
+		symbols = s.symbols()
+	}
+
+	for _, symbol := range symbols {
+		closedOrders, _ := s.exchange.QueryClosedOrders(context.Background(), symbol, time.Time{}, time.Time{}, 0)
+		orders = append(orders, closedOrders...)
+	}
+
+	return orders
+}
+
+// findOrder finds the order matching the filter from the open orders and the closed orders
+func (s *Server) findOrder(symbol string, filter func(o types.Order) bool) (types.Order, bool) {
+	for _, o := range append(s.openOrders(symbol), s.closedOrders(symbol)...) {
+		if filter(o) {
+			return o, true
+		}
+	}
+
+	return types.Order{}, false
+}
+
+func (s *Server) cancelOrder(order types.Order) (types.Order, error) {
+	if err := s.exchange.CancelOrders(context.Background(), order); err != nil {
+		return order, err
+	}
+
+	order.Status = types.OrderStatusCanceled
+	order.IsWorking = false
+	order.UpdateTime = datatype.Time(time.Now())
+	return order, nil
+}
+
+func (s *Server) balances() types.BalanceMap {
+	balances, _ := s.exchange.QueryAccountBalances(context.Background())
+	return balances
+}
+
+// orderTrades returns the trades of the order
+func (s *Server) orderTrades(order types.Order) (trades []types.Trade) {
+	allTrades, _ := s.exchange.QueryTrades(context.Background(), order.Symbol, nil)
+	for _, trade := range allTrades {
+		if trade.OrderID == order.OrderID {
+			trades = append(trades, trade)
+		}
+	}
+
+	return trades
+}
+
+// averagePrice returns the average executed price and the executed quote quantity of the order
+func (s *Server) averagePrice(order types.Order) (price, quoteQuantity float64) {
+	var quantity float64
+	for _, trade := range s.orderTrades(order) {
+		quantity += trade.Quantity
+		quoteQuantity += trade.QuoteQuantity
+	}
+
+	if quantity > 0 {
+		price = quoteQuantity / quantity
+	}
+
+	return price, quoteQuantity
+}
+
+// sortedCurrencies returns the currencies of the balances in order
+func sortedCurrencies(balances types.BalanceMap) []string {
+	var currencies []string
+	for currency := range balances {
+		currencies = append(currencies, currency)
+	}
+
+	sort.Strings(currencies)
+	return currencies
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithError(err).Error("response encode error")
+	}
+}
+
+// conn is a websocket connection of the server
+type conn struct {
+	*websocket.Conn
+
+	writeMu sync.Mutex
+
+	mu   sync.Mutex
+	user bool
+
+	// listenKey is the listen key of the binance user data stream
+	listenKey string
+
+	subscriptions map[string]struct{}
+}
+
+func (c *conn) writeJSON(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.WriteJSON(v)
+}
+
+func (c *conn) setUser(user bool) {
+	c.mu.Lock()
+	c.user = user
+	c.mu.Unlock()
+}
+
+func (c *conn) isUser() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.user
+}
+
+func (c *conn) subscribe(key string) {
+	c.mu.Lock()
+	c.subscriptions[key] = struct{}{}
+	c.mu.Unlock()
+}
+
+func (c *conn) unsubscribe(key string) {
+	c.mu.Lock()
+	delete(c.subscriptions, key)
+	c.mu.Unlock()
+}
+
+func (c *conn) isSubscribed(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.subscriptions[key]
+	return ok
+}
+
+// sourceExchange provides the markets and the prices of the server to the paper exchange
+type sourceExchange struct {
+	types.Exchange
+
+	name    types.ExchangeName
+	markets types.MarketMap
+	stream  *sourceStream
+
+	mu     sync.Mutex
+	prices map[string]float64
+}
+
+func newSourceExchange(name types.ExchangeName, markets types.MarketMap) *sourceExchange {
+	return &sourceExchange{
+		name:    name,
+		markets: markets,
+		stream:  &sourceStream{},
+		prices:  make(map[string]float64),
+	}
+}
+
+func (e *sourceExchange) Name() types.ExchangeName {
+	return e.name
+}
+
+func (e *sourceExchange) NewStream() types.Stream {
+	return e.stream
+}
+
+func (e *sourceExchange) QueryMarkets(ctx context.Context) (types.MarketMap, error) {
+	return e.markets, nil
+}
+
+func (e *sourceExchange) QueryTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
+	price := e.price(symbol)
+	return &types.Ticker{
+		Time: time.Now(),
+		Open: price,
+		High: price,
+		Low:  price,
+		Last: price,
+		Buy:  price,
+		Sell: price,
+	}, nil
+}
+
+func (e *sourceExchange) price(symbol string) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.prices[symbol]
+}
+
+func (e *sourceExchange) setPrice(symbol string, price float64) types.KLine {
+	e.mu.Lock()
+	e.prices[symbol] = price
+	e.mu.Unlock()
+
+	startTime := time.Now().Truncate(time.Minute)
+	return types.KLine{
+		Exchange:  e.name.String(),
+		Symbol:    symbol,
+		Interval:  types.Interval1m,
+		StartTime: startTime,
+		EndTime:   startTime.Add(time.Minute - time.Millisecond),
+		Open:      price,
+		High:      price,
+		Low:       price,
+		Close:     price,
+		Closed:    true,
+	}
+}
+
+// sourceStream is the market data stream of the paper exchange, the klines are emitted by SetPrice
+type sourceStream struct {
+	types.StandardStream
+}
+
+func (s *sourceStream) SetPublicOnly() {}
+
+func (s *sourceStream) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *sourceStream) Close() error {
+	return nil
+}
+
+func sign(payload, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifySignature(payload, secret, signature string) bool {
+	return hmac.Equal([]byte(sign(payload, secret)), []byte(signature))
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func parseFloat(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+
+	return v
+}
+
+func milliseconds(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -2,6 +2,7 @@ package ftx
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -41,7 +42,7 @@ func toGlobalOrder(r order) (types.Order, error) {
 			Type:        types.OrderType(TrimUpperString(r.Type)),
 			Quantity:    r.Size,
 			Price:       r.Price,
-			TimeInForce: "GTC",
+			TimeInForce: timeInForceGTC,
 		},
 		Exchange:         types.ExchangeFTX.String(),
 		IsWorking:        r.Status == "open",
@@ -52,6 +53,14 @@ func toGlobalOrder(r order) (types.Order, error) {
 		UpdateTime:       datatype.Time(r.CreatedAt),
 	}
 
+	if r.PostOnly && o.Type == types.OrderTypeLimit {
+		o.Type = types.OrderTypeLimitMaker
+	}
+
+	if r.Ioc {
+		o.TimeInForce = timeInForceIOC
+	}
+
 	// `new` (accepted but not processed yet), `open`, or `closed` (filled or cancelled)
 	switch r.Status {
 	case "new":
@@ -77,6 +86,137 @@ func toGlobalOrder(r order) (types.Order, error) {
 	return o, nil
 }
 
+const (
+	timeInForceGTC = "GTC"
+	timeInForceIOC = "IOC"
+
+	// timeInForcePostOnly is the same as the LIMIT_MAKER order type
+	timeInForcePostOnly = "PO"
+)
+
+// toLocalOrderOptions maps the order type and the time in force to the FTX ioc and postOnly flags,
+// FTX doesn't support FOK orders.
+func toLocalOrderOptions(so types.SubmitOrder) (orderType string, ioc, postOnly bool, err error) {
+	switch so.Type {
+	case types.OrderTypeLimit, types.OrderTypeMarket:
+		orderType = TrimLowerString(string(so.Type))
+	case types.OrderTypeLimitMaker:
+		orderType = "limit"
+		postOnly = true
+	default:
+		return "", false, false, fmt.Errorf("unsupported order type %s", so.Type)
+	}
+
+	switch so.TimeInForce {
+	case "", timeInForceGTC:
+	case timeInForceIOC:
+		ioc = true
+	case timeInForcePostOnly:
+		postOnly = true
+	default:
+		return "", false, false, fmt.Errorf("unsupported time in force %s", so.TimeInForce)
+	}
+
+	if postOnly && (ioc || so.Type == types.OrderTypeMarket) {
+		return "", false, false, fmt.Errorf("post only can not be used with the %s %s order", so.TimeInForce, so.Type)
+	}
+
+	return orderType, ioc, postOnly, nil
+}
+
+// toLocalTriggerOrder converts the stop order to the FTX stop trigger order
+func toLocalTriggerOrder(so types.SubmitOrder) (PlaceTriggerOrderPayload, error) {
+	if so.StopPrice <= 0 {
+		return PlaceTriggerOrderPayload{}, fmt.Errorf("stop price is required for the %s order", so.Type)
+	}
+
+	p := PlaceTriggerOrderPayload{
+		Market:       toLocalSymbol(so.Symbol),
+		Side:         TrimLowerString(string(so.Side)),
+		TriggerPrice: so.StopPrice,
+		Type:         "stop",
+		Size:         so.Quantity,
+		ReduceOnly:   so.ReduceOnly,
+	}
+
+	switch so.Type {
+	case types.OrderTypeStopLimit:
+		if so.Price <= 0 {
+			return PlaceTriggerOrderPayload{}, fmt.Errorf("price is required for the %s order", so.Type)
+		}
+		p.OrderPrice = so.Price
+
+	case types.OrderTypeStopMarket:
+
+	default:
+		return PlaceTriggerOrderPayload{}, fmt.Errorf("unsupported trigger order type %s", so.Type)
+	}
+
+	// trigger orders are always GTC
+	switch so.TimeInForce {
+	case "", timeInForceGTC:
+	default:
+		return PlaceTriggerOrderPayload{}, fmt.Errorf("unsupported time in force %s for the %s order", so.TimeInForce, so.Type)
+	}
+
+	return p, nil
+}
+
+func isTriggerOrderType(t types.OrderType) bool {
+	return t == types.OrderTypeStopLimit || t == types.OrderTypeStopMarket
+}
+
+func toGlobalTriggerOrder(r triggerOrder) (types.Order, error) {
+	if r.Type != "stop" {
+		return types.Order{}, fmt.Errorf("unsupported trigger order type %s", r.Type)
+	}
+
+	o := types.Order{
+		SubmitOrder: types.SubmitOrder{
+			Symbol:      toGlobalSymbol(r.Market),
+			Side:        types.SideType(TrimUpperString(r.Side)),
+			Quantity:    r.Size,
+			Price:       r.OrderPrice,
+			StopPrice:   r.TriggerPrice,
+			TimeInForce: timeInForceGTC,
+		},
+		Exchange:         types.ExchangeFTX.String(),
+		IsWorking:        r.Status == "open",
+		OrderID:          uint64(r.ID),
+		ExecutedQuantity: r.FilledSize,
+		CreationTime:     datatype.Time(r.CreatedAt),
+		UpdateTime:       datatype.Time(r.CreatedAt),
+	}
+
+	switch r.OrderType {
+	case "limit":
+		o.Type = types.OrderTypeStopLimit
+	case "market":
+		o.Type = types.OrderTypeStopMarket
+	default:
+		return types.Order{}, fmt.Errorf("unsupported trigger order type %s", r.OrderType)
+	}
+
+	switch r.Status {
+	case "open":
+		o.Status = types.OrderStatusNew
+	case "cancelled":
+		o.Status = types.OrderStatusCanceled
+	case "triggered":
+		if fixedpoint.NewFromFloat(o.Quantity) == fixedpoint.NewFromFloat(o.ExecutedQuantity) {
+			o.Status = types.OrderStatusFilled
+		} else if o.ExecutedQuantity > 0 {
+			o.Status = types.OrderStatusPartiallyFilled
+		} else {
+			o.Status = types.OrderStatusNew
+		}
+	default:
+		return types.Order{}, fmt.Errorf("unsupported status %s: %w", r.Status, errUnsupportedOrderStatus)
+	}
+
+	return o, nil
+}
+
 func toGlobalDeposit(input depositHistory) (types.Deposit, error) {
 	s, err := toGlobalDepositStatus(input.Status)
 	if err != nil {
@@ -108,3 +248,113 @@ func toGlobalDepositStatus(input string) (types.DepositStatus, error) {
 	}
 	return "", fmt.Errorf("unsupported status %s", input)
 }
+
+func toGlobalInternalTransfer(input subAccountTransfer, from, to types.TransferAccount) types.InternalTransfer {
+	status := input.Status
+	if status == "complete" {
+		status = "completed"
+	}
+
+	return types.InternalTransfer{
+		Exchange:   types.ExchangeFTX,
+		TransferID: strconv.FormatInt(input.ID, 10),
+		Asset:      toGlobalCurrency(input.Coin),
+		Amount:     input.Size,
+		From:       from,
+		To:         to,
+		Status:     status,
+		Time:       datatype.Time(input.Time),
+	}
+}
+
+func toLocalSymbol(original string) string {
+	return TrimUpperString(original)
+}
+
+var supportedIntervals = map[types.Interval]int64{
+	types.Interval1m:  60,
+	types.Interval5m:  5 * 60,
+	types.Interval15m: 15 * 60,
+	types.Interval1h:  60 * 60,
+	types.Interval4h:  4 * 60 * 60,
+	types.Interval1d:  24 * 60 * 60,
+}
+
+// toLocalInterval converts the interval to the candle resolution in seconds
+func toLocalInterval(interval types.Interval) (int64, error) {
+	resolution, ok := supportedIntervals[interval]
+	if !ok {
+		return 0, fmt.Errorf("unsupported interval %s", interval)
+	}
+
+	return resolution, nil
+}
+
+func toGlobalKLine(symbol string, interval types.Interval, c candle) types.KLine {
+	endTime := c.StartTime.Add(interval.Duration() - time.Millisecond)
+
+	// FTX only returns the quote volume, the base volume is estimated by the close price
+	var volume float64
+	if c.Close > 0 {
+		volume = c.Volume / c.Close
+	}
+
+	return types.KLine{
+		Exchange:    types.ExchangeFTX.String(),
+		Symbol:      symbol,
+		Interval:    interval,
+		StartTime:   c.StartTime,
+		EndTime:     endTime,
+		Open:        c.Open,
+		Close:       c.Close,
+		High:        c.High,
+		Low:         c.Low,
+		Volume:      volume,
+		QuoteVolume: c.Volume,
+		Closed:      endTime.Before(time.Now()),
+	}
+}
+
+func toGlobalTrade(f fill) (types.Trade, error) {
+	side := types.SideType(TrimUpperString(f.Side))
+	if side != types.SideTypeBuy && side != types.SideTypeSell {
+		return types.Trade{}, fmt.Errorf("unsupported trade side %s", f.Side)
+	}
+
+	return types.Trade{
+		ID:            f.ID,
+		OrderID:       f.OrderId,
+		Exchange:      types.ExchangeFTX.String(),
+		Price:         f.Price,
+		Quantity:      f.Size,
+		QuoteQuantity: f.Price * f.Size,
+		Symbol:        toGlobalSymbol(f.Market),
+		Side:          side,
+		IsBuyer:       side == types.SideTypeBuy,
+		IsMaker:       f.Liquidity == "maker",
+		Time:          datatype.Time(f.Time),
+		Fee:           f.Fee,
+		FeeCurrency:   toGlobalCurrency(f.FeeCurrency),
+	}, nil
+}
+
+// toGlobalTicker converts the market to the ticker. The market response only contains the 24h change and the 24h quote volume,
+// so the open price and the base volume are derived from them, and the high/low prices are not available.
+func toGlobalTicker(m market) types.Ticker {
+	ticker := types.Ticker{
+		Time: time.Now(),
+		Last: m.Last,
+		Buy:  m.Bid,
+		Sell: m.Ask,
+	}
+
+	if m.Change24h != -1 {
+		ticker.Open = m.Price / (1 + m.Change24h)
+	}
+
+	if m.Price > 0 {
+		ticker.Volume = m.QuoteVolume24h / m.Price
+	}
+
+	return ticker
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/c9s/bbgo/pkg/exchange/ratelimit"
 	"github.com/c9s/bbgo/pkg/fixedpoint"
 	"github.com/c9s/bbgo/pkg/types"
 )
@@ -17,10 +18,19 @@ import (
 const (
 	restEndpoint       = "https://ftx.com"
 	defaultHTTPTimeout = 15 * time.Second
+
+	defaultKLineLimit = 500
+	defaultTradeLimit = 500
 )
 
 var logger = logrus.WithField("exchange", "ftx")
 
+// restLimiter is shared by all the FTX rest requests, FTX allows 30 requests per second
+var restLimiter = ratelimit.Register("ftx", ratelimit.Config{
+	Weight:   30,
+	Interval: time.Second,
+})
+
 type Exchange struct {
 	key, secret  string
 	subAccount   string
@@ -41,7 +51,7 @@ func NewExchange(key, secret string, subAccount string) *Exchange {
 }
 
 func (e *Exchange) newRest() *restRequest {
-	r := newRestRequest(&http.Client{Timeout: defaultHTTPTimeout}, e.restEndpoint).Auth(e.key, e.secret)
+	r := newRestRequest(restLimiter.HTTPClient(&http.Client{Timeout: defaultHTTPTimeout}), e.restEndpoint).Auth(e.key, e.secret)
 	if len(e.subAccount) > 0 {
 		r.SubAccount(e.subAccount)
 	}
@@ -141,11 +151,110 @@ func (e *Exchange) QueryAccountBalances(ctx context.Context) (types.BalanceMap,
 }
 
 func (e *Exchange) QueryKLines(ctx context.Context, symbol string, interval types.Interval, options types.KLineQueryOptions) ([]types.KLine, error) {
-	panic("implement me")
+	resolution, err := toLocalInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := defaultKLineLimit
+	if options.Limit > 0 {
+		limit = options.Limit
+	}
+
+	// FTX returns the latest candles before the end time, so we have to set the end time when querying from the start time
+	var since, until time.Time
+	if options.StartTime != nil {
+		since = *options.StartTime
+		until = since.Add(time.Duration(limit) * interval.Duration())
+		if until.After(time.Now()) {
+			until = time.Now()
+		}
+	}
+	if options.EndTime != nil {
+		until = *options.EndTime
+	}
+
+	resp, err := e.newRest().HistoricalPrices(ctx, toLocalSymbol(symbol), resolution, limit, since, until)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("ftx returns querying kline failure")
+	}
+
+	var klines []types.KLine
+	for _, c := range resp.Result {
+		if options.StartTime != nil && c.StartTime.Before(*options.StartTime) {
+			continue
+		}
+		klines = append(klines, toGlobalKLine(symbol, interval, c))
+	}
+
+	return klines, nil
 }
 
+// QueryTrades queries the fills of the symbol. FTX doesn't support querying by the trade ID,
+// so the fills are queried by the time range page by page and filtered by the lastTradeID.
 func (e *Exchange) QueryTrades(ctx context.Context, symbol string, options *types.TradeQueryOptions) ([]types.Trade, error) {
-	panic("implement me")
+	var since time.Time
+	var until = time.Now()
+	var limit = defaultTradeLimit
+	var lastTradeID int64
+	if options != nil {
+		if options.StartTime != nil {
+			since = *options.StartTime
+		}
+		if options.EndTime != nil {
+			until = *options.EndTime
+		}
+		if options.Limit > 0 {
+			limit = int(options.Limit)
+		}
+		lastTradeID = options.LastTradeID
+	}
+
+	var trades []types.Trade
+	for {
+		resp, err := e.newRest().Fills(ctx, toLocalSymbol(symbol), since, until)
+		if err != nil {
+			return nil, err
+		}
+		if !resp.Success {
+			return nil, fmt.Errorf("ftx returns querying fills failure")
+		}
+
+		sort.Slice(resp.Result, func(i, j int) bool {
+			return resp.Result[i].ID < resp.Result[j].ID
+		})
+
+		var lastFill *fill
+		for i, f := range resp.Result {
+			if f.ID <= lastTradeID {
+				continue
+			}
+
+			trade, err := toGlobalTrade(f)
+			if err != nil {
+				return nil, err
+			}
+
+			trades = append(trades, trade)
+			if len(trades) >= limit {
+				return trades, nil
+			}
+
+			lastTradeID = f.ID
+			lastFill = &resp.Result[i]
+		}
+
+		// no new fills in this page
+		if lastFill == nil {
+			return trades, nil
+		}
+
+		// the start_time precision is second, the fills of the same second are filtered by the lastTradeID
+		since = lastFill.Time.Truncate(time.Second)
+	}
 }
 
 func (e *Exchange) QueryDepositHistory(ctx context.Context, asset string, since, until time.Time) (allDeposits []types.Deposit, err error) {
@@ -176,43 +285,100 @@ func (e *Exchange) QueryWithdrawHistory(ctx context.Context, asset string, since
 	panic("implement me")
 }
 
+// InternalTransfer transfers the asset between the sub-accounts, the transfer accounts are the sub-account names and
+// "main" is the main account. The api key of the main account is required to transfer from the other sub-accounts.
+func (e *Exchange) InternalTransfer(ctx context.Context, asset string, amount float64, from, to types.TransferAccount) (*types.InternalTransfer, error) {
+	if from == to {
+		return nil, fmt.Errorf("can not transfer %s to the same sub-account %s", asset, from)
+	}
+
+	resp, err := e.newRest().SubAccountTransfer(ctx, TrimUpperString(asset), amount, string(from), string(to))
+	if err != nil {
+		return nil, err
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("ftx returns sub-account transfer failure")
+	}
+
+	transfer := toGlobalInternalTransfer(resp.Result, from, to)
+	return &transfer, nil
+}
+
 func (e *Exchange) SubmitOrders(ctx context.Context, orders ...types.SubmitOrder) (types.OrderSlice, error) {
 	var createdOrders types.OrderSlice
-	// TODO: currently only support limit and market order
-	// TODO: support time in force
 	for _, so := range orders {
-		if so.TimeInForce != "GTC" {
-			return createdOrders, fmt.Errorf("unsupported TimeInForce %s. only support GTC", so.TimeInForce)
-		}
-		or, err := e.newRest().PlaceOrder(ctx, PlaceOrderPayload{
-			Market:     TrimUpperString(so.Symbol),
-			Side:       TrimLowerString(string(so.Side)),
-			Price:      so.Price,
-			Type:       TrimLowerString(string(so.Type)),
-			Size:       so.Quantity,
-			ReduceOnly: false,
-			IOC:        false,
-			PostOnly:   false,
-			ClientID:   so.ClientOrderID,
-		})
-		if err != nil {
-			return createdOrders, fmt.Errorf("failed to place order %+v: %w", so, err)
-		}
-		if !or.Success {
-			return createdOrders, fmt.Errorf("ftx returns placing order failure")
+		var globalOrder types.Order
+		var err error
+		if isTriggerOrderType(so.Type) {
+			globalOrder, err = e.submitTriggerOrder(ctx, so)
+		} else {
+			globalOrder, err = e.submitOrder(ctx, so)
 		}
-		globalOrder, err := toGlobalOrder(or.Result)
 		if err != nil {
-			return createdOrders, fmt.Errorf("failed to convert response to global order")
+			return createdOrders, err
 		}
+
 		createdOrders = append(createdOrders, globalOrder)
 	}
 	return createdOrders, nil
 }
 
+func (e *Exchange) submitOrder(ctx context.Context, so types.SubmitOrder) (types.Order, error) {
+	orderType, ioc, postOnly, err := toLocalOrderOptions(so)
+	if err != nil {
+		return types.Order{}, err
+	}
+
+	or, err := e.newRest().PlaceOrder(ctx, PlaceOrderPayload{
+		Market:     toLocalSymbol(so.Symbol),
+		Side:       TrimLowerString(string(so.Side)),
+		Price:      so.Price,
+		Type:       orderType,
+		Size:       so.Quantity,
+		ReduceOnly: so.ReduceOnly,
+		IOC:        ioc,
+		PostOnly:   postOnly,
+		ClientID:   so.ClientOrderID,
+	})
+	if err != nil {
+		return types.Order{}, fmt.Errorf("failed to place order %+v: %w", so, err)
+	}
+	if !or.Success {
+		return types.Order{}, fmt.Errorf("ftx returns placing order failure")
+	}
+	globalOrder, err := toGlobalOrder(or.Result)
+	if err != nil {
+		return types.Order{}, fmt.Errorf("failed to convert response to global order")
+	}
+	return globalOrder, nil
+}
+
+// submitTriggerOrder places the stop-limit and stop-market orders as the FTX conditional orders,
+// note that FTX conditional orders don't support the client order id.
+func (e *Exchange) submitTriggerOrder(ctx context.Context, so types.SubmitOrder) (types.Order, error) {
+	payload, err := toLocalTriggerOrder(so)
+	if err != nil {
+		return types.Order{}, err
+	}
+
+	or, err := e.newRest().PlaceTriggerOrder(ctx, payload)
+	if err != nil {
+		return types.Order{}, fmt.Errorf("failed to place trigger order %+v: %w", so, err)
+	}
+	if !or.Success {
+		return types.Order{}, fmt.Errorf("ftx returns placing trigger order failure")
+	}
+	globalOrder, err := toGlobalTriggerOrder(or.Result)
+	if err != nil {
+		return types.Order{}, fmt.Errorf("failed to convert response to global order: %w", err)
+	}
+	return globalOrder, nil
+}
+
+// QueryOpenOrders queries the open orders and the open trigger orders
 func (e *Exchange) QueryOpenOrders(ctx context.Context, symbol string) (orders []types.Order, err error) {
-	// TODO: invoke open trigger orders
-	resp, err := e.newRest().OpenOrders(ctx, symbol)
+	resp, err := e.newRest().OpenOrders(ctx, toLocalSymbol(symbol))
 	if err != nil {
 		return nil, err
 	}
@@ -226,6 +392,23 @@ func (e *Exchange) QueryOpenOrders(ctx context.Context, symbol string) (orders [
 		}
 		orders = append(orders, o)
 	}
+
+	triggerResp, err := e.newRest().OpenTriggerOrders(ctx, toLocalSymbol(symbol))
+	if err != nil {
+		return nil, err
+	}
+	if !triggerResp.Success {
+		return nil, fmt.Errorf("ftx returns querying open trigger orders failure")
+	}
+	for _, r := range triggerResp.Result {
+		o, err := toGlobalTriggerOrder(r)
+		if err != nil {
+			// the trailing stop and take profit orders are not supported
+			logger.WithError(err).Warnf("skip trigger order %d", r.ID)
+			continue
+		}
+		orders = append(orders, o)
+	}
 	return orders, nil
 }
 
@@ -278,9 +461,16 @@ func sortByCreatedASC(orders []order) {
 	})
 }
 
+// CancelOrders cancels the orders, the stop orders are canceled by the trigger order id
 func (e *Exchange) CancelOrders(ctx context.Context, orders ...types.Order) error {
 	for _, o := range orders {
 		rest := e.newRest()
+		if isTriggerOrderType(o.Type) {
+			if _, err := rest.CancelTriggerOrder(ctx, o.OrderID); err != nil {
+				return err
+			}
+			continue
+		}
 		if len(o.ClientOrderID) > 0 {
 			if _, err := rest.CancelOrderByClientID(ctx, o.ClientOrderID); err != nil {
 				return err
@@ -294,12 +484,106 @@ func (e *Exchange) CancelOrders(ctx context.Context, orders ...types.Order) erro
 	return nil
 }
 
+func (e *Exchange) CancelAllOrders(ctx context.Context) ([]types.Order, error) {
+	return e.CancelOrdersBySymbol(ctx, "")
+}
+
+// CancelOrdersBySymbol cancels the open orders and the trigger orders of the symbol, all orders are canceled if the
+// symbol is empty. FTX doesn't return the canceled orders, so the open orders are queried before the cancellation.
+// FTX only queues the cancellation and the orders could be filled before they're canceled, so the statuses of the
+// returned orders are not updated, the order updates of the user data stream report the final statuses.
+func (e *Exchange) CancelOrdersBySymbol(ctx context.Context, symbol string) ([]types.Order, error) {
+	orders, err := e.QueryOpenOrders(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.newRest().CancelAllOrders(ctx, toLocalSymbol(symbol))
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("ftx returns canceling orders failure")
+	}
+
+	return orders, nil
+}
+
+// AmendOrder modifies the price and the quantity of the open order by the modify order api,
+// the trigger orders are submitted again and then the original orders are canceled since they can't be modified
+// by the same api, so the original order is kept if the amended order can't be submitted.
+func (e *Exchange) AmendOrder(ctx context.Context, order types.Order, price, quantity float64) (*types.Order, error) {
+	if isTriggerOrderType(order.Type) {
+		createdOrder, err := e.submitTriggerOrder(ctx, order.Amend(price, quantity))
+		if err != nil {
+			return nil, fmt.Errorf("failed to submit the amended trigger order of #%d, the original order is kept: %w", order.OrderID, err)
+		}
+
+		if err := e.CancelOrders(ctx, order); err != nil {
+			// the original order may be triggered already, cancel the amended order to avoid the duplicated position
+			if _, cancelErr := e.newRest().CancelTriggerOrder(ctx, createdOrder.OrderID); cancelErr != nil {
+				return nil, fmt.Errorf("failed to cancel the original trigger order #%d: %v, and failed to cancel the amended order #%d: %w",
+					order.OrderID, err, createdOrder.OrderID, cancelErr)
+			}
+			return nil, fmt.Errorf("failed to cancel the original trigger order #%d, the amended order #%d is canceled: %w",
+				order.OrderID, createdOrder.OrderID, err)
+		}
+		return &createdOrder, nil
+	}
+
+	or, err := e.newRest().ModifyOrder(ctx, order.OrderID, price, quantity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to modify order #%d: %w", order.OrderID, err)
+	}
+	if !or.Success {
+		return nil, fmt.Errorf("ftx returns modifying order failure")
+	}
+
+	globalOrder, err := toGlobalOrder(or.Result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert response to global order")
+	}
+	return &globalOrder, nil
+}
+
 func (e *Exchange) QueryTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
-	panic("implement me")
+	resp, err := e.newRest().Market(ctx, toLocalSymbol(symbol))
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("ftx returns querying ticker failure")
+	}
+
+	ticker := toGlobalTicker(resp.Result)
+	return &ticker, nil
 }
 
 func (e *Exchange) QueryTickers(ctx context.Context, symbol ...string) (map[string]types.Ticker, error) {
-	panic("implement me")
+	resp, err := e.newRest().Markets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("ftx returns querying tickers failure")
+	}
+
+	var selected = make(map[string]struct{})
+	for _, s := range symbol {
+		selected[toGlobalSymbol(s)] = struct{}{}
+	}
+
+	tickers := make(map[string]types.Ticker)
+	for _, m := range resp.Result {
+		globalSymbol := toGlobalSymbol(m.Name)
+		if _, ok := selected[globalSymbol]; len(selected) > 0 && !ok {
+			continue
+		}
+
+		tickers[globalSymbol] = toGlobalTicker(m)
+	}
+
+	return tickers, nil
 }
 
 func verifySinceUntil(since, until time.Time) error {
@@ -2,6 +2,7 @@ package ftx
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -88,9 +89,54 @@ func TestExchange_QueryOpenOrders(t *testing.T) {
     }
   ]
 }
+`
+	triggerOrdersResp := `
+{
+  "success": true,
+  "result": [
+    {
+      "createdAt": "2019-03-05T09:56:55.728933+00:00",
+      "error": null,
+      "future": "XRP-PERP",
+      "id": 50001,
+      "market": "XRP-PERP",
+      "orderId": null,
+      "orderPrice": 0.3,
+      "reduceOnly": false,
+      "side": "buy",
+      "size": 10,
+      "filledSize": 0,
+      "avgFillPrice": null,
+      "status": "open",
+      "triggerPrice": 0.31,
+      "orderType": "limit",
+      "type": "stop"
+    },
+    {
+      "createdAt": "2019-03-05T09:56:55.728933+00:00",
+      "future": "XRP-PERP",
+      "id": 50002,
+      "market": "XRP-PERP",
+      "side": "sell",
+      "size": 10,
+      "status": "open",
+      "triggerPrice": 0.29,
+      "orderType": "market",
+      "type": "trailing_stop"
+    }
+  ]
+}
 `
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintln(w, successResp)
+		assert.Equal(t, "XRP-PERP", r.URL.Query().Get("market"))
+		switch r.URL.Path {
+		case "/api/orders":
+			fmt.Fprintln(w, successResp)
+		case "/api/conditional_orders":
+			fmt.Fprintln(w, triggerOrdersResp)
+		default:
+			http.NotFound(w, r)
+		}
 	}))
 	defer ts.Close()
 
@@ -98,10 +144,317 @@ func TestExchange_QueryOpenOrders(t *testing.T) {
 	serverURL, err := url.Parse(ts.URL)
 	assert.NoError(t, err)
 	ex.restEndpoint = serverURL
-	resp, err := ex.QueryOpenOrders(context.Background(), "XRP-PREP")
+	resp, err := ex.QueryOpenOrders(context.Background(), "XRP-PERP")
 	assert.NoError(t, err)
-	assert.Len(t, resp, 1)
+	assert.Len(t, resp, 2)
 	assert.Equal(t, "XRP-PERP", resp[0].Symbol)
+	assert.Equal(t, types.OrderTypeLimit, resp[0].Type)
+
+	// the trailing stop order is skipped
+	assert.Equal(t, uint64(50001), resp[1].OrderID)
+	assert.Equal(t, types.OrderTypeStopLimit, resp[1].Type)
+	assert.Equal(t, types.SideTypeBuy, resp[1].Side)
+	assert.Equal(t, 0.3, resp[1].Price)
+	assert.Equal(t, 0.31, resp[1].StopPrice)
+	assert.Equal(t, types.OrderStatusNew, resp[1].Status)
+	assert.True(t, resp[1].IsWorking)
+}
+
+func TestExchange_SubmitOrders(t *testing.T) {
+	t.Run("stop limit order", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST", r.Method)
+			assert.Equal(t, "/api/conditional_orders", r.URL.Path)
+
+			var payload map[string]interface{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+			assert.Equal(t, "BTC-PERP", payload["market"])
+			assert.Equal(t, "sell", payload["side"])
+			assert.Equal(t, "stop", payload["type"])
+			assert.Equal(t, 9000.0, payload["triggerPrice"])
+			assert.Equal(t, 8990.0, payload["orderPrice"])
+			assert.Equal(t, 0.1, payload["size"])
+
+			fmt.Fprintln(w, `{"success": true, "result": {
+				"createdAt": "2021-03-05T09:56:55.728933+00:00", "future": "BTC-PERP", "id": 60001, "market": "BTC-PERP",
+				"orderPrice": 8990, "triggerPrice": 9000, "side": "sell", "size": 0.1, "filledSize": 0,
+				"status": "open", "type": "stop", "orderType": "limit"}}`)
+		}))
+		defer ts.Close()
+
+		ex := NewExchange("", "", "")
+		serverURL, err := url.Parse(ts.URL)
+		assert.NoError(t, err)
+		ex.restEndpoint = serverURL
+
+		orders, err := ex.SubmitOrders(context.Background(), types.SubmitOrder{
+			Symbol:    "BTC-PERP",
+			Side:      types.SideTypeSell,
+			Type:      types.OrderTypeStopLimit,
+			Quantity:  0.1,
+			Price:     8990,
+			StopPrice: 9000,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, orders, 1)
+		assert.Equal(t, uint64(60001), orders[0].OrderID)
+		assert.Equal(t, types.OrderTypeStopLimit, orders[0].Type)
+		assert.Equal(t, 9000.0, orders[0].StopPrice)
+	})
+
+	t.Run("stop market order", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/conditional_orders", r.URL.Path)
+
+			var payload map[string]interface{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+			assert.Equal(t, 9000.0, payload["triggerPrice"])
+			_, ok := payload["orderPrice"]
+			assert.False(t, ok)
+
+			fmt.Fprintln(w, `{"success": true, "result": {
+				"createdAt": "2021-03-05T09:56:55.728933+00:00", "future": "BTC-PERP", "id": 60002, "market": "BTC-PERP",
+				"triggerPrice": 9000, "side": "sell", "size": 0.1, "filledSize": 0,
+				"status": "open", "type": "stop", "orderType": "market"}}`)
+		}))
+		defer ts.Close()
+
+		ex := NewExchange("", "", "")
+		serverURL, err := url.Parse(ts.URL)
+		assert.NoError(t, err)
+		ex.restEndpoint = serverURL
+
+		orders, err := ex.SubmitOrders(context.Background(), types.SubmitOrder{
+			Symbol:    "BTC-PERP",
+			Side:      types.SideTypeSell,
+			Type:      types.OrderTypeStopMarket,
+			Quantity:  0.1,
+			StopPrice: 9000,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, orders, 1)
+		assert.Equal(t, types.OrderTypeStopMarket, orders[0].Type)
+	})
+
+	t.Run("ioc and post only", func(t *testing.T) {
+		var payloads []map[string]interface{}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/orders", r.URL.Path)
+
+			var payload map[string]interface{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+			payloads = append(payloads, payload)
+
+			fmt.Fprintf(w, `{"success": true, "result": {
+				"createdAt": "2021-03-05T09:56:55.728933+00:00", "future": "BTC-PERP", "id": 70001, "market": "BTC-PERP",
+				"price": 9000, "side": "buy", "size": 0.1, "filledSize": 0, "remainingSize": 0.1,
+				"status": "new", "type": "limit", "ioc": %v, "postOnly": %v}}`, payload["ioc"], payload["postOnly"])
+		}))
+		defer ts.Close()
+
+		ex := NewExchange("", "", "")
+		serverURL, err := url.Parse(ts.URL)
+		assert.NoError(t, err)
+		ex.restEndpoint = serverURL
+
+		orders, err := ex.SubmitOrders(context.Background(),
+			types.SubmitOrder{Symbol: "BTC-PERP", Side: types.SideTypeBuy, Type: types.OrderTypeLimit, Quantity: 0.1, Price: 9000, TimeInForce: "IOC"},
+			types.SubmitOrder{Symbol: "BTC-PERP", Side: types.SideTypeBuy, Type: types.OrderTypeLimitMaker, Quantity: 0.1, Price: 9000},
+			types.SubmitOrder{Symbol: "BTC-PERP", Side: types.SideTypeBuy, Type: types.OrderTypeLimit, Quantity: 0.1, Price: 9000, TimeInForce: "PO"},
+		)
+		assert.NoError(t, err)
+		assert.Len(t, orders, 3)
+		assert.Len(t, payloads, 3)
+
+		assert.Equal(t, true, payloads[0]["ioc"])
+		assert.Equal(t, false, payloads[0]["postOnly"])
+		assert.Equal(t, "IOC", orders[0].TimeInForce)
+
+		for i := 1; i < 3; i++ {
+			assert.Equal(t, "limit", payloads[i]["type"])
+			assert.Equal(t, false, payloads[i]["ioc"])
+			assert.Equal(t, true, payloads[i]["postOnly"])
+			assert.Equal(t, types.OrderTypeLimitMaker, orders[i].Type)
+		}
+	})
+
+	t.Run("unsupported time in force", func(t *testing.T) {
+		ex := NewExchange("", "", "")
+		_, err := ex.SubmitOrders(context.Background(),
+			types.SubmitOrder{Symbol: "BTC-PERP", Side: types.SideTypeBuy, Type: types.OrderTypeLimit, Quantity: 0.1, Price: 9000, TimeInForce: "FOK"})
+		assert.Error(t, err)
+	})
+}
+
+func TestExchange_CancelOrders(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		paths = append(paths, r.URL.Path)
+		fmt.Fprintln(w, `{"success": true, "result": "Order queued for cancelation"}`)
+	}))
+	defer ts.Close()
+
+	ex := NewExchange("", "", "")
+	serverURL, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	ex.restEndpoint = serverURL
+
+	err = ex.CancelOrders(context.Background(),
+		types.Order{SubmitOrder: types.SubmitOrder{Symbol: "BTC-PERP", Type: types.OrderTypeStopLimit}, OrderID: 60001},
+		types.Order{SubmitOrder: types.SubmitOrder{Symbol: "BTC-PERP", Type: types.OrderTypeLimit, ClientOrderID: "client-1"}, OrderID: 70001},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/api/conditional_orders/60001", "/api/orders/by_client_id"}, paths)
+}
+
+func TestExchange_CancelOrdersBySymbol(t *testing.T) {
+	var deletePayload map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/orders":
+			assert.Equal(t, "BTC-PERP", r.URL.Query().Get("market"))
+			fmt.Fprintln(w, `{"success": true, "result": [{
+				"createdAt": "2021-03-05T09:56:55.728933+00:00", "future": "BTC-PERP", "id": 70001, "market": "BTC-PERP",
+				"price": 9000, "side": "buy", "size": 0.1, "filledSize": 0, "remainingSize": 0.1,
+				"status": "open", "type": "limit", "ioc": false, "postOnly": false}]}`)
+
+		case r.Method == "GET" && r.URL.Path == "/api/conditional_orders":
+			fmt.Fprintln(w, `{"success": true, "result": []}`)
+
+		case r.Method == "DELETE" && r.URL.Path == "/api/orders":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&deletePayload))
+			fmt.Fprintln(w, `{"success": true, "result": "Orders queued for cancelation"}`)
+
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	ex := NewExchange("", "", "")
+	serverURL, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	ex.restEndpoint = serverURL
+
+	orders, err := ex.CancelOrdersBySymbol(context.Background(), "BTC-PERP")
+	assert.NoError(t, err)
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, uint64(70001), orders[0].OrderID)
+		// the cancellation is queued, the status is not asserted
+		assert.Equal(t, types.OrderStatusNew, orders[0].Status)
+	}
+	assert.Equal(t, "BTC-PERP", deletePayload["market"])
+}
+
+func TestExchange_AmendOrder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/orders/70001/modify", r.URL.Path)
+
+		var payload map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, 9100.0, payload["price"])
+		assert.Equal(t, 0.2, payload["size"])
+
+		fmt.Fprintln(w, `{"success": true, "result": {
+			"createdAt": "2021-03-05T09:56:55.728933+00:00", "future": "BTC-PERP", "id": 70002, "market": "BTC-PERP",
+			"price": 9100, "side": "buy", "size": 0.2, "filledSize": 0, "remainingSize": 0.2,
+			"status": "new", "type": "limit", "ioc": false, "postOnly": false}}`)
+	}))
+	defer ts.Close()
+
+	ex := NewExchange("", "", "")
+	serverURL, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	ex.restEndpoint = serverURL
+
+	order, err := ex.AmendOrder(context.Background(), types.Order{
+		SubmitOrder: types.SubmitOrder{Symbol: "BTC-PERP", Side: types.SideTypeBuy, Type: types.OrderTypeLimit, Quantity: 0.1, Price: 9000},
+		OrderID:     70001,
+	}, 9100, 0.2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(70002), order.OrderID)
+	assert.Equal(t, 9100.0, order.Price)
+	assert.Equal(t, 0.2, order.Quantity)
+}
+
+func TestExchange_AmendTriggerOrder(t *testing.T) {
+	triggerOrder := types.Order{
+		SubmitOrder: types.SubmitOrder{Symbol: "BTC-PERP", Side: types.SideTypeSell, Type: types.OrderTypeStopLimit, Quantity: 0.1, Price: 8990, StopPrice: 9000},
+		OrderID:     60001,
+	}
+
+	newServer := func(paths *[]string, failedPath string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*paths = append(*paths, r.Method+" "+r.URL.Path)
+			if r.URL.Path == failedPath {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintln(w, `{"success": false, "error": "Order already closed"}`)
+				return
+			}
+
+			switch {
+			case r.Method == "POST" && r.URL.Path == "/api/conditional_orders":
+				fmt.Fprintln(w, `{"success": true, "result": {
+					"createdAt": "2021-03-05T09:56:55.728933+00:00", "future": "BTC-PERP", "id": 60002, "market": "BTC-PERP",
+					"orderPrice": 9090, "triggerPrice": 9000, "side": "sell", "size": 0.2, "filledSize": 0,
+					"status": "open", "type": "stop", "orderType": "limit"}}`)
+
+			case r.Method == "DELETE":
+				fmt.Fprintln(w, `{"success": true, "result": "Order queued for cancellation"}`)
+
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+		}))
+	}
+
+	t.Run("submit and then cancel", func(t *testing.T) {
+		var paths []string
+		ts := newServer(&paths, "")
+		defer ts.Close()
+
+		ex := NewExchange("", "", "")
+		serverURL, err := url.Parse(ts.URL)
+		assert.NoError(t, err)
+		ex.restEndpoint = serverURL
+
+		order, err := ex.AmendOrder(context.Background(), triggerOrder, 9090, 0.2)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(60002), order.OrderID)
+		assert.Equal(t, []string{"POST /api/conditional_orders", "DELETE /api/conditional_orders/60001"}, paths)
+	})
+
+	t.Run("the original order is kept if the submission fails", func(t *testing.T) {
+		var paths []string
+		ts := newServer(&paths, "/api/conditional_orders")
+		defer ts.Close()
+
+		ex := NewExchange("", "", "")
+		serverURL, err := url.Parse(ts.URL)
+		assert.NoError(t, err)
+		ex.restEndpoint = serverURL
+
+		_, err = ex.AmendOrder(context.Background(), triggerOrder, 9090, 0.2)
+		assert.Error(t, err)
+		assert.Equal(t, []string{"POST /api/conditional_orders"}, paths)
+	})
+
+	t.Run("the amended order is canceled if the original order can't be canceled", func(t *testing.T) {
+		var paths []string
+		ts := newServer(&paths, "/api/conditional_orders/60001")
+		defer ts.Close()
+
+		ex := NewExchange("", "", "")
+		serverURL, err := url.Parse(ts.URL)
+		assert.NoError(t, err)
+		ex.restEndpoint = serverURL
+
+		_, err = ex.AmendOrder(context.Background(), triggerOrder, 9090, 0.2)
+		assert.Error(t, err)
+		assert.Equal(t, []string{"POST /api/conditional_orders", "DELETE /api/conditional_orders/60001", "DELETE /api/conditional_orders/60002"}, paths)
+	})
 }
 
 func TestExchange_QueryClosedOrders(t *testing.T) {
@@ -484,3 +837,316 @@ func TestExchange_QueryDepositHistory(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, dh, 0)
 }
+
+func TestExchange_QueryKLines(t *testing.T) {
+	successResp := `
+{
+  "success": true,
+  "result": [
+    {
+      "close": 11055.25,
+      "high": 11089.0,
+      "low": 11043.5,
+      "open": 11059.25,
+      "startTime": "2019-06-24T17:15:00+00:00",
+      "volume": 464193.95725
+    },
+    {
+      "close": 11060.0,
+      "high": 11070.0,
+      "low": 11050.0,
+      "open": 11055.25,
+      "startTime": "2019-06-24T17:30:00+00:00",
+      "volume": 5530.0
+    }
+  ]
+}
+`
+	var query url.Values
+	var path string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		query = r.URL.Query()
+		fmt.Fprintln(w, successResp)
+	}))
+	defer ts.Close()
+
+	ex := NewExchange("", "", "")
+	serverURL, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	ex.restEndpoint = serverURL
+
+	startTime := time.Date(2019, 6, 24, 17, 20, 0, 0, time.UTC)
+	klines, err := ex.QueryKLines(context.Background(), "BTC-PERP", types.Interval15m, types.KLineQueryOptions{
+		StartTime: &startTime,
+		Limit:     10,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/markets/BTC-PERP/candles", path)
+	assert.Equal(t, "900", query.Get("resolution"))
+	assert.Equal(t, "10", query.Get("limit"))
+	assert.Equal(t, "1561396800", query.Get("start_time"))
+	assert.Equal(t, "1561405800", query.Get("end_time"))
+
+	// the candle before the start time is dropped
+	if assert.Len(t, klines, 1) {
+		k := klines[0]
+		assert.Equal(t, "BTC-PERP", k.Symbol)
+		assert.Equal(t, types.Interval15m, k.Interval)
+		assert.Equal(t, time.Date(2019, 6, 24, 17, 30, 0, 0, time.UTC), k.StartTime.UTC())
+		assert.Equal(t, time.Date(2019, 6, 24, 17, 44, 59, 999000000, time.UTC), k.EndTime.UTC())
+		assert.Equal(t, 11055.25, k.Open)
+		assert.Equal(t, 11060.0, k.Close)
+		assert.Equal(t, 11070.0, k.High)
+		assert.Equal(t, 11050.0, k.Low)
+		assert.Equal(t, 0.5, k.Volume)
+		assert.Equal(t, 5530.0, k.QuoteVolume)
+		assert.True(t, k.Closed)
+	}
+
+	_, err = ex.QueryKLines(context.Background(), "BTC-PERP", types.Interval30m, types.KLineQueryOptions{})
+	assert.Error(t, err)
+}
+
+func TestExchange_QueryTrades(t *testing.T) {
+	pages := []string{`
+{
+  "success": true,
+  "result": [
+    {
+      "fee": 0.0005,
+      "feeCurrency": "USD",
+      "feeRate": 0.0005,
+      "id": 11216,
+      "liquidity": "maker",
+      "market": "BTC-PERP",
+      "orderId": 8436982,
+      "price": 10000.0,
+      "side": "sell",
+      "size": 0.1,
+      "time": "2019-03-27T19:15:11.204619+00:00",
+      "type": "order"
+    },
+    {
+      "fee": 0.0005,
+      "feeCurrency": "USD",
+      "feeRate": 0.0005,
+      "id": 11215,
+      "liquidity": "taker",
+      "market": "BTC-PERP",
+      "orderId": 8436981,
+      "price": 9990.0,
+      "side": "buy",
+      "size": 0.1,
+      "time": "2019-03-27T19:15:10.204619+00:00",
+      "type": "order"
+    }
+  ]
+}
+`, `
+{
+  "success": true,
+  "result": [
+    {
+      "fee": 0.0005,
+      "feeCurrency": "USD",
+      "feeRate": 0.0005,
+      "id": 11216,
+      "liquidity": "maker",
+      "market": "BTC-PERP",
+      "orderId": 8436982,
+      "price": 10000.0,
+      "side": "sell",
+      "size": 0.1,
+      "time": "2019-03-27T19:15:11.204619+00:00",
+      "type": "order"
+    },
+    {
+      "fee": 0.0005,
+      "feeCurrency": "USD",
+      "feeRate": 0.0005,
+      "id": 11217,
+      "liquidity": "taker",
+      "market": "BTC-PERP",
+      "orderId": 8436983,
+      "price": 10010.0,
+      "side": "buy",
+      "size": 0.2,
+      "time": "2019-03-27T19:15:11.504619+00:00",
+      "type": "order"
+    }
+  ]
+}
+`, `{"success": true, "result": []}`}
+
+	var startTimes []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/fills", r.URL.Path)
+		assert.Equal(t, "BTC-PERP", r.URL.Query().Get("market"))
+		startTimes = append(startTimes, r.URL.Query().Get("start_time"))
+
+		page := len(startTimes) - 1
+		if page >= len(pages) {
+			page = len(pages) - 1
+		}
+		fmt.Fprintln(w, pages[page])
+	}))
+	defer ts.Close()
+
+	ex := NewExchange("", "", "")
+	serverURL, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	ex.restEndpoint = serverURL
+
+	startTime := time.Date(2019, 3, 27, 0, 0, 0, 0, time.UTC)
+	trades, err := ex.QueryTrades(context.Background(), "BTC-PERP", &types.TradeQueryOptions{
+		StartTime:   &startTime,
+		LastTradeID: 11215,
+	})
+	assert.NoError(t, err)
+
+	// the second page starts from the second of the last fill, the duplicated fill is filtered by the trade id
+	assert.Equal(t, []string{"1553644800", "1553714111", "1553714111"}, startTimes)
+	if assert.Len(t, trades, 2) {
+		assert.Equal(t, int64(11216), trades[0].ID)
+		assert.Equal(t, uint64(8436982), trades[0].OrderID)
+		assert.Equal(t, types.SideTypeSell, trades[0].Side)
+		assert.False(t, trades[0].IsBuyer)
+		assert.True(t, trades[0].IsMaker)
+		assert.Equal(t, 1000.0, trades[0].QuoteQuantity)
+		assert.Equal(t, "USD", trades[0].FeeCurrency)
+
+		assert.Equal(t, int64(11217), trades[1].ID)
+		assert.True(t, trades[1].IsBuyer)
+	}
+
+	startTimes = nil
+	trades, err = ex.QueryTrades(context.Background(), "BTC-PERP", &types.TradeQueryOptions{
+		StartTime: &startTime,
+		Limit:     1,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, trades, 1) {
+		assert.Equal(t, int64(11215), trades[0].ID)
+	}
+}
+
+func TestExchange_QueryTicker(t *testing.T) {
+	successResp := `
+{
+  "success": true,
+  "result": {
+    "name": "BTC/USD",
+    "enabled": true,
+    "priceIncrement": 1.0,
+    "sizeIncrement": 0.0001,
+    "last": 59039.0,
+    "bid": 59038.0,
+    "ask": 59040.0,
+    "price": 59040.0,
+    "type": "spot",
+    "baseCurrency": "BTC",
+    "quoteCurrency": "USD",
+    "change24h": 0.05,
+    "quoteVolume24h": 5904000.0
+  }
+}
+`
+	var path string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		fmt.Fprintln(w, successResp)
+	}))
+	defer ts.Close()
+
+	ex := NewExchange("", "", "")
+	serverURL, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	ex.restEndpoint = serverURL
+
+	ticker, err := ex.QueryTicker(context.Background(), "btc/usd")
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/markets/BTC/USD", path)
+	assert.Equal(t, 59039.0, ticker.Last)
+	assert.Equal(t, 59038.0, ticker.Buy)
+	assert.Equal(t, 59040.0, ticker.Sell)
+	assert.InDelta(t, 59040.0/1.05, ticker.Open, 1e-8)
+	assert.Equal(t, 100.0, ticker.Volume)
+}
+
+func TestExchange_QueryTickers(t *testing.T) {
+	successResp := `
+{
+  "success": true,
+  "result": [
+    {"name": "BTC/USD", "last": 59039.0, "bid": 59038.0, "ask": 59040.0, "price": 59039.0},
+    {"name": "ETH/USD", "last": 1800.0, "bid": 1799.0, "ask": 1801.0, "price": 1800.0},
+    {"name": "BTC-PERP", "last": 59100.0, "bid": 59099.0, "ask": 59101.0, "price": 59100.0}
+  ]
+}
+`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, successResp)
+	}))
+	defer ts.Close()
+
+	ex := NewExchange("", "", "")
+	serverURL, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	ex.restEndpoint = serverURL
+
+	tickers, err := ex.QueryTickers(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, tickers, 3)
+
+	tickers, err = ex.QueryTickers(context.Background(), "BTC/USD", "btc-perp")
+	assert.NoError(t, err)
+	assert.Len(t, tickers, 2)
+	assert.Equal(t, 59038.0, tickers["BTC/USD"].Buy)
+	assert.Equal(t, 59101.0, tickers["BTC-PERP"].Sell)
+}
+
+func TestExchange_InternalTransfer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/subaccounts/transfer", r.URL.Path)
+
+		var payload map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "USD", payload["coin"])
+		assert.Equal(t, 100.0, payload["size"])
+		assert.Equal(t, "main", payload["source"])
+		assert.Equal(t, "grid", payload["destination"])
+
+		fmt.Fprintln(w, `{"success": true, "result": {
+			"id": 316450, "coin": "USD", "size": 100, "time": "2021-03-05T09:56:55.728933+00:00", "notes": "", "status": "complete"}}`)
+	}))
+	defer ts.Close()
+
+	ex := NewExchange("", "", "")
+	serverURL, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	ex.restEndpoint = serverURL
+
+	transferTime, err := time.Parse(time.RFC3339Nano, "2021-03-05T09:56:55.728933+00:00")
+	assert.NoError(t, err)
+
+	transfer, err := ex.InternalTransfer(context.Background(), "USD", 100.0, types.TransferAccountMain, types.TransferAccount("grid"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, types.InternalTransfer{
+		Exchange:   types.ExchangeFTX,
+		TransferID: "316450",
+		Asset:      "USD",
+		Amount:     100.0,
+		From:       types.TransferAccountMain,
+		To:         types.TransferAccount("grid"),
+		Status:     "completed",
+		Time:       datatype.Time(transferTime),
+	}, *transfer)
+
+	_, err = ex.InternalTransfer(context.Background(), "USD", 100.0, types.TransferAccountMain, types.TransferAccountMain)
+	assert.Error(t, err)
+}
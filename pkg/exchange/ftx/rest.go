@@ -36,6 +36,9 @@ type restRequest struct {
 
 	// payload
 	p map[string]interface{}
+
+	// query string
+	q url.Values
 }
 
 func newRestRequest(c *http.Client, baseURL *url.URL) *restRequest {
@@ -77,7 +80,18 @@ func (r *restRequest) buildURL() (*url.URL, error) {
 	if err != nil {
 		return nil, err
 	}
-	return r.baseURL.ResolveReference(refURL), nil
+
+	u := r.baseURL.ResolveReference(refURL)
+	if len(r.q) > 0 {
+		u.RawQuery = r.q.Encode()
+	}
+	return u, nil
+}
+
+// Query sets the query string, it's used by the GET requests since FTX doesn't read the body of the GET requests
+func (r *restRequest) Query(q url.Values) *restRequest {
+	r.q = q
+	return r
 }
 
 func (r *restRequest) Payloads(payloads map[string]interface{}) *restRequest {
@@ -113,7 +127,8 @@ func (r *restRequest) newAuthenticatedRequest(ctx context.Context) (*http.Reques
 	}
 
 	ts := strconv.FormatInt(timestamp(), 10)
-	p := fmt.Sprintf("%s%s%s%s", ts, r.m, u.Path, jsonPayload)
+	// the signature payload includes the query string
+	p := fmt.Sprintf("%s%s%s%s", ts, r.m, u.RequestURI(), jsonPayload)
 	signature := sign(r.secret, p)
 
 	req, err := http.NewRequestWithContext(ctx, r.m, u.String(), bytes.NewBuffer(jsonPayload))
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 type marketRequest struct {
@@ -27,3 +30,54 @@ func (r *marketRequest) Markets(ctx context.Context) (marketsResponse, error) {
 
 	return m, nil
 }
+
+func (r *marketRequest) Market(ctx context.Context, market string) (marketResponse, error) {
+	resp, err := r.
+		Method("GET").
+		ReferenceURL("api/markets/" + market).
+		DoAuthenticatedRequest(ctx)
+
+	if err != nil {
+		return marketResponse{}, err
+	}
+
+	var m marketResponse
+	if err := json.Unmarshal(resp.Body, &m); err != nil {
+		return marketResponse{}, fmt.Errorf("failed to unmarshal market response body to json: %w", err)
+	}
+
+	return m, nil
+}
+
+// HistoricalPrices queries the candles of the market, resolution is the window length in seconds.
+// start and end are optional, FTX returns the latest candles before the end time.
+func (r *marketRequest) HistoricalPrices(ctx context.Context, market string, resolution int64, limit int, start, end time.Time) (historicalPricesResponse, error) {
+	q := url.Values{}
+	q.Set("resolution", strconv.FormatInt(resolution, 10))
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if start != (time.Time{}) {
+		q.Set("start_time", strconv.FormatInt(start.Unix(), 10))
+	}
+	if end != (time.Time{}) {
+		q.Set("end_time", strconv.FormatInt(end.Unix(), 10))
+	}
+
+	resp, err := r.
+		Method("GET").
+		ReferenceURL("api/markets/" + market + "/candles").
+		Query(q).
+		DoAuthenticatedRequest(ctx)
+
+	if err != nil {
+		return historicalPricesResponse{}, err
+	}
+
+	var h historicalPricesResponse
+	if err := json.Unmarshal(resp.Body, &h); err != nil {
+		return historicalPricesResponse{}, fmt.Errorf("failed to unmarshal historical prices response body to json: %w", err)
+	}
+
+	return h, nil
+}
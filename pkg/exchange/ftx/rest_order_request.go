@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -63,6 +65,85 @@ func (r *orderRequest) PlaceOrder(ctx context.Context, p PlaceOrderPayload) (ord
 
 	return o, nil
 }
+
+/*
+{
+  "market": "XRP-PERP",
+  "side": "sell",
+  "triggerPrice": 0.306525,
+  "orderPrice": 0.3,
+  "size": 31431.0,
+  "type": "stop",
+  "reduceOnly": false,
+  "retryUntilFilled": false
+}
+*/
+type PlaceTriggerOrderPayload struct {
+	Market       string
+	Side         string
+	TriggerPrice float64
+	// OrderPrice is the price of the limit order placed when it's triggered, the market order is placed if it's zero
+	OrderPrice float64
+	Type       string
+	Size       float64
+	ReduceOnly bool
+}
+
+func (r *orderRequest) PlaceTriggerOrder(ctx context.Context, p PlaceTriggerOrderPayload) (triggerOrderResponse, error) {
+	payloads := map[string]interface{}{
+		"market":       p.Market,
+		"side":         p.Side,
+		"triggerPrice": p.TriggerPrice,
+		"type":         p.Type,
+		"size":         p.Size,
+		"reduceOnly":   p.ReduceOnly,
+	}
+	if p.OrderPrice > 0 {
+		payloads["orderPrice"] = p.OrderPrice
+	}
+
+	resp, err := r.
+		Method("POST").
+		ReferenceURL("api/conditional_orders").
+		Payloads(payloads).
+		DoAuthenticatedRequest(ctx)
+
+	if err != nil {
+		return triggerOrderResponse{}, err
+	}
+
+	var o triggerOrderResponse
+	if err := json.Unmarshal(resp.Body, &o); err != nil {
+		return triggerOrderResponse{}, fmt.Errorf("failed to unmarshal trigger order response body to json: %w", err)
+	}
+
+	return o, nil
+}
+
+// ModifyOrder modifies the price and the size of the open order, the original order is canceled and
+// a new order with a new order id is placed by FTX.
+func (r *orderRequest) ModifyOrder(ctx context.Context, orderID uint64, price, size float64) (orderResponse, error) {
+	resp, err := r.
+		Method("POST").
+		ReferenceURL("api/orders/" + strconv.FormatUint(orderID, 10) + "/modify").
+		Payloads(map[string]interface{}{
+			"price": price,
+			"size":  size,
+		}).
+		DoAuthenticatedRequest(ctx)
+
+	if err != nil {
+		return orderResponse{}, err
+	}
+
+	var o orderResponse
+	if err := json.Unmarshal(resp.Body, &o); err != nil {
+		return orderResponse{}, fmt.Errorf("failed to unmarshal modify order response body to json: %w", err)
+	}
+
+	return o, nil
+}
+
 func (r *orderRequest) CancelOrderByOrderID(ctx context.Context, orderID uint64) (cancelOrderResponse, error) {
 	resp, err := r.
 		Method("DELETE").
@@ -74,7 +155,7 @@ func (r *orderRequest) CancelOrderByOrderID(ctx context.Context, orderID uint64)
 	}
 
 	var co cancelOrderResponse
-	if err := json.Unmarshal(resp.Body, &r); err != nil {
+	if err := json.Unmarshal(resp.Body, &co); err != nil {
 		return cancelOrderResponse{}, err
 	}
 	return co, nil
@@ -91,17 +172,61 @@ func (r *orderRequest) CancelOrderByClientID(ctx context.Context, clientID strin
 	}
 
 	var co cancelOrderResponse
-	if err := json.Unmarshal(resp.Body, &r); err != nil {
+	if err := json.Unmarshal(resp.Body, &co); err != nil {
+		return cancelOrderResponse{}, err
+	}
+	return co, nil
+}
+
+// CancelAllOrders cancels the open orders and the trigger orders, the market is optional
+func (r *orderRequest) CancelAllOrders(ctx context.Context, market string) (cancelOrderResponse, error) {
+	p := make(map[string]interface{})
+	if len(market) > 0 {
+		p["market"] = market
+	}
+
+	resp, err := r.
+		Method("DELETE").
+		ReferenceURL("api/orders").
+		Payloads(p).
+		DoAuthenticatedRequest(ctx)
+	if err != nil {
+		return cancelOrderResponse{}, err
+	}
+
+	var co cancelOrderResponse
+	if err := json.Unmarshal(resp.Body, &co); err != nil {
+		return cancelOrderResponse{}, err
+	}
+	return co, nil
+}
+
+func (r *orderRequest) CancelTriggerOrder(ctx context.Context, orderID uint64) (cancelOrderResponse, error) {
+	resp, err := r.
+		Method("DELETE").
+		ReferenceURL("api/conditional_orders/" + strconv.FormatUint(orderID, 10)).
+		DoAuthenticatedRequest(ctx)
+	if err != nil {
+		return cancelOrderResponse{}, err
+	}
+
+	var co cancelOrderResponse
+	if err := json.Unmarshal(resp.Body, &co); err != nil {
 		return cancelOrderResponse{}, err
 	}
 	return co, nil
 }
 
 func (r *orderRequest) OpenOrders(ctx context.Context, market string) (ordersResponse, error) {
+	q := url.Values{}
+	if len(market) > 0 {
+		q.Set("market", market)
+	}
+
 	resp, err := r.
 		Method("GET").
 		ReferenceURL("api/orders").
-		Payloads(map[string]interface{}{"market": market}).
+		Query(q).
 		DoAuthenticatedRequest(ctx)
 
 	if err != nil {
@@ -116,6 +241,31 @@ func (r *orderRequest) OpenOrders(ctx context.Context, market string) (ordersRes
 	return o, nil
 }
 
+// OpenTriggerOrders queries the open trigger orders (conditional orders), market is optional
+func (r *orderRequest) OpenTriggerOrders(ctx context.Context, market string) (triggerOrdersResponse, error) {
+	q := url.Values{}
+	if len(market) > 0 {
+		q.Set("market", market)
+	}
+
+	resp, err := r.
+		Method("GET").
+		ReferenceURL("api/conditional_orders").
+		Query(q).
+		DoAuthenticatedRequest(ctx)
+
+	if err != nil {
+		return triggerOrdersResponse{}, err
+	}
+
+	var o triggerOrdersResponse
+	if err := json.Unmarshal(resp.Body, &o); err != nil {
+		return triggerOrdersResponse{}, fmt.Errorf("failed to unmarshal open trigger orders response body to json: %w", err)
+	}
+
+	return o, nil
+}
+
 func (r *orderRequest) OrdersHistory(ctx context.Context, market string, start, end time.Time, limit int) (ordersHistoryResponse, error) {
 	p := make(map[string]interface{})
 
@@ -149,3 +299,35 @@ func (r *orderRequest) OrdersHistory(ctx context.Context, market string, start,
 
 	return o, nil
 }
+
+// Fills queries the fills of the market in the ascending order of the time, market, start and end are optional.
+func (r *orderRequest) Fills(ctx context.Context, market string, start, end time.Time) (fillsResponse, error) {
+	q := url.Values{}
+	q.Set("order", "asc")
+	if len(market) > 0 {
+		q.Set("market", market)
+	}
+	if start != (time.Time{}) {
+		q.Set("start_time", strconv.FormatInt(start.Unix(), 10))
+	}
+	if end != (time.Time{}) {
+		q.Set("end_time", strconv.FormatInt(end.Unix(), 10))
+	}
+
+	resp, err := r.
+		Method("GET").
+		ReferenceURL("api/fills").
+		Query(q).
+		DoAuthenticatedRequest(ctx)
+
+	if err != nil {
+		return fillsResponse{}, err
+	}
+
+	var f fillsResponse
+	if err := json.Unmarshal(resp.Body, &f); err != nil {
+		return fillsResponse{}, fmt.Errorf("failed to unmarshal fills response body to json: %w", err)
+	}
+
+	return f, nil
+}
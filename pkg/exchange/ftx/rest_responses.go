@@ -254,3 +254,175 @@ type address struct {
 	Method  string `json:"method"`
 	Coin    string `json:"coin"`
 }
+
+type marketResponse struct {
+	Success bool   `json:"success"`
+	Result  market `json:"result"`
+}
+
+/*
+{
+  "success": true,
+  "result": [
+    {
+      "close": 11055.25,
+      "high": 11089.0,
+      "low": 11043.5,
+      "open": 11059.25,
+      "startTime": "2019-06-24T17:15:00+00:00",
+      "volume": 464193.95725
+    }
+  ]
+}
+*/
+type historicalPricesResponse struct {
+	Success bool     `json:"success"`
+	Result  []candle `json:"result"`
+}
+
+type candle struct {
+	Close     float64   `json:"close"`
+	High      float64   `json:"high"`
+	Low       float64   `json:"low"`
+	Open      float64   `json:"open"`
+	StartTime time.Time `json:"startTime"`
+
+	// Volume is the quote volume
+	Volume float64 `json:"volume"`
+}
+
+/*
+{
+  "success": true,
+  "result": [
+    {
+      "fee": 20.1374935,
+      "feeCurrency": "USD",
+      "feeRate": 0.0005,
+      "future": "EOS-0329",
+      "id": 11215,
+      "liquidity": "taker",
+      "market": "EOS-0329",
+      "baseCurrency": null,
+      "quoteCurrency": null,
+      "orderId": 8436981,
+      "tradeId": 1013912,
+      "price": 4.201,
+      "side": "buy",
+      "size": 9587,
+      "time": "2019-03-27T19:15:10.204619+00:00",
+      "type": "order"
+    }
+  ]
+}
+*/
+type fillsResponse struct {
+	Success bool   `json:"success"`
+	Result  []fill `json:"result"`
+}
+
+type fill struct {
+	ID            int64     `json:"id"`
+	Market        string    `json:"market"`
+	Future        string    `json:"future"`
+	BaseCurrency  string    `json:"baseCurrency"`
+	QuoteCurrency string    `json:"quoteCurrency"`
+	Type          string    `json:"type"`
+	Side          string    `json:"side"`
+	Price         float64   `json:"price"`
+	Size          float64   `json:"size"`
+	OrderId       uint64    `json:"orderId"`
+	Time          time.Time `json:"time"`
+	TradeId       uint64    `json:"tradeId"`
+	FeeRate       float64   `json:"feeRate"`
+	Fee           float64   `json:"fee"`
+	FeeCurrency   string    `json:"feeCurrency"`
+	Liquidity     string    `json:"liquidity"`
+}
+
+/*
+{
+  "createdAt": "2019-03-05T09:56:55.728933+00:00",
+  "error": null,
+  "future": "XRP-PERP",
+  "id": 50001,
+  "market": "XRP-PERP",
+  "orderId": null,
+  "orderPrice": null,
+  "reduceOnly": false,
+  "side": "buy",
+  "size": 0.003,
+  "status": "open",
+  "trailStart": null,
+  "trailValue": null,
+  "triggerPrice": 0.49,
+  "triggeredAt": null,
+  "type": "stop",
+  "orderType": "market",
+  "filledSize": 0,
+  "avgFillPrice": null,
+  "retryUntilFilled": false
+}
+*/
+type triggerOrder struct {
+	CreatedAt    time.Time `json:"createdAt"`
+	Future       string    `json:"future"`
+	ID           int64     `json:"id"`
+	Market       string    `json:"market"`
+	OrderID      int64     `json:"orderId"`
+	OrderPrice   float64   `json:"orderPrice"`
+	TriggerPrice float64   `json:"triggerPrice"`
+	ReduceOnly   bool      `json:"reduceOnly"`
+	Side         string    `json:"side"`
+	Size         float64   `json:"size"`
+	FilledSize   float64   `json:"filledSize"`
+	AvgFillPrice float64   `json:"avgFillPrice"`
+
+	// Status is `open`, `cancelled` or `triggered`
+	Status string `json:"status"`
+
+	// Type is `stop`, `trailing_stop` or `take_profit`
+	Type string `json:"type"`
+
+	// OrderType is the type of the order placed when it's triggered: `market` or `limit`
+	OrderType string `json:"orderType"`
+
+	Error string `json:"error"`
+}
+
+type triggerOrderResponse struct {
+	Success bool         `json:"success"`
+	Result  triggerOrder `json:"result"`
+}
+
+type triggerOrdersResponse struct {
+	Success bool           `json:"success"`
+	Result  []triggerOrder `json:"result"`
+}
+
+/*
+{
+  "success": true,
+  "result": {
+    "id": 316450,
+    "coin": "XRP",
+    "size": 10000,
+    "time": "2019-03-05T09:56:55.728933+00:00",
+    "notes": "",
+    "status": "complete"
+  }
+}
+*/
+type subAccountTransferResponse struct {
+	Success bool               `json:"success"`
+	Result  subAccountTransfer `json:"result"`
+}
+
+type subAccountTransfer struct {
+	ID     int64     `json:"id"`
+	Coin   string    `json:"coin"`
+	Size   float64   `json:"size"`
+	Time   time.Time `json:"time"`
+	Notes  string    `json:"notes"`
+	Status string    `json:"status"`
+}
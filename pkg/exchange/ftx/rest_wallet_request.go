@@ -45,3 +45,28 @@ func (r *walletRequest) Balances(ctx context.Context) (balances, error) {
 
 	return b, nil
 }
+
+// SubAccountTransfer transfers the coin between the sub-accounts, "main" is the main account.
+func (r *walletRequest) SubAccountTransfer(ctx context.Context, coin string, size float64, source, destination string) (subAccountTransferResponse, error) {
+	resp, err := r.
+		Method("POST").
+		ReferenceURL("api/subaccounts/transfer").
+		Payloads(map[string]interface{}{
+			"coin":        coin,
+			"size":        size,
+			"source":      source,
+			"destination": destination,
+		}).
+		DoAuthenticatedRequest(ctx)
+
+	if err != nil {
+		return subAccountTransferResponse{}, err
+	}
+
+	var t subAccountTransferResponse
+	if err := json.Unmarshal(resp.Body, &t); err != nil {
+		return subAccountTransferResponse{}, fmt.Errorf("failed to unmarshal sub-account transfer response body to json: %w", err)
+	}
+
+	return t, nil
+}
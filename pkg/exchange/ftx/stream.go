@@ -12,6 +12,8 @@ type Stream struct {
 
 	wsService *WebsocketService
 
+	bookSync *types.BookSynchronizer
+
 	// publicOnly must be accessed atomically
 	publicOnly int32
 }
@@ -23,12 +25,24 @@ func NewStream(key, secret string) *Stream {
 		wsService:      wss,
 	}
 
-	wss.OnMessage((&messageHandler{StandardStream: s.StandardStream}).handleMessage)
+	s.bookSync = types.NewBookSynchronizer(s.StandardStream)
+	s.bookSync.OnResync(func(symbol string) {
+		if err := wss.resubscribeOrderBook(symbol); err != nil {
+			logger.WithError(err).Errorf("failed to resubscribe %s orderbook", symbol)
+		}
+	})
+
+	wss.OnMessage(newMessageHandler(s.StandardStream, s.bookSync).handleMessage)
 	return s
 }
 
 func (s *Stream) Connect(ctx context.Context) error {
-	return s.wsService.Connect(ctx)
+	if err := s.wsService.Connect(ctx); err != nil {
+		return err
+	}
+
+	go s.bookSync.Run(ctx)
+	return nil
 }
 
 func (s *Stream) SetPublicOnly() {
@@ -2,6 +2,7 @@ package ftx
 
 import (
 	"encoding/json"
+	"fmt"
 
 	log "github.com/sirupsen/logrus"
 
@@ -10,6 +11,20 @@ import (
 
 type messageHandler struct {
 	*types.StandardStream
+
+	bookSync *types.BookSynchronizer
+
+	// books keeps the raw orderbooks for verifying the checksums of the updates,
+	// the checksum is calculated from the original string representation of the numbers.
+	books map[string]*orderBookResponse
+}
+
+func newMessageHandler(stream *types.StandardStream, bookSync *types.BookSynchronizer) *messageHandler {
+	return &messageHandler{
+		StandardStream: stream,
+		bookSync:       bookSync,
+		books:          make(map[string]*orderBookResponse),
+	}
 }
 
 func (h *messageHandler) handleMessage(message []byte) {
@@ -22,6 +37,8 @@ func (h *messageHandler) handleMessage(message []byte) {
 	switch r.Type {
 	case subscribedRespType:
 		h.handleSubscribedMessage(r)
+	case unsubscribedRespType:
+		logger.Infof("%s %s is unsubscribed", r.Market, r.Channel)
 	case partialRespType, updateRespType:
 		h.handleMarketData(r)
 	default:
@@ -36,19 +53,47 @@ func (h messageHandler) handleSubscribedMessage(response rawResponse) {
 }
 
 func (h *messageHandler) handleMarketData(response rawResponse) {
-	r, err := response.toOrderBookResponse()
-	if err != nil {
-		log.WithError(err).Errorf("failed to convert the partial response to data response")
-		return
-	}
-
-	switch r.Channel {
+	switch response.Channel {
 	case orderbook:
+		r, err := response.toOrderBookResponse()
+		if err != nil {
+			log.WithError(err).Errorf("failed to convert the partial response to data response")
+			return
+		}
+
 		h.handleOrderBook(r)
+	case trades:
+		r, err := response.toTradeResponse()
+		if err != nil {
+			log.WithError(err).Errorf("failed to convert the trades response")
+			return
+		}
+
+		h.handleTrades(r)
+	case ticker:
+		r, err := response.toTickerResponse()
+		if err != nil {
+			log.WithError(err).Errorf("failed to convert the ticker response")
+			return
+		}
+
+		h.EmitBookTickerUpdate(toGlobalBookTicker(r))
 	default:
-		log.Errorf("unsupported market data channel %s", r.Channel)
+		log.Errorf("unsupported market data channel %s", response.Channel)
+		return
+	}
+}
+
+func (h *messageHandler) handleTrades(r tradeResponse) {
+	trades, err := toGlobalMarketTrades(r)
+	if err != nil {
+		log.WithError(err).Errorf("failed to convert the market trades")
 		return
 	}
+
+	for _, trade := range trades {
+		h.EmitMarketTrade(trade)
+	}
 }
 
 func (h *messageHandler) handleOrderBook(r orderBookResponse) {
@@ -62,12 +107,30 @@ func (h *messageHandler) handleOrderBook(r orderBookResponse) {
 	case partialRespType:
 		if err := r.verifyChecksum(); err != nil {
 			log.WithError(err).Errorf("invalid orderbook snapshot")
+			delete(h.books, r.Market)
+			h.bookSync.EmitResync(globalOrderBook.Symbol)
 			return
 		}
-		h.EmitBookSnapshot(globalOrderBook)
+
+		book := r
+		h.books[r.Market] = &book
+		h.bookSync.LoadSnapshot(globalOrderBook, 0)
 	case updateRespType:
+		book, ok := h.books[r.Market]
+		if !ok {
+			// the snapshot is not loaded yet
+			return
+		}
+
+		book.update(r)
+		if err := book.verifyChecksum(); err != nil {
+			delete(h.books, r.Market)
+			h.bookSync.Invalidate(globalOrderBook.Symbol, fmt.Errorf("%v: %w", err, types.ErrBookChecksumMismatch))
+			return
+		}
+
 		// emit updates, not the whole orderbook
-		h.EmitBookUpdate(globalOrderBook)
+		h.bookSync.Update(globalOrderBook, 0, 0)
 	default:
 		log.Errorf("unsupported order book data type %s", r.Type)
 		return
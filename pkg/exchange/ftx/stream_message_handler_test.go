@@ -0,0 +1,119 @@
+package ftx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func Test_messageHandler_handleOrderBook(t *testing.T) {
+	stream := &types.StandardStream{}
+	bookSync := types.NewBookSynchronizer(stream)
+	h := newMessageHandler(stream, bookSync)
+
+	var snapshots, updates []types.OrderBook
+	stream.OnBookSnapshot(func(book types.OrderBook) {
+		snapshots = append(snapshots, book)
+	})
+	stream.OnBookUpdate(func(book types.OrderBook) {
+		updates = append(updates, book)
+	})
+
+	var invalidErrors []error
+	stream.OnBookInvalid(func(symbol string, err error) {
+		assert.Equal(t, "BTC/USDT", symbol)
+		invalidErrors = append(invalidErrors, err)
+	})
+
+	var resyncSymbols []string
+	bookSync.OnResync(func(symbol string) {
+		resyncSymbols = append(resyncSymbols, symbol)
+	})
+
+	snapshot, err := ioutil.ReadFile("./orderbook_snapshot.json")
+	assert.NoError(t, err)
+	h.handleMessage(snapshot)
+	assert.Len(t, snapshots, 1)
+	assert.True(t, bookSync.IsSynced("BTC/USDT"))
+
+	// remove the best bid and calculate the checksum of the updated book
+	book := *h.books["BTC/USDT"]
+	bids := append([][]json.Number{}, book.Bids[1:]...)
+	checksum := crc32.ChecksumIEEE([]byte(checksumString(bids, book.Asks)))
+
+	update := fmt.Sprintf(`{"channel": "orderbook", "market": "BTC/USDT", "type": "update", "data": {"time": 1614520369.0, "checksum": %d, "bids": [[44555.0, 0.0]], "asks": [], "action": "update"}}`, checksum)
+	h.handleMessage([]byte(update))
+	assert.Len(t, updates, 1)
+	assert.True(t, bookSync.IsSynced("BTC/USDT"))
+
+	// the checksum of the update doesn't match the local book
+	mismatched, err := ioutil.ReadFile("./orderbook_update.json")
+	assert.NoError(t, err)
+	h.handleMessage(mismatched)
+	assert.Len(t, updates, 1)
+	assert.False(t, bookSync.IsSynced("BTC/USDT"))
+	assert.Equal(t, []string{"BTC/USDT"}, resyncSymbols)
+	if assert.Len(t, invalidErrors, 1) {
+		assert.True(t, errors.Is(invalidErrors[0], types.ErrBookChecksumMismatch))
+	}
+
+	// the updates are dropped until the next partial snapshot
+	h.handleMessage(mismatched)
+	assert.Len(t, updates, 1)
+
+	h.handleMessage(snapshot)
+	assert.Len(t, snapshots, 2)
+	assert.True(t, bookSync.IsSynced("BTC/USDT"))
+}
+
+func Test_messageHandler_handleTrades(t *testing.T) {
+	stream := &types.StandardStream{}
+	h := newMessageHandler(stream, types.NewBookSynchronizer(stream))
+
+	var trades []types.Trade
+	stream.OnMarketTrade(func(trade types.Trade) {
+		trades = append(trades, trade)
+	})
+
+	h.handleMessage([]byte(`{"channel": "trades", "market": "BTC-PERP", "type": "update", "data": [
+		{"id": 1001, "price": 9000.5, "size": 0.1, "side": "buy", "liquidation": false, "time": "2021-03-05T09:56:55.728933+00:00"},
+		{"id": 1002, "price": 9000.0, "size": 0.2, "side": "sell", "liquidation": true, "time": "2021-03-05T09:56:55.828933+00:00"}
+	]}`))
+
+	if assert.Len(t, trades, 2) {
+		assert.Equal(t, int64(1001), trades[0].ID)
+		assert.Equal(t, "BTC-PERP", trades[0].Symbol)
+		assert.Equal(t, 9000.5, trades[0].Price)
+		assert.Equal(t, types.SideTypeBuy, trades[0].Side)
+		assert.Equal(t, types.SideTypeSell, trades[1].Side)
+		assert.Equal(t, 0.2, trades[1].Quantity)
+	}
+}
+
+func Test_messageHandler_handleTicker(t *testing.T) {
+	stream := &types.StandardStream{}
+	h := newMessageHandler(stream, types.NewBookSynchronizer(stream))
+
+	var bookTickers []types.BookTicker
+	stream.OnBookTickerUpdate(func(bookTicker types.BookTicker) {
+		bookTickers = append(bookTickers, bookTicker)
+	})
+
+	h.handleMessage([]byte(`{"channel": "ticker", "market": "BTC-PERP", "type": "update", "data": {"bid": 9000.0, "ask": 9000.5, "bidSize": 1.5, "askSize": 0.3, "last": 9000.5, "time": 1614520368.9313724}}`))
+
+	if assert.Len(t, bookTickers, 1) {
+		assert.Equal(t, "BTC-PERP", bookTickers[0].Symbol)
+		assert.Equal(t, 9000.0, bookTickers[0].Buy)
+		assert.Equal(t, 1.5, bookTickers[0].BuySize)
+		assert.Equal(t, 9000.5, bookTickers[0].Sell)
+		assert.Equal(t, 0.3, bookTickers[0].SellSize)
+		assert.Equal(t, int64(1614520368), bookTickers[0].Time.Unix())
+	}
+}
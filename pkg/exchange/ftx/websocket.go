@@ -3,6 +3,7 @@ package ftx
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -18,6 +19,9 @@ type WebsocketService struct {
 	secret string
 
 	subscriptions []SubscribeRequest
+
+	// writeMu serializes the writes since the book resubscription is sent from another goroutine
+	writeMu sync.Mutex
 }
 
 const endpoint = "wss://ftx.com/ws/"
@@ -40,10 +44,16 @@ func (w *WebsocketService) Subscribe(channel types.Channel, symbol string) error
 	r := SubscribeRequest{
 		Operation: subscribe,
 	}
-	if channel != types.BookChannel {
+	switch channel {
+	case types.BookChannel:
+		r.Channel = orderbook
+	case types.MarketTradeChannel:
+		r.Channel = trades
+	case types.BookTickerChannel:
+		r.Channel = ticker
+	default:
 		return fmt.Errorf("unsupported channel %+v", channel)
 	}
-	r.Channel = orderbook
 	r.Market = strings.ToUpper(strings.TrimSpace(symbol))
 
 	w.subscriptions = append(w.subscriptions, r)
@@ -53,6 +63,9 @@ func (w *WebsocketService) Subscribe(channel types.Channel, symbol string) error
 var errSubscriptionFailed = fmt.Errorf("failed to subscribe")
 
 func (w *WebsocketService) sendSubscriptions() error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
 	conn := w.Conn()
 	for _, s := range w.subscriptions {
 		if err := conn.WriteJSON(s); err != nil {
@@ -62,6 +75,30 @@ func (w *WebsocketService) sendSubscriptions() error {
 	return nil
 }
 
+// resubscribeOrderBook unsubscribes and subscribes the orderbook channel of the market again,
+// ftx sends a new partial snapshot after the subscription.
+func (w *WebsocketService) resubscribeOrderBook(market string) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	conn := w.Conn()
+	if conn == nil {
+		return fmt.Errorf("can't resubscribe %s orderbook: websocket is not connected", market)
+	}
+
+	for _, op := range []operation{unsubscribe, subscribe} {
+		r := SubscribeRequest{
+			Operation: op,
+			Channel:   orderbook,
+			Market:    market,
+		}
+		if err := conn.WriteJSON(r); err != nil {
+			return fmt.Errorf("can't send subscription request %+v: %w", r, errSubscriptionFailed)
+		}
+	}
+	return nil
+}
+
 func (w *WebsocketService) Close() error {
 	return w.Conn().Close()
 }
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/c9s/bbgo/pkg/datatype"
 	"github.com/c9s/bbgo/pkg/fixedpoint"
 	"github.com/c9s/bbgo/pkg/types"
 )
@@ -82,6 +83,33 @@ func (r rawResponse) toOrderBookResponse() (orderBookResponse, error) {
 	return o, nil
 }
 
+func (r rawResponse) toTradeResponse() (tradeResponse, error) {
+	o := tradeResponse{
+		mandatoryFields: r.mandatoryFields,
+	}
+
+	if err := json.Unmarshal(r.Data, &o.Trades); err != nil {
+		return tradeResponse{}, err
+	}
+
+	return o, nil
+}
+
+func (r rawResponse) toTickerResponse() (tickerResponse, error) {
+	o := tickerResponse{
+		mandatoryFields: r.mandatoryFields,
+	}
+
+	if err := json.Unmarshal(r.Data, &o); err != nil {
+		return tickerResponse{}, err
+	}
+
+	sec, dec := math.Modf(o.Time)
+	o.Timestamp = time.Unix(int64(sec), int64(dec*1e9))
+
+	return o, nil
+}
+
 // {"type": "subscribed", "channel": "orderbook", "market": "BTC/USDT"}
 type subscribedResponse struct {
 	mandatoryFields
@@ -105,6 +133,36 @@ type orderBookResponse struct {
 	Asks [][]json.Number `json:"asks"`
 }
 
+// {"channel": "trades", "market": "BTC-PERP", "type": "update", "data": [{"id": 1, "price": 9000.0, "size": 0.1, "side": "buy", "liquidation": false, "time": "2021-03-05T09:56:55.728933+00:00"}]}
+type tradeResponse struct {
+	mandatoryFields
+
+	Trades []tradeData
+}
+
+type tradeData struct {
+	ID          int64     `json:"id"`
+	Price       float64   `json:"price"`
+	Size        float64   `json:"size"`
+	Side        string    `json:"side"`
+	Liquidation bool      `json:"liquidation"`
+	Time        time.Time `json:"time"`
+}
+
+// {"channel": "ticker", "market": "BTC-PERP", "type": "update", "data": {"bid": 9000.0, "ask": 9000.5, "bidSize": 1.5, "askSize": 0.3, "last": 9000.5, "time": 1614520368.9313724}}
+type tickerResponse struct {
+	mandatoryFields
+
+	Bid     float64 `json:"bid"`
+	Ask     float64 `json:"ask"`
+	BidSize float64 `json:"bidSize"`
+	AskSize float64 `json:"askSize"`
+	Last    float64 `json:"last"`
+	Time    float64 `json:"time"`
+
+	Timestamp time.Time
+}
+
 // only 100 orders so we use linear search here
 func (r *orderBookResponse) update(orderUpdates orderBookResponse) {
 	r.Checksum = orderUpdates.Checksum
@@ -117,7 +175,7 @@ func (r *orderBookResponse) updateAsks(asks [][]json.Number) {
 		return dst < src
 	}
 	for _, o := range asks {
-		if remove := o[1] == "0"; remove {
+		if remove := isZeroVolume(o[1]); remove {
 			r.Asks = removePrice(r.Asks, o[0])
 		} else {
 			r.Asks = upsertPriceVolume(r.Asks, o, higherPrice)
@@ -130,7 +188,7 @@ func (r *orderBookResponse) updateBids(bids [][]json.Number) {
 		return dst > src
 	}
 	for _, o := range bids {
-		if remove := o[1] == "0"; remove {
+		if remove := isZeroVolume(o[1]); remove {
 			r.Bids = removePrice(r.Bids, o[0])
 		} else {
 			r.Bids = upsertPriceVolume(r.Bids, o, lessPrice)
@@ -138,6 +196,12 @@ func (r *orderBookResponse) updateBids(bids [][]json.Number) {
 	}
 }
 
+// isZeroVolume checks the volume numerically since ftx sends the removed price level as 0.0
+func isZeroVolume(volume json.Number) bool {
+	v, err := volume.Float64()
+	return err == nil && v == 0
+}
+
 func upsertPriceVolume(dst [][]json.Number, src []json.Number, priceComparator func(dst float64, src float64) bool) [][]json.Number {
 	for i, pv := range dst {
 		dstPrice := pv[0]
@@ -238,6 +302,41 @@ func toGlobalOrderBook(r orderBookResponse) (types.OrderBook, error) {
 	}, nil
 }
 
+// toGlobalMarketTrades converts the public trades, the side of the trade is the taker side
+func toGlobalMarketTrades(r tradeResponse) ([]types.Trade, error) {
+	var trades []types.Trade
+	for _, t := range r.Trades {
+		side := types.SideType(TrimUpperString(t.Side))
+		if side != types.SideTypeBuy && side != types.SideTypeSell {
+			return nil, fmt.Errorf("unsupported trade side %s", t.Side)
+		}
+
+		trades = append(trades, types.Trade{
+			ID:            t.ID,
+			Exchange:      types.ExchangeFTX.String(),
+			Price:         t.Price,
+			Quantity:      t.Size,
+			QuoteQuantity: t.Price * t.Size,
+			Symbol:        toGlobalSymbol(r.Market),
+			Side:          side,
+			IsBuyer:       side == types.SideTypeBuy,
+			Time:          datatype.Time(t.Time),
+		})
+	}
+	return trades, nil
+}
+
+func toGlobalBookTicker(r tickerResponse) types.BookTicker {
+	return types.BookTicker{
+		Time:     r.Timestamp,
+		Symbol:   toGlobalSymbol(r.Market),
+		Buy:      r.Bid,
+		BuySize:  r.BidSize,
+		Sell:     r.Ask,
+		SellSize: r.AskSize,
+	}
+}
+
 func toPriceVolumeSlice(orders [][]json.Number) (types.PriceVolumeSlice, error) {
 	var pv types.PriceVolumeSlice
 	for _, o := range orders {
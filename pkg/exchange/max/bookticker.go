@@ -0,0 +1,110 @@
+package max
+
+import (
+	"sync"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+// bookTickerDeriver derives the book tickers from the top of the validated books since MAX doesn't provide
+// the book ticker channel, the book ticker is emitted when the best bid or the best ask is changed.
+type bookTickerDeriver struct {
+	stream *types.StandardStream
+
+	mu          sync.Mutex
+	books       map[string]*types.OrderBook
+	lastTickers map[string]types.BookTicker
+}
+
+func newBookTickerDeriver(stream *types.StandardStream) *bookTickerDeriver {
+	d := &bookTickerDeriver{
+		stream:      stream,
+		books:       make(map[string]*types.OrderBook),
+		lastTickers: make(map[string]types.BookTicker),
+	}
+
+	stream.OnBookSnapshot(d.handleBookSnapshot)
+	stream.OnBookUpdate(d.handleBookUpdate)
+	stream.OnBookInvalid(d.handleBookInvalid)
+	return d
+}
+
+// AddSymbol starts deriving the book tickers of the symbol
+func (d *bookTickerDeriver) AddSymbol(symbol string) {
+	d.mu.Lock()
+	if _, ok := d.books[symbol]; !ok {
+		d.books[symbol] = &types.OrderBook{Symbol: symbol}
+	}
+	d.mu.Unlock()
+}
+
+func (d *bookTickerDeriver) handleBookSnapshot(snapshot types.OrderBook) {
+	d.mu.Lock()
+	book, ok := d.books[snapshot.Symbol]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+
+	book.Load(snapshot)
+	bookTicker, changed := d.update(book)
+	d.mu.Unlock()
+
+	if changed {
+		d.stream.EmitBookTickerUpdate(bookTicker)
+	}
+}
+
+func (d *bookTickerDeriver) handleBookUpdate(update types.OrderBook) {
+	d.mu.Lock()
+	book, ok := d.books[update.Symbol]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+
+	book.Update(update)
+	bookTicker, changed := d.update(book)
+	d.mu.Unlock()
+
+	if changed {
+		d.stream.EmitBookTickerUpdate(bookTicker)
+	}
+}
+
+func (d *bookTickerDeriver) handleBookInvalid(symbol string, _ error) {
+	d.mu.Lock()
+	if book, ok := d.books[symbol]; ok {
+		book.Reset()
+		delete(d.lastTickers, symbol)
+	}
+	d.mu.Unlock()
+}
+
+// update returns the book ticker of the book and whether it's changed, it's called with the lock held
+func (d *bookTickerDeriver) update(book *types.OrderBook) (types.BookTicker, bool) {
+	bid, hasBid := book.BestBid()
+	ask, hasAsk := book.BestAsk()
+	if !hasBid || !hasAsk {
+		return types.BookTicker{}, false
+	}
+
+	bookTicker := types.BookTicker{
+		Symbol:   book.Symbol,
+		Buy:      bid.Price.Float64(),
+		BuySize:  bid.Volume.Float64(),
+		Sell:     ask.Price.Float64(),
+		SellSize: ask.Volume.Float64(),
+	}
+
+	last, ok := d.lastTickers[book.Symbol]
+	if ok && last.Buy == bookTicker.Buy && last.BuySize == bookTicker.BuySize &&
+		last.Sell == bookTicker.Sell && last.SellSize == bookTicker.SellSize {
+		return last, false
+	}
+
+	bookTicker.Time = time.Now()
+	d.lastTickers[book.Symbol] = bookTicker
+	return bookTicker, true
+}
@@ -0,0 +1,68 @@
+package max
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func newTestPriceVolumes(pvs ...float64) (slice types.PriceVolumeSlice) {
+	for i := 0; i+1 < len(pvs); i += 2 {
+		slice = append(slice, types.PriceVolume{
+			Price:  fixedpoint.NewFromFloat(pvs[i]),
+			Volume: fixedpoint.NewFromFloat(pvs[i+1]),
+		})
+	}
+	return slice
+}
+
+func TestBookTickerDeriver(t *testing.T) {
+	stream := &types.StandardStream{}
+	d := newBookTickerDeriver(stream)
+	d.AddSymbol("BTCUSDT")
+
+	var bookTickers []types.BookTicker
+	stream.OnBookTickerUpdate(func(bookTicker types.BookTicker) {
+		bookTickers = append(bookTickers, bookTicker)
+	})
+
+	// the books of the other symbols are ignored
+	stream.EmitBookSnapshot(types.OrderBook{
+		Symbol: "ETHUSDT",
+		Bids:   newTestPriceVolumes(2000.0, 1.0),
+		Asks:   newTestPriceVolumes(2001.0, 1.0),
+	})
+	assert.Len(t, bookTickers, 0)
+
+	stream.EmitBookSnapshot(types.OrderBook{
+		Symbol: "BTCUSDT",
+		Bids:   newTestPriceVolumes(30000.0, 1.0, 29999.0, 2.0),
+		Asks:   newTestPriceVolumes(30001.0, 0.5, 30002.0, 3.0),
+	})
+	if assert.Len(t, bookTickers, 1) {
+		assert.Equal(t, 30000.0, bookTickers[0].Buy)
+		assert.Equal(t, 1.0, bookTickers[0].BuySize)
+		assert.Equal(t, 30001.0, bookTickers[0].Sell)
+		assert.Equal(t, 0.5, bookTickers[0].SellSize)
+	}
+
+	// the update below the top of the book doesn't change the book ticker
+	stream.EmitBookUpdate(types.OrderBook{Symbol: "BTCUSDT", Bids: newTestPriceVolumes(29999.0, 5.0)})
+	assert.Len(t, bookTickers, 1)
+
+	// the best ask is removed
+	stream.EmitBookUpdate(types.OrderBook{Symbol: "BTCUSDT", Asks: newTestPriceVolumes(30001.0, 0.0)})
+	if assert.Len(t, bookTickers, 2) {
+		assert.Equal(t, 30002.0, bookTickers[1].Sell)
+		assert.Equal(t, 3.0, bookTickers[1].SellSize)
+	}
+
+	// the book is reset until the next snapshot
+	stream.EmitBookInvalid("BTCUSDT", errors.New("invalid book"))
+	stream.EmitBookUpdate(types.OrderBook{Symbol: "BTCUSDT", Bids: newTestPriceVolumes(30000.5, 1.0)})
+	assert.Len(t, bookTickers, 2)
+}
@@ -29,8 +29,9 @@ var marketDataLimiter = rate.NewLimiter(rate.Every(5*time.Second), 1)
 var log = logrus.WithField("exchange", "max")
 
 type Exchange struct {
-	client      *maxapi.RestClient
-	key, secret string
+	client       *maxapi.RestClient
+	webSocketURL string
+	key, secret  string
 }
 
 func New(key, secret string) *Exchange {
@@ -39,12 +40,23 @@ func New(key, secret string) *Exchange {
 		baseURL = override
 	}
 
+	webSocketURL := maxapi.WebSocketURL
+	if override := os.Getenv("MAX_API_WS_URL"); len(override) > 0 {
+		webSocketURL = override
+	}
+
+	return NewWithEndpoints(key, secret, baseURL, webSocketURL)
+}
+
+// NewWithEndpoints creates the exchange with the given api endpoints, e.g., for the fake exchange server
+func NewWithEndpoints(key, secret, baseURL, webSocketURL string) *Exchange {
 	client := maxapi.NewRestClient(baseURL)
 	client.Auth(key, secret)
 	return &Exchange{
-		client: client,
-		key:    key,
-		secret: secret,
+		client:       client,
+		webSocketURL: webSocketURL,
+		key:          key,
+		secret:       secret,
 	}
 }
 
@@ -153,7 +165,10 @@ func (e *Exchange) QueryMarkets(ctx context.Context) (types.MarketMap, error) {
 }
 
 func (e *Exchange) NewStream() types.Stream {
-	return NewStream(e.key, e.secret)
+	stream := NewStreamWithURL(e.webSocketURL, e.key, e.secret)
+	stream.client = e.client
+	stream.stateSyncer = types.NewStreamStateSyncer(e, &stream.StandardStream)
+	return stream
 }
 
 func (e *Exchange) QueryOpenOrders(ctx context.Context, symbol string) (orders []types.Order, err error) {
@@ -226,6 +241,8 @@ func (e *Exchange) QueryClosedOrders(ctx context.Context, symbol string, since,
 	return orders, err
 }
 
+var _ types.ExchangeCancelService = &Exchange{}
+
 func (e *Exchange) CancelAllOrders(ctx context.Context) ([]types.Order, error) {
 	var req = e.client.OrderService.NewOrderCancelAllRequest()
 	var maxOrders, err = req.Do(ctx)
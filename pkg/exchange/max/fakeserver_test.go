@@ -0,0 +1,283 @@
+package max
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/exchange/fakeserver"
+	"github.com/c9s/bbgo/pkg/exchange/paper"
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+var fakeServerMarkets = types.MarketMap{
+	"BTCUSDT": {
+		Symbol:          "BTCUSDT",
+		BaseCurrency:    "BTC",
+		QuoteCurrency:   "USDT",
+		PricePrecision:  2,
+		VolumePrecision: 6,
+		MinQuantity:     0.0001,
+		MinNotional:     10.0,
+		MinPrice:        0.01,
+		MaxPrice:        1000000.0,
+		TickSize:        0.01,
+		StepSize:        0.000001,
+	},
+}
+
+func newFakeServerExchange(t *testing.T, secret string) (*fakeserver.Server, *Exchange) {
+	server := fakeserver.NewMaxServer(fakeserver.Config{
+		APIKey:    "fake_key",
+		APISecret: "fake_secret",
+		Markets:   fakeServerMarkets,
+		Account: paper.AccountConfig{
+			Balances: map[string]fixedpoint.Value{
+				"BTC":  fixedpoint.NewFromFloat(1.0),
+				"USDT": fixedpoint.NewFromFloat(10000.0),
+			},
+			MakerFeeRate: fixedpoint.NewFromFloat(0.0005),
+			TakerFeeRate: fixedpoint.NewFromFloat(0.0015),
+		},
+	})
+	server.SetPrice("BTCUSDT", 10000.0)
+
+	return server, NewWithEndpoints("fake_key", secret, server.BaseURL(), server.WebSocketURL())
+}
+
+func TestFakeServer_OrderFlow(t *testing.T) {
+	server, ex := newFakeServerExchange(t, "fake_secret")
+	defer server.Close()
+
+	ctx := context.Background()
+
+	markets, err := ex.QueryMarkets(ctx)
+	assert.NoError(t, err)
+	assert.Contains(t, markets, "BTCUSDT")
+
+	ticker, err := ex.QueryTicker(ctx, "BTCUSDT")
+	assert.NoError(t, err)
+	assert.Equal(t, 10000.0, ticker.Last)
+
+	createdOrders, err := ex.SubmitOrders(ctx, types.SubmitOrder{
+		Symbol:   "BTCUSDT",
+		Side:     types.SideTypeSell,
+		Type:     types.OrderTypeLimit,
+		Quantity: 0.5,
+		Price:    11000.0,
+		Market:   markets["BTCUSDT"],
+	})
+	assert.NoError(t, err)
+	if !assert.Len(t, createdOrders, 1) {
+		return
+	}
+	assert.Equal(t, types.OrderStatusNew, createdOrders[0].Status)
+
+	openOrders, err := ex.QueryOpenOrders(ctx, "BTCUSDT")
+	assert.NoError(t, err)
+	assert.Len(t, openOrders, 1)
+
+	balances, err := ex.QueryAccountBalances(ctx)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.5, balances["BTC"].Locked.Float64(), 1e-8)
+
+	// the limit order is matched when the price goes above the order price
+	server.SetPrice("BTCUSDT", 11200.0)
+
+	openOrders, err = ex.QueryOpenOrders(ctx, "BTCUSDT")
+	assert.NoError(t, err)
+	assert.Len(t, openOrders, 0)
+
+	trades, err := ex.QueryTrades(ctx, "BTCUSDT", &types.TradeQueryOptions{Limit: 100})
+	assert.NoError(t, err)
+	if assert.Len(t, trades, 1) {
+		assert.Equal(t, 11000.0, trades[0].Price)
+		assert.Equal(t, 0.5, trades[0].Quantity)
+		assert.Equal(t, createdOrders[0].OrderID, trades[0].OrderID)
+	}
+
+	// the filled order can not be canceled
+	err = ex.CancelOrders(ctx, createdOrders...)
+	assert.Error(t, err)
+}
+
+func TestFakeServer_CancelOrders(t *testing.T) {
+	server, ex := newFakeServerExchange(t, "fake_secret")
+	defer server.Close()
+
+	ctx := context.Background()
+	createdOrders, err := ex.SubmitOrders(ctx, types.SubmitOrder{
+		Symbol:   "BTCUSDT",
+		Side:     types.SideTypeBuy,
+		Type:     types.OrderTypeLimit,
+		Quantity: 0.1,
+		Price:    9000.0,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, createdOrders, 1)
+
+	err = ex.CancelOrders(ctx, createdOrders...)
+	assert.NoError(t, err)
+
+	openOrders, err := ex.QueryOpenOrders(ctx, "BTCUSDT")
+	assert.NoError(t, err)
+	assert.Len(t, openOrders, 0)
+}
+
+func TestFakeServer_InvalidSignature(t *testing.T) {
+	server, ex := newFakeServerExchange(t, "wrong_secret")
+	defer server.Close()
+
+	_, err := ex.QueryOpenOrders(context.Background(), "BTCUSDT")
+	assert.Error(t, err)
+}
+
+func TestFakeServer_UserDataStream(t *testing.T) {
+	server, ex := newFakeServerExchange(t, "fake_secret")
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	orderC := make(chan types.Order, 10)
+	tradeC := make(chan types.Trade, 10)
+	klineC := make(chan types.KLine, 10)
+
+	stream := ex.NewStream()
+	stream.Subscribe(types.KLineChannel, "BTCUSDT", types.SubscribeOptions{Interval: "1m"})
+	stream.OnOrderUpdate(func(order types.Order) { orderC <- order })
+	stream.OnTradeUpdate(func(trade types.Trade) { tradeC <- trade })
+	stream.OnKLineClosed(func(kline types.KLine) { klineC <- kline })
+	assert.NoError(t, stream.Connect(ctx))
+
+	defer func() {
+		cancel()
+		_ = stream.Close()
+	}()
+
+	// wait for the order snapshot pushed after the authentication
+	time.Sleep(200 * time.Millisecond)
+
+	createdOrders, err := ex.SubmitOrders(ctx, types.SubmitOrder{
+		Symbol:   "BTCUSDT",
+		Side:     types.SideTypeBuy,
+		Type:     types.OrderTypeMarket,
+		Quantity: 0.1,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, createdOrders, 1)
+
+	select {
+	case trade := <-tradeC:
+		assert.Equal(t, "BTCUSDT", trade.Symbol)
+		assert.Equal(t, 0.1, trade.Quantity)
+		assert.Equal(t, 10000.0, trade.Price)
+	case <-time.After(3 * time.Second):
+		t.Fatal("trade update is not received")
+	}
+
+	select {
+	case order := <-orderC:
+		assert.Equal(t, "BTCUSDT", order.Symbol)
+	case <-time.After(3 * time.Second):
+		t.Fatal("order update is not received")
+	}
+
+	server.SetPrice("BTCUSDT", 10100.0)
+
+	select {
+	case kline := <-klineC:
+		assert.Equal(t, 10100.0, kline.Close)
+	case <-time.After(3 * time.Second):
+		t.Fatal("kline is not received")
+	}
+}
+
+func TestFakeServer_Reconnect(t *testing.T) {
+	server, ex := newFakeServerExchange(t, "fake_secret")
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	connectC := make(chan struct{}, 10)
+	orderC := make(chan types.Order, 10)
+
+	stream := ex.NewStream()
+	stream.OnConnect(func() { connectC <- struct{}{} })
+	stream.OnOrderUpdate(func(order types.Order) { orderC <- order })
+	assert.NoError(t, stream.Connect(ctx))
+
+	defer func() {
+		cancel()
+		_ = stream.Close()
+	}()
+
+	<-connectC
+	server.CloseConnections()
+
+	select {
+	case <-connectC:
+	case <-time.After(10 * time.Second):
+		t.Fatal("stream is not reconnected")
+	}
+
+	// wait for the authentication of the new connection
+	time.Sleep(200 * time.Millisecond)
+
+	_, err := ex.SubmitOrders(ctx, types.SubmitOrder{
+		Symbol:   "BTCUSDT",
+		Side:     types.SideTypeBuy,
+		Type:     types.OrderTypeLimit,
+		Quantity: 0.1,
+		Price:    9000.0,
+	})
+	assert.NoError(t, err)
+
+	select {
+	case order := <-orderC:
+		assert.Equal(t, types.OrderStatusNew, order.Status)
+	case <-time.After(3 * time.Second):
+		t.Fatal("order update is not received after reconnecting")
+	}
+}
+
+func TestFakeServer_ReloadBook(t *testing.T) {
+	server, ex := newFakeServerExchange(t, "fake_secret")
+	defer server.Close()
+
+	ctx := context.Background()
+
+	_, err := ex.SubmitOrders(ctx,
+		types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeBuy, Type: types.OrderTypeLimit, Quantity: 0.1, Price: 9000.0},
+		types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeBuy, Type: types.OrderTypeLimit, Quantity: 0.2, Price: 9000.0},
+		types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeBuy, Type: types.OrderTypeLimit, Quantity: 0.3, Price: 9500.0},
+		types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeSell, Type: types.OrderTypeLimit, Quantity: 0.5, Price: 11000.0},
+	)
+	assert.NoError(t, err)
+
+	stream := ex.NewStream().(*Stream)
+	stream.Subscribe(types.BookChannel, "BTCUSDT", types.SubscribeOptions{})
+
+	var snapshot types.OrderBook
+	stream.OnBookSnapshot(func(book types.OrderBook) {
+		snapshot = book
+	})
+
+	stream.reloadBook("BTCUSDT")
+
+	assert.Equal(t, "BTCUSDT", snapshot.Symbol)
+	if assert.Len(t, snapshot.Bids, 2) {
+		assert.Equal(t, fixedpoint.NewFromFloat(9500.0), snapshot.Bids[0].Price)
+		assert.Equal(t, fixedpoint.NewFromFloat(9000.0), snapshot.Bids[1].Price)
+		assert.Equal(t, fixedpoint.NewFromFloat(0.3), snapshot.Bids[1].Volume)
+	}
+	if assert.Len(t, snapshot.Asks, 1) {
+		assert.Equal(t, fixedpoint.NewFromFloat(11000.0), snapshot.Asks[0].Price)
+	}
+
+	assert.True(t, stream.bookSync.IsSynced("BTCUSDT"))
+}
@@ -305,3 +305,106 @@ func (s *AccountService) NewGetWithdrawalHistoryRequest() *GetWithdrawHistoryReq
 		client: s.client,
 	}
 }
+
+type WithdrawAddress struct {
+	UUID            string `json:"uuid"`
+	Currency        string `json:"currency"`
+	CurrencyVersion string `json:"currency_version"`
+	Address         string `json:"address"`
+	ExtraLabel      string `json:"extra_label"`
+	State           string `json:"state"`
+	IsInternal      bool   `json:"is_internal"`
+}
+
+type GetWithdrawAddressesRequest struct {
+	client   *RestClient
+	currency string
+}
+
+func (r *GetWithdrawAddressesRequest) Currency(currency string) *GetWithdrawAddressesRequest {
+	r.currency = currency
+	return r
+}
+
+func (r *GetWithdrawAddressesRequest) Do(ctx context.Context) (addresses []WithdrawAddress, err error) {
+	payload := map[string]interface{}{
+		"currency": r.currency,
+	}
+
+	req, err := r.client.newAuthenticatedRequest("GET", "v2/withdraw_addresses", payload)
+	if err != nil {
+		return addresses, err
+	}
+
+	response, err := r.client.sendRequest(req)
+	if err != nil {
+		return addresses, err
+	}
+
+	if err := response.DecodeJSON(&addresses); err != nil {
+		return addresses, err
+	}
+
+	return addresses, err
+}
+
+// NewGetWithdrawAddressesRequest queries the whitelisted withdraw addresses,
+// MAX only accepts the withdrawals to the addresses that are added on the website.
+func (s *AccountService) NewGetWithdrawAddressesRequest() *GetWithdrawAddressesRequest {
+	return &GetWithdrawAddressesRequest{
+		client: s.client,
+	}
+}
+
+type WithdrawalRequest struct {
+	client      *RestClient
+	currency    string
+	addressUUID string
+	amount      string
+}
+
+func (r *WithdrawalRequest) Currency(currency string) *WithdrawalRequest {
+	r.currency = currency
+	return r
+}
+
+func (r *WithdrawalRequest) AddressUUID(uuid string) *WithdrawalRequest {
+	r.addressUUID = uuid
+	return r
+}
+
+func (r *WithdrawalRequest) Amount(amount string) *WithdrawalRequest {
+	r.amount = amount
+	return r
+}
+
+func (r *WithdrawalRequest) Do(ctx context.Context) (*Withdraw, error) {
+	payload := map[string]interface{}{
+		"currency":              r.currency,
+		"withdraw_address_uuid": r.addressUUID,
+		"amount":                r.amount,
+	}
+
+	req, err := r.client.newAuthenticatedRequest("POST", "v2/withdrawal", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.client.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var withdraw Withdraw
+	if err := response.DecodeJSON(&withdraw); err != nil {
+		return nil, err
+	}
+
+	return &withdraw, nil
+}
+
+func (s *AccountService) NewWithdrawalRequest() *WithdrawalRequest {
+	return &WithdrawalRequest{
+		client: s.client,
+	}
+}
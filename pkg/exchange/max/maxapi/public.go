@@ -134,6 +134,52 @@ func (s *PublicService) Ticker(market string) (*Ticker, error) {
 	return &ticker, nil
 }
 
+// Depth queries the book snapshot of the market, the snapshot is returned as a book event so that it can be
+// converted like the snapshots of the websocket book channel.
+func (s *PublicService) Depth(market string, limit int) (*BookEvent, error) {
+	params := url.Values{}
+	params.Set("market", market)
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	req, err := s.client.newRequest("GET", "v2/depth", params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.client.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := fastjson.ParseBytes(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	event := BookEvent{
+		Event:     "snapshot",
+		Market:    market,
+		Channel:   "book",
+		Timestamp: v.GetInt64("timestamp"),
+	}
+
+	t := time.Unix(event.Timestamp, 0)
+
+	event.Asks, err = parseBookEntries(v.GetArray("asks"), Sell, t)
+	if err != nil {
+		return nil, err
+	}
+
+	event.Bids, err = parseBookEntries(v.GetArray("bids"), Buy, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
 func mustParseTicker(v *fastjson.Value) Ticker {
 	var at = v.GetInt64("at")
 	return Ticker{
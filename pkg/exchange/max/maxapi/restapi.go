@@ -22,6 +22,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 
+	"github.com/c9s/bbgo/pkg/exchange/ratelimit"
 	"github.com/c9s/bbgo/pkg/util"
 	"github.com/c9s/bbgo/pkg/version"
 )
@@ -53,6 +54,13 @@ var htmlTagPattern = regexp.MustCompile("<[/]?[a-zA-Z-]+.*?>")
 // timeOffset is used for nonce
 var timeOffset int64 = 0
 
+// restLimiter is shared by all the MAX rest clients, MAX doesn't publish the request weight of the REST api,
+// so each request is counted as 1 and the budget is kept at 1200 requests per minute.
+var restLimiter = ratelimit.Register("max", ratelimit.Config{
+	Weight:   1200,
+	Interval: time.Minute,
+})
+
 // serverTimestamp is used for storing the server timestamp, default to Now
 var serverTimestamp = time.Now().Unix()
 
@@ -86,7 +94,7 @@ func NewRestClientWithHttpClient(baseURL string, httpClient *http.Client) *RestC
 	}
 
 	var client = &RestClient{
-		client:  httpClient,
+		client:  restLimiter.HTTPClient(httpClient),
 		BaseURL: u,
 	}
 
@@ -47,6 +47,10 @@ type WebSocketService struct {
 	mu   sync.Mutex
 	conn *websocket.Conn
 
+	// writeMu serializes the writes and guards the conn swap and the subscriptions,
+	// mu can't be used since it's held by the read loop while waiting for the messages
+	writeMu sync.Mutex
+
 	reconnectC chan struct{}
 
 	// Subscriptions is the subscription request payloads that will be used for sending subscription request
@@ -107,6 +111,10 @@ func (s *WebSocketService) Auth() error {
 		Signature: signPayload(fmt.Sprintf("%d", nonce), s.secret),
 		ID:        uuid.New().String(),
 	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	return s.conn.WriteJSON(auth)
 }
 
@@ -120,7 +128,10 @@ func (s *WebSocketService) connect(ctx context.Context) error {
 		return err
 	}
 
+	s.writeMu.Lock()
 	s.conn = conn
+	s.writeMu.Unlock()
+
 	s.EmitConnect(conn)
 
 	go s.read(ctx)
@@ -162,15 +173,21 @@ func (s *WebSocketService) read(ctx context.Context) {
 			s.mu.Unlock()
 
 			if err != nil {
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) {
-					s.EmitDisconnect()
+				// the read error of the websocket connection is permanent, we can't read from the connection anymore
+				s.EmitDisconnect()
+
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+					return
+				}
+
+				if ctx.Err() == nil {
+					log.WithError(err).Error("websocket error")
+
 					// emit reconnect to start a new connection
 					s.emitReconnect()
-					return
 				}
 
-				log.WithError(err).Error("websocket error")
-				continue
+				return
 			}
 
 			if mt != websocket.TextMessage {
@@ -234,7 +251,9 @@ func (s *WebSocketService) dispatch(msg interface{}) {
 }
 
 func (s *WebSocketService) ClearSubscriptions() {
+	s.writeMu.Lock()
 	s.Subscriptions = nil
+	s.writeMu.Unlock()
 }
 
 func (s *WebSocketService) Reconnect() {
@@ -255,7 +274,9 @@ func (s *WebSocketService) Subscribe(channel, market string, options SubscribeOp
 
 // AddSubscription adds the subscription request to the buffer, these requests will be sent to the server right after connecting to the endpoint.
 func (s *WebSocketService) AddSubscription(subscription Subscription) {
+	s.writeMu.Lock()
 	s.Subscriptions = append(s.Subscriptions, subscription)
+	s.writeMu.Unlock()
 }
 
 func (s *WebSocketService) Resubscribe() {
@@ -273,6 +294,9 @@ func (s *WebSocketService) Resubscribe() {
 }
 
 func (s *WebSocketService) SendSubscriptionRequest(action string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	request := WebsocketCommand{
 		Action:        action,
 		Subscriptions: s.Subscriptions,
@@ -289,5 +313,9 @@ func (s *WebSocketService) SendSubscriptionRequest(action string) error {
 
 // Close web socket connection
 func (s *WebSocketService) Close() error {
-	return s.conn.Close()
+	s.writeMu.Lock()
+	conn := s.conn
+	s.writeMu.Unlock()
+
+	return conn.Close()
 }
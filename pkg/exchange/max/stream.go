@@ -22,6 +22,24 @@ type Stream struct {
 
 	websocketService *max.WebSocketService
 
+	// client queries the book snapshots when the books are invalidated, the stream reconnects instead if it's nil
+	client *max.RestClient
+
+	// bookSync validates the books since the book events of MAX don't have the sequence numbers
+	bookSync *types.BookSynchronizer
+
+	// stateSyncer resyncs the orders, the trades and the balances after reconnecting
+	stateSyncer *types.StreamStateSyncer
+
+	// bookTicker derives the book tickers from the books since MAX doesn't have the book ticker channel
+	bookTicker *bookTickerDeriver
+
+	// bookSymbols and bookTickerSymbols are the subscribed symbols of the book channel and the book ticker channel,
+	// the book channel with depth 1 is subscribed for the book ticker symbols that are not in the book subscriptions.
+	// the values of bookSymbols are the subscribed depths, which are used as the limits of the book snapshot queries.
+	bookSymbols       map[string]int
+	bookTickerSymbols map[string]struct{}
+
 	publicOnly bool
 }
 
@@ -31,11 +49,25 @@ func NewStream(key, secret string) *Stream {
 		url = max.WebSocketURL
 	}
 
+	return NewStreamWithURL(url, key, secret)
+}
+
+func NewStreamWithURL(url, key, secret string) *Stream {
 	wss := max.NewWebSocketService(url, key, secret)
 	stream := &Stream{
-		websocketService: wss,
+		websocketService:  wss,
+		bookSymbols:       make(map[string]int),
+		bookTickerSymbols: make(map[string]struct{}),
 	}
 
+	stream.bookSync = types.NewBookSynchronizer(&stream.StandardStream)
+	stream.bookTicker = newBookTickerDeriver(&stream.StandardStream)
+
+	// the book channel only sends the snapshot after subscribing, reload the snapshot from the restful api
+	stream.bookSync.OnResync(func(symbol string) {
+		go stream.reloadBook(symbol)
+	})
+
 	wss.OnConnect(func(conn *websocket.Conn) {
 		if key == "" || secret == "" {
 			log.Warn("MAX API key or secret is empty, will not send authentication command")
@@ -97,6 +129,18 @@ func NewStream(key, secret string) *Stream {
 		}
 	})
 
+	wss.OnTradeEvent(func(e max.PublicTradeEvent) {
+		for _, tradeEntry := range e.Trades {
+			trade, err := convertWebSocketMarketTrade(e.Market, tradeEntry)
+			if err != nil {
+				log.WithError(err).Error("websocket market trade convert error")
+				return
+			}
+
+			stream.EmitMarketTrade(*trade)
+		}
+	})
+
 	wss.OnBookEvent(func(e max.BookEvent) {
 		newBook, err := e.OrderBook()
 		if err != nil {
@@ -108,14 +152,18 @@ func NewStream(key, secret string) *Stream {
 
 		switch e.Event {
 		case "snapshot":
-			stream.EmitBookSnapshot(newBook)
+			stream.bookSync.LoadSnapshot(newBook, 0)
 		case "update":
-			stream.EmitBookUpdate(newBook)
+			stream.bookSync.Update(newBook, 0, 0)
 		}
 	})
 
 	wss.OnConnect(func(conn *websocket.Conn) {
 		stream.EmitConnect()
+
+		if !stream.publicOnly && stream.stateSyncer != nil {
+			stream.stateSyncer.HandleConnect()
+		}
 	})
 
 	wss.OnAccountSnapshotEvent(func(e max.AccountSnapshotEvent) {
@@ -158,6 +206,12 @@ func (s *Stream) SetPublicOnly() {
 }
 
 func (s *Stream) Subscribe(channel types.Channel, symbol string, options types.SubscribeOptions) {
+	if channel == types.BookTickerChannel {
+		s.bookTickerSymbols[symbol] = struct{}{}
+		s.bookTicker.AddSymbol(symbol)
+		return
+	}
+
 	opt := max.SubscribeOptions{}
 
 	if len(options.Depth) > 0 {
@@ -168,6 +222,10 @@ func (s *Stream) Subscribe(channel types.Channel, symbol string, options types.S
 		opt.Depth = depth
 	}
 
+	if channel == types.BookChannel {
+		s.bookSymbols[symbol] = opt.Depth
+	}
+
 	if len(options.Interval) > 0 {
 		opt.Resolution = options.Interval
 	}
@@ -176,15 +234,60 @@ func (s *Stream) Subscribe(channel types.Channel, symbol string, options types.S
 }
 
 func (s *Stream) Connect(ctx context.Context) error {
+	for symbol := range s.bookTickerSymbols {
+		if _, ok := s.bookSymbols[symbol]; ok {
+			continue
+		}
+
+		s.bookSymbols[symbol] = 1
+		s.websocketService.Subscribe(string(types.BookChannel), toLocalSymbol(symbol), max.SubscribeOptions{Depth: 1})
+	}
+
 	err := s.websocketService.Connect(ctx)
 	if err != nil {
 		return err
 	}
 
+	go s.bookSync.Run(ctx)
+
 	s.EmitStart()
 	return nil
 }
 
+// reloadBook loads the book snapshot from the restful api, it reconnects the websocket to receive the snapshots
+// of the book channel if the snapshot can not be queried.
+func (s *Stream) reloadBook(symbol string) {
+	if s.client == nil {
+		logger.Warnf("the rest client is not set, reconnecting to reload the book of %s...", symbol)
+		s.websocketService.Reconnect()
+		return
+	}
+
+	snapshot, err := s.queryBook(symbol)
+	if err != nil {
+		logger.WithError(err).Errorf("can not query the book snapshot of %s, reconnecting...", symbol)
+		s.websocketService.Reconnect()
+		return
+	}
+
+	s.bookSync.LoadSnapshot(snapshot, 0)
+}
+
+func (s *Stream) queryBook(symbol string) (types.OrderBook, error) {
+	event, err := s.client.PublicService.Depth(toLocalSymbol(symbol), s.bookSymbols[symbol])
+	if err != nil {
+		return types.OrderBook{}, err
+	}
+
+	snapshot, err := event.OrderBook()
+	if err != nil {
+		return types.OrderBook{}, err
+	}
+
+	snapshot.Symbol = symbol
+	return snapshot, nil
+}
+
 func (s *Stream) Close() error {
 	return s.websocketService.Close()
 }
@@ -260,3 +363,33 @@ func toGlobalOrderUpdate(u max.OrderUpdate) (*types.Order, error) {
 		CreationTime:     datatype.Time(time.Unix(0, u.CreatedAtMs*int64(time.Millisecond))),
 	}, nil
 }
+
+// convertWebSocketMarketTrade converts the public trade, the trend "up" means the taker is the buyer
+// and the trend "down" means the taker is the seller. MAX doesn't send the trade ID of the public trades.
+func convertWebSocketMarketTrade(market string, t max.TradeEntry) (*types.Trade, error) {
+	price, err := strconv.ParseFloat(t.Price, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	quantity, err := strconv.ParseFloat(t.Volume, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var side = types.SideTypeBuy
+	if t.Trend == "down" {
+		side = types.SideTypeSell
+	}
+
+	return &types.Trade{
+		Symbol:        toGlobalSymbol(market),
+		Exchange:      types.ExchangeMax.String(),
+		Price:         price,
+		Quantity:      quantity,
+		QuoteQuantity: price * quantity,
+		Side:          side,
+		IsBuyer:       side == types.SideTypeBuy,
+		Time:          datatype.Time(t.Time()),
+	}, nil
+}
@@ -0,0 +1,31 @@
+package max
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	max "github.com/c9s/bbgo/pkg/exchange/max/maxapi"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func TestConvertWebSocketMarketTrade(t *testing.T) {
+	trade, err := convertWebSocketMarketTrade("btcusdt", max.TradeEntry{
+		Trend:     "down",
+		Price:     "58000.5",
+		Volume:    "0.01",
+		Timestamp: 1616000000000,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "BTCUSDT", trade.Symbol)
+		assert.Equal(t, 58000.5, trade.Price)
+		assert.Equal(t, 0.01, trade.Quantity)
+		assert.Equal(t, types.SideTypeSell, trade.Side)
+		assert.False(t, trade.IsBuyer)
+	}
+
+	trade, err = convertWebSocketMarketTrade("btcusdt", max.TradeEntry{Trend: "up", Price: "58001", Volume: "0.02"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, types.SideTypeBuy, trade.Side)
+	}
+}
@@ -0,0 +1,88 @@
+package max
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/datatype"
+	"github.com/c9s/bbgo/pkg/types"
+	"github.com/c9s/bbgo/pkg/util"
+)
+
+var _ types.ExchangeWithdrawService = &Exchange{}
+
+// Withdraw submits the withdrawal to the withdraw address that is added on the MAX website,
+// the address is looked up from the withdraw addresses of the asset and the network is matched against the
+// currency version of the address, e.g. "erc20" or "trc20".
+func (e *Exchange) Withdraw(ctx context.Context, asset string, amount float64, address string, network string) (*types.Withdraw, error) {
+	currency := toLocalCurrency(asset)
+
+	addresses, err := e.client.AccountService.NewGetWithdrawAddressesRequest().
+		Currency(currency).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	addressUUID := ""
+	for _, a := range addresses {
+		if a.Address != address {
+			continue
+		}
+
+		if network != "" && !strings.EqualFold(a.CurrencyVersion, network) {
+			continue
+		}
+
+		addressUUID = a.UUID
+		break
+	}
+
+	if addressUUID == "" {
+		return nil, fmt.Errorf("max withdraw address %s (network %q) of %s is not found, the address must be added on the website first", address, network, asset)
+	}
+
+	logger.Infof("withdrawing %f %s to %s via network %q", amount, asset, address, network)
+
+	withdraw, err := e.client.AccountService.NewWithdrawalRequest().
+		Currency(currency).
+		AddressUUID(addressUUID).
+		Amount(util.FormatFloat(amount, -1)).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the withdrawal is submitted already, the parse errors are returned with the withdrawal uuid for checking it
+	withdrawAmount, err := strconv.ParseFloat(withdraw.Amount, 64)
+	if err != nil {
+		return nil, fmt.Errorf("max withdrawal %s is submitted, but the amount %q can not be parsed: %w", withdraw.UUID, withdraw.Amount, err)
+	}
+
+	fee, err := strconv.ParseFloat(withdraw.Fee, 64)
+	if err != nil {
+		return nil, fmt.Errorf("max withdrawal %s is submitted, but the fee %q can not be parsed: %w", withdraw.UUID, withdraw.Fee, err)
+	}
+
+	applyTime := time.Now()
+	if withdraw.CreatedAt > 0 {
+		applyTime = time.Unix(withdraw.CreatedAt, 0)
+	}
+
+	return &types.Withdraw{
+		Exchange:               types.ExchangeMax,
+		ApplyTime:              datatype.Time(applyTime),
+		Asset:                  toGlobalCurrency(withdraw.Currency),
+		Amount:                 withdrawAmount,
+		Address:                address,
+		Network:                network,
+		TransactionID:          withdraw.TxID,
+		TransactionFee:         fee,
+		TransactionFeeCurrency: withdraw.FeeCurrency,
+		WithdrawOrderID:        withdraw.UUID,
+		Status:                 withdraw.State,
+	}, nil
+}
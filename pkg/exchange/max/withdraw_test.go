@@ -0,0 +1,69 @@
+package max
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	maxapi "github.com/c9s/bbgo/pkg/exchange/max/maxapi"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func TestExchange_Withdraw(t *testing.T) {
+	var withdrawPayload map[string]interface{}
+	var withdrawResponse = `{"uuid": "w-1", "currency": "usdt", "amount": "100.5", "fee": "1.0", "fee_currency": "usdt", "state": "submitting", "created_at": 1616000000}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/timestamp":
+			_, _ = fmt.Fprint(w, `1616000000`)
+
+		case "/api/v2/withdraw_addresses":
+			_, _ = fmt.Fprint(w, `[
+				{"uuid": "addr-erc20", "currency": "usdt", "currency_version": "erc20", "address": "0xabc"},
+				{"uuid": "addr-trc20", "currency": "usdt", "currency_version": "trc20", "address": "0xabc"}
+			]`)
+
+		case "/api/v2/withdrawal":
+			data, _ := ioutil.ReadAll(r.Body)
+			_ = json.Unmarshal(data, &withdrawPayload)
+			_, _ = fmt.Fprint(w, withdrawResponse)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := maxapi.NewRestClient(server.URL + "/api/v2")
+	client.Auth("key", "secret")
+	e := &Exchange{client: client}
+
+	withdraw, err := e.Withdraw(context.Background(), "USDT", 100.5, "0xabc", "TRC20")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, types.ExchangeMax, withdraw.Exchange)
+	assert.Equal(t, "USDT", withdraw.Asset)
+	assert.Equal(t, "w-1", withdraw.WithdrawOrderID)
+	assert.Equal(t, 100.5, withdraw.Amount)
+	assert.Equal(t, "addr-trc20", withdrawPayload["withdraw_address_uuid"])
+	assert.Equal(t, "usdt", withdrawPayload["currency"])
+	assert.Equal(t, "100.5", withdrawPayload["amount"])
+
+	_, err = e.Withdraw(context.Background(), "USDT", 100.5, "0xdef", "")
+	assert.Error(t, err)
+
+	// the invalid amount is returned as an error instead of panicking
+	withdrawResponse = `{"uuid": "w-2", "currency": "usdt", "amount": "", "fee": "1.0", "fee_currency": "usdt", "state": "submitting"}`
+	_, err = e.Withdraw(context.Background(), "USDT", 100.5, "0xabc", "TRC20")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "w-2")
+	}
+}
@@ -0,0 +1,42 @@
+package paper
+
+import (
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+// DefaultFeeRate is used when the fee rates are not configured, 0.1% for both maker and taker
+const DefaultFeeRate = 0.001
+
+// AccountConfig defines the virtual account of the paper trading session
+type AccountConfig struct {
+	Balances map[string]fixedpoint.Value `json:"balances" yaml:"balances"`
+
+	// MakerFeeRate and TakerFeeRate are the fee rates in decimal, e.g. 0.001 for 0.1%
+	MakerFeeRate fixedpoint.Value `json:"makerFeeRate,omitempty" yaml:"makerFeeRate,omitempty"`
+	TakerFeeRate fixedpoint.Value `json:"takerFeeRate,omitempty" yaml:"takerFeeRate,omitempty"`
+}
+
+func (c AccountConfig) BalanceMap() types.BalanceMap {
+	balances := make(types.BalanceMap)
+	for currency, value := range c.Balances {
+		balances[currency] = types.Balance{
+			Currency:  currency,
+			Available: value,
+		}
+	}
+	return balances
+}
+
+func (c AccountConfig) feeRates() (maker, taker float64) {
+	maker, taker = DefaultFeeRate, DefaultFeeRate
+	if c.MakerFeeRate > 0 {
+		maker = c.MakerFeeRate.Float64()
+	}
+
+	if c.TakerFeeRate > 0 {
+		taker = c.TakerFeeRate.Float64()
+	}
+
+	return maker, taker
+}
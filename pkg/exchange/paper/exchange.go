@@ -0,0 +1,580 @@
+package paper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/c9s/bbgo/pkg/datatype"
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+var log = logrus.WithField("exchange", "paper")
+
+// paperOrder is an open order of the paper exchange
+type paperOrder struct {
+	types.Order
+
+	// lockedPrice is the price used for locking the quote balance of the buy order
+	lockedPrice float64
+
+	// triggered is set when the stop price of the stop limit order is reached
+	triggered bool
+}
+
+// Exchange is the paper trading exchange.
+// The market data is queried from the source exchange, and the orders are matched with the live prices
+// of the source stream against the virtual balances. Nothing is sent to the trading API of the source exchange.
+type Exchange struct {
+	// types.Exchange is the source exchange of the market data
+	types.Exchange
+
+	makerFeeRate, takerFeeRate float64
+
+	account *types.Account
+
+	marketsMu sync.Mutex
+	markets   types.MarketMap
+
+	mu           sync.Mutex
+	lastPrices   map[string]float64
+	openOrders   map[string][]*paperOrder
+	closedOrders map[string][]types.Order
+	trades       map[string][]types.Trade
+	orderID      uint64
+	tradeID      int64
+
+	// events are the order updates, the trade updates and the balance updates that are not emitted yet,
+	// they are emitted after the lock is released so that the callbacks can submit orders.
+	events []interface{}
+
+	streams []*Stream
+}
+
+func New(source types.Exchange, config AccountConfig) *Exchange {
+	account := types.NewAccount()
+	account.AccountType = "SPOT"
+	account.UpdateBalances(config.BalanceMap())
+
+	makerFeeRate, takerFeeRate := config.feeRates()
+	return &Exchange{
+		Exchange:     source,
+		makerFeeRate: makerFeeRate,
+		takerFeeRate: takerFeeRate,
+		account:      account,
+		lastPrices:   make(map[string]float64),
+		openOrders:   make(map[string][]*paperOrder),
+		closedOrders: make(map[string][]types.Order),
+		trades:       make(map[string][]types.Trade),
+	}
+}
+
+func (e *Exchange) NewStream() types.Stream {
+	stream := newStream(e.Exchange.NewStream(), e)
+
+	e.mu.Lock()
+	e.streams = append(e.streams, stream)
+	e.mu.Unlock()
+	return stream
+}
+
+func (e *Exchange) QueryAccount(ctx context.Context) (*types.Account, error) {
+	account := types.NewAccount()
+	account.AccountType = e.account.AccountType
+	account.MakerCommission = fixedpoint.NewFromFloat(e.makerFeeRate * 10000.0)
+	account.TakerCommission = fixedpoint.NewFromFloat(e.takerFeeRate * 10000.0)
+	account.UpdateBalances(e.account.Balances())
+	return account, nil
+}
+
+func (e *Exchange) QueryAccountBalances(ctx context.Context) (types.BalanceMap, error) {
+	return e.account.Balances(), nil
+}
+
+func (e *Exchange) QueryTrades(ctx context.Context, symbol string, options *types.TradeQueryOptions) ([]types.Trade, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var trades []types.Trade
+	for _, trade := range e.trades[symbol] {
+		if options != nil {
+			if options.LastTradeID > 0 && trade.ID <= options.LastTradeID {
+				continue
+			}
+
+			if options.StartTime != nil && trade.Time.Time().Before(*options.StartTime) {
+				continue
+			}
+
+			if options.EndTime != nil && trade.Time.Time().After(*options.EndTime) {
+				continue
+			}
+
+			if options.Limit > 0 && int64(len(trades)) >= options.Limit {
+				break
+			}
+		}
+
+		trades = append(trades, trade)
+	}
+
+	return trades, nil
+}
+
+func (e *Exchange) QueryOpenOrders(ctx context.Context, symbol string) (orders []types.Order, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, o := range e.openOrders[symbol] {
+		orders = append(orders, o.Order)
+	}
+
+	return orders, nil
+}
+
+func (e *Exchange) QueryClosedOrders(ctx context.Context, symbol string, since, until time.Time, lastOrderID uint64) (orders []types.Order, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, o := range e.closedOrders[symbol] {
+		if lastOrderID > 0 && o.OrderID <= lastOrderID {
+			continue
+		}
+
+		updateTime := o.UpdateTime.Time()
+		if updateTime.Before(since) || (!until.IsZero() && updateTime.After(until)) {
+			continue
+		}
+
+		orders = append(orders, o)
+	}
+
+	return orders, nil
+}
+
+func (e *Exchange) SubmitOrders(ctx context.Context, orders ...types.SubmitOrder) (createdOrders types.OrderSlice, err error) {
+	for _, so := range orders {
+		market, err := e.market(ctx, so.Symbol)
+		if err != nil {
+			return createdOrders, err
+		}
+
+		lastPrice, err := e.lastPrice(ctx, so.Symbol)
+		if err != nil {
+			return createdOrders, err
+		}
+
+		e.mu.Lock()
+		order, err := e.placeOrder(market, so, lastPrice)
+		events := e.flushEvents()
+		e.mu.Unlock()
+
+		e.emit(events)
+
+		if err != nil {
+			return createdOrders, err
+		}
+
+		createdOrders = append(createdOrders, order)
+	}
+
+	return createdOrders, nil
+}
+
+func (e *Exchange) CancelOrders(ctx context.Context, orders ...types.Order) error {
+	for _, o := range orders {
+		market, err := e.market(ctx, o.Symbol)
+		if err != nil {
+			return err
+		}
+
+		e.mu.Lock()
+		err = e.cancelOrder(market, o.OrderID)
+		events := e.flushEvents()
+		e.mu.Unlock()
+
+		e.emit(events)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Exchange) market(ctx context.Context, symbol string) (types.Market, error) {
+	e.marketsMu.Lock()
+	defer e.marketsMu.Unlock()
+
+	if e.markets == nil {
+		markets, err := e.Exchange.QueryMarkets(ctx)
+		if err != nil {
+			return types.Market{}, err
+		}
+
+		e.markets = markets
+	}
+
+	market, ok := e.markets[symbol]
+	if !ok {
+		return types.Market{}, fmt.Errorf("market %s is not defined", symbol)
+	}
+
+	return market, nil
+}
+
+// lastPrice returns the last price received from the stream, the ticker is queried if no price is received yet
+func (e *Exchange) lastPrice(ctx context.Context, symbol string) (float64, error) {
+	e.mu.Lock()
+	price, ok := e.lastPrices[symbol]
+	e.mu.Unlock()
+
+	if ok {
+		return price, nil
+	}
+
+	ticker, err := e.Exchange.QueryTicker(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// the price might be updated by the stream while querying the ticker
+	if price, ok := e.lastPrices[symbol]; ok {
+		return price, nil
+	}
+
+	e.lastPrices[symbol] = ticker.Last
+	return ticker.Last, nil
+}
+
+// placeOrder locks the balance and executes or books the order, the lock of the exchange must be held
+func (e *Exchange) placeOrder(market types.Market, so types.SubmitOrder, lastPrice float64) (types.Order, error) {
+	if so.Quantity <= 0 {
+		return types.Order{}, fmt.Errorf("invalid order quantity %f", so.Quantity)
+	}
+
+	var lockedPrice float64
+	switch so.Type {
+	case types.OrderTypeMarket:
+		if lastPrice <= 0 {
+			return types.Order{}, fmt.Errorf("last price of %s is not available", so.Symbol)
+		}
+		lockedPrice = lastPrice
+
+	case types.OrderTypeLimit, types.OrderTypeLimitMaker:
+		if so.Price <= 0 {
+			return types.Order{}, fmt.Errorf("price is required for the %s order", so.Type)
+		}
+		lockedPrice = so.Price
+
+	case types.OrderTypeStopMarket, types.OrderTypeStopLimit:
+		if so.StopPrice <= 0 {
+			return types.Order{}, fmt.Errorf("stop price is required for the %s order", so.Type)
+		}
+
+		if isStopTriggered(so.Side, so.StopPrice, lastPrice) {
+			return types.Order{}, fmt.Errorf("%s %s order would trigger immediately, stop price %f, last price %f", so.Side, so.Type, so.StopPrice, lastPrice)
+		}
+
+		lockedPrice = so.StopPrice
+		if so.Type == types.OrderTypeStopLimit {
+			if so.Price <= 0 {
+				return types.Order{}, fmt.Errorf("price is required for the %s order", so.Type)
+			}
+			lockedPrice = so.Price
+		}
+
+	default:
+		return types.Order{}, fmt.Errorf("unsupported order type %s", so.Type)
+	}
+
+	if so.Type == types.OrderTypeLimitMaker && isMarketable(so.Side, so.Price, lastPrice) {
+		return types.Order{}, fmt.Errorf("%s %s order would immediately match, price %f, last price %f", so.Side, so.Type, so.Price, lastPrice)
+	}
+
+	if err := e.lockBalance(market, so.Side, lockedPrice, so.Quantity); err != nil {
+		return types.Order{}, err
+	}
+
+	e.orderID++
+	now := time.Now()
+	o := &paperOrder{
+		Order: types.Order{
+			SubmitOrder:  so,
+			Exchange:     e.Name().String(),
+			OrderID:      e.orderID,
+			Status:       types.OrderStatusNew,
+			IsWorking:    true,
+			CreationTime: datatype.Time(now),
+			UpdateTime:   datatype.Time(now),
+		},
+		lockedPrice: lockedPrice,
+	}
+
+	e.events = append(e.events, e.account.Balances(), o.Order)
+
+	switch {
+	case so.Type == types.OrderTypeMarket:
+		return e.fill(market, o, lastPrice, false), nil
+
+	case so.Type == types.OrderTypeLimit && isMarketable(so.Side, so.Price, lastPrice):
+		return e.fill(market, o, lastPrice, false), nil
+
+	case so.TimeInForce == "IOC":
+		return e.cancel(market, o), nil
+	}
+
+	e.openOrders[so.Symbol] = append(e.openOrders[so.Symbol], o)
+	return o.Order, nil
+}
+
+func (e *Exchange) cancelOrder(market types.Market, orderID uint64) error {
+	orders := e.openOrders[market.Symbol]
+	for i, o := range orders {
+		if o.OrderID != orderID {
+			continue
+		}
+
+		e.openOrders[market.Symbol] = append(orders[:i:i], orders[i+1:]...)
+		e.cancel(market, o)
+		return nil
+	}
+
+	return fmt.Errorf("open order %d of %s is not found", orderID, market.Symbol)
+}
+
+// cancel unlocks the balance of the order and closes the order
+func (e *Exchange) cancel(market types.Market, o *paperOrder) types.Order {
+	e.unlockBalance(market, o.Side, o.lockedPrice, o.Quantity)
+
+	o.Status = types.OrderStatusCanceled
+	o.IsWorking = false
+	o.UpdateTime = datatype.Time(time.Now())
+	e.closedOrders[market.Symbol] = append(e.closedOrders[market.Symbol], o.Order)
+	e.events = append(e.events, e.account.Balances(), o.Order)
+	return o.Order
+}
+
+// fill executes the whole order at the given price and updates the balances
+func (e *Exchange) fill(market types.Market, o *paperOrder, price float64, isMaker bool) types.Order {
+	// the buy order could be executed at a price higher than the locked price (the triggered stop market order),
+	// the order is executed at the locked price if the available balance is not enough.
+	if o.Side == types.SideTypeBuy && price > o.lockedPrice {
+		diff := fixedpoint.NewFromFloat(price*o.Quantity) - fixedpoint.NewFromFloat(o.lockedPrice*o.Quantity)
+		if err := e.account.LockBalance(market.QuoteCurrency, diff); err != nil {
+			log.WithError(err).Warnf("insufficient balance, executing the %s order at %f", o.Symbol, o.lockedPrice)
+			price = o.lockedPrice
+		} else {
+			o.lockedPrice = price
+		}
+	}
+
+	feeRate := e.takerFeeRate
+	if isMaker {
+		feeRate = e.makerFeeRate
+	}
+
+	quoteQuantity := price * o.Quantity
+
+	var fee float64
+	var feeCurrency string
+	var err error
+	switch o.Side {
+	case types.SideTypeBuy:
+		// unlock the rest of the locked quote balance if the order is executed at a better price
+		locked := fixedpoint.NewFromFloat(o.lockedPrice * o.Quantity)
+		cost := fixedpoint.NewFromFloat(quoteQuantity)
+		if locked > cost {
+			err = e.account.UnlockBalance(market.QuoteCurrency, locked-cost)
+		}
+
+		if err == nil {
+			err = e.account.UseLockedBalance(market.QuoteCurrency, cost)
+		}
+
+		fee, feeCurrency = o.Quantity*feeRate, market.BaseCurrency
+		_ = e.account.AddBalance(market.BaseCurrency, fixedpoint.NewFromFloat(o.Quantity-fee))
+
+	case types.SideTypeSell:
+		err = e.account.UseLockedBalance(market.BaseCurrency, fixedpoint.NewFromFloat(o.Quantity))
+
+		fee, feeCurrency = quoteQuantity*feeRate, market.QuoteCurrency
+		_ = e.account.AddBalance(market.QuoteCurrency, fixedpoint.NewFromFloat(quoteQuantity-fee))
+	}
+
+	if err != nil {
+		log.WithError(err).Errorf("unable to use the locked balance of the order: %+v", o.Order)
+	}
+
+	now := time.Now()
+	e.tradeID++
+	trade := types.Trade{
+		ID:            e.tradeID,
+		OrderID:       o.OrderID,
+		Exchange:      o.Exchange,
+		Price:         price,
+		Quantity:      o.Quantity,
+		QuoteQuantity: quoteQuantity,
+		Symbol:        o.Symbol,
+		Side:          o.Side,
+		IsBuyer:       o.Side == types.SideTypeBuy,
+		IsMaker:       isMaker,
+		Time:          datatype.Time(now),
+		Fee:           fee,
+		FeeCurrency:   feeCurrency,
+	}
+	e.trades[o.Symbol] = append(e.trades[o.Symbol], trade)
+
+	o.Status = types.OrderStatusFilled
+	o.ExecutedQuantity = o.Quantity
+	o.IsWorking = false
+	o.UpdateTime = datatype.Time(now)
+	e.closedOrders[o.Symbol] = append(e.closedOrders[o.Symbol], o.Order)
+
+	e.events = append(e.events, trade, o.Order, e.account.Balances())
+	return o.Order
+}
+
+// processPrice updates the last price of the symbol and matches the open orders
+func (e *Exchange) processPrice(symbol string, price float64) {
+	if price <= 0 {
+		return
+	}
+
+	e.mu.Lock()
+	e.lastPrices[symbol] = price
+	hasOpenOrders := len(e.openOrders[symbol]) > 0
+	e.mu.Unlock()
+
+	if !hasOpenOrders {
+		return
+	}
+
+	// the market is always loaded by the order submission
+	market, err := e.market(context.Background(), symbol)
+	if err != nil {
+		log.WithError(err).Errorf("can not match the orders of %s", symbol)
+		return
+	}
+
+	e.mu.Lock()
+
+	var openOrders []*paperOrder
+	for _, o := range e.openOrders[symbol] {
+		isStopOrder := o.Type == types.OrderTypeStopMarket || o.Type == types.OrderTypeStopLimit
+		if isStopOrder && !o.triggered {
+			if !isStopTriggered(o.Side, o.StopPrice, price) {
+				openOrders = append(openOrders, o)
+				continue
+			}
+
+			o.triggered = true
+			if o.Type == types.OrderTypeStopMarket || isMarketable(o.Side, o.Price, price) {
+				e.fill(market, o, price, false)
+				continue
+			}
+		}
+
+		// the limit orders are executed at the order price
+		if isMarketable(o.Side, o.Price, price) {
+			e.fill(market, o, o.Price, true)
+			continue
+		}
+
+		openOrders = append(openOrders, o)
+	}
+
+	e.openOrders[symbol] = openOrders
+	events := e.flushEvents()
+	e.mu.Unlock()
+
+	e.emit(events)
+}
+
+func (e *Exchange) lockBalance(market types.Market, side types.SideType, price, quantity float64) error {
+	switch side {
+	case types.SideTypeBuy:
+		return e.account.LockBalance(market.QuoteCurrency, fixedpoint.NewFromFloat(price*quantity))
+	case types.SideTypeSell:
+		return e.account.LockBalance(market.BaseCurrency, fixedpoint.NewFromFloat(quantity))
+	}
+
+	return fmt.Errorf("unsupported side %s", side)
+}
+
+func (e *Exchange) unlockBalance(market types.Market, side types.SideType, price, quantity float64) {
+	var err error
+	switch side {
+	case types.SideTypeBuy:
+		err = e.account.UnlockBalance(market.QuoteCurrency, fixedpoint.NewFromFloat(price*quantity))
+	case types.SideTypeSell:
+		err = e.account.UnlockBalance(market.BaseCurrency, fixedpoint.NewFromFloat(quantity))
+	}
+
+	if err != nil {
+		log.WithError(err).Errorf("unable to unlock the %s balance of %s", side, market.Symbol)
+	}
+}
+
+func (e *Exchange) flushEvents() []interface{} {
+	events := e.events
+	e.events = nil
+	return events
+}
+
+// emit emits the updates through the streams in the order they happened
+func (e *Exchange) emit(events []interface{}) {
+	e.mu.Lock()
+	streams := e.streams
+	e.mu.Unlock()
+
+	for _, event := range events {
+		for _, stream := range streams {
+			switch ev := event.(type) {
+			case types.Order:
+				stream.EmitOrderUpdate(ev)
+			case types.Trade:
+				stream.EmitTradeUpdate(ev)
+			case types.BalanceMap:
+				stream.EmitBalanceUpdate(ev)
+			}
+		}
+	}
+}
+
+// isMarketable returns true if the limit order can be executed at the given price
+func isMarketable(side types.SideType, orderPrice, price float64) bool {
+	if price <= 0 {
+		return false
+	}
+
+	switch side {
+	case types.SideTypeBuy:
+		return price <= orderPrice
+	case types.SideTypeSell:
+		return price >= orderPrice
+	}
+	return false
+}
+
+func isStopTriggered(side types.SideType, stopPrice, price float64) bool {
+	if price <= 0 {
+		return false
+	}
+
+	switch side {
+	case types.SideTypeBuy:
+		return price >= stopPrice
+	case types.SideTypeSell:
+		return price <= stopPrice
+	}
+	return false
+}
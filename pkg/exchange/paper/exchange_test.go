@@ -0,0 +1,281 @@
+package paper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+type testStream struct {
+	types.StandardStream
+
+	publicOnly bool
+}
+
+func (s *testStream) SetPublicOnly() {
+	s.publicOnly = true
+}
+
+func (s *testStream) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *testStream) Close() error {
+	return nil
+}
+
+// testExchange implements the market data methods used by the paper exchange,
+// the trading methods panic since they should never be called.
+type testExchange struct {
+	types.Exchange
+
+	stream *testStream
+	last   float64
+}
+
+func (e *testExchange) Name() types.ExchangeName {
+	return types.ExchangeBinance
+}
+
+func (e *testExchange) NewStream() types.Stream {
+	return e.stream
+}
+
+func (e *testExchange) QueryMarkets(ctx context.Context) (types.MarketMap, error) {
+	return types.MarketMap{
+		"BTCUSDT": {Symbol: "BTCUSDT", BaseCurrency: "BTC", QuoteCurrency: "USDT"},
+	}, nil
+}
+
+func (e *testExchange) QueryTicker(ctx context.Context, symbol string) (*types.Ticker, error) {
+	return &types.Ticker{Last: e.last, Buy: e.last, Sell: e.last}, nil
+}
+
+func newTestExchange(last float64) (*Exchange, *testStream, types.Stream) {
+	source := &testExchange{stream: &testStream{}, last: last}
+	ex := New(source, AccountConfig{
+		Balances: map[string]fixedpoint.Value{
+			"BTC":  fixedpoint.NewFromFloat(1.0),
+			"USDT": fixedpoint.NewFromFloat(10000.0),
+		},
+		MakerFeeRate: fixedpoint.NewFromFloat(0.001),
+		TakerFeeRate: fixedpoint.NewFromFloat(0.002),
+	})
+	return ex, source.stream, ex.NewStream()
+}
+
+func TestExchange_SubmitMarketOrder(t *testing.T) {
+	ex, _, stream := newTestExchange(10000.0)
+
+	var trades []types.Trade
+	var orders []types.Order
+	var balances types.BalanceMap
+	stream.OnTradeUpdate(func(trade types.Trade) { trades = append(trades, trade) })
+	stream.OnOrderUpdate(func(order types.Order) { orders = append(orders, order) })
+	stream.OnBalanceUpdate(func(b types.BalanceMap) { balances = b })
+
+	createdOrders, err := ex.SubmitOrders(context.Background(), types.SubmitOrder{
+		Symbol:   "BTCUSDT",
+		Side:     types.SideTypeBuy,
+		Type:     types.OrderTypeMarket,
+		Quantity: 0.5,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, createdOrders, 1)
+	assert.Equal(t, types.OrderStatusFilled, createdOrders[0].Status)
+	assert.Equal(t, "binance", createdOrders[0].Exchange)
+
+	if assert.Len(t, trades, 1) {
+		assert.Equal(t, 10000.0, trades[0].Price)
+		assert.False(t, trades[0].IsMaker)
+		assert.Equal(t, "BTC", trades[0].FeeCurrency)
+		assert.InDelta(t, 0.001, trades[0].Fee, 1e-9)
+	}
+
+	if assert.Len(t, orders, 2) {
+		assert.Equal(t, types.OrderStatusNew, orders[0].Status)
+		assert.Equal(t, types.OrderStatusFilled, orders[1].Status)
+	}
+
+	assert.InDelta(t, 5000.0, balances["USDT"].Available.Float64(), 1e-8)
+	assert.Equal(t, fixedpoint.Value(0), balances["USDT"].Locked)
+	assert.InDelta(t, 1.499, balances["BTC"].Available.Float64(), 1e-8)
+
+	accountBalances, err := ex.QueryAccountBalances(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, balances, accountBalances)
+
+	trades, err = ex.QueryTrades(context.Background(), "BTCUSDT", &types.TradeQueryOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, trades, 1)
+}
+
+func TestExchange_LimitOrderMatchedByStream(t *testing.T) {
+	ex, source, stream := newTestExchange(10000.0)
+
+	var trades []types.Trade
+	var klines []types.KLine
+	stream.OnTradeUpdate(func(trade types.Trade) { trades = append(trades, trade) })
+	stream.OnKLine(func(kline types.KLine) { klines = append(klines, kline) })
+
+	createdOrders, err := ex.SubmitOrders(context.Background(), types.SubmitOrder{
+		Symbol:   "BTCUSDT",
+		Side:     types.SideTypeSell,
+		Type:     types.OrderTypeLimit,
+		Quantity: 0.5,
+		Price:    11000.0,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, types.OrderStatusNew, createdOrders[0].Status)
+	assert.Len(t, trades, 0)
+
+	balance, _ := ex.account.Balance("BTC")
+	assert.InDelta(t, 0.5, balance.Locked.Float64(), 1e-8)
+
+	source.EmitKLine(types.KLine{Symbol: "BTCUSDT", Close: 10500.0})
+	assert.Len(t, trades, 0)
+
+	source.EmitKLine(types.KLine{Symbol: "BTCUSDT", Close: 11200.0})
+	assert.Len(t, klines, 2)
+	if assert.Len(t, trades, 1) {
+		// the limit order is executed at the order price as a maker
+		assert.Equal(t, 11000.0, trades[0].Price)
+		assert.True(t, trades[0].IsMaker)
+		assert.Equal(t, "USDT", trades[0].FeeCurrency)
+		assert.InDelta(t, 5.5, trades[0].Fee, 1e-9)
+	}
+
+	openOrders, err := ex.QueryOpenOrders(context.Background(), "BTCUSDT")
+	assert.NoError(t, err)
+	assert.Len(t, openOrders, 0)
+
+	balance, _ = ex.account.Balance("USDT")
+	assert.InDelta(t, 10000.0+5500.0-5.5, balance.Available.Float64(), 1e-8)
+}
+
+func TestExchange_CancelOrders(t *testing.T) {
+	ex, _, stream := newTestExchange(10000.0)
+
+	var orders []types.Order
+	stream.OnOrderUpdate(func(order types.Order) { orders = append(orders, order) })
+
+	createdOrders, err := ex.SubmitOrders(context.Background(), types.SubmitOrder{
+		Symbol:   "BTCUSDT",
+		Side:     types.SideTypeBuy,
+		Type:     types.OrderTypeLimit,
+		Quantity: 0.5,
+		Price:    9000.0,
+	})
+	assert.NoError(t, err)
+
+	balance, _ := ex.account.Balance("USDT")
+	assert.InDelta(t, 4500.0, balance.Locked.Float64(), 1e-8)
+
+	err = ex.CancelOrders(context.Background(), createdOrders...)
+	assert.NoError(t, err)
+
+	balance, _ = ex.account.Balance("USDT")
+	assert.Equal(t, fixedpoint.Value(0), balance.Locked)
+	assert.InDelta(t, 10000.0, balance.Available.Float64(), 1e-8)
+
+	if assert.Len(t, orders, 2) {
+		assert.Equal(t, types.OrderStatusCanceled, orders[1].Status)
+	}
+
+	// the canceled order can not be canceled again
+	err = ex.CancelOrders(context.Background(), createdOrders...)
+	assert.Error(t, err)
+}
+
+func TestExchange_RejectedOrders(t *testing.T) {
+	ex, _, _ := newTestExchange(10000.0)
+
+	// insufficient balance
+	_, err := ex.SubmitOrders(context.Background(), types.SubmitOrder{
+		Symbol:   "BTCUSDT",
+		Side:     types.SideTypeBuy,
+		Type:     types.OrderTypeLimit,
+		Quantity: 2.0,
+		Price:    9000.0,
+	})
+	assert.Error(t, err)
+
+	// the limit maker order would immediately match
+	_, err = ex.SubmitOrders(context.Background(), types.SubmitOrder{
+		Symbol:   "BTCUSDT",
+		Side:     types.SideTypeBuy,
+		Type:     types.OrderTypeLimitMaker,
+		Quantity: 0.1,
+		Price:    10100.0,
+	})
+	assert.Error(t, err)
+
+	// the stop order would trigger immediately
+	_, err = ex.SubmitOrders(context.Background(), types.SubmitOrder{
+		Symbol:    "BTCUSDT",
+		Side:      types.SideTypeSell,
+		Type:      types.OrderTypeStopMarket,
+		Quantity:  0.1,
+		StopPrice: 10100.0,
+	})
+	assert.Error(t, err)
+
+	balance, _ := ex.account.Balance("USDT")
+	assert.Equal(t, fixedpoint.Value(0), balance.Locked)
+}
+
+func TestExchange_StopOrders(t *testing.T) {
+	ex, source, stream := newTestExchange(10000.0)
+
+	var trades []types.Trade
+	stream.OnTradeUpdate(func(trade types.Trade) { trades = append(trades, trade) })
+
+	_, err := ex.SubmitOrders(context.Background(),
+		types.SubmitOrder{
+			Symbol:    "BTCUSDT",
+			Side:      types.SideTypeSell,
+			Type:      types.OrderTypeStopMarket,
+			Quantity:  0.1,
+			StopPrice: 9500.0,
+		},
+		types.SubmitOrder{
+			Symbol:    "BTCUSDT",
+			Side:      types.SideTypeSell,
+			Type:      types.OrderTypeStopLimit,
+			Quantity:  0.1,
+			StopPrice: 9500.0,
+			Price:     9600.0,
+		})
+	assert.NoError(t, err)
+
+	source.EmitKLineClosed(types.KLine{Symbol: "BTCUSDT", Close: 9400.0})
+	if assert.Len(t, trades, 1) {
+		assert.Equal(t, 9400.0, trades[0].Price)
+	}
+
+	// the triggered stop limit order works as a limit order
+	openOrders, err := ex.QueryOpenOrders(context.Background(), "BTCUSDT")
+	assert.NoError(t, err)
+	assert.Len(t, openOrders, 1)
+
+	source.EmitKLineClosed(types.KLine{Symbol: "BTCUSDT", Close: 9700.0})
+	if assert.Len(t, trades, 2) {
+		assert.Equal(t, 9600.0, trades[1].Price)
+	}
+}
+
+func TestStream_Connect(t *testing.T) {
+	_, source, stream := newTestExchange(10000.0)
+
+	stream.Subscribe(types.KLineChannel, "BTCUSDT", types.SubscribeOptions{Interval: "5m"})
+	assert.NoError(t, stream.Connect(context.Background()))
+	assert.True(t, source.publicOnly)
+	assert.Equal(t, []types.Subscription{
+		{Symbol: "BTCUSDT", Channel: types.KLineChannel, Options: types.SubscribeOptions{Interval: "5m"}},
+		{Symbol: "BTCUSDT", Channel: types.KLineChannel, Options: types.SubscribeOptions{Interval: "1m"}},
+	}, source.Subscriptions)
+}
@@ -0,0 +1,75 @@
+package paper
+
+import (
+	"context"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+// Stream forwards the public market data of the source stream,
+// the order updates, the trade updates and the balance updates are emitted by the paper exchange.
+type Stream struct {
+	types.StandardStream
+
+	source   types.Stream
+	exchange *Exchange
+}
+
+func newStream(source types.Stream, exchange *Exchange) *Stream {
+	s := &Stream{
+		source:   source,
+		exchange: exchange,
+	}
+
+	source.OnStart(s.EmitStart)
+	source.OnConnect(s.EmitConnect)
+	source.OnDisconnect(s.EmitDisconnect)
+	source.OnBookSnapshot(s.EmitBookSnapshot)
+	source.OnBookUpdate(s.EmitBookUpdate)
+	source.OnBookInvalid(s.EmitBookInvalid)
+	source.OnMarketTrade(s.EmitMarketTrade)
+	source.OnBookTickerUpdate(s.EmitBookTickerUpdate)
+
+	// match the orders before the strategies receive the kline
+	source.OnKLine(func(kline types.KLine) {
+		exchange.processPrice(kline.Symbol, kline.Close)
+		s.EmitKLine(kline)
+	})
+	source.OnKLineClosed(func(kline types.KLine) {
+		exchange.processPrice(kline.Symbol, kline.Close)
+		s.EmitKLineClosed(kline)
+	})
+	return s
+}
+
+func (s *Stream) Subscribe(channel types.Channel, symbol string, options types.SubscribeOptions) {
+	s.StandardStream.Subscribe(channel, symbol, options)
+	s.source.Subscribe(channel, symbol, options)
+}
+
+// SetPublicOnly does nothing since the source stream is always public only
+func (s *Stream) SetPublicOnly() {}
+
+func (s *Stream) Connect(ctx context.Context) error {
+	// the 1m kline keeps the last prices updated for matching the orders
+	var subscribed = map[string]bool{}
+	for _, sub := range s.Subscriptions {
+		if sub.Channel == types.KLineChannel && sub.Options.Interval == string(types.Interval1m) {
+			subscribed[sub.Symbol] = true
+		}
+	}
+
+	for _, sub := range s.Subscriptions {
+		if !subscribed[sub.Symbol] {
+			s.Subscribe(types.KLineChannel, sub.Symbol, types.SubscribeOptions{Interval: string(types.Interval1m)})
+			subscribed[sub.Symbol] = true
+		}
+	}
+
+	s.source.SetPublicOnly()
+	return s.source.Connect(ctx)
+}
+
+func (s *Stream) Close() error {
+	return s.source.Close()
+}
@@ -0,0 +1,324 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+var log = logrus.WithField("component", "ratelimit")
+
+const (
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = 5 * time.Minute
+)
+
+// Config defines the request weight budget of an exchange
+type Config struct {
+	// Weight is the request weight budget per Interval
+	Weight int
+
+	// Interval is the time window of the weight budget
+	Interval time.Duration
+
+	// UsedWeightHeader is the response header that reports the used weight of the current interval,
+	// for example, binance reports the used weight via the X-MBX-USED-WEIGHT-1M header.
+	UsedWeightHeader string
+
+	// WeightFunc returns the weight of the request, the weight is 1 if WeightFunc is not set
+	WeightFunc func(req *http.Request) int
+
+	// MinBackoff and MaxBackoff are the range of the exponential backoff when the server responds 429 or 418,
+	// the Retry-After header of the response is used when it's given.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Metrics is the snapshot of the throttling metrics of a limiter
+type Metrics struct {
+	Name string `json:"name"`
+
+	Requests int64 `json:"requests"`
+	Weight   int64 `json:"weight"`
+
+	// ThrottledRequests is the number of the requests that are delayed by the limiter
+	ThrottledRequests int64         `json:"throttledRequests"`
+	ThrottledDuration time.Duration `json:"throttledDuration"`
+
+	// TooManyRequests is the number of the 429 responses
+	TooManyRequests int64 `json:"tooManyRequests"`
+
+	// Banned is the number of the 418 responses, the IP is banned by binance after the 429 responses are ignored
+	Banned int64 `json:"banned"`
+
+	// UsedWeight is the last used weight reported by the server
+	UsedWeight int64 `json:"usedWeight"`
+
+	BlockedUntil time.Time `json:"blockedUntil,omitempty"`
+}
+
+// Limiter is the request weight budget shared by all the REST clients of an exchange
+type Limiter struct {
+	name    string
+	config  Config
+	limiter *rate.Limiter
+
+	mu           sync.Mutex
+	blockedUntil time.Time
+	backoff      time.Duration
+
+	requests          int64
+	weight            int64
+	throttledRequests int64
+	throttledDuration int64
+	tooManyRequests   int64
+	banned            int64
+	usedWeight        int64
+}
+
+var registry = struct {
+	sync.Mutex
+	limiters map[string]*Limiter
+}{limiters: make(map[string]*Limiter)}
+
+// Register returns the limiter of the given name, the limiter is created with the config if it's not registered yet,
+// so that the REST clients of the same exchange share the same budget.
+func Register(name string, config Config) *Limiter {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if l, ok := registry.limiters[name]; ok {
+		return l
+	}
+
+	l := New(name, config)
+	registry.limiters[name] = l
+	return l
+}
+
+// AllMetrics returns the metrics of all the registered limiters sorted by name
+func AllMetrics() (metrics []Metrics) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for _, l := range registry.limiters {
+		metrics = append(metrics, l.Metrics())
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Name < metrics[j].Name
+	})
+	return metrics
+}
+
+func New(name string, config Config) *Limiter {
+	if config.Weight <= 0 {
+		config.Weight = 1
+	}
+
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = defaultMinBackoff
+	}
+
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = defaultMaxBackoff
+	}
+
+	r := rate.Limit(float64(config.Weight) / config.Interval.Seconds())
+	return &Limiter{
+		name:    name,
+		config:  config,
+		limiter: rate.NewLimiter(r, config.Weight),
+	}
+}
+
+func (l *Limiter) Name() string {
+	return l.name
+}
+
+// Wait blocks until the weight is available in the budget and the backoff window is over
+func (l *Limiter) Wait(ctx context.Context, weight int) error {
+	if weight <= 0 {
+		weight = 1
+	} else if weight > l.config.Weight {
+		weight = l.config.Weight
+	}
+
+	start := time.Now()
+
+	l.mu.Lock()
+	blockedUntil := l.blockedUntil
+	l.mu.Unlock()
+
+	if blockedUntil.After(start) {
+		timer := time.NewTimer(blockedUntil.Sub(start))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if err := l.limiter.WaitN(ctx, weight); err != nil {
+		return err
+	}
+
+	atomic.AddInt64(&l.requests, 1)
+	atomic.AddInt64(&l.weight, int64(weight))
+
+	// ignore the scheduling delay
+	if delay := time.Since(start); delay > time.Millisecond {
+		atomic.AddInt64(&l.throttledRequests, 1)
+		atomic.AddInt64(&l.throttledDuration, int64(delay))
+		log.Debugf("%s request is throttled for %s", l.name, delay)
+	}
+
+	return nil
+}
+
+// Update updates the limiter state from the response, the following requests are blocked when
+// the server responds 429 or 418, or the used weight reported by the server exceeds the budget.
+func (l *Limiter) Update(resp *http.Response) {
+	now := time.Now()
+
+	if l.config.UsedWeightHeader != "" {
+		if s := resp.Header.Get(l.config.UsedWeightHeader); s != "" {
+			if usedWeight, err := strconv.ParseInt(s, 10, 64); err == nil {
+				atomic.StoreInt64(&l.usedWeight, usedWeight)
+
+				// the local budget drifted from the server, wait for the next interval
+				if usedWeight >= int64(l.config.Weight) {
+					next := now.Truncate(l.config.Interval).Add(l.config.Interval)
+					log.Warnf("%s used weight %d exceeds the budget %d, blocking the requests until %s", l.name, usedWeight, l.config.Weight, next)
+					l.block(next)
+				}
+			}
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusTeapot:
+		if resp.StatusCode == http.StatusTeapot {
+			atomic.AddInt64(&l.banned, 1)
+		} else {
+			atomic.AddInt64(&l.tooManyRequests, 1)
+		}
+
+		l.mu.Lock()
+		if l.backoff == 0 {
+			l.backoff = l.config.MinBackoff
+		} else {
+			l.backoff *= 2
+		}
+
+		if l.backoff > l.config.MaxBackoff {
+			l.backoff = l.config.MaxBackoff
+		}
+
+		backoff := l.backoff
+		l.mu.Unlock()
+
+		if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && retryAfter > 0 {
+			backoff = time.Duration(retryAfter) * time.Second
+		}
+
+		log.Warnf("%s responds %d, backing off for %s", l.name, resp.StatusCode, backoff)
+		l.block(now.Add(backoff))
+
+	default:
+		if resp.StatusCode < http.StatusBadRequest {
+			l.mu.Lock()
+			l.backoff = 0
+			l.mu.Unlock()
+		}
+	}
+}
+
+func (l *Limiter) block(until time.Time) {
+	l.mu.Lock()
+	if until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+	l.mu.Unlock()
+}
+
+func (l *Limiter) Metrics() Metrics {
+	l.mu.Lock()
+	blockedUntil := l.blockedUntil
+	l.mu.Unlock()
+
+	if blockedUntil.Before(time.Now()) {
+		blockedUntil = time.Time{}
+	}
+
+	return Metrics{
+		Name:              l.name,
+		Requests:          atomic.LoadInt64(&l.requests),
+		Weight:            atomic.LoadInt64(&l.weight),
+		ThrottledRequests: atomic.LoadInt64(&l.throttledRequests),
+		ThrottledDuration: time.Duration(atomic.LoadInt64(&l.throttledDuration)),
+		TooManyRequests:   atomic.LoadInt64(&l.tooManyRequests),
+		Banned:            atomic.LoadInt64(&l.banned),
+		UsedWeight:        atomic.LoadInt64(&l.usedWeight),
+		BlockedUntil:      blockedUntil,
+	}
+}
+
+func (l *Limiter) requestWeight(req *http.Request) int {
+	if l.config.WeightFunc != nil {
+		return l.config.WeightFunc(req)
+	}
+
+	return 1
+}
+
+// Transport wraps the http round tripper, the requests wait for the budget before they are sent
+func (l *Limiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{limiter: l, base: base}
+}
+
+// HTTPClient returns a shallow copy of the http client with the rate limited transport
+func (l *Limiter) HTTPClient(client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	c := *client
+	c.Transport = l.Transport(client.Transport)
+	return &c
+}
+
+type transport struct {
+	limiter *Limiter
+	base    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context(), t.limiter.requestWeight(req)); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.limiter.Update(resp)
+	return resp, nil
+}
@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(handler func(w http.ResponseWriter, n int64)) *httptest.Server {
+	var count int64
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, atomic.AddInt64(&count, 1))
+	}))
+}
+
+func TestLimiter_WeightBudget(t *testing.T) {
+	server := newTestServer(func(w http.ResponseWriter, n int64) {
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	limiter := New("test", Config{
+		Weight:   2,
+		Interval: 200 * time.Millisecond,
+		WeightFunc: func(req *http.Request) int {
+			return 2
+		},
+	})
+	client := limiter.HTTPClient(nil)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if assert.NoError(t, err) {
+			_ = resp.Body.Close()
+		}
+	}
+
+	// the first request uses the burst, the next two requests wait for the refill
+	assert.True(t, time.Since(start) >= 350*time.Millisecond, "requests should be throttled")
+
+	metrics := limiter.Metrics()
+	assert.Equal(t, "test", metrics.Name)
+	assert.Equal(t, int64(3), metrics.Requests)
+	assert.Equal(t, int64(6), metrics.Weight)
+	assert.Equal(t, int64(2), metrics.ThrottledRequests)
+	assert.True(t, metrics.ThrottledDuration > 0)
+}
+
+func TestLimiter_ExponentialBackoff(t *testing.T) {
+	server := newTestServer(func(w http.ResponseWriter, n int64) {
+		switch n {
+		case 1, 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 3:
+			w.WriteHeader(http.StatusTeapot)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+	defer server.Close()
+
+	limiter := New("test", Config{
+		Weight:     100,
+		Interval:   time.Second,
+		MinBackoff: 50 * time.Millisecond,
+		MaxBackoff: 150 * time.Millisecond,
+	})
+	client := limiter.HTTPClient(&http.Client{Timeout: time.Second})
+
+	var durations []time.Duration
+	for i := 0; i < 4; i++ {
+		start := time.Now()
+		resp, err := client.Get(server.URL)
+		if assert.NoError(t, err) {
+			_ = resp.Body.Close()
+		}
+		durations = append(durations, time.Since(start))
+
+		// the third backoff is capped by the max backoff
+		if i == 2 {
+			limiter.mu.Lock()
+			assert.Equal(t, 150*time.Millisecond, limiter.backoff)
+			limiter.mu.Unlock()
+		}
+	}
+
+	// the second request waits for the min backoff, the third one waits for the doubled backoff
+	assert.True(t, durations[1] >= 50*time.Millisecond, durations[1].String())
+	assert.True(t, durations[2] >= 100*time.Millisecond, durations[2].String())
+	assert.True(t, durations[3] >= 150*time.Millisecond, durations[3].String())
+
+	metrics := limiter.Metrics()
+	assert.Equal(t, int64(2), metrics.TooManyRequests)
+	assert.Equal(t, int64(1), metrics.Banned)
+
+	// the successful response resets the backoff
+	limiter.mu.Lock()
+	assert.Equal(t, time.Duration(0), limiter.backoff)
+	limiter.mu.Unlock()
+}
+
+func TestLimiter_RetryAfter(t *testing.T) {
+	server := newTestServer(func(w http.ResponseWriter, n int64) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTeapot)
+	})
+	defer server.Close()
+
+	limiter := New("test", Config{Weight: 10, Interval: time.Second})
+	resp, err := limiter.HTTPClient(nil).Get(server.URL)
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+	}
+
+	blockedUntil := limiter.Metrics().BlockedUntil
+	assert.WithinDuration(t, time.Now().Add(120*time.Second), blockedUntil, 5*time.Second)
+
+	// the blocked request is cancelled by the context
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, limiter.Wait(ctx, 1))
+}
+
+func TestLimiter_UsedWeightHeader(t *testing.T) {
+	server := newTestServer(func(w http.ResponseWriter, n int64) {
+		w.Header().Set("X-USED-WEIGHT", "1200")
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	limiter := New("test", Config{
+		Weight:           1200,
+		Interval:         time.Minute,
+		UsedWeightHeader: "X-USED-WEIGHT",
+	})
+
+	resp, err := limiter.HTTPClient(nil).Get(server.URL)
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+	}
+
+	metrics := limiter.Metrics()
+	assert.Equal(t, int64(1200), metrics.UsedWeight)
+
+	// the requests are blocked until the next minute
+	assert.True(t, metrics.BlockedUntil.After(time.Now()))
+	assert.True(t, metrics.BlockedUntil.Sub(time.Now()) <= time.Minute)
+	assert.Equal(t, metrics.BlockedUntil, metrics.BlockedUntil.Truncate(time.Minute))
+}
+
+func TestRegister(t *testing.T) {
+	a := Register("test-register", Config{Weight: 10, Interval: time.Second})
+	b := Register("test-register", Config{Weight: 20, Interval: time.Second})
+	assert.True(t, a == b, "the limiter of the same name should be shared")
+
+	var found bool
+	for _, metrics := range AllMetrics() {
+		if metrics.Name == "test-register" {
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
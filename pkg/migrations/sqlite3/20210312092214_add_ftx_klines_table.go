@@ -0,0 +1,34 @@
+package sqlite3
+
+import (
+	"context"
+
+	"github.com/c9s/rockhopper"
+)
+
+func init() {
+	AddMigration(upAddFtxKlinesTable, downAddFtxKlinesTable)
+
+}
+
+func upAddFtxKlinesTable(ctx context.Context, tx rockhopper.SQLExecutor) (err error) {
+	// This code is executed when the migration is applied.
+
+	_, err = tx.ExecContext(ctx, "CREATE TABLE `ftx_klines` AS SELECT * FROM `klines` WHERE 0")
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+func downAddFtxKlinesTable(ctx context.Context, tx rockhopper.SQLExecutor) (err error) {
+	// This code is executed when the migration is rolled back.
+
+	_, err = tx.ExecContext(ctx, "DROP TABLE IF EXISTS `ftx_klines`;")
+	if err != nil {
+		return err
+	}
+
+	return err
+}
@@ -0,0 +1,74 @@
+package sqlite3
+
+import (
+	"context"
+
+	"github.com/c9s/rockhopper"
+)
+
+func init() {
+	AddMigration(upAddBacktestRunsTables, downAddBacktestRunsTables)
+
+}
+
+func upAddBacktestRunsTables(ctx context.Context, tx rockhopper.SQLExecutor) (err error) {
+	// This code is executed when the migration is applied.
+
+	_, err = tx.ExecContext(ctx, "CREATE TABLE `backtest_runs`\n(\n    `id`             INTEGER PRIMARY KEY AUTOINCREMENT,\n    `session`        VARCHAR(32)    NOT NULL,\n    `exchange`       VARCHAR(24)    NOT NULL,\n    `quote_currency` VARCHAR(10)    NOT NULL,\n    `start_time`     DATETIME(3)    NOT NULL,\n    `end_time`       DATETIME(3)    NOT NULL,\n    -- config is the json snapshot of the bbgo config used by the run\n    `config`         TEXT           NOT NULL,\n    -- metrics is the json performance report without the equity curve\n    `metrics`        TEXT           NOT NULL,\n    `equity_curve`   TEXT           NOT NULL,\n    `created_at`     DATETIME(3)    NOT NULL DEFAULT CURRENT_TIMESTAMP\n);")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "CREATE TABLE `backtest_trades`\n(\n    `gid`            INTEGER PRIMARY KEY AUTOINCREMENT,\n    `run_id`         INTEGER        NOT NULL,\n    `id`             INTEGER        NOT NULL,\n    `order_id`       INTEGER        NOT NULL,\n    `exchange`       VARCHAR(24)    NOT NULL DEFAULT '',\n    `symbol`         VARCHAR(20)    NOT NULL,\n    `price`          DECIMAL(16, 8) NOT NULL,\n    `quantity`       DECIMAL(16, 8) NOT NULL,\n    `quote_quantity` DECIMAL(16, 8) NOT NULL,\n    `fee`            DECIMAL(16, 8) NOT NULL,\n    `fee_currency`   VARCHAR(10)    NOT NULL,\n    `is_buyer`       BOOLEAN        NOT NULL DEFAULT FALSE,\n    `is_maker`       BOOLEAN        NOT NULL DEFAULT FALSE,\n    `side`           VARCHAR(4)     NOT NULL DEFAULT '',\n    `is_margin`      BOOLEAN        NOT NULL DEFAULT FALSE,\n    `is_isolated`    BOOLEAN        NOT NULL DEFAULT FALSE,\n    `traded_at`      DATETIME(3)    NOT NULL\n);")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "CREATE INDEX `backtest_trades_run_id` ON `backtest_trades` (`run_id`, `traded_at`);")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "CREATE TABLE `backtest_orders`\n(\n    `gid`               INTEGER PRIMARY KEY AUTOINCREMENT,\n    `run_id`            INTEGER        NOT NULL,\n    `exchange`          VARCHAR(24)    NOT NULL DEFAULT '',\n    `order_id`          INTEGER        NOT NULL,\n    `client_order_id`   VARCHAR(42)    NOT NULL DEFAULT '',\n    `order_type`        VARCHAR(16)    NOT NULL,\n    `symbol`            VARCHAR(20)    NOT NULL,\n    `status`            VARCHAR(12)    NOT NULL,\n    `time_in_force`     VARCHAR(4)     NOT NULL,\n    `price`             DECIMAL(16, 8) NOT NULL,\n    `stop_price`        DECIMAL(16, 8) NOT NULL,\n    `quantity`          DECIMAL(16, 8) NOT NULL,\n    `executed_quantity` DECIMAL(16, 8) NOT NULL DEFAULT 0.0,\n    `side`              VARCHAR(4)     NOT NULL DEFAULT '',\n    `is_working`        BOOLEAN        NOT NULL DEFAULT FALSE,\n    `is_margin`         BOOLEAN        NOT NULL DEFAULT FALSE,\n    `is_isolated`       BOOLEAN        NOT NULL DEFAULT FALSE,\n    `created_at`        DATETIME(3)    NOT NULL,\n    `updated_at`        DATETIME(3)    NOT NULL\n);")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "CREATE INDEX `backtest_orders_run_id` ON `backtest_orders` (`run_id`, `created_at`);")
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+func downAddBacktestRunsTables(ctx context.Context, tx rockhopper.SQLExecutor) (err error) {
+	// This code is executed when the migration is rolled back.
+
+	_, err = tx.ExecContext(ctx, "DROP INDEX IF EXISTS `backtest_orders_run_id`;")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DROP TABLE IF EXISTS `backtest_orders`;")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DROP INDEX IF EXISTS `backtest_trades_run_id`;")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DROP TABLE IF EXISTS `backtest_trades`;")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DROP TABLE IF EXISTS `backtest_runs`;")
+	if err != nil {
+		return err
+	}
+
+	return err
+}
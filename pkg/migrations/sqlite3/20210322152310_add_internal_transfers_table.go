@@ -0,0 +1,44 @@
+package sqlite3
+
+import (
+	"context"
+
+	"github.com/c9s/rockhopper"
+)
+
+func init() {
+	AddMigration(upAddInternalTransfersTable, downAddInternalTransfersTable)
+
+}
+
+func upAddInternalTransfersTable(ctx context.Context, tx rockhopper.SQLExecutor) (err error) {
+	// This code is executed when the migration is applied.
+
+	_, err = tx.ExecContext(ctx, "CREATE TABLE `internal_transfers`\n(\n    `gid`          INTEGER PRIMARY KEY AUTOINCREMENT,\n    `exchange`     VARCHAR(24)    NOT NULL DEFAULT '',\n    -- transfer_id is the transfer id returned by the exchange\n    `transfer_id`  VARCHAR(64)    NOT NULL,\n    `asset`        VARCHAR(10)    NOT NULL,\n    `amount`       DECIMAL(16, 8) NOT NULL,\n    -- from_account and to_account are the sub-accounts or the wallets, e.g., main, spot, margin or isolated:BTCUSDT\n    `from_account` VARCHAR(64)    NOT NULL,\n    `to_account`   VARCHAR(64)    NOT NULL,\n    `status`       VARCHAR(32)    NOT NULL DEFAULT '',\n    `time`         DATETIME(3)    NOT NULL\n);")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "CREATE UNIQUE INDEX `internal_transfers_transfer_id` ON `internal_transfers` (`exchange`, `transfer_id`);")
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+func downAddInternalTransfersTable(ctx context.Context, tx rockhopper.SQLExecutor) (err error) {
+	// This code is executed when the migration is rolled back.
+
+	_, err = tx.ExecContext(ctx, "DROP INDEX IF EXISTS `internal_transfers_transfer_id`;")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DROP TABLE IF EXISTS `internal_transfers`;")
+	if err != nil {
+		return err
+	}
+
+	return err
+}
@@ -1,7 +1,11 @@
 package telegramnotifier
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/pquerna/otp"
 	"github.com/pquerna/otp/totp"
@@ -13,6 +17,10 @@ import (
 
 var log = logrus.WithField("service", "telegram")
 
+// usedPasscodeTTL is longer than the validity window of the one-time password,
+// which is one period of 30 seconds with one period of skew on both sides.
+const usedPasscodeTTL = 2 * time.Minute
+
 type Session struct {
 	Owner              *telebot.User `json:"owner"`
 	OneTimePasswordKey *otp.Key      `json:"otpKey"`
@@ -35,19 +43,30 @@ type Interaction struct {
 
 	session *Session
 
+	// confirmMutex serializes the confirmations, only one confirmation is pending at a time
+	confirmMutex sync.Mutex
+
+	pendingMutex   sync.Mutex
+	pendingConfirm chan struct{}
+
+	// usedPasscodes records the time of the one-time passwords used for the confirmations, guarded by pendingMutex
+	usedPasscodes map[string]time.Time
+
 	StartCallbacks []func()
 	AuthCallbacks  []func(user *telebot.User)
 }
 
 func NewInteraction(bot *telebot.Bot, store service.Store) *Interaction {
 	interaction := &Interaction{
-		store: store,
-		bot:   bot,
+		store:         store,
+		bot:           bot,
+		usedPasscodes: make(map[string]time.Time),
 	}
 
 	bot.Handle("/help", interaction.HandleHelp)
 	bot.Handle("/auth", interaction.HandleAuth)
 	bot.Handle("/info", interaction.HandleInfo)
+	bot.Handle("/confirm", interaction.HandleConfirm)
 	return interaction
 }
 
@@ -92,6 +111,7 @@ func (it *Interaction) HandleHelp(m *telebot.Message) {
 help	- show this help message
 auth	- authorize current telegram user to access telegram bot with authentication token or one-time password. ex. /auth my-token
 info	- show information about current chat
+confirm	- confirm the pending operation with one-time password. ex. /confirm 123456
 `
 	if _, err := it.bot.Send(m.Sender, message); err != nil {
 		log.WithError(err).Error("failed to send help message")
@@ -139,6 +159,91 @@ func (it *Interaction) HandleAuth(m *telebot.Message) {
 	}
 }
 
+// Confirm sends the message to the owner and waits until the owner confirms it by /confirm with the one-time password
+// of the session, the context should be canceled with a timeout if the owner doesn't respond.
+func (it *Interaction) Confirm(ctx context.Context, message string) error {
+	if it.session == nil || it.session.Owner == nil {
+		return errors.New("telegram owner is not authorized")
+	}
+
+	if it.session.OneTimePasswordKey == nil {
+		return errors.New("telegram one-time password key is not set up")
+	}
+
+	it.confirmMutex.Lock()
+	defer it.confirmMutex.Unlock()
+
+	c := make(chan struct{})
+	it.pendingMutex.Lock()
+	it.pendingConfirm = c
+	it.pendingMutex.Unlock()
+
+	it.SendToOwner(message + "\n\nreply /confirm with the one-time password to confirm")
+
+	select {
+	case <-c:
+		return nil
+
+	case <-ctx.Done():
+		it.pendingMutex.Lock()
+		if it.pendingConfirm == c {
+			it.pendingConfirm = nil
+		}
+		it.pendingMutex.Unlock()
+
+		it.SendToOwner("confirmation is expired")
+		return ctx.Err()
+	}
+}
+
+func (it *Interaction) HandleConfirm(m *telebot.Message) {
+	if it.session == nil || it.session.Owner == nil || m.Sender.ID != it.session.Owner.ID {
+		log.Warningf("incorrect user tried to confirm! sender: %+v", m.Sender)
+		return
+	}
+
+	if it.session.OneTimePasswordKey == nil || !totp.Validate(m.Payload, it.session.OneTimePasswordKey.Secret()) {
+		it.SendToOwner("Confirmation failed. please check your one-time password")
+		return
+	}
+
+	it.pendingMutex.Lock()
+	if !it.usePasscode(m.Payload, time.Now()) {
+		it.pendingMutex.Unlock()
+		it.SendToOwner("Confirmation failed. the one-time password has been used, please wait for the next one")
+		return
+	}
+
+	c := it.pendingConfirm
+	it.pendingConfirm = nil
+	it.pendingMutex.Unlock()
+
+	if c == nil {
+		it.SendToOwner("There is nothing to confirm")
+		return
+	}
+
+	close(c)
+	it.SendToOwner("Confirmed")
+}
+
+// usePasscode records the passcode as used, it returns false if the passcode has been used within its validity window.
+// The caller must hold pendingMutex.
+func (it *Interaction) usePasscode(passcode string, now time.Time) bool {
+	for code, usedTime := range it.usedPasscodes {
+		if now.Sub(usedTime) > usedPasscodeTTL {
+			delete(it.usedPasscodes, code)
+		}
+	}
+
+	if _, ok := it.usedPasscodes[passcode]; ok {
+		return false
+	}
+
+	it.usedPasscodes[passcode] = now
+	return true
+}
+
 func (it *Interaction) Start(session Session) {
 	it.session = &session
 
@@ -0,0 +1,20 @@
+package telegramnotifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInteraction_usePasscode(t *testing.T) {
+	it := &Interaction{usedPasscodes: make(map[string]time.Time)}
+	now := time.Date(2021, 3, 22, 0, 0, 0, 0, time.UTC)
+
+	assert.True(t, it.usePasscode("123456", now))
+	assert.False(t, it.usePasscode("123456", now.Add(30*time.Second)), "the passcode can not be reused in its validity window")
+	assert.True(t, it.usePasscode("654321", now.Add(30*time.Second)))
+
+	// the used passcodes are expired after the validity window
+	assert.True(t, it.usePasscode("123456", now.Add(usedPasscodeTTL+time.Second)))
+}
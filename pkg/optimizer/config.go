@@ -0,0 +1,132 @@
+package optimizer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SelectorTypeIterate = "iterate"
+	SelectorTypeRange   = "range"
+)
+
+// SelectorConfig defines the candidate values of a strategy parameter.
+// The parameter is addressed by the JSON pointer of the bbgo config, e.g., /exchangeStrategies/0/grid/gridNumber
+type SelectorConfig struct {
+	Type  string `json:"type" yaml:"type"`
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+	Path  string `json:"path" yaml:"path"`
+
+	// Values is used by the iterate selector
+	Values []interface{} `json:"values,omitempty" yaml:"values,omitempty"`
+
+	// Min, Max and Step are used by the range selector, Max is inclusive
+	Min  float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	Max  float64 `json:"max,omitempty" yaml:"max,omitempty"`
+	Step float64 `json:"step,omitempty" yaml:"step,omitempty"`
+}
+
+func (c SelectorConfig) label() string {
+	if len(c.Label) > 0 {
+		return c.Label
+	}
+
+	return c.Path
+}
+
+func (c SelectorConfig) values() ([]interface{}, error) {
+	switch c.Type {
+
+	case SelectorTypeIterate:
+		if len(c.Values) == 0 {
+			return nil, fmt.Errorf("selector %s: values can not be empty", c.label())
+		}
+
+		return c.Values, nil
+
+	case SelectorTypeRange:
+		if c.Step <= 0 {
+			return nil, fmt.Errorf("selector %s: step must be greater than zero", c.label())
+		}
+
+		if c.Max < c.Min {
+			return nil, fmt.Errorf("selector %s: max %f is less than min %f", c.label(), c.Max, c.Min)
+		}
+
+		var values []interface{}
+		for i := 0; ; i++ {
+			// round the value to avoid the accumulated floating point error, e.g., 0.1 + 0.2
+			v := math.Round((c.Min+float64(i)*c.Step)*1e8) / 1e8
+			if v > c.Max {
+				break
+			}
+
+			values = append(values, v)
+		}
+
+		return values, nil
+
+	default:
+		return nil, fmt.Errorf("selector %s: unsupported selector type: %q", c.label(), c.Type)
+
+	}
+}
+
+// Config is the sweep spec of the optimizer
+type Config struct {
+	Matrix []SelectorConfig `json:"matrix" yaml:"matrix"`
+
+	// WalkForward is used by the walk-forward mode of the backtest command
+	WalkForward *WalkForwardConfig `json:"walkForward,omitempty" yaml:"walkForward,omitempty"`
+}
+
+func LoadConfig(configFile string) (*Config, error) {
+	content, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, err
+	}
+
+	if len(config.Matrix) == 0 {
+		return nil, fmt.Errorf("optimizer config %s: matrix can not be empty", configFile)
+	}
+
+	return &config, nil
+}
+
+// ParamSets expands the matrix into the cartesian product of the selector values
+func (c *Config) ParamSets() ([]ParamSet, error) {
+	var paramSets = []ParamSet{{}}
+
+	for _, selector := range c.Matrix {
+		values, err := selector.values()
+		if err != nil {
+			return nil, err
+		}
+
+		var expanded []ParamSet
+		for _, paramSet := range paramSets {
+			for _, value := range values {
+				var ps = make(ParamSet, len(paramSet), len(paramSet)+1)
+				copy(ps, paramSet)
+				ps = append(ps, Param{
+					Label: selector.label(),
+					Path:  selector.Path,
+					Value: value,
+				})
+				expanded = append(expanded, ps)
+			}
+		}
+
+		paramSets = expanded
+	}
+
+	return paramSets, nil
+}
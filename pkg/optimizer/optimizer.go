@@ -0,0 +1,159 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/c9s/bbgo/pkg/backtest"
+)
+
+// Runner runs a backtest with the given (patched) config document
+type Runner func(ctx context.Context, configJSON []byte) (*backtest.PerformanceReport, error)
+
+type Result struct {
+	Params ParamSet                    `json:"params"`
+	Report *backtest.PerformanceReport `json:"report,omitempty"`
+	Error  error                       `json:"-"`
+}
+
+// Metric picks the value used for ranking the results from the performance report
+type Metric struct {
+	Value func(report *backtest.PerformanceReport) float64
+
+	// LowerIsBetter sorts the results in ascending order
+	LowerIsBetter bool
+}
+
+var Metrics = map[string]Metric{
+	"totalReturn":  {Value: func(r *backtest.PerformanceReport) float64 { return r.TotalReturn }},
+	"finalEquity":  {Value: func(r *backtest.PerformanceReport) float64 { return r.FinalEquity }},
+	"sharpeRatio":  {Value: func(r *backtest.PerformanceReport) float64 { return r.SharpeRatio }},
+	"sortinoRatio": {Value: func(r *backtest.PerformanceReport) float64 { return r.SortinoRatio }},
+	"profitFactor": {Value: func(r *backtest.PerformanceReport) float64 { return r.ProfitFactor }},
+	"winRate":      {Value: func(r *backtest.PerformanceReport) float64 { return r.WinRate }},
+	"maxDrawdown":  {Value: func(r *backtest.PerformanceReport) float64 { return r.MaxDrawdown }, LowerIsBetter: true},
+}
+
+type Optimizer struct {
+	Config *Config
+
+	// Workers is the number of the backtest runs executed concurrently
+	Workers int
+}
+
+// Run runs the backtest of every parameter set with the worker goroutines,
+// the results are returned in the order of the parameter sets.
+func (o *Optimizer) Run(ctx context.Context, configJSON []byte, runner Runner) ([]Result, error) {
+	paramSets, err := o.Config.ParamSets()
+	if err != nil {
+		return nil, err
+	}
+
+	workers := o.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	log.Infof("running %d backtests with %d workers", len(paramSets), workers)
+
+	var results = make([]Result, len(paramSets))
+	var jobC = make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobC {
+				results[idx] = o.run(ctx, configJSON, paramSets[idx], runner)
+			}
+		}()
+	}
+
+dispatch:
+	for idx := range paramSets {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobC <- idx:
+		}
+	}
+	close(jobC)
+	wg.Wait()
+
+	return results, ctx.Err()
+}
+
+func (o *Optimizer) run(ctx context.Context, configJSON []byte, paramSet ParamSet, runner Runner) Result {
+	result := Result{Params: paramSet}
+
+	patched, err := paramSet.Apply(configJSON)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	result.Report, result.Error = runner(ctx, patched)
+	if result.Error != nil {
+		log.WithError(result.Error).Errorf("backtest failed: %s", paramSet)
+	} else {
+		log.Infof("backtest done: %s", paramSet)
+	}
+
+	return result
+}
+
+// SortResults sorts the results by the given metric, the best result comes first and the failed runs come last.
+func SortResults(results []Result, metricName string) error {
+	metric, ok := Metrics[metricName]
+	if !ok {
+		return fmt.Errorf("unsupported metric: %s", metricName)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Report == nil || b.Report == nil {
+			return b.Report == nil && a.Report != nil
+		}
+
+		if metric.LowerIsBetter {
+			return metric.Value(a.Report) < metric.Value(b.Report)
+		}
+		return metric.Value(a.Report) > metric.Value(b.Report)
+	})
+
+	return nil
+}
+
+// RenderResults renders the ranked results as a table
+func RenderResults(w io.Writer, results []Result, metricName string) error {
+	metric, ok := Metrics[metricName]
+	if !ok {
+		return fmt.Errorf("unsupported metric: %s", metricName)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "RANK\t%s\tTOTAL RETURN\tMAX DRAWDOWN\tTRADES\tPARAMS\n", metricName)
+	for i, result := range results {
+		if result.Report == nil {
+			fmt.Fprintf(tw, "-\tERROR\t-\t-\t-\t%s (%v)\n", result.Params, result.Error)
+			continue
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%s\n",
+			i+1,
+			strconv.FormatFloat(metric.Value(result.Report), 'f', 4, 64),
+			strconv.FormatFloat(result.Report.TotalReturn*100.0, 'f', 2, 64)+"%",
+			strconv.FormatFloat(result.Report.MaxDrawdown*100.0, 'f', 2, 64)+"%",
+			result.Report.NumTrades,
+			result.Params)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,107 @@
+package optimizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/backtest"
+)
+
+const testConfigJSON = `{
+  "backtest": {"startTime": "2021-01-01"},
+  "exchangeStrategies": [
+    {"on": "binance", "grid": {"symbol": "BTCUSDT", "gridNumber": 10, "profitSpread": 100}}
+  ]
+}`
+
+func TestConfig_ParamSets(t *testing.T) {
+	config := &Config{
+		Matrix: []SelectorConfig{
+			{Type: SelectorTypeIterate, Label: "gridNumber", Path: "/exchangeStrategies/0/grid/gridNumber", Values: []interface{}{10, 20}},
+			{Type: SelectorTypeRange, Path: "/exchangeStrategies/0/grid/profitSpread", Min: 0.1, Max: 0.3, Step: 0.1},
+		},
+	}
+
+	paramSets, err := config.ParamSets()
+	assert.NoError(t, err)
+	if assert.Len(t, paramSets, 6) {
+		assert.Equal(t, "gridNumber=10 /exchangeStrategies/0/grid/profitSpread=0.1", paramSets[0].String())
+		assert.Equal(t, "gridNumber=20 /exchangeStrategies/0/grid/profitSpread=0.3", paramSets[5].String())
+	}
+
+	config.Matrix[1].Step = 0
+	_, err = config.ParamSets()
+	assert.Error(t, err)
+}
+
+func TestParamSet_Apply(t *testing.T) {
+	paramSet := ParamSet{
+		{Path: "/exchangeStrategies/0/grid/gridNumber", Value: 20},
+		{Path: "/exchangeStrategies/0/grid/upperPrice", Value: 40000.0},
+	}
+
+	patched, err := paramSet.Apply([]byte(testConfigJSON))
+	assert.NoError(t, err)
+
+	var doc struct {
+		ExchangeStrategies []map[string]interface{} `json:"exchangeStrategies"`
+	}
+	assert.NoError(t, json.Unmarshal(patched, &doc))
+	grid := doc.ExchangeStrategies[0]["grid"].(map[string]interface{})
+	assert.Equal(t, 20.0, grid["gridNumber"])
+	assert.Equal(t, 40000.0, grid["upperPrice"])
+	assert.Equal(t, 100.0, grid["profitSpread"])
+
+	_, err = ParamSet{{Path: "/exchangeStrategies/1/grid/gridNumber", Value: 1}}.Apply([]byte(testConfigJSON))
+	assert.Error(t, err)
+
+	_, err = ParamSet{{Path: "exchangeStrategies", Value: 1}}.Apply([]byte(testConfigJSON))
+	assert.Error(t, err)
+}
+
+func TestOptimizer_Run(t *testing.T) {
+	optimizer := &Optimizer{
+		Config: &Config{
+			Matrix: []SelectorConfig{
+				{Type: SelectorTypeRange, Label: "gridNumber", Path: "/exchangeStrategies/0/grid/gridNumber", Min: 1, Max: 5, Step: 1},
+			},
+		},
+		Workers: 3,
+	}
+
+	results, err := optimizer.Run(context.Background(), []byte(testConfigJSON), func(ctx context.Context, configJSON []byte) (*backtest.PerformanceReport, error) {
+		var doc struct {
+			ExchangeStrategies []struct {
+				Grid struct {
+					GridNumber float64 `json:"gridNumber"`
+				} `json:"grid"`
+			} `json:"exchangeStrategies"`
+		}
+		if err := json.Unmarshal(configJSON, &doc); err != nil {
+			return nil, err
+		}
+
+		gridNumber := doc.ExchangeStrategies[0].Grid.GridNumber
+		if gridNumber == 4 {
+			return nil, errors.New("backtest failed")
+		}
+
+		return &backtest.PerformanceReport{TotalReturn: gridNumber / 10, MaxDrawdown: gridNumber / 100}, nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 5)
+
+	assert.NoError(t, SortResults(results, "totalReturn"))
+	assert.Equal(t, 5.0, results[0].Params[0].Value)
+	assert.Nil(t, results[4].Report)
+	assert.Error(t, results[4].Error)
+
+	assert.NoError(t, SortResults(results, "maxDrawdown"))
+	assert.Equal(t, 1.0, results[0].Params[0].Value)
+
+	assert.Error(t, SortResults(results, "luck"))
+}
@@ -0,0 +1,93 @@
+package optimizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Param struct {
+	Label string      `json:"label"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// ParamSet is a combination of the parameter values of one backtest run
+type ParamSet []Param
+
+func (s ParamSet) String() string {
+	var parts []string
+	for _, p := range s {
+		parts = append(parts, fmt.Sprintf("%s=%v", p.Label, p.Value))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Apply patches the given json config document with the parameter values and returns the patched document
+func (s ParamSet) Apply(configJSON []byte) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(configJSON, &doc); err != nil {
+		return nil, err
+	}
+
+	for _, p := range s {
+		if err := setByPointer(doc, p.Path, p.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+// setByPointer sets the value addressed by the JSON pointer (RFC 6901).
+// The parent of the target must exist, a missing key of an object will be added.
+func setByPointer(doc interface{}, pointer string, value interface{}) error {
+	if !strings.HasPrefix(pointer, "/") {
+		return fmt.Errorf("invalid json pointer %q, it must start with /", pointer)
+	}
+
+	var tokens = strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	var node = doc
+	for i, token := range tokens {
+		last := i == len(tokens)-1
+
+		switch tv := node.(type) {
+
+		case map[string]interface{}:
+			if last {
+				tv[token] = value
+				return nil
+			}
+
+			child, ok := tv[token]
+			if !ok {
+				return fmt.Errorf("json pointer %q: key %q not found", pointer, token)
+			}
+			node = child
+
+		case []interface{}:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(tv) {
+				return fmt.Errorf("json pointer %q: invalid array index %q", pointer, token)
+			}
+
+			if last {
+				tv[idx] = value
+				return nil
+			}
+			node = tv[idx]
+
+		default:
+			return fmt.Errorf("json pointer %q: can not traverse into %T at %q", pointer, node, token)
+
+		}
+	}
+
+	return nil
+}
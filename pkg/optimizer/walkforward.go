@@ -0,0 +1,218 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/c9s/bbgo/pkg/backtest"
+)
+
+const dateFormat = "2006-01-02"
+
+// WalkForwardConfig defines the rolling in-sample/out-of-sample windows, the window sizes are in days
+// since the backtest time range is defined by dates.
+type WalkForwardConfig struct {
+	InSampleDays    int `json:"inSampleDays" yaml:"inSampleDays"`
+	OutOfSampleDays int `json:"outOfSampleDays" yaml:"outOfSampleDays"`
+
+	// StepDays is the distance between the windows, defaults to OutOfSampleDays so that the out-of-sample windows are continuous
+	StepDays int `json:"stepDays,omitempty" yaml:"stepDays,omitempty"`
+
+	// Anchored keeps the in-sample windows starting from the beginning of the backtest range (expanding windows)
+	Anchored bool `json:"anchored,omitempty" yaml:"anchored,omitempty"`
+
+	// Metric is used for selecting the best parameters of the in-sample window, defaults to totalReturn
+	Metric string `json:"metric,omitempty" yaml:"metric,omitempty"`
+}
+
+// MetricName returns the metric used for selecting the best in-sample parameters
+func (c WalkForwardConfig) MetricName() string {
+	if len(c.Metric) == 0 {
+		return "totalReturn"
+	}
+
+	return c.Metric
+}
+
+// Window is a pair of the in-sample range and the following out-of-sample range
+type Window struct {
+	InSampleStart    time.Time `json:"inSampleStart"`
+	InSampleEnd      time.Time `json:"inSampleEnd"`
+	OutOfSampleStart time.Time `json:"outOfSampleStart"`
+	OutOfSampleEnd   time.Time `json:"outOfSampleEnd"`
+}
+
+// Windows splits the given time range into the walk-forward windows,
+// the last out-of-sample window is truncated at the end time.
+func (c WalkForwardConfig) Windows(startTime, endTime time.Time) ([]Window, error) {
+	if c.InSampleDays <= 0 || c.OutOfSampleDays <= 0 {
+		return nil, fmt.Errorf("walk forward: inSampleDays and outOfSampleDays must be greater than zero")
+	}
+
+	if _, ok := Metrics[c.MetricName()]; !ok {
+		return nil, fmt.Errorf("walk forward: unsupported metric: %s", c.MetricName())
+	}
+
+	step := c.StepDays
+	if step <= 0 {
+		step = c.OutOfSampleDays
+	}
+
+	var windows []Window
+	for i := 0; ; i++ {
+		inSampleStart := startTime.AddDate(0, 0, i*step)
+		inSampleEnd := inSampleStart.AddDate(0, 0, c.InSampleDays)
+		if c.Anchored {
+			inSampleStart = startTime
+			inSampleEnd = startTime.AddDate(0, 0, c.InSampleDays+i*step)
+		}
+
+		if !inSampleEnd.Before(endTime) {
+			break
+		}
+
+		outOfSampleEnd := inSampleEnd.AddDate(0, 0, c.OutOfSampleDays)
+		if outOfSampleEnd.After(endTime) {
+			outOfSampleEnd = endTime
+		}
+
+		windows = append(windows, Window{
+			InSampleStart:    inSampleStart,
+			InSampleEnd:      inSampleEnd,
+			OutOfSampleStart: inSampleEnd,
+			OutOfSampleEnd:   outOfSampleEnd,
+		})
+	}
+
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("walk forward: the time range %s ~ %s is shorter than the in-sample window of %d days",
+			startTime.Format(dateFormat), endTime.Format(dateFormat), c.InSampleDays)
+	}
+
+	return windows, nil
+}
+
+type WindowResult struct {
+	Window
+
+	// Params is the best parameter set of the in-sample window
+	Params            ParamSet                    `json:"params"`
+	InSampleReport    *backtest.PerformanceReport `json:"inSampleReport,omitempty"`
+	OutOfSampleReport *backtest.PerformanceReport `json:"outOfSampleReport,omitempty"`
+}
+
+type WalkForwardReport struct {
+	Windows []WindowResult `json:"windows"`
+
+	// Report is the performance report of the stitched out-of-sample equity curve
+	Report *backtest.PerformanceReport `json:"report"`
+}
+
+// WalkForward optimizes the parameters on every in-sample window,
+// and evaluates the best parameters on the following out-of-sample window.
+type WalkForward struct {
+	Config    *Config
+	Optimizer *Optimizer
+}
+
+func (w *WalkForward) Run(ctx context.Context, configJSON []byte, startTime, endTime time.Time, runner Runner) (*WalkForwardReport, error) {
+	if w.Config.WalkForward == nil {
+		return nil, fmt.Errorf("walk forward is not defined in the optimizer config")
+	}
+
+	wfConfig := *w.Config.WalkForward
+	windows, err := wfConfig.Windows(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var report = &WalkForwardReport{}
+	var outOfSampleReports []*backtest.PerformanceReport
+	for i, window := range windows {
+		log.Infof("walk forward window #%d: in-sample %s ~ %s, out-of-sample %s ~ %s", i+1,
+			window.InSampleStart.Format(dateFormat), window.InSampleEnd.Format(dateFormat),
+			window.OutOfSampleStart.Format(dateFormat), window.OutOfSampleEnd.Format(dateFormat))
+
+		inSampleJSON, err := withTimeRange(configJSON, window.InSampleStart, window.InSampleEnd)
+		if err != nil {
+			return nil, err
+		}
+
+		results, err := w.Optimizer.Run(ctx, inSampleJSON, runner)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := SortResults(results, wfConfig.MetricName()); err != nil {
+			return nil, err
+		}
+
+		if len(results) == 0 || results[0].Report == nil {
+			return nil, fmt.Errorf("walk forward window #%d: all the in-sample backtests failed", i+1)
+		}
+
+		best := results[0]
+		outOfSampleJSON, err := best.Params.Apply(configJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		outOfSampleJSON, err = withTimeRange(outOfSampleJSON, window.OutOfSampleStart, window.OutOfSampleEnd)
+		if err != nil {
+			return nil, err
+		}
+
+		outOfSampleReport, err := runner(ctx, outOfSampleJSON)
+		if err != nil {
+			return nil, fmt.Errorf("walk forward window #%d: out-of-sample backtest error: %w", i+1, err)
+		}
+
+		report.Windows = append(report.Windows, WindowResult{
+			Window:            window,
+			Params:            best.Params,
+			InSampleReport:    best.Report,
+			OutOfSampleReport: outOfSampleReport,
+		})
+		outOfSampleReports = append(outOfSampleReports, outOfSampleReport)
+	}
+
+	report.Report = backtest.StitchReports(outOfSampleReports)
+	return report, nil
+}
+
+// withTimeRange patches the backtest time range of the config document
+func withTimeRange(configJSON []byte, startTime, endTime time.Time) ([]byte, error) {
+	return ParamSet{
+		{Path: "/backtest/startTime", Value: startTime.Format(dateFormat)},
+		{Path: "/backtest/endTime", Value: endTime.Format(dateFormat)},
+	}.Apply(configJSON)
+}
+
+// RenderWindows renders the window results as a table
+func RenderWindows(w io.Writer, windows []WindowResult, metricName string) error {
+	metric, ok := Metrics[metricName]
+	if !ok {
+		return fmt.Errorf("unsupported metric: %s", metricName)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "WINDOW\tIN-SAMPLE\tOUT-OF-SAMPLE\tIN-SAMPLE %s\tOUT-OF-SAMPLE %s\tOUT-OF-SAMPLE RETURN\tPARAMS\n", metricName, metricName)
+	for i, result := range windows {
+		fmt.Fprintf(tw, "%d\t%s ~ %s\t%s ~ %s\t%s\t%s\t%s\t%s\n",
+			i+1,
+			result.InSampleStart.Format(dateFormat), result.InSampleEnd.Format(dateFormat),
+			result.OutOfSampleStart.Format(dateFormat), result.OutOfSampleEnd.Format(dateFormat),
+			strconv.FormatFloat(metric.Value(result.InSampleReport), 'f', 4, 64),
+			strconv.FormatFloat(metric.Value(result.OutOfSampleReport), 'f', 4, 64),
+			strconv.FormatFloat(result.OutOfSampleReport.TotalReturn*100.0, 'f', 2, 64)+"%",
+			result.Params)
+	}
+
+	return tw.Flush()
+}
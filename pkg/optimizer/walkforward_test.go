@@ -0,0 +1,118 @@
+package optimizer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/backtest"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(dateFormat, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestWalkForwardConfig_Windows(t *testing.T) {
+	config := WalkForwardConfig{InSampleDays: 10, OutOfSampleDays: 5}
+
+	windows, err := config.Windows(date("2021-01-01"), date("2021-01-23"))
+	assert.NoError(t, err)
+	if assert.Len(t, windows, 3) {
+		assert.Equal(t, Window{
+			InSampleStart:    date("2021-01-01"),
+			InSampleEnd:      date("2021-01-11"),
+			OutOfSampleStart: date("2021-01-11"),
+			OutOfSampleEnd:   date("2021-01-16"),
+		}, windows[0])
+		assert.Equal(t, date("2021-01-06"), windows[1].InSampleStart)
+		assert.Equal(t, date("2021-01-16"), windows[1].OutOfSampleStart)
+
+		// the last out-of-sample window is truncated
+		assert.Equal(t, date("2021-01-21"), windows[2].OutOfSampleStart)
+		assert.Equal(t, date("2021-01-23"), windows[2].OutOfSampleEnd)
+	}
+
+	config.Anchored = true
+	windows, err = config.Windows(date("2021-01-01"), date("2021-01-23"))
+	assert.NoError(t, err)
+	if assert.Len(t, windows, 3) {
+		assert.Equal(t, date("2021-01-01"), windows[2].InSampleStart)
+		assert.Equal(t, date("2021-01-21"), windows[2].InSampleEnd)
+	}
+
+	_, err = config.Windows(date("2021-01-01"), date("2021-01-05"))
+	assert.Error(t, err)
+
+	_, err = WalkForwardConfig{InSampleDays: 10}.Windows(date("2021-01-01"), date("2021-01-23"))
+	assert.Error(t, err)
+
+	_, err = WalkForwardConfig{InSampleDays: 10, OutOfSampleDays: 5, Metric: "unknown"}.Windows(date("2021-01-01"), date("2021-01-23"))
+	assert.Error(t, err)
+}
+
+func TestWalkForward_Run(t *testing.T) {
+	config := &Config{
+		Matrix: []SelectorConfig{
+			{Type: SelectorTypeIterate, Label: "gridNumber", Path: "/exchangeStrategies/0/grid/gridNumber", Values: []interface{}{10, 20}},
+		},
+		WalkForward: &WalkForwardConfig{InSampleDays: 10, OutOfSampleDays: 10},
+	}
+
+	walkForward := &WalkForward{
+		Config:    config,
+		Optimizer: &Optimizer{Config: config, Workers: 2},
+	}
+
+	// gridNumber 10 wins in january, gridNumber 20 wins after january
+	report, err := walkForward.Run(context.Background(), []byte(testConfigJSON), date("2021-01-01"), date("2021-01-31"), func(ctx context.Context, configJSON []byte) (*backtest.PerformanceReport, error) {
+		var doc struct {
+			Backtest struct {
+				StartTime string `json:"startTime"`
+				EndTime   string `json:"endTime"`
+			} `json:"backtest"`
+			ExchangeStrategies []struct {
+				Grid struct {
+					GridNumber float64 `json:"gridNumber"`
+				} `json:"grid"`
+			} `json:"exchangeStrategies"`
+		}
+		if err := json.Unmarshal(configJSON, &doc); err != nil {
+			return nil, err
+		}
+
+		startTime, endTime := date(doc.Backtest.StartTime), date(doc.Backtest.EndTime)
+		gridNumber := doc.ExchangeStrategies[0].Grid.GridNumber
+		finalEquity := 1000.0
+		if (gridNumber == 10) == (startTime.Day() == 1) {
+			finalEquity = 1100.0
+		}
+
+		return backtest.NewPerformanceReport("USDT", nil, []backtest.EquityPoint{
+			{Time: startTime, Equity: 1000.0},
+			{Time: endTime, Equity: finalEquity},
+		}, nil), nil
+	})
+	assert.NoError(t, err)
+
+	if assert.Len(t, report.Windows, 2) {
+		assert.Equal(t, ParamSet{{Label: "gridNumber", Path: "/exchangeStrategies/0/grid/gridNumber", Value: 10}}, report.Windows[0].Params)
+		assert.Equal(t, 1000.0, report.Windows[0].OutOfSampleReport.FinalEquity)
+		assert.Equal(t, ParamSet{{Label: "gridNumber", Path: "/exchangeStrategies/0/grid/gridNumber", Value: 20}}, report.Windows[1].Params)
+		assert.Equal(t, 1100.0, report.Windows[1].OutOfSampleReport.FinalEquity)
+	}
+
+	assert.Equal(t, date("2021-01-11"), report.Report.StartTime)
+	assert.Equal(t, date("2021-01-31"), report.Report.EndTime)
+	assert.InDelta(t, 0.1, report.Report.TotalReturn, 1e-9)
+
+	walkForward.Config = &Config{Matrix: config.Matrix}
+	_, err = walkForward.Run(context.Background(), []byte(testConfigJSON), date("2021-01-01"), date("2021-01-31"), nil)
+	assert.Error(t, err)
+}
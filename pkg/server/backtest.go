@@ -0,0 +1,140 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/c9s/bbgo/pkg/service"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func (s *Server) listBacktestRuns(c *gin.Context) {
+	if s.Environ.BacktestRunService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database is not configured"})
+		return
+	}
+
+	lastID, err := strconv.ParseInt(c.DefaultQuery("lastID", "0"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lastID"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	runs, err := s.Environ.BacktestRunService.Query(service.QueryBacktestRunsOptions{
+		Session:  c.Query("session"),
+		Exchange: types.ExchangeName(c.Query("exchange")),
+		LastID:   lastID,
+		Limit:    limit,
+	})
+	if err != nil {
+		logrus.WithError(err).Error("backtest run query error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(runs) == 0 {
+		c.JSON(http.StatusOK, gin.H{"runs": []int{}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// loadBacktestRun loads the run of the :id parameter, the error response is written if the run can not be loaded
+func (s *Server) loadBacktestRun(c *gin.Context) (*service.BacktestRun, bool) {
+	if s.Environ.BacktestRunService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database is not configured"})
+		return nil, false
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid backtest run id"})
+		return nil, false
+	}
+
+	run, err := s.Environ.BacktestRunService.Load(c, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("backtest run %d not found", id)})
+		return nil, false
+	} else if err != nil {
+		logrus.WithError(err).Error("backtest run load error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return run, true
+}
+
+func (s *Server) getBacktestRun(c *gin.Context) {
+	run, ok := s.loadBacktestRun(c)
+	if !ok {
+		return
+	}
+
+	// the equity curve is served by its own route
+	run.EquityCurve = nil
+	c.JSON(http.StatusOK, gin.H{"run": run})
+}
+
+func (s *Server) getBacktestRunEquity(c *gin.Context) {
+	run, ok := s.loadBacktestRun(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"equityCurve": run.EquityCurve})
+}
+
+func (s *Server) listBacktestRunTrades(c *gin.Context) {
+	run, ok := s.loadBacktestRun(c)
+	if !ok {
+		return
+	}
+
+	trades, err := s.Environ.BacktestRunService.QueryTrades(c, run.ID)
+	if err != nil {
+		logrus.WithError(err).Error("backtest trade query error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(trades) == 0 {
+		c.JSON(http.StatusOK, gin.H{"trades": []int{}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"trades": trades})
+}
+
+func (s *Server) listBacktestRunOrders(c *gin.Context) {
+	run, ok := s.loadBacktestRun(c)
+	if !ok {
+		return
+	}
+
+	orders, err := s.Environ.BacktestRunService.QueryOrders(c, run.ID)
+	if err != nil {
+		logrus.WithError(err).Error("backtest order query error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(orders) == 0 {
+		c.JSON(http.StatusOK, gin.H{"orders": []int{}})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orders": orders})
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/c9s/bbgo/pkg/bbgo"
+	"github.com/c9s/bbgo/pkg/exchange/ratelimit"
 	"github.com/c9s/bbgo/pkg/fixedpoint"
 	"github.com/c9s/bbgo/pkg/service"
 	"github.com/c9s/bbgo/pkg/types"
@@ -61,6 +62,7 @@ func (s *Server) newEngine() *gin.Engine {
 	}))
 
 	r.GET("/api/ping", s.ping)
+	r.GET("/api/ratelimit/metrics", s.listRateLimitMetrics)
 
 	if s.Setup != nil {
 		r.POST("/api/setup/test-db", s.setupTestDB)
@@ -231,6 +233,12 @@ func (s *Server) newEngine() *gin.Engine {
 		c.JSON(200, gin.H{"message": "pong"})
 	})
 
+	r.GET("/api/backtest/runs", s.listBacktestRuns)
+	r.GET("/api/backtest/runs/:id", s.getBacktestRun)
+	r.GET("/api/backtest/runs/:id/equity", s.getBacktestRunEquity)
+	r.GET("/api/backtest/runs/:id/trades", s.listBacktestRunTrades)
+	r.GET("/api/backtest/runs/:id/orders", s.listBacktestRunOrders)
+
 	r.GET("/api/strategies/single", s.listStrategies)
 	r.NoRoute(s.assetsHandler)
 	return r
@@ -263,6 +271,10 @@ func (s *Server) ping(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "pong"})
 }
 
+func (s *Server) listRateLimitMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"metrics": ratelimit.AllMetrics()})
+}
+
 func (s *Server) listClosedOrders(c *gin.Context) {
 	if s.Environ.OrderService == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "database is not configured"})
@@ -0,0 +1,586 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+type KLineFileFormat string
+
+const (
+	KLineFileFormatCSV       = KLineFileFormat("csv")
+	KLineFileFormatJSONLines = KLineFileFormat("jsonl")
+)
+
+const (
+	TimeFormatUnix      = "unix"
+	TimeFormatUnixMilli = "unixms"
+)
+
+// KLineColumnMapping maps the kline fields to the columns of the import file.
+// For the CSV files, a column is either a zero-based column index or a header name.
+// For the JSON lines files, a column is the key of the JSON object.
+// EndTime is optional, when it's not mapped, it's derived from the start time and the interval.
+// The quote volume is not mapped since the kline tables don't store it.
+type KLineColumnMapping struct {
+	StartTime string `json:"startTime" yaml:"startTime"`
+	EndTime   string `json:"endTime,omitempty" yaml:"endTime,omitempty"`
+	Open      string `json:"open" yaml:"open"`
+	High      string `json:"high" yaml:"high"`
+	Low       string `json:"low" yaml:"low"`
+	Close     string `json:"close" yaml:"close"`
+	Volume    string `json:"volume" yaml:"volume"`
+}
+
+// DefaultCSVColumnMapping matches the column layout of the binance kline dumps:
+// open time, open, high, low, close, volume, close time, quote volume ...
+var DefaultCSVColumnMapping = KLineColumnMapping{
+	StartTime: "0",
+	Open:      "1",
+	High:      "2",
+	Low:       "3",
+	Close:     "4",
+	Volume:    "5",
+}
+
+// DefaultJSONColumnMapping matches the json encoding of types.KLine
+var DefaultJSONColumnMapping = KLineColumnMapping{
+	StartTime: "startTime",
+	Open:      "open",
+	High:      "high",
+	Low:       "low",
+	Close:     "close",
+	Volume:    "volume",
+}
+
+type KLineImportOptions struct {
+	Exchange types.ExchangeName
+	Symbol   string
+	Interval types.Interval
+
+	Format KLineFileFormat
+
+	// Columns is the column mapping, the default mapping of the format is used if it's not set
+	Columns *KLineColumnMapping
+
+	// TimeFormat is one of "unix", "unixms" or a go time layout.
+	// When it's empty, numeric timestamps are detected by their magnitude (seconds, milliseconds or microseconds)
+	// and the other values are parsed as RFC3339 or "2006-01-02 15:04:05" in UTC.
+	TimeFormat string
+
+	// Header means the first row of the CSV file is the header row
+	Header bool
+
+	// AllowGaps imports the klines even if there are missing klines in the file, the gaps are still reported.
+	AllowGaps bool
+}
+
+func (o KLineImportOptions) columns() KLineColumnMapping {
+	if o.Columns != nil {
+		return *o.Columns
+	}
+
+	if o.Format == KLineFileFormatJSONLines {
+		return DefaultJSONColumnMapping
+	}
+
+	return DefaultCSVColumnMapping
+}
+
+// ValidKLineExchangeName validates the exchange name of the kline tables,
+// the ftx klines can be imported for backtesting although ftx is not a valid session exchange yet.
+func ValidKLineExchangeName(name string) (types.ExchangeName, error) {
+	if strings.EqualFold(name, types.ExchangeFTX.String()) {
+		return types.ExchangeFTX, nil
+	}
+
+	return types.ValidExchangeName(name)
+}
+
+// validate validates the options and normalizes the exchange name, e.g., "bn" to "binance"
+func (o *KLineImportOptions) validate() error {
+	exchange, err := ValidKLineExchangeName(o.Exchange.String())
+	if err != nil {
+		return err
+	}
+
+	o.Exchange = exchange
+
+	if len(o.Symbol) == 0 {
+		return errors.New("symbol can not be empty")
+	}
+
+	if _, ok := types.SupportedIntervals[o.Interval]; !ok {
+		return fmt.Errorf("unsupported interval: %q", o.Interval)
+	}
+
+	switch o.Format {
+	case KLineFileFormatCSV, KLineFileFormatJSONLines:
+	default:
+		return fmt.Errorf("unsupported file format: %q", o.Format)
+	}
+
+	columns := o.columns()
+	for name, column := range map[string]string{
+		"startTime": columns.StartTime,
+		"open":      columns.Open,
+		"high":      columns.High,
+		"low":       columns.Low,
+		"close":     columns.Close,
+		"volume":    columns.Volume,
+	} {
+		if len(column) == 0 {
+			return fmt.Errorf("column of %s is not mapped", name)
+		}
+	}
+
+	return nil
+}
+
+// KLineGap is a range of the missing klines, From is inclusive and To is exclusive
+type KLineGap struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type KLineImportReport struct {
+	Imported  int        `json:"imported"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   time.Time  `json:"endTime"`
+	Gaps      []KLineGap `json:"gaps,omitempty"`
+}
+
+// KLineReader reads the klines from an import file, io.EOF is returned when there is no more kline
+type KLineReader interface {
+	Read() (types.KLine, error)
+}
+
+func NewKLineReader(r io.Reader, options KLineImportOptions) (KLineReader, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	switch options.Format {
+	case KLineFileFormatJSONLines:
+		return &jsonKLineReader{decoder: newJSONLinesDecoder(r), options: options}, nil
+
+	default:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		reader.ReuseRecord = true
+		return &csvKLineReader{reader: reader, options: options}, nil
+	}
+}
+
+func newJSONLinesDecoder(r io.Reader) *json.Decoder {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return decoder
+}
+
+type csvKLineReader struct {
+	reader  *csv.Reader
+	options KLineImportOptions
+	header  map[string]int
+	line    int
+}
+
+func (r *csvKLineReader) Read() (types.KLine, error) {
+	if r.options.Header && r.header == nil {
+		row, err := r.reader.Read()
+		if err != nil {
+			return types.KLine{}, err
+		}
+
+		r.line++
+		r.header = make(map[string]int, len(row))
+		for i, name := range row {
+			r.header[strings.TrimSpace(name)] = i
+		}
+	}
+
+	row, err := r.reader.Read()
+	if err != nil {
+		return types.KLine{}, err
+	}
+
+	r.line++
+
+	kline, err := parseKLineRecord(r.options, func(column string) (string, error) {
+		idx, err := strconv.Atoi(column)
+		if err != nil {
+			var ok bool
+			if idx, ok = r.header[column]; !ok {
+				return "", fmt.Errorf("column %q not found in the header", column)
+			}
+		}
+
+		if idx < 0 || idx >= len(row) {
+			return "", fmt.Errorf("column index %d out of range", idx)
+		}
+
+		return strings.TrimSpace(row[idx]), nil
+	})
+
+	if err != nil {
+		return kline, errors.Wrapf(err, "line %d", r.line)
+	}
+
+	return kline, nil
+}
+
+type jsonKLineReader struct {
+	decoder *json.Decoder
+	options KLineImportOptions
+	line    int
+}
+
+func (r *jsonKLineReader) Read() (types.KLine, error) {
+	var object map[string]interface{}
+	if err := r.decoder.Decode(&object); err != nil {
+		return types.KLine{}, err
+	}
+
+	r.line++
+
+	kline, err := parseKLineRecord(r.options, func(column string) (string, error) {
+		value, ok := object[column]
+		if !ok {
+			return "", fmt.Errorf("key %q not found", column)
+		}
+
+		switch v := value.(type) {
+		case json.Number:
+			return v.String(), nil
+		case string:
+			return v, nil
+		default:
+			return "", fmt.Errorf("unexpected value type %T of key %q", value, column)
+		}
+	})
+
+	if err != nil {
+		return kline, errors.Wrapf(err, "record %d", r.line)
+	}
+
+	return kline, nil
+}
+
+func parseKLineRecord(options KLineImportOptions, get func(column string) (string, error)) (kline types.KLine, err error) {
+	columns := options.columns()
+
+	kline.Exchange = options.Exchange.String()
+	kline.Symbol = options.Symbol
+	kline.Interval = options.Interval
+	kline.Closed = true
+
+	value, err := get(columns.StartTime)
+	if err != nil {
+		return kline, err
+	}
+
+	if kline.StartTime, err = parseKLineTime(value, options.TimeFormat); err != nil {
+		return kline, err
+	}
+
+	if len(columns.EndTime) > 0 {
+		if value, err = get(columns.EndTime); err != nil {
+			return kline, err
+		}
+
+		if kline.EndTime, err = parseKLineTime(value, options.TimeFormat); err != nil {
+			return kline, err
+		}
+	} else {
+		// follow the binance convention, the end time is the last millisecond of the interval
+		kline.EndTime = kline.StartTime.Add(options.Interval.Duration() - time.Millisecond)
+	}
+
+	for _, field := range []struct {
+		column string
+		target *float64
+	}{
+		{columns.Open, &kline.Open},
+		{columns.High, &kline.High},
+		{columns.Low, &kline.Low},
+		{columns.Close, &kline.Close},
+		{columns.Volume, &kline.Volume},
+	} {
+		if len(field.column) == 0 {
+			continue
+		}
+
+		if value, err = get(field.column); err != nil {
+			return kline, err
+		}
+
+		if *field.target, err = strconv.ParseFloat(value, 64); err != nil {
+			return kline, errors.Wrapf(err, "column %q", field.column)
+		}
+	}
+
+	return kline, nil
+}
+
+func parseKLineTime(value, format string) (time.Time, error) {
+	switch format {
+
+	case TimeFormatUnix:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, int64(math.Round(f*1e9))).UTC(), nil
+
+	case TimeFormatUnixMilli:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, int64(math.Round(f*1e6))).UTC(), nil
+
+	case "":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			switch {
+			case f >= 1e15: // microseconds
+				return time.Unix(0, int64(math.Round(f*1e3))).UTC(), nil
+			case f >= 1e12: // milliseconds
+				return time.Unix(0, int64(math.Round(f*1e6))).UTC(), nil
+			default:
+				return time.Unix(0, int64(math.Round(f*1e9))).UTC(), nil
+			}
+		}
+
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t.UTC(), nil
+			}
+		}
+
+		return time.Time{}, fmt.Errorf("unable to parse time %q", value)
+
+	default:
+		t, err := time.Parse(format, value)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.UTC(), nil
+
+	}
+}
+
+// klineContinuityChecker checks the klines are sorted, not duplicated and aligned to the interval,
+// the missing klines are collected as gaps.
+type klineContinuityChecker struct {
+	interval time.Duration
+	last     *types.KLine
+	gaps     []KLineGap
+}
+
+func (c *klineContinuityChecker) check(kline types.KLine) error {
+	if c.last == nil {
+		c.last = &kline
+		return nil
+	}
+
+	diff := kline.StartTime.Sub(c.last.StartTime)
+	if diff <= 0 {
+		return fmt.Errorf("kline %s is not after the previous kline %s, the klines must be sorted by time without duplicates",
+			kline.StartTime, c.last.StartTime)
+	}
+
+	if diff%c.interval != 0 {
+		return fmt.Errorf("kline %s is not aligned to the interval %s of the previous kline %s",
+			kline.StartTime, c.interval, c.last.StartTime)
+	}
+
+	if diff > c.interval {
+		c.gaps = append(c.gaps, KLineGap{
+			From: c.last.StartTime.Add(c.interval),
+			To:   kline.StartTime,
+		})
+	}
+
+	c.last = &kline
+	return nil
+}
+
+// CheckKLineContinuity returns the gaps of the given klines,
+// an error is returned if the klines are not sorted, duplicated or not aligned to the interval.
+func CheckKLineContinuity(klines []types.KLine, interval types.Interval) ([]KLineGap, error) {
+	checker := &klineContinuityChecker{interval: interval.Duration()}
+	for _, kline := range klines {
+		if err := checker.check(kline); err != nil {
+			return nil, err
+		}
+	}
+
+	return checker.gaps, nil
+}
+
+// Import reads the klines from the given CSV or JSON lines reader and upserts them into the kline table of the exchange.
+// The import runs in one transaction, nothing is written if the file is invalid or not continuous (unless AllowGaps is set).
+func (s *BacktestService) Import(ctx context.Context, r io.Reader, options KLineImportOptions) (*KLineImportReport, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	reader, err := NewKLineReader(r, options)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.importKLines(ctx, tx, reader, options)
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.WithError(rollbackErr).Error("kline import rollback error")
+		}
+
+		return report, err
+	}
+
+	return report, tx.Commit()
+}
+
+func (s *BacktestService) importKLines(ctx context.Context, tx *sqlx.Tx, reader KLineReader, options KLineImportOptions) (*KLineImportReport, error) {
+	upsert, err := newKLineUpserter(tx, options.Exchange)
+	if err != nil {
+		return nil, err
+	}
+	defer upsert.Close()
+
+	var report = &KLineImportReport{}
+	var checker = &klineContinuityChecker{interval: options.Interval.Duration()}
+	for {
+		select {
+		case <-ctx.Done():
+			return report, ctx.Err()
+		default:
+		}
+
+		kline, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return report, err
+		}
+
+		if err := checker.check(kline); err != nil {
+			return report, err
+		}
+
+		if err := upsert.Upsert(kline); err != nil {
+			return report, err
+		}
+
+		if report.Imported == 0 {
+			report.StartTime = kline.StartTime
+		}
+
+		report.EndTime = kline.EndTime
+		report.Imported++
+	}
+
+	report.Gaps = checker.gaps
+	if len(report.Gaps) > 0 && !options.AllowGaps {
+		return report, fmt.Errorf("found %d gaps in the klines, the first gap is from %s to %s",
+			len(report.Gaps), report.Gaps[0].From, report.Gaps[0].To)
+	}
+
+	return report, nil
+}
+
+// Upsert inserts the klines, the existing klines of the same symbol, interval and start time are replaced.
+func (s *BacktestService) Upsert(exchange types.ExchangeName, klines []types.KLine) error {
+	exchange, err := ValidKLineExchangeName(exchange.String())
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	upsert, err := newKLineUpserter(tx, exchange)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, kline := range klines {
+		kline.Exchange = exchange.String()
+		if err := upsert.Upsert(kline); err != nil {
+			upsert.Close()
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	upsert.Close()
+	return tx.Commit()
+}
+
+// klineUpserter replaces the kline of the same symbol, interval and start time by deleting it before the insertion,
+// the kline tables don't have an unique index for the dialect specific upsert syntax.
+type klineUpserter struct {
+	deleteStmt *sqlx.NamedStmt
+	insertStmt *sqlx.NamedStmt
+}
+
+func newKLineUpserter(tx *sqlx.Tx, exchange types.ExchangeName) (*klineUpserter, error) {
+	table := exchange.String() + "_klines"
+
+	deleteSQL := "DELETE FROM `binance_klines` WHERE `symbol` = :symbol AND `interval` = :interval AND `start_time` = :start_time"
+	deleteSQL = strings.ReplaceAll(deleteSQL, "binance_klines", table)
+
+	insertSQL := "INSERT INTO `binance_klines` (`exchange`, `start_time`, `end_time`, `symbol`, `interval`, `open`, `high`, `low`, `close`, `closed`, `volume`)" +
+		"VALUES (:exchange, :start_time, :end_time, :symbol, :interval, :open, :high, :low, :close, :closed, :volume)"
+	insertSQL = strings.ReplaceAll(insertSQL, "binance_klines", table)
+
+	deleteStmt, err := tx.PrepareNamed(deleteSQL)
+	if err != nil {
+		return nil, err
+	}
+
+	insertStmt, err := tx.PrepareNamed(insertSQL)
+	if err != nil {
+		_ = deleteStmt.Close()
+		return nil, err
+	}
+
+	return &klineUpserter{deleteStmt: deleteStmt, insertStmt: insertStmt}, nil
+}
+
+func (u *klineUpserter) Upsert(kline types.KLine) error {
+	if _, err := u.deleteStmt.Exec(kline); err != nil {
+		return err
+	}
+
+	_, err := u.insertStmt.Exec(kline)
+	return err
+}
+
+func (u *klineUpserter) Close() {
+	_ = u.deleteStmt.Close()
+	_ = u.insertStmt.Close()
+}
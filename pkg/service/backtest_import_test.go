@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+const testKLineCSV = `open_time,open,high,low,close,volume
+1609459200000,28923.63,29031.34,28690.17,28995.13,2311.811445
+1609459260000,28995.13,29062.49,28850.00,29007.65,1781.325532
+1609459320000,29007.65,29072.99,28985.70,29047.94,1093.427223
+`
+
+func TestNewKLineReader_CSV(t *testing.T) {
+	reader, err := NewKLineReader(strings.NewReader(testKLineCSV), KLineImportOptions{
+		Exchange: "bn",
+		Symbol:   "BTCUSDT",
+		Interval: types.Interval1m,
+		Format:   KLineFileFormatCSV,
+		Header:   true,
+		Columns: &KLineColumnMapping{
+			StartTime: "open_time",
+			Open:      "open",
+			High:      "high",
+			Low:       "low",
+			Close:     "4",
+			Volume:    "volume",
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	kline, err := reader.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "binance", kline.Exchange)
+	assert.Equal(t, "BTCUSDT", kline.Symbol)
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), kline.StartTime)
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 59, int(999*time.Millisecond), time.UTC), kline.EndTime)
+	assert.Equal(t, 28923.63, kline.Open)
+	assert.Equal(t, 28995.13, kline.Close)
+	assert.Equal(t, 2311.811445, kline.Volume)
+	assert.True(t, kline.Closed)
+
+	_, err = reader.Read()
+	assert.NoError(t, err)
+	_, err = reader.Read()
+	assert.NoError(t, err)
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestNewKLineReader_JSONLines(t *testing.T) {
+	input := `{"t": "2021-01-01T00:00:00Z", "o": 1.0, "h": "2.0", "l": 0.5, "c": 1.5, "v": 100}
+{"t": "2021-01-01T01:00:00Z", "o": 1.5, "h": "2.5", "l": 1.0, "c": 2.0, "v": 200}
+`
+	reader, err := NewKLineReader(strings.NewReader(input), KLineImportOptions{
+		Exchange: types.ExchangeMax,
+		Symbol:   "BTCUSDT",
+		Interval: types.Interval1h,
+		Format:   KLineFileFormatJSONLines,
+		Columns:  &KLineColumnMapping{StartTime: "t", Open: "o", High: "h", Low: "l", Close: "c", Volume: "v"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	kline, err := reader.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), kline.StartTime)
+	assert.Equal(t, 2.0, kline.High)
+	assert.Equal(t, 100.0, kline.Volume)
+
+	kline, err = reader.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 1, 1, 1, 0, 0, 0, time.UTC), kline.StartTime)
+
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = NewKLineReader(strings.NewReader(input), KLineImportOptions{
+		Exchange: types.ExchangeMax,
+		Symbol:   "BTCUSDT",
+		Interval: types.Interval1h,
+		Format:   KLineFileFormatJSONLines,
+		Columns:  &KLineColumnMapping{StartTime: "t", Open: "o"},
+	})
+	assert.Error(t, err, "unmapped columns should be rejected")
+}
+
+func TestValidKLineExchangeName(t *testing.T) {
+	exchange, err := ValidKLineExchangeName("FTX")
+	assert.NoError(t, err)
+	assert.Equal(t, types.ExchangeFTX, exchange)
+
+	exchange, err = ValidKLineExchangeName("bn")
+	assert.NoError(t, err)
+	assert.Equal(t, types.ExchangeBinance, exchange)
+
+	_, err = ValidKLineExchangeName("okex")
+	assert.Error(t, err)
+
+	// ftx is only accepted by the kline import
+	_, err = types.ValidExchangeName("ftx")
+	assert.Error(t, err)
+}
+
+func Test_parseKLineTime(t *testing.T) {
+	expected := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		value, format string
+	}{
+		{"1609459200", ""},
+		{"1609459200000", ""},
+		{"1609459200000000", ""},
+		{"1609459200", TimeFormatUnix},
+		{"1609459200000", TimeFormatUnixMilli},
+		{"2021-01-01T00:00:00Z", ""},
+		{"2021-01-01 00:00:00", ""},
+		{"01/01/2021 00:00", "01/02/2006 15:04"},
+	} {
+		tt, err := parseKLineTime(c.value, c.format)
+		if assert.NoError(t, err, c.value) {
+			assert.Equal(t, expected, tt, c.value)
+		}
+	}
+
+	_, err := parseKLineTime("yesterday", "")
+	assert.Error(t, err)
+}
+
+func TestCheckKLineContinuity(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	kline := func(minutes int) types.KLine {
+		return types.KLine{StartTime: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	gaps, err := CheckKLineContinuity([]types.KLine{kline(0), kline(1), kline(2)}, types.Interval1m)
+	assert.NoError(t, err)
+	assert.Empty(t, gaps)
+
+	gaps, err = CheckKLineContinuity([]types.KLine{kline(0), kline(1), kline(4), kline(5)}, types.Interval1m)
+	assert.NoError(t, err)
+	assert.Equal(t, []KLineGap{{From: start.Add(2 * time.Minute), To: start.Add(4 * time.Minute)}}, gaps)
+
+	_, err = CheckKLineContinuity([]types.KLine{kline(0), kline(1), kline(1)}, types.Interval1m)
+	assert.Error(t, err, "duplicated klines")
+
+	_, err = CheckKLineContinuity([]types.KLine{kline(5), kline(0)}, types.Interval5m)
+	assert.Error(t, err, "unsorted klines")
+
+	_, err = CheckKLineContinuity([]types.KLine{kline(0), kline(7)}, types.Interval5m)
+	assert.Error(t, err, "misaligned klines")
+}
+
+func TestBacktestService_Import(t *testing.T) {
+	db, err := prepareDB(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	ctx := context.Background()
+	xdb := sqlx.NewDb(db.DB, "sqlite3")
+	service := &BacktestService{DB: xdb}
+
+	options := KLineImportOptions{
+		Exchange: types.ExchangeBinance,
+		Symbol:   "BTCUSDT",
+		Interval: types.Interval1m,
+		Format:   KLineFileFormatCSV,
+		Header:   true,
+	}
+
+	countKLines := func() (cnt int) {
+		assert.NoError(t, xdb.Get(&cnt, "SELECT COUNT(*) FROM `binance_klines` WHERE `symbol` = 'BTCUSDT' AND `interval` = '1m'"))
+		return cnt
+	}
+
+	report, err := service.Import(ctx, strings.NewReader(testKLineCSV), options)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, report.Imported)
+		assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), report.StartTime)
+		assert.Empty(t, report.Gaps)
+	}
+	assert.Equal(t, 3, countKLines())
+
+	// importing the same file again replaces the existing klines
+	_, err = service.Import(ctx, strings.NewReader(testKLineCSV), options)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, countKLines())
+
+	// the klines with gaps are rejected and nothing is written
+	withGap := testKLineCSV + "1609459500000,29047.94,29100.00,29000.00,29050.00,1000.0\n"
+	report, err = service.Import(ctx, strings.NewReader(withGap), options)
+	assert.Error(t, err)
+	if assert.NotNil(t, report) {
+		assert.Len(t, report.Gaps, 1)
+	}
+	assert.Equal(t, 3, countKLines())
+
+	options.AllowGaps = true
+	report, err = service.Import(ctx, strings.NewReader(withGap), options)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 4, report.Imported)
+		assert.Len(t, report.Gaps, 1)
+	}
+	assert.Equal(t, 4, countKLines())
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	sqlxtypes "github.com/jmoiron/sqlx/types"
+
+	"github.com/c9s/bbgo/pkg/datatype"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+// BacktestRun is the stored result of a backtest session run,
+// the config, the metrics and the equity curve are stored as json documents.
+type BacktestRun struct {
+	ID            int64              `json:"id" db:"id"`
+	Session       string             `json:"session" db:"session"`
+	Exchange      types.ExchangeName `json:"exchange" db:"exchange"`
+	QuoteCurrency string             `json:"quoteCurrency" db:"quote_currency"`
+	StartTime     datatype.Time      `json:"startTime" db:"start_time"`
+	EndTime       datatype.Time      `json:"endTime" db:"end_time"`
+	Config        sqlxtypes.JSONText `json:"config,omitempty" db:"config"`
+	Metrics       sqlxtypes.JSONText `json:"metrics" db:"metrics"`
+	EquityCurve   sqlxtypes.JSONText `json:"equityCurve,omitempty" db:"equity_curve"`
+	CreatedAt     datatype.Time      `json:"createdAt" db:"created_at"`
+}
+
+type BacktestTrade struct {
+	RunID int64 `json:"runID" db:"run_id"`
+	types.Trade
+}
+
+type BacktestOrder struct {
+	RunID int64 `json:"runID" db:"run_id"`
+	types.Order
+}
+
+type QueryBacktestRunsOptions struct {
+	Session  string
+	Exchange types.ExchangeName
+
+	// LastID is used for the pagination, the runs older than the given id are returned
+	LastID int64
+	Limit  int
+}
+
+// the equity curve is excluded from the run list since it's large
+const backtestRunListColumns = "`id`, `session`, `exchange`, `quote_currency`, `start_time`, `end_time`, `metrics`, `created_at`"
+
+type BacktestRunService struct {
+	DB *sqlx.DB
+}
+
+// Insert stores the run with its trades and orders in one transaction, the run ID is assigned to run.ID
+func (s *BacktestRunService) Insert(ctx context.Context, run *BacktestRun, trades []types.Trade, orders []types.Order) error {
+	if run.CreatedAt.Time().IsZero() {
+		run.CreatedAt = datatype.Time(time.Now())
+	}
+
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.NamedExecContext(ctx, `
+			INSERT INTO backtest_runs (session, exchange, quote_currency, start_time, end_time, config, metrics, equity_curve, created_at)
+			VALUES (:session, :exchange, :quote_currency, :start_time, :end_time, :config, :metrics, :equity_curve, :created_at)`, run)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	runID, err := result.LastInsertId()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, trade := range trades {
+		_, err := tx.NamedExecContext(ctx, `
+			INSERT INTO backtest_trades (run_id, id, order_id, exchange, symbol, price, quantity, quote_quantity, fee, fee_currency, is_buyer, is_maker, side, is_margin, is_isolated, traded_at)
+			VALUES (:run_id, :id, :order_id, :exchange, :symbol, :price, :quantity, :quote_quantity, :fee, :fee_currency, :is_buyer, :is_maker, :side, :is_margin, :is_isolated, :traded_at)`,
+			BacktestTrade{RunID: runID, Trade: trade})
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	for _, order := range orders {
+		_, err := tx.NamedExecContext(ctx, `
+			INSERT INTO backtest_orders (run_id, exchange, order_id, client_order_id, order_type, symbol, status, time_in_force, price, stop_price, quantity, executed_quantity, side, is_working, is_margin, is_isolated, created_at, updated_at)
+			VALUES (:run_id, :exchange, :order_id, :client_order_id, :order_type, :symbol, :status, :time_in_force, :price, :stop_price, :quantity, :executed_quantity, :side, :is_working, :is_margin, :is_isolated, :created_at, :updated_at)`,
+			BacktestOrder{RunID: runID, Order: order})
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	run.ID = runID
+	return nil
+}
+
+// Query returns the runs in the descending order of the run ID, the config and the equity curve are not loaded.
+func (s *BacktestRunService) Query(options QueryBacktestRunsOptions) ([]BacktestRun, error) {
+	sql := "SELECT " + backtestRunListColumns + " FROM `backtest_runs`"
+
+	var where []string
+	args := map[string]interface{}{}
+	if len(options.Session) > 0 {
+		where = append(where, "`session` = :session")
+		args["session"] = options.Session
+	}
+
+	if len(options.Exchange) > 0 {
+		where = append(where, "`exchange` = :exchange")
+		args["exchange"] = options.Exchange
+	}
+
+	if options.LastID > 0 {
+		where = append(where, "`id` < :last_id")
+		args["last_id"] = options.LastID
+	}
+
+	if len(where) > 0 {
+		sql += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	if options.Limit <= 0 {
+		options.Limit = 50
+	}
+
+	sql += " ORDER BY `id` DESC LIMIT :limit"
+	args["limit"] = options.Limit
+
+	rows, err := s.DB.NamedQuery(sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var runs []BacktestRun
+	for rows.Next() {
+		var run BacktestRun
+		if err := rows.StructScan(&run); err != nil {
+			return runs, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// Load loads the run of the given ID with its config and equity curve
+func (s *BacktestRunService) Load(ctx context.Context, id int64) (*BacktestRun, error) {
+	var run BacktestRun
+	if err := s.DB.GetContext(ctx, &run, "SELECT * FROM `backtest_runs` WHERE `id` = ?", id); err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+func (s *BacktestRunService) QueryTrades(ctx context.Context, runID int64) ([]types.Trade, error) {
+	rows, err := s.DB.QueryxContext(ctx, "SELECT * FROM `backtest_trades` WHERE `run_id` = ? ORDER BY `traded_at` ASC, `gid` ASC", runID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var trades []types.Trade
+	for rows.Next() {
+		var trade BacktestTrade
+		if err := rows.StructScan(&trade); err != nil {
+			return trades, err
+		}
+
+		trades = append(trades, trade.Trade)
+	}
+
+	return trades, rows.Err()
+}
+
+func (s *BacktestRunService) QueryOrders(ctx context.Context, runID int64) ([]types.Order, error) {
+	rows, err := s.DB.QueryxContext(ctx, "SELECT * FROM `backtest_orders` WHERE `run_id` = ? ORDER BY `created_at` ASC, `gid` ASC", runID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var orders []types.Order
+	for rows.Next() {
+		var order BacktestOrder
+		if err := rows.StructScan(&order); err != nil {
+			return orders, err
+		}
+
+		orders = append(orders, order.Order)
+	}
+
+	return orders, rows.Err()
+}
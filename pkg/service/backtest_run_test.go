@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	sqlxtypes "github.com/jmoiron/sqlx/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/datatype"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func TestBacktestRunService(t *testing.T) {
+	db, err := prepareDB(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	ctx := context.Background()
+	xdb := sqlx.NewDb(db.DB, "sqlite3")
+	service := &BacktestRunService{DB: xdb}
+
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	trades := []types.Trade{
+		{ID: 1, OrderID: 1, Exchange: "binance", Symbol: "BTCUSDT", Price: 30000.0, Quantity: 0.1, QuoteQuantity: 3000.0, Side: types.SideTypeBuy, IsBuyer: true, Fee: 3.0, FeeCurrency: "USDT", Time: datatype.Time(startTime)},
+		{ID: 2, OrderID: 2, Exchange: "binance", Symbol: "BTCUSDT", Price: 31000.0, Quantity: 0.1, QuoteQuantity: 3100.0, Side: types.SideTypeSell, Fee: 3.1, FeeCurrency: "USDT", Time: datatype.Time(startTime.Add(time.Hour))},
+	}
+	orders := []types.Order{
+		{
+			SubmitOrder:      types.SubmitOrder{Symbol: "BTCUSDT", Side: types.SideTypeBuy, Type: types.OrderTypeLimit, Price: 30000.0, Quantity: 0.1, TimeInForce: "GTC"},
+			Exchange:         "binance",
+			OrderID:          1,
+			Status:           types.OrderStatusFilled,
+			ExecutedQuantity: 0.1,
+			CreationTime:     datatype.Time(startTime),
+			UpdateTime:       datatype.Time(startTime),
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		run := &BacktestRun{
+			Session:       "binance",
+			Exchange:      types.ExchangeBinance,
+			QuoteCurrency: "USDT",
+			StartTime:     datatype.Time(startTime),
+			EndTime:       datatype.Time(startTime.AddDate(0, 0, 1)),
+			Config:        sqlxtypes.JSONText(`{"backtest":{"startTime":"2021-01-01"}}`),
+			Metrics:       sqlxtypes.JSONText(`{"totalReturn":0.1}`),
+			EquityCurve:   sqlxtypes.JSONText(`[{"time":"2021-01-01T00:00:00Z","equity":1000}]`),
+		}
+		err = service.Insert(ctx, run, trades, orders)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i+1), run.ID)
+	}
+
+	runs, err := service.Query(QueryBacktestRunsOptions{Session: "binance"})
+	assert.NoError(t, err)
+	if assert.Len(t, runs, 2) {
+		assert.Equal(t, int64(2), runs[0].ID)
+		assert.Equal(t, `{"totalReturn":0.1}`, runs[0].Metrics.String())
+		assert.Empty(t, runs[0].EquityCurve)
+	}
+
+	runs, err = service.Query(QueryBacktestRunsOptions{LastID: 2})
+	assert.NoError(t, err)
+	assert.Len(t, runs, 1)
+
+	run, err := service.Load(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "USDT", run.QuoteCurrency)
+	assert.Equal(t, `[{"time":"2021-01-01T00:00:00Z","equity":1000}]`, run.EquityCurve.String())
+
+	_, err = service.Load(ctx, 3)
+	assert.Error(t, err)
+
+	loadedTrades, err := service.QueryTrades(ctx, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, loadedTrades, 2) {
+		assert.Equal(t, 30000.0, loadedTrades[0].Price)
+		assert.Equal(t, types.SideTypeSell, loadedTrades[1].Side)
+	}
+
+	loadedOrders, err := service.QueryOrders(ctx, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, loadedOrders, 1) {
+		assert.Equal(t, types.OrderStatusFilled, loadedOrders[0].Status)
+		assert.Equal(t, 0.1, loadedOrders[0].ExecutedQuantity)
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"github.com/jmoiron/sqlx"
+
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+type InternalTransferService struct {
+	DB *sqlx.DB
+}
+
+func (s *InternalTransferService) QueryLast(ex types.ExchangeName, limit int) ([]types.InternalTransfer, error) {
+	sql := "SELECT * FROM `internal_transfers` WHERE `exchange` = :exchange ORDER BY `time` DESC LIMIT :limit"
+	rows, err := s.DB.NamedQuery(sql, map[string]interface{}{
+		"exchange": ex,
+		"limit":    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	return s.scanRows(rows)
+}
+
+func (s *InternalTransferService) Query(exchangeName types.ExchangeName) ([]types.InternalTransfer, error) {
+	args := map[string]interface{}{
+		"exchange": exchangeName,
+	}
+	sql := "SELECT * FROM `internal_transfers` WHERE `exchange` = :exchange ORDER BY `time` ASC"
+	rows, err := s.DB.NamedQuery(sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return s.scanRows(rows)
+}
+
+func (s *InternalTransferService) scanRows(rows *sqlx.Rows) (transfers []types.InternalTransfer, err error) {
+	for rows.Next() {
+		var transfer types.InternalTransfer
+		if err := rows.StructScan(&transfer); err != nil {
+			return transfers, err
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+func (s *InternalTransferService) Insert(transfer types.InternalTransfer) error {
+	sql := `INSERT INTO internal_transfers (exchange, transfer_id, asset, amount, from_account, to_account, status, time)
+			VALUES (:exchange, :transfer_id, :asset, :amount, :from_account, :to_account, :status, :time)`
+	_, err := s.DB.NamedExec(sql, transfer)
+	return err
+}
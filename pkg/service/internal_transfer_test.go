@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/datatype"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func TestInternalTransferService(t *testing.T) {
+	db, err := prepareDB(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	xdb := sqlx.NewDb(db.DB, "sqlite3")
+	service := &InternalTransferService{DB: xdb}
+
+	err = service.Insert(types.InternalTransfer{
+		Exchange:   types.ExchangeBinance,
+		TransferID: "13526853623",
+		Asset:      "USDT",
+		Amount:     100.0,
+		From:       types.TransferAccountSpot,
+		To:         types.IsolatedMarginTransferAccount("BTCUSDT"),
+		Status:     "completed",
+		Time:       datatype.Time(time.Now()),
+	})
+	assert.NoError(t, err)
+
+	transfers, err := service.Query(types.ExchangeBinance)
+	assert.NoError(t, err)
+	if assert.Len(t, transfers, 1) {
+		assert.Equal(t, types.ExchangeBinance, transfers[0].Exchange)
+		assert.Equal(t, types.TransferAccountSpot, transfers[0].From)
+		assert.Equal(t, types.TransferAccount("isolated:BTCUSDT"), transfers[0].To)
+		assert.Equal(t, 100.0, transfers[0].Amount)
+	}
+
+	transfers, err = service.QueryLast(types.ExchangeBinance, 10)
+	assert.NoError(t, err)
+	assert.Len(t, transfers, 1)
+}
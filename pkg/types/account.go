@@ -14,13 +14,31 @@ type Balance struct {
 	Currency  string           `json:"currency"`
 	Available fixedpoint.Value `json:"available"`
 	Locked    fixedpoint.Value `json:"locked"`
+
+	// Borrowed and Interest are the liabilities of the margin account
+	Borrowed fixedpoint.Value `json:"borrowed,omitempty"`
+	Interest fixedpoint.Value `json:"interest,omitempty"`
 }
 
 func (b Balance) Total() fixedpoint.Value {
 	return b.Available + b.Locked
 }
 
+// Debt returns the borrowed amount plus the accrued interest
+func (b Balance) Debt() fixedpoint.Value {
+	return b.Borrowed + b.Interest
+}
+
+// Net returns the total balance minus the debt
+func (b Balance) Net() fixedpoint.Value {
+	return b.Total() - b.Debt()
+}
+
 func (b Balance) String() string {
+	if b.Debt() > 0 {
+		return fmt.Sprintf("%s: %f (locked %f, borrowed %f, interest %f)", b.Currency, b.Available.Float64(), b.Locked.Float64(), b.Borrowed.Float64(), b.Interest.Float64())
+	}
+
 	if b.Locked > 0 {
 		return fmt.Sprintf("%s: %f (locked %f)", b.Currency, b.Available.Float64(), b.Locked.Float64())
 	}
@@ -203,6 +221,66 @@ func (a *Account) LockBalance(currency string, locked fixedpoint.Value) error {
 	return fmt.Errorf("insufficient available balance %s for lock: want to lock %f, available %f", currency, locked.Float64(), balance.Available.Float64())
 }
 
+// BorrowBalance adds the borrowed fund to the available balance and the liability of the currency
+func (a *Account) BorrowBalance(currency string, fund fixedpoint.Value) error {
+	a.Lock()
+	defer a.Unlock()
+
+	if fund <= 0 {
+		return fmt.Errorf("invalid borrow amount %s: %f", currency, fund.Float64())
+	}
+
+	if a.balances == nil {
+		a.balances = make(BalanceMap)
+	}
+
+	balance := a.balances[currency]
+	balance.Currency = currency
+	balance.Available += fund
+	balance.Borrowed += fund
+	a.balances[currency] = balance
+	return nil
+}
+
+// AddInterest adds the accrued interest to the liability of the currency
+func (a *Account) AddInterest(currency string, interest fixedpoint.Value) {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.balances == nil {
+		a.balances = make(BalanceMap)
+	}
+
+	balance := a.balances[currency]
+	balance.Currency = currency
+	balance.Interest += interest
+	a.balances[currency] = balance
+}
+
+// RepayBalance repays the liability of the currency with the available balance, the interest is repaid first.
+// It returns the repaid amount, which is limited by the available balance and the liability.
+func (a *Account) RepayBalance(currency string, fund fixedpoint.Value) (fixedpoint.Value, error) {
+	a.Lock()
+	defer a.Unlock()
+
+	balance, ok := a.balances[currency]
+	if !ok {
+		return 0, fmt.Errorf("trying to repay inexisted balance: %s", currency)
+	}
+
+	repaid := fixedpoint.Min(fund, fixedpoint.Min(balance.Available, balance.Debt()))
+	if repaid <= 0 {
+		return 0, nil
+	}
+
+	interest := fixedpoint.Min(repaid, balance.Interest)
+	balance.Interest -= interest
+	balance.Borrowed -= repaid - interest
+	balance.Available -= repaid
+	a.balances[currency] = balance
+	return repaid, nil
+}
+
 func (a *Account) UpdateBalances(balances BalanceMap) {
 	a.Lock()
 	defer a.Unlock()
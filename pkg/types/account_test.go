@@ -60,3 +60,37 @@ func TestAccountLockAndUse(t *testing.T) {
 	assert.Equal(t, balance.Available, fixedpoint.Value(900))
 	assert.Equal(t, balance.Locked, fixedpoint.Value(0))
 }
+
+func TestAccountBorrowAndRepay(t *testing.T) {
+	a := NewAccount()
+	err := a.BorrowBalance("USDT", 1000)
+	assert.NoError(t, err)
+
+	a.AddInterest("USDT", 10)
+
+	balance, ok := a.Balance("USDT")
+	assert.True(t, ok)
+	assert.Equal(t, fixedpoint.Value(1000), balance.Available)
+	assert.Equal(t, fixedpoint.Value(1000), balance.Borrowed)
+	assert.Equal(t, fixedpoint.Value(1010), balance.Debt())
+	assert.Equal(t, fixedpoint.Value(-10), balance.Net())
+
+	// the interest is repaid first
+	repaid, err := a.RepayBalance("USDT", 500)
+	assert.NoError(t, err)
+	assert.Equal(t, fixedpoint.Value(500), repaid)
+
+	balance, _ = a.Balance("USDT")
+	assert.Equal(t, fixedpoint.Value(500), balance.Available)
+	assert.Equal(t, fixedpoint.Value(510), balance.Borrowed)
+	assert.Equal(t, fixedpoint.Value(0), balance.Interest)
+
+	// the repaid amount is limited by the available balance
+	repaid, err = a.RepayBalance("USDT", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, fixedpoint.Value(500), repaid)
+
+	balance, _ = a.Balance("USDT")
+	assert.Equal(t, fixedpoint.Value(0), balance.Available)
+	assert.Equal(t, fixedpoint.Value(10), balance.Borrowed)
+}
@@ -0,0 +1,236 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultBookStaleTimeout is the stale timeout of the new book synchronizers, the stale check is opt-in since
+// the books of the quiet markets may not be updated for a long time. Set BOOK_STALE_TIMEOUT, e.g., 30m, to enable it.
+var defaultBookStaleTimeout time.Duration
+
+func init() {
+	if s := os.Getenv("BOOK_STALE_TIMEOUT"); len(s) > 0 {
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			log.Error(err)
+		} else {
+			defaultBookStaleTimeout = v
+			log.Infof("book stale check is enabled, timeout: %s", v)
+		}
+	}
+}
+
+var ErrBookSequenceGap = errors.New("book sequence gap")
+
+var ErrBookChecksumMismatch = errors.New("book checksum mismatch")
+
+var ErrBookStale = errors.New("book is stale")
+
+var ErrBookCrossed = errors.New("book is crossed")
+
+// BookSynchronizer validates the book snapshots and the book updates of a stream before they are emitted.
+//
+// The exchange stream feeds the snapshots and the updates with their sequence numbers (0 if the exchange doesn't
+// provide them), and invalidates the book by itself for the exchange specific checks like the checksum.
+// The book is invalidated when a sequence gap is found, the book is crossed or no update is received within
+// the stale timeout if the stale check is enabled. When the book is invalidated, the BookInvalid event is emitted on the stream so that the
+// strategies can pause quoting, and the resync callbacks are called to reload the snapshot.
+// The updates are dropped until the next snapshot is loaded.
+//
+//go:generate callbackgen -type BookSynchronizer
+type BookSynchronizer struct {
+	// StaleTimeout is the max duration without any book update, the stale check is disabled if it's zero
+	StaleTimeout time.Duration
+
+	stream *StandardStream
+
+	mu    sync.Mutex
+	books map[string]*syncBook
+
+	resyncCallbacks []func(symbol string)
+}
+
+type syncBook struct {
+	book OrderBook
+
+	// synced is false before the snapshot is loaded or after the book is invalidated
+	synced bool
+
+	sequence uint64
+
+	// updateTime is the time of the last snapshot, update or resync request
+	updateTime time.Time
+}
+
+func NewBookSynchronizer(stream *StandardStream) *BookSynchronizer {
+	return &BookSynchronizer{
+		StaleTimeout: defaultBookStaleTimeout,
+		stream:       stream,
+		books:        make(map[string]*syncBook),
+	}
+}
+
+// IsSynced returns true if the snapshot of the symbol is loaded and the book is not invalidated
+func (s *BookSynchronizer) IsSynced(symbol string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.books[symbol]
+	return ok && b.synced
+}
+
+// LoadSnapshot loads the book snapshot, sequence is the sequence number of the last update included in the snapshot.
+func (s *BookSynchronizer) LoadSnapshot(snapshot OrderBook, sequence uint64) {
+	s.mu.Lock()
+	b, ok := s.books[snapshot.Symbol]
+	if !ok {
+		b = &syncBook{book: OrderBook{Symbol: snapshot.Symbol}}
+		s.books[snapshot.Symbol] = b
+	}
+
+	b.book.Reset()
+	b.book.update(snapshot)
+	b.sequence = sequence
+	b.synced = true
+	b.updateTime = time.Now()
+
+	if err := checkCrossed(&b.book); err != nil {
+		s.mu.Unlock()
+		s.Invalidate(snapshot.Symbol, err)
+		return
+	}
+
+	s.mu.Unlock()
+
+	s.stream.EmitBookSnapshot(snapshot)
+}
+
+// Update applies the book update, firstSequence and finalSequence are the sequence range of the update.
+// The update which is older than the loaded snapshot is skipped.
+func (s *BookSynchronizer) Update(update OrderBook, firstSequence, finalSequence uint64) {
+	s.mu.Lock()
+	b, ok := s.books[update.Symbol]
+	if !ok || !b.synced {
+		s.mu.Unlock()
+		return
+	}
+
+	if finalSequence > 0 && b.sequence > 0 {
+		if finalSequence <= b.sequence {
+			s.mu.Unlock()
+			return
+		}
+
+		if firstSequence > b.sequence+1 {
+			err := fmt.Errorf("expected sequence %d, got %d: %w", b.sequence+1, firstSequence, ErrBookSequenceGap)
+			s.mu.Unlock()
+			s.Invalidate(update.Symbol, err)
+			return
+		}
+	}
+
+	b.book.update(update)
+	b.sequence = finalSequence
+	b.updateTime = time.Now()
+
+	if err := checkCrossed(&b.book); err != nil {
+		s.mu.Unlock()
+		s.Invalidate(update.Symbol, err)
+		return
+	}
+
+	s.mu.Unlock()
+
+	s.stream.EmitBookUpdate(update)
+}
+
+// Invalidate marks the book of the symbol as invalid, emits the BookInvalid event and requests the snapshot reload.
+// Invalidating a book which is not synced does nothing, the resync is requested again by the stale check.
+func (s *BookSynchronizer) Invalidate(symbol string, err error) {
+	s.mu.Lock()
+	b, ok := s.books[symbol]
+	if !ok || !b.synced {
+		s.mu.Unlock()
+		return
+	}
+
+	b.synced = false
+	b.book.Reset()
+	b.updateTime = time.Now()
+	s.mu.Unlock()
+
+	log.WithError(err).Warnf("%s book is invalid, resyncing the book", symbol)
+
+	s.stream.EmitBookInvalid(symbol, err)
+	s.EmitResync(symbol)
+}
+
+// Run checks the stale books periodically until the context is cancelled
+func (s *BookSynchronizer) Run(ctx context.Context) {
+	if s.StaleTimeout <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.StaleTimeout / 5)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case now := <-ticker.C:
+			s.checkStale(now)
+		}
+	}
+}
+
+func (s *BookSynchronizer) checkStale(now time.Time) {
+	var staleSymbols, resyncSymbols []string
+
+	s.mu.Lock()
+	for symbol, b := range s.books {
+		if now.Sub(b.updateTime) < s.StaleTimeout {
+			continue
+		}
+
+		if b.synced {
+			staleSymbols = append(staleSymbols, symbol)
+		} else {
+			// the previous resync request didn't load the snapshot in time, try again
+			b.updateTime = now
+			resyncSymbols = append(resyncSymbols, symbol)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, symbol := range staleSymbols {
+		s.Invalidate(symbol, fmt.Errorf("no update since %s: %w", s.StaleTimeout, ErrBookStale))
+	}
+
+	for _, symbol := range resyncSymbols {
+		log.Warnf("%s book is still not synced, resyncing the book", symbol)
+		s.EmitResync(symbol)
+	}
+}
+
+// checkCrossed checks the book with OrderBook.IsValid, the book with an empty side is allowed
+// since it could be an illiquid market.
+func checkCrossed(book *OrderBook) error {
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return nil
+	}
+
+	if valid, err := book.IsValid(); !valid {
+		return fmt.Errorf("%s: %w", err.Error(), ErrBookCrossed)
+	}
+
+	return nil
+}
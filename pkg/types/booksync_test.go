@@ -0,0 +1,166 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+)
+
+func newTestBook(bid, ask float64) OrderBook {
+	return OrderBook{
+		Symbol: "BTCUSDT",
+		Bids:   PriceVolumeSlice{{fixedpoint.NewFromFloat(bid), fixedpoint.NewFromFloat(1.0)}},
+		Asks:   PriceVolumeSlice{{fixedpoint.NewFromFloat(ask), fixedpoint.NewFromFloat(1.0)}},
+	}
+}
+
+type testStream struct {
+	StandardStream
+}
+
+func (s *testStream) SetPublicOnly() {}
+
+func (s *testStream) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *testStream) Close() error {
+	return nil
+}
+
+func TestBookSynchronizer_SequenceGap(t *testing.T) {
+	ts := &testStream{}
+	sb := NewStreamBook("BTCUSDT")
+	sb.BindStream(ts)
+
+	stream := &ts.StandardStream
+
+	var invalidErrors []error
+	stream.OnBookInvalid(func(symbol string, err error) {
+		assert.Equal(t, "BTCUSDT", symbol)
+		invalidErrors = append(invalidErrors, err)
+	})
+
+	s := NewBookSynchronizer(stream)
+
+	var resyncSymbols []string
+	s.OnResync(func(symbol string) {
+		resyncSymbols = append(resyncSymbols, symbol)
+	})
+
+	// the updates before the snapshot are dropped
+	s.Update(newTestBook(99.0, 101.0), 1, 1)
+	assert.False(t, s.IsSynced("BTCUSDT"))
+
+	s.LoadSnapshot(newTestBook(100.0, 101.0), 10)
+	assert.True(t, s.IsSynced("BTCUSDT"))
+
+	// the update which is older than the snapshot is skipped
+	s.Update(newTestBook(90.0, 91.0), 5, 10)
+	book := sb.Get()
+	bid, _ := book.BestBid()
+	assert.Equal(t, fixedpoint.NewFromFloat(100.0), bid.Price)
+
+	s.Update(newTestBook(100.5, 101.0), 11, 12)
+	book = sb.Get()
+	bid, _ = book.BestBid()
+	assert.Equal(t, fixedpoint.NewFromFloat(100.5), bid.Price)
+
+	// the update 13 is missing
+	s.Update(newTestBook(100.6, 101.0), 14, 14)
+	assert.False(t, s.IsSynced("BTCUSDT"))
+	assert.Equal(t, []string{"BTCUSDT"}, resyncSymbols)
+	if assert.Len(t, invalidErrors, 1) {
+		assert.True(t, errors.Is(invalidErrors[0], ErrBookSequenceGap))
+	}
+
+	// the stream book is reset until the next snapshot
+	book = sb.Get()
+	valid, _ := book.IsValid()
+	assert.False(t, valid)
+
+	// the updates are dropped and the book is not invalidated again before the next snapshot
+	s.Update(newTestBook(100.6, 101.0), 15, 15)
+	s.Invalidate("BTCUSDT", ErrBookChecksumMismatch)
+	assert.Len(t, invalidErrors, 1)
+
+	s.LoadSnapshot(newTestBook(100.0, 101.0), 20)
+	assert.True(t, s.IsSynced("BTCUSDT"))
+	book = sb.Get()
+	valid, _ = book.IsValid()
+	assert.True(t, valid)
+}
+
+func TestBookSynchronizer_Crossed(t *testing.T) {
+	stream := &StandardStream{}
+
+	var invalidErrors []error
+	stream.OnBookInvalid(func(symbol string, err error) {
+		invalidErrors = append(invalidErrors, err)
+	})
+
+	var updates int
+	stream.OnBookUpdate(func(book OrderBook) {
+		updates++
+	})
+
+	s := NewBookSynchronizer(stream)
+	s.LoadSnapshot(newTestBook(100.0, 101.0), 0)
+
+	// the book without the sequence numbers
+	s.Update(OrderBook{
+		Symbol: "BTCUSDT",
+		Bids:   PriceVolumeSlice{{fixedpoint.NewFromFloat(100.5), fixedpoint.NewFromFloat(1.0)}},
+	}, 0, 0)
+	assert.Equal(t, 1, updates)
+
+	// the bid price 102 is higher than the ask price 101
+	s.Update(OrderBook{
+		Symbol: "BTCUSDT",
+		Bids:   PriceVolumeSlice{{fixedpoint.NewFromFloat(102.0), fixedpoint.NewFromFloat(1.0)}},
+	}, 0, 0)
+	assert.Equal(t, 1, updates)
+	assert.False(t, s.IsSynced("BTCUSDT"))
+	if assert.Len(t, invalidErrors, 1) {
+		assert.True(t, errors.Is(invalidErrors[0], ErrBookCrossed))
+	}
+}
+
+func TestBookSynchronizer_Stale(t *testing.T) {
+	stream := &StandardStream{}
+
+	var invalidErrors []error
+	stream.OnBookInvalid(func(symbol string, err error) {
+		invalidErrors = append(invalidErrors, err)
+	})
+
+	s := NewBookSynchronizer(stream)
+	s.StaleTimeout = time.Minute
+
+	var resyncs int
+	s.OnResync(func(symbol string) {
+		resyncs++
+	})
+
+	s.LoadSnapshot(newTestBook(100.0, 101.0), 0)
+
+	s.checkStale(time.Now().Add(30 * time.Second))
+	assert.True(t, s.IsSynced("BTCUSDT"))
+
+	s.checkStale(time.Now().Add(2 * time.Minute))
+	assert.False(t, s.IsSynced("BTCUSDT"))
+	assert.Equal(t, 1, resyncs)
+	if assert.Len(t, invalidErrors, 1) {
+		assert.True(t, errors.Is(invalidErrors[0], ErrBookStale))
+	}
+
+	// the snapshot is not reloaded in time, request the resync again
+	s.checkStale(time.Now().Add(4 * time.Minute))
+	assert.Equal(t, 2, resyncs)
+	assert.Len(t, invalidErrors, 1)
+}
@@ -83,6 +83,22 @@ type ExchangeTradingService interface {
 	CancelOrders(ctx context.Context, orders ...Order) error
 }
 
+// ExchangeAmendService amends the price and the quantity of an open order.
+// The order is amended natively if the exchange supports it, or replaced by the cancel-replace api atomically.
+// Otherwise, the order is canceled and then resubmitted, the order could be left canceled if the resubmission fails.
+// The amended order could have a new order ID, the returned order should replace the original order.
+type ExchangeAmendService interface {
+	AmendOrder(ctx context.Context, order Order, price, quantity float64) (*Order, error)
+}
+
+// ExchangeCancelService cancels the open orders in batch, the canceled orders are returned.
+// The statuses of the returned orders are canceled only if the exchange cancels the orders synchronously,
+// otherwise they are the statuses before the cancellation.
+type ExchangeCancelService interface {
+	CancelAllOrders(ctx context.Context) ([]Order, error)
+	CancelOrdersBySymbol(ctx context.Context, symbol string) ([]Order, error)
+}
+
 type ExchangeMarketDataService interface {
 	NewStream() Stream
 
@@ -100,6 +116,17 @@ type ExchangeTransferService interface {
 	QueryWithdrawHistory(ctx context.Context, asset string, since, until time.Time) (allWithdraws []Withdraw, err error)
 }
 
+// ExchangeWithdrawService submits the withdrawals, the network is optional and the default network of the asset
+// is used if it's empty.
+type ExchangeWithdrawService interface {
+	Withdraw(ctx context.Context, asset string, amount float64, address string, network string) (*Withdraw, error)
+}
+
+// ExchangeInternalTransferService transfers the asset between the sub-accounts or the wallets of the exchange
+type ExchangeInternalTransferService interface {
+	InternalTransfer(ctx context.Context, asset string, amount float64, from, to TransferAccount) (*InternalTransfer, error)
+}
+
 type ExchangeRewardService interface {
 	QueryRewards(ctx context.Context, startTime time.Time) ([]Reward, error)
 }
@@ -0,0 +1,84 @@
+package types
+
+import (
+	"context"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+)
+
+type FuturesExchange interface {
+	UseFutures()
+	GetFuturesSettings() FuturesSettings
+}
+
+type FuturesSettings struct {
+	IsFutures bool
+}
+
+func (s FuturesSettings) GetFuturesSettings() FuturesSettings {
+	return s
+}
+
+func (s *FuturesSettings) UseFutures() {
+	s.IsFutures = true
+}
+
+// ExchangePositionService is implemented by the exchanges that hold positions, like the futures exchanges
+type ExchangePositionService interface {
+	// QueryPositions queries the positions of the given symbols, all the open positions are returned if no symbol is given
+	QueryPositions(ctx context.Context, symbols ...string) ([]FuturesPosition, error)
+
+	// SetLeverage changes the initial leverage of the symbol
+	SetLeverage(ctx context.Context, symbol string, leverage int) error
+
+	QueryFundingRateHistory(ctx context.Context, symbol string, since, until time.Time) ([]FundingRate, error)
+}
+
+// PositionUpdateStream is implemented by the streams that push the position updates
+type PositionUpdateStream interface {
+	OnPositionUpdate(cb func(positions []FuturesPosition))
+}
+
+type PositionSide string
+
+const (
+	PositionSideBoth  = PositionSide("BOTH")
+	PositionSideLong  = PositionSide("LONG")
+	PositionSideShort = PositionSide("SHORT")
+)
+
+type FuturesPosition struct {
+	Symbol string       `json:"symbol"`
+	Side   PositionSide `json:"side"`
+
+	// Quantity is the position amount, it's negative for the short position in the one-way mode
+	Quantity         fixedpoint.Value `json:"quantity"`
+	EntryPrice       fixedpoint.Value `json:"entryPrice"`
+	MarkPrice        fixedpoint.Value `json:"markPrice,omitempty"`
+	LiquidationPrice fixedpoint.Value `json:"liquidationPrice,omitempty"`
+	UnrealizedProfit fixedpoint.Value `json:"unrealizedProfit"`
+
+	Leverage int  `json:"leverage,omitempty"`
+	Isolated bool `json:"isolated"`
+
+	UpdateTime time.Time `json:"updateTime,omitempty"`
+}
+
+func (p FuturesPosition) IsLong() bool {
+	return p.Quantity > 0
+}
+
+func (p FuturesPosition) IsShort() bool {
+	return p.Quantity < 0
+}
+
+func (p FuturesPosition) IsClosed() bool {
+	return p.Quantity == 0
+}
+
+type FundingRate struct {
+	Symbol      string           `json:"symbol"`
+	FundingRate fixedpoint.Value `json:"fundingRate"`
+	FundingTime time.Time        `json:"fundingTime"`
+}
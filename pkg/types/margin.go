@@ -1,6 +1,11 @@
 package types
 
-import "github.com/c9s/bbgo/pkg/fixedpoint"
+import (
+	"context"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+)
 
 type MarginExchange interface {
 	UseMargin()
@@ -9,6 +14,63 @@ type MarginExchange interface {
 	// QueryMarginAccount(ctx context.Context) (*binance.MarginAccount, error)
 }
 
+// TransferDirection is the direction of the asset transfer between the spot account and the margin account
+type TransferDirection int
+
+const (
+	// TransferIn transfers the asset from the spot account to the margin account
+	TransferIn TransferDirection = 1
+
+	// TransferOut transfers the asset from the margin account back to the spot account
+	TransferOut TransferDirection = -1
+)
+
+// MarginBorrowRepayService provides the api for managing the debts of the margin account explicitly,
+// the isolated margin symbol of the margin settings is used when the isolated margin is enabled.
+type MarginBorrowRepayService interface {
+	BorrowMarginAsset(ctx context.Context, asset string, amount fixedpoint.Value) error
+	RepayMarginAsset(ctx context.Context, asset string, amount fixedpoint.Value) error
+	QueryMarginAssetMaxBorrowable(ctx context.Context, asset string) (fixedpoint.Value, error)
+	TransferMarginAccountAsset(ctx context.Context, asset string, amount fixedpoint.Value, direction TransferDirection) error
+
+	MarginHistoryService
+}
+
+type MarginHistoryService interface {
+	QueryLoanHistory(ctx context.Context, asset string, since, until time.Time) ([]MarginLoan, error)
+	QueryRepayHistory(ctx context.Context, asset string, since, until time.Time) ([]MarginRepay, error)
+	QueryInterestHistory(ctx context.Context, asset string, since, until time.Time) ([]MarginInterest, error)
+}
+
+type MarginLoan struct {
+	TransactionID  uint64           `json:"transactionID"`
+	Exchange       ExchangeName     `json:"exchange"`
+	Asset          string           `json:"asset"`
+	Principal      fixedpoint.Value `json:"principal"`
+	Time           time.Time        `json:"time"`
+	IsolatedSymbol string           `json:"isolatedSymbol,omitempty"`
+}
+
+type MarginRepay struct {
+	TransactionID  uint64           `json:"transactionID"`
+	Exchange       ExchangeName     `json:"exchange"`
+	Asset          string           `json:"asset"`
+	Principal      fixedpoint.Value `json:"principal"`
+	Interest       fixedpoint.Value `json:"interest"`
+	Time           time.Time        `json:"time"`
+	IsolatedSymbol string           `json:"isolatedSymbol,omitempty"`
+}
+
+type MarginInterest struct {
+	Exchange       ExchangeName     `json:"exchange"`
+	Asset          string           `json:"asset"`
+	Principal      fixedpoint.Value `json:"principal"`
+	Interest       fixedpoint.Value `json:"interest"`
+	InterestRate   fixedpoint.Value `json:"interestRate"`
+	Time           time.Time        `json:"time"`
+	IsolatedSymbol string           `json:"isolatedSymbol,omitempty"`
+}
+
 type MarginSettings struct {
 	IsMargin             bool
 	IsIsolatedMargin     bool
@@ -46,6 +46,35 @@ func (d *Duration) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func (d *Duration) UnmarshalYAML(unmarshal func(a interface{}) error) error {
+	var o interface{}
+	if err := unmarshal(&o); err != nil {
+		return err
+	}
+
+	switch t := o.(type) {
+	case string:
+		dd, err := time.ParseDuration(t)
+		if err != nil {
+			return err
+		}
+
+		*d = Duration(dd)
+
+	case float64:
+		*d = Duration(int64(t * float64(time.Second)))
+
+	case int:
+		*d = Duration(t * int(time.Second))
+
+	default:
+		return fmt.Errorf("unsupported type %T value: %v", t, t)
+
+	}
+
+	return nil
+}
+
 type Market struct {
 	Symbol          string
 	PricePrecision  int
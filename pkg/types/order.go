@@ -88,6 +88,13 @@ const (
 	OrderStatusRejected        OrderStatus = "REJECTED"
 )
 
+// the time in force values of the submit order
+const (
+	TimeInForceGTC = "GTC"
+	TimeInForceIOC = "IOC"
+	TimeInForceFOK = "FOK"
+)
+
 type SubmitOrder struct {
 	ClientOrderID string `json:"clientOrderID" db:"client_order_id"`
 
@@ -111,6 +118,9 @@ type SubmitOrder struct {
 	GroupID uint32 `json:"groupID,omitempty"`
 
 	MarginSideEffect MarginOrderSideEffectType `json:"marginSideEffect,omitempty"` // AUTO_REPAY = repay, MARGIN_BUY = borrow, defaults to  NO_SIDE_EFFECT
+
+	// ReduceOnly is for the futures orders, the reduce-only order only reduces the position
+	ReduceOnly bool `json:"reduceOnly,omitempty" db:"-"`
 }
 
 func (o *SubmitOrder) String() string {
@@ -167,6 +177,25 @@ func (o Order) Backup() SubmitOrder {
 	return so
 }
 
+// Amend returns the submit order for replacing the order with the new price and the new quantity,
+// the price and the quantity strings are formatted by the market if it's set.
+func (o Order) Amend(price, quantity float64) SubmitOrder {
+	so := o.SubmitOrder
+	so.Price = price
+	so.Quantity = quantity
+	so.PriceString = ""
+	so.QuantityString = ""
+
+	if o.Market.Symbol != "" {
+		so.PriceString = o.Market.FormatPrice(price)
+		so.QuantityString = o.Market.FormatQuantity(quantity)
+	}
+
+	// ClientOrderID can not be reused
+	so.ClientOrderID = ""
+	return so
+}
+
 func (o Order) String() string {
 	return fmt.Sprintf("order %s %s %f/%f at %f -> %s", o.Symbol, o.Side, o.ExecutedQuantity, o.Quantity, o.Price, o.Status)
 }
@@ -333,4 +333,14 @@ func (sb *StreamOrderBook) BindStream(stream Stream) {
 		sb.Update(book)
 		sb.C.Emit()
 	})
+
+	// reset the book so that the strategies see an invalid book until the next snapshot is loaded
+	stream.OnBookInvalid(func(symbol string, err error) {
+		if sb.Symbol != symbol {
+			return
+		}
+
+		sb.Reset()
+		sb.C.Emit()
+	})
 }
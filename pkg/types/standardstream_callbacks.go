@@ -114,6 +114,36 @@ func (stream *StandardStream) EmitBookSnapshot(book OrderBook) {
 	}
 }
 
+func (stream *StandardStream) OnBookInvalid(cb func(symbol string, err error)) {
+	stream.bookInvalidCallbacks = append(stream.bookInvalidCallbacks, cb)
+}
+
+func (stream *StandardStream) EmitBookInvalid(symbol string, err error) {
+	for _, cb := range stream.bookInvalidCallbacks {
+		cb(symbol, err)
+	}
+}
+
+func (stream *StandardStream) OnMarketTrade(cb func(trade Trade)) {
+	stream.marketTradeCallbacks = append(stream.marketTradeCallbacks, cb)
+}
+
+func (stream *StandardStream) EmitMarketTrade(trade Trade) {
+	for _, cb := range stream.marketTradeCallbacks {
+		cb(trade)
+	}
+}
+
+func (stream *StandardStream) OnBookTickerUpdate(cb func(bookTicker BookTicker)) {
+	stream.bookTickerUpdateCallbacks = append(stream.bookTickerUpdateCallbacks, cb)
+}
+
+func (stream *StandardStream) EmitBookTickerUpdate(bookTicker BookTicker) {
+	for _, cb := range stream.bookTickerUpdateCallbacks {
+		cb(bookTicker)
+	}
+}
+
 type StandardStreamEventHub interface {
 	OnStart(cb func())
 
@@ -136,4 +166,10 @@ type StandardStreamEventHub interface {
 	OnBookUpdate(cb func(book OrderBook))
 
 	OnBookSnapshot(cb func(book OrderBook))
+
+	OnBookInvalid(cb func(symbol string, err error))
+
+	OnMarketTrade(cb func(trade Trade))
+
+	OnBookTickerUpdate(cb func(bookTicker BookTicker))
 }
@@ -19,6 +19,12 @@ var BookChannel = Channel("book")
 
 var KLineChannel = Channel("kline")
 
+// MarketTradeChannel is the public trade channel of the market, the trades are emitted by OnMarketTrade
+var MarketTradeChannel = Channel("trade")
+
+// BookTickerChannel is the best bid and ask channel of the market, the book tickers are emitted by OnBookTickerUpdate
+var BookTickerChannel = Channel("bookTicker")
+
 //go:generate callbackgen -type StandardStream -interface
 type StandardStream struct {
 	Subscriptions []Subscription
@@ -47,6 +53,15 @@ type StandardStream struct {
 	bookUpdateCallbacks []func(book OrderBook)
 
 	bookSnapshotCallbacks []func(book OrderBook)
+
+	// book invalid callbacks are called when the book is out of sync, the book is valid again after the next snapshot
+	bookInvalidCallbacks []func(symbol string, err error)
+
+	// market trade callbacks are called with the public trades of the market,
+	// the side of the trade is the taker side and the order ID is not set.
+	marketTradeCallbacks []func(trade Trade)
+
+	bookTickerUpdateCallbacks []func(bookTicker BookTicker)
 }
 
 func (stream *StandardStream) Subscribe(channel Channel, symbol string, options SubscribeOptions) {
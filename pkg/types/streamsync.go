@@ -0,0 +1,248 @@
+package types
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// streamSyncTimeout is the timeout of the REST queries of a resync
+	streamSyncTimeout = time.Minute
+
+	// streamSyncOverlap is subtracted from the disconnect time when querying the trades and the closed orders,
+	// so that the updates around the disconnection are not missed because of the clock difference.
+	streamSyncOverlap = time.Minute
+
+	// streamSyncMaxLookback is the max lookback of the closed orders query before the disconnection
+	streamSyncMaxLookback = 12 * time.Hour
+
+	// streamSyncRetention is how long the synced trade keys and order states are kept for deduplication
+	streamSyncRetention = 24 * time.Hour
+)
+
+type syncOrderState struct {
+	status           OrderStatus
+	executedQuantity float64
+	creationTime     time.Time
+	updateTime       time.Time
+}
+
+// StreamStateSyncer resyncs the private state of a stream after it's reconnected.
+//
+// The fills, the order updates and the balance changes during the disconnection are lost since the exchanges don't
+// replay them after the reconnection. The syncer queries the open orders, the recent trades and the balances via
+// the REST api and emits them through the stream callbacks, so that OrderStore, LocalActiveOrderBook and Account
+// are updated as if the updates were received from the stream. The trades are deduplicated by the trade key and
+// the orders are deduplicated by the order ID with the order status and the executed quantity.
+type StreamStateSyncer struct {
+	exchange ExchangeTradingService
+	stream   *StandardStream
+
+	mu sync.Mutex
+
+	// disconnectTime is the time of the first disconnection since the last sync, zero if the stream is connected
+	disconnectTime time.Time
+
+	symbols   map[string]struct{}
+	trades    map[TradeKey]time.Time
+	orders    map[uint64]syncOrderState
+	pruneTime time.Time
+}
+
+func NewStreamStateSyncer(exchange ExchangeTradingService, stream *StandardStream) *StreamStateSyncer {
+	s := &StreamStateSyncer{
+		exchange:  exchange,
+		stream:    stream,
+		symbols:   make(map[string]struct{}),
+		trades:    make(map[TradeKey]time.Time),
+		orders:    make(map[uint64]syncOrderState),
+		pruneTime: time.Now(),
+	}
+
+	stream.OnTradeUpdate(s.handleTradeUpdate)
+	stream.OnOrderUpdate(s.handleOrderUpdate)
+	stream.OnDisconnect(s.handleDisconnect)
+	return s
+}
+
+// AddSymbol adds the symbols to sync, the symbols of the subscriptions, the received trades and the received orders
+// are added automatically
+func (s *StreamStateSyncer) AddSymbol(symbols ...string) {
+	s.mu.Lock()
+	for _, symbol := range symbols {
+		s.symbols[symbol] = struct{}{}
+	}
+	s.mu.Unlock()
+}
+
+func (s *StreamStateSyncer) handleTradeUpdate(trade Trade) {
+	s.mu.Lock()
+	s.symbols[trade.Symbol] = struct{}{}
+	s.trades[trade.Key()] = time.Time(trade.Time)
+	s.prune(time.Now())
+	s.mu.Unlock()
+}
+
+func (s *StreamStateSyncer) handleOrderUpdate(order Order) {
+	s.mu.Lock()
+	s.symbols[order.Symbol] = struct{}{}
+	s.orders[order.OrderID] = syncOrderState{
+		status:           order.Status,
+		executedQuantity: order.ExecutedQuantity,
+		creationTime:     time.Time(order.CreationTime),
+		updateTime:       time.Now(),
+	}
+	s.prune(time.Now())
+	s.mu.Unlock()
+}
+
+func (s *StreamStateSyncer) handleDisconnect() {
+	s.mu.Lock()
+	if s.disconnectTime.IsZero() {
+		s.disconnectTime = time.Now()
+	}
+	s.mu.Unlock()
+}
+
+// HandleConnect should be called when the private stream is connected,
+// the resync is started in the background if the stream was disconnected.
+func (s *StreamStateSyncer) HandleConnect() {
+	s.mu.Lock()
+	disconnectTime := s.disconnectTime
+	s.disconnectTime = time.Time{}
+	s.mu.Unlock()
+
+	if disconnectTime.IsZero() {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), streamSyncTimeout)
+		defer cancel()
+
+		s.Sync(ctx, disconnectTime.Add(-streamSyncOverlap))
+	}()
+}
+
+// Sync queries the trades and the closed orders since the given time, the open orders and the balances,
+// and emits the updates that are not received from the stream.
+func (s *StreamStateSyncer) Sync(ctx context.Context, since time.Time) {
+	s.mu.Lock()
+	for _, sub := range s.stream.Subscriptions {
+		s.symbols[sub.Symbol] = struct{}{}
+	}
+
+	var symbols []string
+	for symbol := range s.symbols {
+		symbols = append(symbols, symbol)
+	}
+	s.mu.Unlock()
+
+	log.Infof("resyncing the trades, the orders and the balances of %v since %s", symbols, since)
+
+	for _, symbol := range symbols {
+		s.syncTrades(ctx, symbol, since)
+		s.syncOrders(ctx, symbol, since)
+	}
+
+	balances, err := s.exchange.QueryAccountBalances(ctx)
+	if err != nil {
+		log.WithError(err).Error("resync balances error")
+		return
+	}
+
+	s.stream.EmitBalanceSnapshot(balances)
+}
+
+func (s *StreamStateSyncer) syncTrades(ctx context.Context, symbol string, since time.Time) {
+	trades, err := s.exchange.QueryTrades(ctx, symbol, &TradeQueryOptions{StartTime: &since})
+	if err != nil {
+		log.WithError(err).Errorf("resync %s trades error", symbol)
+		return
+	}
+
+	for _, trade := range trades {
+		s.mu.Lock()
+		_, ok := s.trades[trade.Key()]
+		s.mu.Unlock()
+
+		if ok {
+			continue
+		}
+
+		log.Infof("resynced missing trade: %s", trade.PlainText())
+		s.stream.EmitTradeUpdate(trade)
+	}
+}
+
+func (s *StreamStateSyncer) syncOrders(ctx context.Context, symbol string, since time.Time) {
+	openOrders, err := s.exchange.QueryOpenOrders(ctx, symbol)
+	if err != nil {
+		log.WithError(err).Errorf("resync %s open orders error", symbol)
+		return
+	}
+
+	// the orders that were open before the disconnection could be filled or canceled during the disconnection,
+	// query the closed orders since the creation time of the oldest one.
+	s.mu.Lock()
+	closedSince := since
+	for _, state := range s.orders {
+		if state.status != OrderStatusNew && state.status != OrderStatusPartiallyFilled {
+			continue
+		}
+
+		if !state.creationTime.IsZero() && state.creationTime.Before(closedSince) {
+			closedSince = state.creationTime
+		}
+	}
+	s.mu.Unlock()
+
+	// some exchanges limit the time range of the closed orders query
+	if earliest := since.Add(-streamSyncMaxLookback); closedSince.Before(earliest) {
+		closedSince = earliest
+	}
+
+	closedOrders, err := s.exchange.QueryClosedOrders(ctx, symbol, closedSince, time.Now(), 0)
+	if err != nil {
+		log.WithError(err).Errorf("resync %s closed orders error", symbol)
+	}
+
+	// the closed orders api of some exchanges also returns the open orders,
+	// the emitted order updates are recorded by the order update handler, so they are not emitted twice.
+	for _, order := range append(closedOrders, openOrders...) {
+		s.mu.Lock()
+		state, ok := s.orders[order.OrderID]
+		s.mu.Unlock()
+
+		if ok && state.status == order.Status && state.executedQuantity == order.ExecutedQuantity {
+			continue
+		}
+
+		log.Infof("resynced order update: %s", order.String())
+		s.stream.EmitOrderUpdate(order)
+	}
+}
+
+// prune removes the expired trade keys and order states, it's called with the lock held
+func (s *StreamStateSyncer) prune(now time.Time) {
+	if now.Sub(s.pruneTime) < time.Hour {
+		return
+	}
+
+	s.pruneTime = now
+
+	for key, t := range s.trades {
+		if now.Sub(t) > streamSyncRetention {
+			delete(s.trades, key)
+		}
+	}
+
+	for orderID, state := range s.orders {
+		if now.Sub(state.updateTime) > streamSyncRetention {
+			delete(s.orders, orderID)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package types
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/datatype"
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+)
+
+type testTradingService struct {
+	ExchangeTradingService
+
+	trades       []Trade
+	openOrders   []Order
+	closedOrders []Order
+	balances     BalanceMap
+
+	closedOrdersSince time.Time
+}
+
+func (e *testTradingService) QueryTrades(ctx context.Context, symbol string, options *TradeQueryOptions) ([]Trade, error) {
+	return e.trades, nil
+}
+
+func (e *testTradingService) QueryOpenOrders(ctx context.Context, symbol string) ([]Order, error) {
+	return e.openOrders, nil
+}
+
+func (e *testTradingService) QueryClosedOrders(ctx context.Context, symbol string, since, until time.Time, lastOrderID uint64) ([]Order, error) {
+	e.closedOrdersSince = since
+	return e.closedOrders, nil
+}
+
+func (e *testTradingService) QueryAccountBalances(ctx context.Context) (BalanceMap, error) {
+	return e.balances, nil
+}
+
+func newTestOrder(orderID uint64, status OrderStatus, executedQuantity float64, creationTime time.Time) Order {
+	return Order{
+		SubmitOrder: SubmitOrder{
+			Symbol:   "BTCUSDT",
+			Side:     SideTypeBuy,
+			Type:     OrderTypeLimit,
+			Quantity: 1.0,
+			Price:    10000.0,
+		},
+		OrderID:          orderID,
+		Status:           status,
+		ExecutedQuantity: executedQuantity,
+		CreationTime:     datatype.Time(creationTime),
+	}
+}
+
+func TestStreamStateSyncer_Sync(t *testing.T) {
+	now := time.Now()
+	exchange := &testTradingService{}
+	stream := &StandardStream{}
+	syncer := NewStreamStateSyncer(exchange, stream)
+
+	var trades []Trade
+	var orders []Order
+	var balances []BalanceMap
+	stream.OnTradeUpdate(func(trade Trade) { trades = append(trades, trade) })
+	stream.OnOrderUpdate(func(order Order) { orders = append(orders, order) })
+	stream.OnBalanceSnapshot(func(m BalanceMap) { balances = append(balances, m) })
+
+	// the updates received before the disconnection
+	oldOrderTime := now.Add(-2 * time.Hour)
+	stream.EmitOrderUpdate(newTestOrder(1, OrderStatusNew, 0, oldOrderTime))
+	stream.EmitOrderUpdate(newTestOrder(2, OrderStatusNew, 0, now))
+	stream.EmitTradeUpdate(Trade{ID: 100, Symbol: "BTCUSDT", Side: SideTypeBuy})
+
+	// the first connection doesn't trigger the resync
+	syncer.HandleConnect()
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, balances, 0)
+
+	// the order 1 is filled and the order 3 is created during the disconnection
+	exchange.trades = []Trade{
+		{ID: 100, Symbol: "BTCUSDT", Side: SideTypeBuy},
+		{ID: 101, Symbol: "BTCUSDT", Side: SideTypeBuy},
+	}
+	exchange.closedOrders = []Order{newTestOrder(1, OrderStatusFilled, 1.0, oldOrderTime)}
+	exchange.openOrders = []Order{
+		newTestOrder(2, OrderStatusNew, 0, now),
+		newTestOrder(3, OrderStatusNew, 0, now),
+	}
+	exchange.balances = BalanceMap{"BTC": {Currency: "BTC", Available: fixedpoint.NewFromFloat(1.0)}}
+
+	trades, orders = nil, nil
+	syncer.Sync(context.Background(), now)
+
+	if assert.Len(t, trades, 1) {
+		assert.Equal(t, int64(101), trades[0].ID)
+	}
+
+	if assert.Len(t, orders, 2) {
+		assert.Equal(t, uint64(1), orders[0].OrderID)
+		assert.Equal(t, OrderStatusFilled, orders[0].Status)
+		assert.Equal(t, uint64(3), orders[1].OrderID)
+	}
+
+	assert.Len(t, balances, 1)
+
+	// the closed orders are queried since the creation time of the oldest open order
+	assert.Equal(t, oldOrderTime, exchange.closedOrdersSince)
+
+	// the synced updates are not emitted again
+	trades, orders = nil, nil
+	syncer.Sync(context.Background(), now)
+	assert.Len(t, trades, 0)
+	assert.Len(t, orders, 0)
+}
+
+func TestStreamStateSyncer_HandleConnect(t *testing.T) {
+	exchange := &testTradingService{
+		balances: BalanceMap{"BTC": {Currency: "BTC", Available: fixedpoint.NewFromFloat(1.0)}},
+	}
+	stream := &StandardStream{}
+	syncer := NewStreamStateSyncer(exchange, stream)
+
+	balanceC := make(chan BalanceMap, 1)
+	stream.OnBalanceSnapshot(func(m BalanceMap) { balanceC <- m })
+
+	stream.EmitDisconnect()
+	syncer.HandleConnect()
+
+	select {
+	case balances := <-balanceC:
+		assert.Equal(t, fixedpoint.NewFromFloat(1.0), balances["BTC"].Available)
+	case <-time.After(3 * time.Second):
+		t.Fatal("balances are not resynced after reconnecting")
+	}
+}
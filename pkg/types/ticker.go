@@ -14,3 +14,18 @@ type Ticker struct {
 	Buy    float64 // `buy` from Max, `bidPrice` from binance
 	Sell   float64 // `sell` from Max, `askPrice` from binance
 }
+
+// BookTicker is the best bid and the best ask of the order book
+type BookTicker struct {
+	Time     time.Time
+	Symbol   string
+	Buy      float64 // the best bid price
+	BuySize  float64
+	Sell     float64 // the best ask price
+	SellSize float64
+}
+
+// MidPrice returns the average of the best bid and the best ask
+func (t BookTicker) MidPrice() float64 {
+	return (t.Buy + t.Sell) / 2.0
+}
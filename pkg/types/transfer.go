@@ -0,0 +1,64 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/datatype"
+)
+
+// TransferAccount is the account or the wallet of the internal transfer, the supported accounts are different by
+// the exchanges, e.g., the sub-account names of ftx and the wallets of binance.
+type TransferAccount string
+
+const (
+	// TransferAccountMain is the main account of the exchanges that support sub-accounts
+	TransferAccountMain = TransferAccount("main")
+
+	// TransferAccountSpot is the spot wallet
+	TransferAccountSpot = TransferAccount("spot")
+
+	// TransferAccountMargin is the cross margin wallet
+	TransferAccountMargin = TransferAccount("margin")
+
+	// TransferAccountFutures is the USDT-M futures wallet
+	TransferAccountFutures = TransferAccount("futures")
+)
+
+const isolatedMarginTransferAccountPrefix = "isolated:"
+
+// IsolatedMarginTransferAccount returns the isolated margin wallet of the symbol, e.g., "isolated:BTCUSDT"
+func IsolatedMarginTransferAccount(symbol string) TransferAccount {
+	return TransferAccount(isolatedMarginTransferAccountPrefix + symbol)
+}
+
+// IsolatedMarginSymbol returns the symbol of the isolated margin wallet
+func (a TransferAccount) IsolatedMarginSymbol() (string, bool) {
+	if !strings.HasPrefix(string(a), isolatedMarginTransferAccountPrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(string(a), isolatedMarginTransferAccountPrefix), true
+}
+
+// InternalTransfer is the asset transfer between the sub-accounts or the wallets of the same exchange
+type InternalTransfer struct {
+	GID        int64           `json:"gid" db:"gid"`
+	Exchange   ExchangeName    `json:"exchange" db:"exchange"`
+	TransferID string          `json:"transferID" db:"transfer_id"`
+	Asset      string          `json:"asset" db:"asset"`
+	Amount     float64         `json:"amount" db:"amount"`
+	From       TransferAccount `json:"from" db:"from_account"`
+	To         TransferAccount `json:"to" db:"to_account"`
+	Status     string          `json:"status" db:"status"`
+	Time       datatype.Time   `json:"time" db:"time"`
+}
+
+func (t InternalTransfer) String() string {
+	return fmt.Sprintf("internal transfer %s %f from %s to %s at %s", t.Asset, t.Amount, t.From, t.To, t.Time.Time())
+}
+
+func (t InternalTransfer) EffectiveTime() time.Time {
+	return t.Time.Time()
+}